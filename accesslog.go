@@ -0,0 +1,188 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AccessLogFormat chooses the format of the lines written by AccessLog.
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon is the Common Log Format used by web servers, for example
+	// `192.0.2.1 - alice [14/Feb/2020:12:00:00 +0000] "GET /index.html HTTP/1.1" 200 1234`.
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined is the Common Log Format followed by the referer and the
+	// user agent in quotes.
+	AccessLogCombined
+	// AccessLogJSON is one JSON object per request, which includes the latency.
+	AccessLogJSON
+)
+
+// AccessRecord describes one HTTP request for the access log.  AccessLog fills
+// it in, and the adapters for other routers can do the same using
+// NewAccessRecord.
+type AccessRecord struct {
+	Time       time.Time     // When the request arrived.
+	RemoteAddr string        // The client's address, without the port.
+	User       string        // The user name from basic authentication (empty if none).
+	Method     string        // The HTTP method, for example "GET".
+	URI        string        // The request URI as sent by the client.
+	Proto      string        // The protocol, for example "HTTP/1.1".
+	Status     int           // The status code of the response.
+	Bytes      int64         // The number of bytes in the body of the response.
+	Latency    time.Duration // The time taken to handle the request.
+	Referer    string        // The Referer header (empty if none).
+	UserAgent  string        // The User-Agent header (empty if none).
+}
+
+// NewAccessRecord returns an AccessRecord holding the details of the request
+// that arrived at the given time.  The caller fills in the status, the number of
+// bytes and the latency once the request has been handled.
+func NewAccessRecord(r *http.Request, start time.Time) AccessRecord {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	user, _, _ := r.BasicAuth()
+
+	return AccessRecord{
+		Time:       start,
+		RemoteAddr: remote,
+		User:       user,
+		Method:     r.Method,
+		URI:        r.RequestURI,
+		Proto:      r.Proto,
+		Referer:    r.Referer(),
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// Format returns the record as a line in the given format, ending with a
+// newline.
+func (ar AccessRecord) Format(format AccessLogFormat) []byte {
+	if format == AccessLogJSON {
+		return ar.formatJSON()
+	}
+
+	bytes := "-"
+	if ar.Bytes > 0 {
+		bytes = strconv.FormatInt(ar.Bytes, 10)
+	}
+	line := fmt.Sprintf("%s - %s [%s] %s %d %s",
+		orDash(ar.RemoteAddr), orDash(ar.User), ar.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(ar.Method+" "+ar.URI+" "+ar.Proto), ar.Status, bytes)
+	if format == AccessLogCombined {
+		line += " " + strconv.Quote(ar.Referer) + " " + strconv.Quote(ar.UserAgent)
+	}
+
+	return []byte(line + "\n")
+}
+
+// formatJSON is a helper function for Format that produces the JSON line.
+func (ar AccessRecord) formatJSON() []byte {
+	line, _ := json.Marshal(struct {
+		Time      string  `json:"time"`
+		Remote    string  `json:"remote"`
+		User      string  `json:"user,omitempty"`
+		Method    string  `json:"method"`
+		URI       string  `json:"uri"`
+		Proto     string  `json:"proto"`
+		Status    int     `json:"status"`
+		Bytes     int64   `json:"bytes"`
+		Latency   float64 `json:"latencySeconds"`
+		Referer   string  `json:"referer,omitempty"`
+		UserAgent string  `json:"userAgent,omitempty"`
+	}{
+		ar.Time.Format(time.RFC3339Nano), ar.RemoteAddr, ar.User, ar.Method, ar.URI, ar.Proto,
+		ar.Status, ar.Bytes, ar.Latency.Seconds(), ar.Referer, ar.UserAgent,
+	})
+
+	return append(line, '\n')
+}
+
+// orDash returns the string, or "-" if it's empty, as in the Common Log Format.
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+// AccessLog returns net/http middleware that writes a line to the given writer,
+// usually a daily Writer, for each request, giving the status, the size of the
+// response and, in JSON, the latency.  The line is written after the handler
+// returns.  The middleware has the type that chi expects, so with chi it's
+// simply
+//
+//	router.Use(dailylogger.AccessLog(writer, dailylogger.AccessLogCombined))
+//
+// and with net/http it wraps the handler:
+//
+//	http.ListenAndServe(":8080", dailylogger.AccessLog(writer, dailylogger.AccessLogCommon)(mux))
+//
+// The dailygin and dailyecho modules do the same for gin and echo.
+func AccessLog(writer io.Writer, format AccessLogFormat) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			record := NewAccessRecord(r, start)
+			record.Status = recorder.status
+			if record.Status == 0 {
+				// The handler didn't write anything, so net/http sends 200.
+				record.Status = http.StatusOK
+			}
+			record.Bytes = recorder.bytes
+			record.Latency = time.Since(start)
+			writer.Write(record.Format(format))
+		})
+	}
+}
+
+// statusRecorder is an http.ResponseWriter that notes the status and the size of
+// the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int   // The status code (0 until the header is written).
+	bytes  int64 // The bytes in the body so far.
+}
+
+// WriteHeader notes the status and passes it on.
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Write notes the size and passes the data on.
+func (sr *statusRecorder) Write(buffer []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	n, err := sr.ResponseWriter.Write(buffer)
+	sr.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the underlying ResponseWriter
+// can, for handlers that stream the response.
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController
+// can reach its Flush and Hijack methods.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
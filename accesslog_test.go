@@ -0,0 +1,80 @@
+package dailylogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAccessRecordFormat checks the lines in each format.
+func TestAccessRecordFormat(t *testing.T) {
+	locationUTC, _ := time.LoadLocation("UTC")
+	record := AccessRecord{
+		Time:       time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC),
+		RemoteAddr: "192.0.2.1",
+		User:       "alice",
+		Method:     "GET",
+		URI:        "/index.html?q=1",
+		Proto:      "HTTP/1.1",
+		Status:     200,
+		Bytes:      1234,
+		Latency:    1500 * time.Microsecond,
+		UserAgent:  "curl/8.0",
+	}
+
+	const wantCommon = `192.0.2.1 - alice [14/Feb/2020:12:00:00 +0000] "GET /index.html?q=1 HTTP/1.1" 200 1234` + "\n"
+	if got := string(record.Format(AccessLogCommon)); got != wantCommon {
+		t.Errorf("want %q got %q", wantCommon, got)
+	}
+
+	const wantCombined = `192.0.2.1 - alice [14/Feb/2020:12:00:00 +0000] "GET /index.html?q=1 HTTP/1.1" 200 1234 "" "curl/8.0"` + "\n"
+	if got := string(record.Format(AccessLogCombined)); got != wantCombined {
+		t.Errorf("want %q got %q", wantCombined, got)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(record.Format(AccessLogJSON), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["status"] != 200.0 || fields["latencySeconds"] != 0.0015 || fields["time"] != "2020-02-14T12:00:00Z" {
+		t.Errorf("want the status, latency and time got %v", fields)
+	}
+
+	record.User = ""
+	record.Bytes = 0
+	const wantEmpty = `192.0.2.1 - - [14/Feb/2020:12:00:00 +0000] "GET /index.html?q=1 HTTP/1.1" 200 -` + "\n"
+	if got := string(record.Format(AccessLogCommon)); got != wantEmpty {
+		t.Errorf("want %q got %q", wantEmpty, got)
+	}
+}
+
+// TestAccessLog checks that the middleware records the status and the size of
+// each response.
+func TestAccessLog(t *testing.T) {
+	var log bytes.Buffer
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) })
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	handler := AccessLog(&log, AccessLogCommon)(mux)
+
+	for _, path := range []string{"/hello", "/empty", "/missing"} {
+		request := httptest.NewRequest("GET", path, nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	want := []string{`"GET /hello HTTP/1.1" 200 5`, `"GET /empty HTTP/1.1" 200 -`, `"GET /missing HTTP/1.1" 404 19`}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines got %q", len(want), lines)
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], "192.0.2.1 - - [") || !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("want a line ending %s got %q", want[i], lines[i])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build darwin
+
+package dailylogger
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// setAppendOnly sets the user append-only flag of the file.
+func setAppendOnly(pathname string) error {
+	return changeFlags(pathname, func(flags uint32) uint32 { return flags | unix.UF_APPEND })
+}
+
+// clearAppendOnly clears the user append-only flag of the file.
+func clearAppendOnly(pathname string) error {
+	return changeFlags(pathname, func(flags uint32) uint32 { return flags &^ unix.UF_APPEND })
+}
+
+// changeFlags is a helper function for setAppendOnly and clearAppendOnly that
+// changes the flags of the file, if that makes a difference.
+func changeFlags(pathname string, change func(uint32) uint32) error {
+	var stat unix.Stat_t
+	if err := unix.Stat(pathname, &stat); err != nil {
+		return &fs.PathError{Op: "stat", Path: pathname, Err: err}
+	}
+	if change(stat.Flags) == stat.Flags {
+		return nil
+	}
+
+	if err := unix.Chflags(pathname, int(change(stat.Flags))); err != nil {
+		return &fs.PathError{Op: "chflags", Path: pathname, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build linux
+
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// fsAppendFlag is the append-only attribute (FS_APPEND_FL).
+const fsAppendFlag = 0x20
+
+// setAppendOnly sets the append-only attribute of the file, as "chattr +a" does.
+func setAppendOnly(pathname string) error {
+	return changeAttributes(pathname, func(flags int) int { return flags | fsAppendFlag })
+}
+
+// clearAppendOnly clears the append-only attribute of the file.
+func clearAppendOnly(pathname string) error {
+	return changeAttributes(pathname, func(flags int) int { return flags &^ fsAppendFlag })
+}
+
+// changeAttributes is a helper function for setAppendOnly and clearAppendOnly
+// that changes the attributes of the file, if that makes a difference.
+func changeAttributes(pathname string, change func(int) int) error {
+	file, err := os.Open(longPath(pathname))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	flags, err := unix.IoctlGetInt(int(file.Fd()), unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return &fs.PathError{Op: "ioctl", Path: pathname, Err: err}
+	}
+	newFlags := change(flags)
+	if newFlags == flags {
+		return nil
+	}
+
+	if err := unix.IoctlSetPointerInt(int(file.Fd()), unix.FS_IOC_SETFLAGS, newFlags); err != nil {
+		return &fs.PathError{Op: "ioctl", Path: pathname, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package dailylogger
+
+// setAppendOnly does nothing on this system, which has no portable way to make a
+// file append-only.
+func setAppendOnly(pathname string) error {
+	return nil
+}
+
+// clearAppendOnly does nothing on this system.
+func clearAppendOnly(pathname string) error {
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// setAppendOnly adds an entry to the access control list of the file that
+// denies everyone permission to write anywhere but the end of it.  Appending
+// needs FILE_APPEND_DATA, which is still allowed.
+func setAppendOnly(pathname string) error {
+	return changeAccess(pathname, windows.DENY_ACCESS)
+}
+
+// clearAppendOnly removes the entries that setAppendOnly added.  It removes all
+// the entries for everyone that were set on the file itself, but not those that
+// it inherits from its directory.
+func clearAppendOnly(pathname string) error {
+	return changeAccess(pathname, windows.REVOKE_ACCESS)
+}
+
+// changeAccess is a helper function for setAppendOnly and clearAppendOnly that
+// merges an entry for everyone into the access control list of the file.
+func changeAccess(pathname string, mode windows.ACCESS_MODE) error {
+	everyone, err := windows.CreateWellKnownSid(windows.WinWorldSid)
+	if err != nil {
+		return err
+	}
+
+	name := longPath(pathname)
+	sd, err := windows.GetNamedSecurityInfo(name, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return &fs.PathError{Op: "GetNamedSecurityInfo", Path: pathname, Err: err}
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return &fs.PathError{Op: "GetNamedSecurityInfo", Path: pathname, Err: err}
+	}
+
+	entry := windows.EXPLICIT_ACCESS{
+		AccessPermissions: windows.FILE_WRITE_DATA,
+		AccessMode:        mode,
+		Inheritance:       windows.NO_INHERITANCE,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_WELL_KNOWN_GROUP,
+			TrusteeValue: windows.TrusteeValueFromSID(everyone),
+		},
+	}
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{entry}, dacl)
+	if err != nil {
+		return &fs.PathError{Op: "SetEntriesInAcl", Path: pathname, Err: err}
+	}
+
+	err = windows.SetNamedSecurityInfo(name, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION,
+		nil, nil, acl, nil)
+	if err != nil {
+		return &fs.PathError{Op: "SetNamedSecurityInfo", Path: pathname, Err: err}
+	}
+
+	return nil
+}
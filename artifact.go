@@ -0,0 +1,200 @@
+package dailylogger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// An Artifact makes a file derived from a log file once the Writer has finished
+// with it, for example a compressed copy or an index.
+type Artifact interface {
+	// Suffix returns the string added to the log file's path name to make the
+	// artifact's, for example ".gz".
+	Suffix() string
+
+	// Make reads the log file and writes the artifact.
+	Make(dest io.Writer, logFile io.Reader) error
+}
+
+// WithArtifact makes the Writer produce the artifact from each log file after
+// rotating away from it.  The artifacts are made by a separate goroutine so that
+// rotation isn't held up, and Close waits for any that are still being made.
+// Each artifact is written to a temporary file in the same directory, flushed
+// to the disk and then renamed into place, and the directory is flushed too, so
+// after a crash or a power failure the artifact is either complete or missing,
+// never half-written.  Several artifacts may be supplied and they are made in
+// the order given.  Artifacts aren't made if rotation is disabled.
+func WithArtifact(artifact Artifact) Option {
+	return func(dw *Writer) {
+		if artifact != nil {
+			dw.artifacts = append(dw.artifacts, artifact)
+		}
+	}
+}
+
+// startArtifacts is a helper function for rotation that starts a goroutine to
+// make the artifacts from the log file that has just been closed.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) startArtifacts(pathname string) {
+	if !dw.hasRotationWork() || dw.noRotation || len(pathname) == 0 || dw.creationPending {
+		return
+	}
+
+	if len(dw.partialLine) > 0 && dw.partialLinePath == pathname {
+		// The end of a record is still to be appended to the file (see
+		// WithWholeLines), so wait until it has been.
+		dw.heldArtifacts = pathname
+		dw.heldArtifactsDay = dw.startOfToday
+		return
+	}
+
+	dw.artifactsPending.Add(1)
+	go dw.makeArtifacts(pathname, dw.startOfToday)
+}
+
+// releaseArtifacts is a helper function for writePartialLine that starts making
+// the artifacts held back by startArtifacts, once the end of the record has been
+// appended to the file.  It closes the file first, so that the artifacts are
+// made from all of it.  It doesn't apply the lock, so it should only be called
+// by a function that does.
+func (dw *Writer) releaseArtifacts() error {
+	if len(dw.heldArtifacts) == 0 {
+		return nil
+	}
+
+	pathname := dw.heldArtifacts
+	dw.heldArtifacts = ""
+	err := dw.files.release(pathname)
+
+	dw.artifactsPending.Add(1)
+	go dw.makeArtifacts(pathname, dw.heldArtifactsDay)
+
+	return err
+}
+
+// hasRotationWork returns true if there is anything to do with a log file once
+// the Writer has rotated away from it.
+func (dw *Writer) hasRotationWork() bool {
+	return len(dw.artifacts) > 0 || (dw.compressor != nil && !dw.compressLive) || dw.manifest
+}
+
+// makeArtifacts makes the artifacts from the given log file for the given day,
+// compresses it and records it in the day's manifest, if requested.  It should
+// be run in a goroutine.
+func (dw *Writer) makeArtifacts(pathname string, day time.Time) {
+	defer dw.artifactsPending.Done()
+
+	finished := dw.startArtifactWork()
+	defer finished()
+
+	for _, artifact := range dw.artifacts {
+		err := dw.makeArtifact(pathname, artifact)
+		dw.logMutex.Lock()
+		if err != nil {
+			dw.reportError(fmt.Errorf("dailylogger: error making %s - %w", pathname+artifact.Suffix(), err))
+			dw.emitLifecycle(Event{Type: EventArtifactFailed, Path: pathname + artifact.Suffix(), Err: err})
+		} else {
+			dw.emitLifecycle(Event{Type: EventArtifactMade, Path: pathname + artifact.Suffix()})
+		}
+		dw.logMutex.Unlock()
+	}
+
+	if dw.compressor != nil && !dw.compressLive {
+		pathname = dw.compress(pathname, day)
+	}
+
+	if dw.manifest {
+		dw.addToManifest(pathname, day)
+	}
+}
+
+// makeArtifact makes one artifact from the given log file.
+func (dw *Writer) makeArtifact(pathname string, artifact Artifact) error {
+	logFile, err := os.Open(longPath(pathname))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	if dw.progressReport == nil {
+		return dw.writeAtomically(pathname+artifact.Suffix(), func(w io.Writer) error {
+			return artifact.Make(w, logFile)
+		})
+	}
+
+	var total int64
+	if info, err := logFile.Stat(); err == nil {
+		total = info.Size()
+	}
+	progress := progressReader{
+		reader:   logFile,
+		pathname: pathname + artifact.Suffix(),
+		total:    total,
+		every:    dw.progressEvery,
+		reported: -1,
+		report:   dw.progressReport,
+	}
+
+	return dw.writeAtomically(pathname+artifact.Suffix(), func(w io.Writer) error {
+		if err := artifact.Make(w, &progress); err != nil {
+			return err
+		}
+		progress.finish()
+		return nil
+	})
+}
+
+// writeAtomically creates a file with the given path name, or replaces an
+// existing one, so that a reader sees either the old contents or the complete
+// new contents.  The write function is called to produce the contents, which go
+// into a temporary file in the same directory.  That's flushed to the disk and
+// renamed into place, and then the directory is flushed so that the rename
+// survives a power failure.  The file gets the same permissions, owner and group
+// as a log file.
+func (dw *Writer) writeAtomically(pathname string, write func(io.Writer) error) error {
+	directory := filepath.Dir(pathname)
+
+	var temp *os.File
+	var err error
+	dw.withUmask(func() {
+		temp, err = os.CreateTemp(longPath(directory), "."+filepath.Base(pathname)+".*.tmp")
+	})
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+
+	err = write(temp)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if ce := temp.Close(); ce != nil && err == nil {
+		err = ce
+	}
+	if err == nil && runtime.GOOS != "windows" {
+		// The temporary file is only readable by its owner.
+		mode := os.FileMode(0644)
+		if dw.logFilePermissions != 0 {
+			mode = dw.logFilePermissions
+		}
+		err = os.Chmod(tempName, mode)
+	}
+	if err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	dw.applyOwnership(tempName)
+	dw.applyLabel(tempName)
+
+	if err := os.Rename(tempName, longPath(pathname)); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	return syncDirectory(directory)
+}
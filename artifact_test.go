@@ -0,0 +1,129 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upperCaseArtifact is an Artifact that makes an upper case copy of the log file.
+type upperCaseArtifact struct{}
+
+func (upperCaseArtifact) Suffix() string { return ".upper" }
+
+func (upperCaseArtifact) Make(dest io.Writer, logFile io.Reader) error {
+	contents, err := io.ReadAll(logFile)
+	if err != nil {
+		return err
+	}
+	_, err = dest.Write(bytes.ToUpper(contents))
+	return err
+}
+
+// TestArtifact checks that an artifact is made from each log file after rotation
+// and that no temporary files are left behind.
+func TestArtifact(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithArtifact(upperCaseArtifact{}))
+	writer.Write([]byte("first"))
+	writer.rotate(now)
+	writer.Write([]byte("second"))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("third"))
+	writer.Close()
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar.upper", "FIRST"},
+		{"foo.2020-02-14.1.bar.upper", "SECOND"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+
+	// The current file is not finished, so it has no artifact.
+	if _, err := os.Stat("foo.2020-02-15.bar.upper"); !os.IsNotExist(err) {
+		t.Errorf("want no artifact for the current file, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(".")
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file %s was left behind", entry.Name())
+		}
+	}
+}
+
+// TestWriteAtomically checks that writeAtomically replaces an existing file and
+// leaves it alone if the new contents can't be written.
+func TestWriteAtomically(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	writer := Writer{}
+	os.WriteFile("index", []byte("old"), 0644)
+
+	err = writer.writeAtomically("./index", func(w io.Writer) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	contents, _ := os.ReadFile("index")
+	if string(contents) != "new" {
+		t.Errorf("want \"new\" got \"%s\"", string(contents))
+	}
+
+	wantErr := errors.New("disk on fire")
+	err = writer.writeAtomically("./index", func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("want %v got %v", wantErr, err)
+	}
+	contents, _ = os.ReadFile("index")
+	if string(contents) != "new" {
+		t.Errorf("after a failure want \"new\" got \"%s\"", string(contents))
+	}
+
+	entries, _ := os.ReadDir(".")
+	if len(entries) != 1 {
+		t.Errorf("want only the index file, got %d files", len(entries))
+	}
+}
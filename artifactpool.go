@@ -0,0 +1,111 @@
+package dailylogger
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// WithArtifactWorkers limits the number of log files whose artifacts (see
+// WithArtifact) are made at the same time.  Without it, the artifacts of each log
+// file are made as soon as the Writer rotates away from it, so a burst of
+// rotations, for example from repeated calls of Rotate, can keep several CPUs
+// busy compressing at once.  With it, the work for the
+// extra files waits until a worker is free.  Close still waits for all of it.
+func WithArtifactWorkers(workers int) Option {
+	return func(dw *Writer) {
+		if workers > 0 {
+			dw.artifactSlots = make(chan struct{}, workers)
+		}
+	}
+}
+
+// WithArtifactPriority asks the system to run the work of making artifacts at a
+// lower priority than the rest of the program, so that compressing a big log
+// file at midnight doesn't starve the application.  The nice level, from 1,
+// slightly lower, to 19, the lowest, is added to the program's own, and idleIO
+// asks for the file reads and writes to be done only when the disk is otherwise
+// idle.  These are hints: only Linux applies them, to the threads making the
+// artifacts and not to the rest of the program, and elsewhere the option has no
+// effect.
+func WithArtifactPriority(nice int, idleIO bool) Option {
+	return func(dw *Writer) {
+		dw.artifactNice = min(max(nice, 0), 19)
+		dw.artifactIdleIO = idleIO
+	}
+}
+
+// ProgressFunc receives the progress of making an artifact: the path name of
+// the artifact, the number of bytes of the log file read so far and the size of
+// the log file.
+type ProgressFunc func(pathname string, done, total int64)
+
+// WithArtifactProgress makes the Writer report its progress through the log
+// file while making each artifact, each time another chunk of the given number
+// of bytes has been read, and once more at the end.  The function is called by
+// the goroutine making the artifact, without the Writer's lock, so it may take
+// its time, but it must not call the Writer's Close method.
+func WithArtifactProgress(chunk int64, report ProgressFunc) Option {
+	return func(dw *Writer) {
+		if chunk > 0 && report != nil {
+			dw.progressEvery = chunk
+			dw.progressReport = report
+		}
+	}
+}
+
+// startArtifactWork is a helper function for makeArtifacts that waits for a
+// free worker and lowers the priority of the goroutine's thread, if requested.
+// It returns a function that frees the worker.
+func (dw *Writer) startArtifactWork() func() {
+	if dw.artifactSlots != nil {
+		dw.artifactSlots <- struct{}{}
+	}
+
+	if dw.artifactNice > 0 || dw.artifactIdleIO {
+		// The thread is never unlocked, so it ends with the goroutine and its lowered
+		// priority doesn't leak into the rest of the program.
+		runtime.LockOSThread()
+		if err := lowerThreadPriority(dw.artifactNice, dw.artifactIdleIO); err != nil {
+			dw.logMutex.Lock()
+			dw.reportError(fmt.Errorf("dailylogger: error lowering the priority of the artifact maker - %w", err))
+			dw.logMutex.Unlock()
+		}
+	}
+
+	return func() {
+		if dw.artifactSlots != nil {
+			<-dw.artifactSlots
+		}
+	}
+}
+
+// progressReader passes on the data read from a log file and reports the
+// progress each time another chunk has been read.
+type progressReader struct {
+	reader   io.Reader
+	pathname string // The path name of the artifact being made.
+	total    int64  // The size of the log file.
+	done     int64  // The bytes read so far.
+	every    int64  // The bytes between reports.
+	reported int64  // The bytes read at the last report (-1 if none).
+	report   ProgressFunc
+}
+
+// Read reads from the log file and reports progress.
+func (pr *progressReader) Read(buffer []byte) (int, error) {
+	n, err := pr.reader.Read(buffer)
+	pr.done += int64(n)
+	if pr.done >= max(pr.reported, 0)+pr.every {
+		pr.report(pr.pathname, pr.done, pr.total)
+		pr.reported = pr.done
+	}
+	return n, err
+}
+
+// finish sends the final report, if the last Read didn't.
+func (pr *progressReader) finish() {
+	if pr.reported != pr.done {
+		pr.report(pr.pathname, pr.done, pr.total)
+	}
+}
@@ -0,0 +1,137 @@
+package dailylogger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// busyArtifact is an Artifact that records the greatest number of copies of
+// itself that are being made at once.
+type busyArtifact struct {
+	mutex   sync.Mutex
+	running int
+	most    int
+}
+
+func (ba *busyArtifact) Suffix() string { return ".busy" }
+
+func (ba *busyArtifact) Make(dest io.Writer, logFile io.Reader) error {
+	ba.mutex.Lock()
+	ba.running++
+	ba.most = max(ba.most, ba.running)
+	ba.mutex.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	_, err := io.Copy(dest, logFile)
+
+	ba.mutex.Lock()
+	ba.running--
+	ba.mutex.Unlock()
+	return err
+}
+
+// TestArtifactWorkers checks that WithArtifactWorkers limits the number of
+// artifacts made at once and that Close waits for the ones that were queued.
+func TestArtifactWorkers(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	artifact := &busyArtifact{}
+	writer := New(now, ".", "foo.", ".bar", WithArtifact(artifact), WithArtifactWorkers(1))
+	for range 4 {
+		writer.Write([]byte("hello\n"))
+		writer.rotate(now)
+	}
+	writer.Close()
+
+	if artifact.most != 1 {
+		t.Errorf("want at most 1 artifact at once, got %d", artifact.most)
+	}
+
+	logFiles, _ := writer.List()
+	for _, logFile := range logFiles[:len(logFiles)-1] {
+		if _, err := os.Stat(logFile.Pathname + ".busy"); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestArtifactProgress checks that WithArtifactProgress reports each chunk and
+// the end of the file.
+func TestArtifactProgress(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var mutex sync.Mutex
+	var reports []int64
+	report := func(pathname string, done, total int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if pathname != "./foo.2020-02-14.bar.copy" || total != 10 {
+			t.Errorf("unexpected report %s %d", pathname, total)
+		}
+		reports = append(reports, done)
+	}
+
+	writer := New(now, ".", "foo.", ".bar", WithArtifact(&chunkedArtifact{size: 4}),
+		WithArtifactProgress(4, report))
+	writer.Write([]byte("0123456789"))
+	writer.rotate(now)
+	writer.Close()
+
+	want := []int64{4, 8, 10}
+	if len(reports) != len(want) {
+		t.Fatalf("want reports %v got %v", want, reports)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("want reports %v got %v", want, reports)
+			break
+		}
+	}
+}
+
+// chunkedArtifact is an Artifact that copies the log file reading the given
+// number of bytes at a time.
+type chunkedArtifact struct {
+	size int
+}
+
+func (ca *chunkedArtifact) Suffix() string { return ".copy" }
+
+func (ca *chunkedArtifact) Make(dest io.Writer, logFile io.Reader) error {
+	buffer := make([]byte, ca.size)
+	for {
+		n, err := logFile.Read(buffer)
+		dest.Write(buffer[:n])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package dailylogger
+
+import "fmt"
+
+// WithBuffering puts a write buffer of the given size in front of the log file.
+// The buffer is flushed when it fills up, when Sync or Close is called and
+// before the log is rotated, so data written before a rotation always goes to
+// the old file.  A size of zero or less disables buffering.
+func WithBuffering(size int) Option {
+	return func(dw *Writer) {
+		if size > 0 {
+			dw.bufferSize = size
+		}
+	}
+}
+
+// WithAsync makes the Writer asynchronous.  Write copies the data into a queue
+// of the given length and returns without waiting for it to be written to the
+// log file.  The copies are made in buffers taken from a pool, so Write doesn't
+// allocate memory.  A separate goroutine writes the queued data in the order
+// that it was queued.  If the queue is full, Write waits until there is room.  Rotation,
+// Sync and Close wait until the queue has been emptied, so data queued before a
+// rotation always goes to the old file.  A length of zero or less leaves the
+// Writer synchronous.
+//
+// In asynchronous mode Write can't report errors from the log file, so the first
+// such error is returned by the next call of Sync or Close.
+func WithAsync(queueLength int) Option {
+	return func(dw *Writer) {
+		if queueLength > 0 {
+			dw.queueLength = queueLength
+		}
+	}
+}
+
+// writeAsync queues a copy of the buffer to be written by writeQueue.
+func (dw *Writer) writeAsync(buffer []byte) (int, error) {
+	// Avoid a race with rotation, Sync and Close.
+	dw.queueMutex.Lock()
+	defer dw.queueMutex.Unlock()
+
+	if dw.closed {
+		return 0, ErrClosed
+	}
+
+	if dw.journal != nil {
+		if err := dw.journalData(buffer); err != nil {
+			return 0, fmt.Errorf("Write: cannot write to the journal - %w", err)
+		}
+	}
+
+	// The caller may reuse the buffer as soon as Write returns, so queue a copy.
+	dw.pending.Add(1)
+	dw.queue <- copyToPooledBuffer(buffer)
+
+	return len(buffer), nil
+}
+
+// writeQueue runs until the Writer is closed, writing the queued data to the log.
+// It should be run in a goroutine.
+func (dw *Writer) writeQueue() {
+	for buffer := range dw.queue {
+		dw.writeQueuedBuffer(buffer)
+	}
+}
+
+// writeQueuedBuffer writes one buffer from the queue to the log and returns it
+// to the pool.
+func (dw *Writer) writeQueuedBuffer(buffer *[]byte) {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+	defer dw.pending.Done()
+	defer releasePooledBuffer(buffer)
+
+	_, err := dw.writeToLog(*buffer)
+	if err != nil {
+		dw.reportError(fmt.Errorf("writeQueue: %w", err))
+		if dw.asyncError == nil {
+			dw.asyncError = err
+		}
+	}
+}
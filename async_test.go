@@ -0,0 +1,233 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOrderingAcrossRotation checks that in each of the modes, everything written
+// before a rotation goes into the old file, in order, and everything written after
+// it goes into the new file.
+func TestOrderingAcrossRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	var testData = []struct {
+		description string
+		option      Option
+	}{
+		{"synchronous", WithAsync(0)},
+		{"buffered", WithBuffering(64)},
+		{"asynchronous", WithAsync(8)},
+		{"buffered asynchronous", func(dw *Writer) {
+			WithBuffering(64)(dw)
+			WithAsync(8)(dw)
+		}},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				t.Errorf("createWorkingDirectory failed - %v", err)
+				return
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			const linesPerDay = 1000
+			const wantFilename1 = "foo.2020-02-14.bar"
+			const wantFilename2 = "foo.2020-02-15.bar"
+
+			locationUTC, _ := time.LoadLocation("UTC")
+			now := time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC)
+			tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+			writer := New(now, ".", "foo.", ".bar", td.option)
+			defer writer.Close()
+
+			var want1, want2 strings.Builder
+			for i := 0; i < linesPerDay; i++ {
+				line := fmt.Sprintf("line %d\n", i)
+				want1.WriteString(line)
+				writer.Write([]byte(line))
+			}
+
+			writer.rotateLogs(tomorrow)
+
+			for i := linesPerDay; i < 2*linesPerDay; i++ {
+				line := fmt.Sprintf("line %d\n", i)
+				want2.WriteString(line)
+				writer.Write([]byte(line))
+			}
+
+			if err := writer.Sync(); err != nil {
+				t.Error(err)
+				return
+			}
+
+			contents1, err := os.ReadFile(wantFilename1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents1) != want1.String() {
+				t.Errorf("%s does not contain the lines written before the rotation", wantFilename1)
+			}
+
+			contents2, err := os.ReadFile(wantFilename2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents2) != want2.String() {
+				t.Errorf("%s does not contain the lines written after the rotation", wantFilename2)
+			}
+		})
+	}
+}
+
+// TestBufferingAndSync checks that in buffered mode data is held back until Sync
+// is called.
+func TestBufferingAndSync(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const wantContents = "hello world"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithBuffering(1024))
+	defer writer.Close()
+
+	writer.Write([]byte(wantContents))
+
+	contents, err := os.ReadFile(wantFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(contents) != 0 {
+		t.Errorf("want an empty file before Sync, got \"%s\"", string(contents))
+		return
+	}
+
+	if err := writer.Sync(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	contents, err = os.ReadFile(wantFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
+
+// TestClose checks that Close flushes queued data and that the Writer can't be
+// used afterwards.
+func TestClose(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const wantContents = "hello world"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithAsync(4), WithBuffering(1024))
+	writer.Write([]byte(wantContents))
+
+	if err := writer.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	contents, err := os.ReadFile(wantFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	if _, err := writer.Write([]byte("more")); err != ErrClosed {
+		t.Errorf("want ErrClosed from Write got %v", err)
+	}
+
+	if err := writer.Sync(); err != ErrClosed {
+		t.Errorf("want ErrClosed from Sync got %v", err)
+	}
+
+	if err := writer.Close(); err != ErrClosed {
+		t.Errorf("want ErrClosed from Close got %v", err)
+	}
+}
+
+// TestReopen checks that Reopen carries on with the same file name and creates the
+// file again if it has been moved away.
+func TestReopen(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const movedFilename = "moved.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithBuffering(1024))
+	defer writer.Close()
+
+	writer.Write([]byte("hello"))
+	os.Rename(wantFilename, movedFilename)
+
+	if err := writer.Reopen(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	writer.Write([]byte("world"))
+	writer.Sync()
+
+	moved, _ := os.ReadFile(movedFilename)
+	if string(moved) != "hello" {
+		t.Errorf("want \"hello\" in %s got \"%s\"", movedFilename, string(moved))
+	}
+
+	contents, _ := os.ReadFile(wantFilename)
+	if string(contents) != "world" {
+		t.Errorf("want \"world\" in %s got \"%s\"", wantFilename, string(contents))
+	}
+}
@@ -0,0 +1,101 @@
+package dailylogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditWriter is a Writer set up for an audit log.  Its lines are chained (see
+// WithHashChain), its log files are protected so that they can only be appended
+// to (see WithAppendOnly) and nothing it does cuts a log file short.  VerifyDay
+// checks a day's files.
+type AuditWriter struct {
+	*Writer
+}
+
+// NewAuditWriter creates an AuditWriter.  The arguments are the same as those of
+// New, which it calls with WithHashChain and WithAppendOnly added to the options.
+func NewAuditWriter(now time.Time, logDir, leader, trailer string, args ...any) *AuditWriter {
+	args = append(args, WithHashChain(), WithAppendOnly())
+	return &AuditWriter{New(now, logDir, leader, trailer, args...)}
+}
+
+// WithAppendOnly makes the Writer protect each log file, when it opens it, so
+// that the file can only be added to.  Under Linux it sets the append-only
+// attribute, as "chattr +a" does, which needs the CAP_LINUX_IMMUTABLE capability,
+// usually root.  Under macOS it sets the user append-only flag (UF_APPEND).  Under
+// Windows, where the Writer already opens the file for appending only, it adds an
+// entry to the file's access control list that denies everyone permission to
+// write anywhere but the end.  Elsewhere the file is just opened for appending.
+// If the file can't be protected, the first failure is reported and the Writer
+// carries on without the protection.  The protection is lifted when the Writer
+// itself removes or moves the file, for example to apply the retention policy.
+//
+// The options that cut a log file short can't be combined with this one and are
+// turned off: WithJournal, WithPreallocation and WithRecordFraming.  So is
+// WithPermissionsReapplied, because the permissions of a protected file can't be
+// changed.  A file that is compressed when it's opened (see WithCompressedLiveFile)
+// or that comes from a FileFactory isn't protected.
+func WithAppendOnly() Option {
+	return func(dw *Writer) {
+		dw.appendOnly = true
+	}
+}
+
+// checkAppendOnly is a helper function for newWriter that turns off the options
+// that can't be combined with WithAppendOnly.
+func (dw *Writer) checkAppendOnly() {
+	if !dw.appendOnly {
+		return
+	}
+
+	var conflicts []string
+	if len(dw.journalName) > 0 {
+		conflicts = append(conflicts, "WithJournal")
+		dw.journalName = ""
+	}
+	if dw.preallocateSize > 0 {
+		conflicts = append(conflicts, "WithPreallocation")
+		dw.preallocateSize = 0
+	}
+	if dw.framing {
+		conflicts = append(conflicts, "WithRecordFraming")
+		dw.framing = false
+	}
+	if dw.reapplyPermissions {
+		conflicts = append(conflicts, "WithPermissionsReapplied")
+		dw.reapplyPermissions = false
+	}
+
+	for _, name := range conflicts {
+		err := fmt.Errorf("WithAppendOnly: %s can't be combined with append-only files - turning it off", name)
+		dw.reportError(err)
+		dw.recordStartupError(err)
+	}
+}
+
+// protectLog is a helper function for openLogFile that makes the log file
+// append-only.  It doesn't apply the lock, so it should only be called by a
+// function that does.
+func (dw *Writer) protectLog() {
+	err := setAppendOnly(dw.pathname)
+	if err == nil || dw.protectionFailed {
+		return
+	}
+
+	// Only report the first failure - the rest are bound to be the same.
+	dw.protectionFailed = true
+	dw.reportError(fmt.Errorf("WithAppendOnly: error protecting %s - %w", dw.pathname, err))
+}
+
+// releaseFile lifts the protection set by WithAppendOnly so that the file can be
+// removed or moved.  A file that isn't protected is left alone.
+func (dw *Writer) releaseFile(pathname string) {
+	if !dw.appendOnly {
+		return
+	}
+
+	if err := clearAppendOnly(pathname); err != nil {
+		dw.reportError(fmt.Errorf("WithAppendOnly: error releasing %s - %w", pathname, err))
+	}
+}
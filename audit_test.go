@@ -0,0 +1,59 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestNewAuditWriter checks that an AuditWriter chains its lines, turns off the
+// options that would cut a log file short and, where the system allows it,
+// protects its log files.
+func TestNewAuditWriter(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	const filename = "foo.2020-02-14.bar"
+	// Lift the protection so that the working directory can be removed.
+	defer clearAppendOnly(filename)
+
+	var errs []error
+	handler := func(err error) { errs = append(errs, err) }
+	writer := NewAuditWriter(now, ".", "foo.", ".bar",
+		WithJournal("journal"), WithPreallocation(4096), WithErrorHandler(handler))
+	defer writer.Close()
+
+	if len(writer.journalName) > 0 || writer.preallocateSize > 0 {
+		t.Error("want the journal and preallocation turned off")
+	}
+	if !writer.hashChain {
+		t.Error("want the lines chained")
+	}
+
+	writer.Write([]byte("login alice"))
+	writer.Sync()
+
+	if err := writer.VerifyDay(now); err != nil {
+		t.Errorf("want the chain to verify, got %v", err)
+	}
+
+	if writer.protectionFailed {
+		// The system doesn't allow it, for example the test isn't running as root.
+		return
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err == nil {
+		file.Close()
+		t.Error("want a protected file not to be writable other than by appending")
+	}
+}
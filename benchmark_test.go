@@ -0,0 +1,100 @@
+package dailylogger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BenchmarkConcurrentWrite measures Write with 1, 8 and 64 goroutines writing
+// small and large records at once, in unbuffered, synchronous, asynchronous and
+// sharded modes.  Run it with "go test -run XXX -bench ConcurrentWrite" to
+// compare the modes on a machine.
+func BenchmarkConcurrentWrite(b *testing.B) {
+	var modes = []struct {
+		name    string
+		options []any
+	}{
+		{"unbuffered", nil},
+		{"sync", []any{WithBuffering(64 * 1024)}},
+		{"async", []any{WithBuffering(64 * 1024), WithAsync(1024)}},
+		{"sharded", []any{WithBuffering(64 * 1024), WithShardedQueue(16, 64)}},
+	}
+
+	var sizes = []struct {
+		name   string
+		record []byte
+	}{
+		{"small", []byte("2020-02-14T12:00:00Z INFO something happened\n")},
+		{"large", []byte(strings.Repeat("x", 16*1024-1) + "\n")},
+	}
+
+	for _, mode := range modes {
+		for _, writers := range []int{1, 8, 64} {
+			for _, size := range sizes {
+				b.Run(fmt.Sprintf("%s/%d/%s", mode.name, writers, size.name), func(b *testing.B) {
+					directoryName, err := CreateWorkingDirectory()
+					if err != nil {
+						b.Fatal(err)
+					}
+					defer RemoveWorkingDirectory(directoryName)
+
+					writer := New(time.Now(), ".", "bench.", ".log", mode.options...)
+					defer writer.Close()
+
+					b.SetBytes(int64(len(size.record)))
+					b.ResetTimer()
+
+					var wg sync.WaitGroup
+					for w := 0; w < writers; w++ {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							for i := w; i < b.N; i += writers {
+								writer.Write(size.record)
+							}
+						}()
+					}
+					wg.Wait()
+					writer.Sync()
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkWriteAllocations reports the memory allocated by each Write in
+// synchronous and asynchronous modes.
+func BenchmarkWriteAllocations(b *testing.B) {
+	var modes = []struct {
+		name    string
+		options []any
+	}{
+		{"unbuffered", nil},
+		{"async", []any{WithAsync(1024)}},
+		{"sharded", []any{WithShardedQueue(16, 64)}},
+	}
+
+	record := []byte("2020-02-14T12:00:00Z INFO something happened\n")
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			writer := New(time.Now(), ".", "bench.", ".log", mode.options...)
+			defer writer.Close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				writer.Write(record)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package dailylogger
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// WithMonthlyBundle makes the Writer bundle the log files for each month into a
+// single tar archive once the month is over, for archivists who want one object
+// per month rather than one per day.  The archive is made on the second day of
+// the next month, so that the artifacts for the last day are ready.  Any
+// artifacts made from the files, such as compressed copies (see WithArtifact), go
+// into the archive too.  The archive is called leader.YYYY-MM.tar, for example
+// "foo.2020-02.tar", and goes in the directory holding the month's files.  If an
+// archive of that name already exists, for example because a file for the month
+// turned up late, the new one is called leader.YYYY-MM.1.tar and so on.  The
+// archive is written atomically (see WithArtifact), and then read back and each
+// file in it compared with the original.  Only if they all match are the
+// originals deleted.  The check for finished months is made when the Writer is
+// created and every hour after that.  Each archive emits an EventBundleMade (see
+// WithLifecycleEvents).  The option has no effect when rotation is disabled.
+func WithMonthlyBundle() Option {
+	return func(dw *Writer) {
+		dw.monthlyBundle = true
+	}
+}
+
+// bundleEntry is a file to be put into a monthly archive.
+type bundleEntry struct {
+	name     string   // The name of the file in the archive.
+	pathname string   // The path name of the file.
+	sum      [32]byte // The SHA-256 hash of the contents, set when it's archived.
+}
+
+// applyBundling is a helper function for retentionMonitor that bundles the log
+// files for each finished month.  The archives are made without the lock, so that
+// writing carries on meanwhile.
+func (dw *Writer) applyBundling() {
+	dw.refreshInUse()
+
+	for {
+		directory, month, entries, ok := dw.nextBundle()
+		if !ok {
+			return
+		}
+
+		pathname, err := dw.makeBundle(directory, month, entries)
+
+		dw.logMutex.Lock()
+		if err != nil {
+			dw.reportError(fmt.Errorf("bundle: error making the archive for %s - %w",
+				month.Format("2006-01"), err))
+			dw.logMutex.Unlock()
+			return
+		}
+
+		dw.emitLifecycle(Event{Type: EventBundleMade, Path: pathname})
+		removed := true
+		for _, entry := range entries {
+			dw.releaseFile(entry.pathname)
+			if err := os.Remove(longPath(entry.pathname)); err != nil {
+				dw.reportError(fmt.Errorf("bundle: error removing %s - %w", entry.pathname, err))
+				removed = false
+				continue
+			}
+			dw.emitLifecycle(Event{
+				Type: EventFileDeleted,
+				Path: entry.pathname,
+				Err:  fmt.Errorf("bundled into %s", pathname),
+			})
+		}
+		dw.logMutex.Unlock()
+
+		if !removed {
+			// Don't bundle the leftovers again until the next check.
+			return
+		}
+	}
+}
+
+// nextBundle is a helper function for applyBundling that finds the earliest
+// finished month that has log files.  It returns the directory holding them, the
+// start of the month, the files to go into the archive and true, or false if
+// there is nothing to bundle.
+func (dw *Writer) nextBundle() (string, time.Time, []bundleEntry, bool) {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed || dw.noRotation {
+		return "", time.Time{}, nil, false
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		dw.reportError(fmt.Errorf("bundle: error listing %s - %w", dw.logDir, err))
+		return "", time.Time{}, nil, false
+	}
+
+	// Wait until a day after the end of the month, so that the artifacts for its
+	// last day have been made.
+	cutoff := startOfMonth(dw.startOfToday.AddDate(0, 0, -1))
+
+	var month time.Time
+	var directory string
+	var entries []bundleEntry
+	for _, logFile := range logFiles {
+		if !startOfMonth(logFile.Date).Before(cutoff) {
+			// The list is sorted, so we are done.
+			break
+		}
+		if dw.offLimits(logFile) {
+			continue
+		}
+		if len(entries) == 0 {
+			month = startOfMonth(logFile.Date)
+			directory = dw.tierDirectory(logFile)
+		}
+		if !startOfMonth(logFile.Date).Equal(month) || dw.tierDirectory(logFile) != directory {
+			continue
+		}
+
+		entries = append(entries, bundleEntry{name: logFile.Name, pathname: logFile.Pathname})
+		base := dw.uncompressedPathname(logFile)
+		for _, artifact := range dw.artifacts {
+			artifactPath := base + artifact.Suffix()
+			if _, err := os.Stat(longPath(artifactPath)); err == nil {
+				name := strings.TrimPrefix(artifactPath, directory+"/")
+				entries = append(entries, bundleEntry{name: name, pathname: artifactPath})
+			}
+		}
+	}
+
+	return directory, month, entries, len(entries) > 0
+}
+
+// makeBundle is a helper function for applyBundling that writes the archive for
+// the month in the directory, checks it and returns its path name.
+func (dw *Writer) makeBundle(directory string, month time.Time, entries []bundleEntry) (string, error) {
+	name := dw.leader + month.Format("2006-01")
+	pathname := directory + "/" + name + ".tar"
+	for sequence := 1; ; sequence++ {
+		if _, err := os.Stat(longPath(pathname)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		pathname = fmt.Sprintf("%s/%s.%d.tar", directory, name, sequence)
+	}
+
+	err := dw.writeAtomically(pathname, func(w io.Writer) error {
+		archive := tar.NewWriter(w)
+		for i := range entries {
+			if err := addToBundle(archive, &entries[i]); err != nil {
+				return err
+			}
+		}
+		return archive.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyBundle(pathname, entries); err != nil {
+		os.Remove(longPath(pathname))
+		return "", err
+	}
+
+	return pathname, nil
+}
+
+// addToBundle adds a file to the archive and records the hash of its contents.
+func addToBundle(archive *tar.Writer, entry *bundleEntry) error {
+	file, err := os.Open(longPath(entry.pathname))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = entry.name
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.CopyN(archive, io.TeeReader(file, hash), info.Size()); err != nil {
+		return err
+	}
+	copy(entry.sum[:], hash.Sum(nil))
+
+	return nil
+}
+
+// verifyBundle reads the archive back and checks that it holds the files with
+// the recorded contents, in order.
+func verifyBundle(pathname string, entries []bundleEntry) error {
+	file, err := os.Open(longPath(pathname))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := tar.NewReader(file)
+	for _, entry := range entries {
+		header, err := archive.Next()
+		if err != nil {
+			return fmt.Errorf("verifying %s: %w", pathname, err)
+		}
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, archive); err != nil {
+			return fmt.Errorf("verifying %s: %w", pathname, err)
+		}
+		if header.Name != entry.name || !bytes.Equal(hash.Sum(nil), entry.sum[:]) {
+			return fmt.Errorf("verifying %s: %s doesn't match the original", pathname, header.Name)
+		}
+	}
+
+	return nil
+}
+
+// startOfMonth returns midnight at the start of the month of t, in t's timezone.
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,78 @@
+package dailylogger
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMonthlyBundle checks that the log files for a finished month and their
+// artifacts are bundled into a tar archive and the originals deleted.
+func TestMonthlyBundle(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-01-30.bar", []byte("30th"), 0644)
+	os.WriteFile("foo.2020-01-31.bar", []byte("31st"), 0644)
+	os.WriteFile("foo.2020-01-31.bar.upper", []byte("31ST"), 0644)
+	os.WriteFile("foo.2020-02-01.bar", []byte("1st"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 20)
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithArtifact(upperCaseArtifact{}),
+		WithMonthlyBundle(), WithLifecycleEvents(), WithEventChannel(events))
+	defer writer.Close()
+
+	event := nextEvent(events, EventBundleMade)
+	if event.Path != "./foo.2020-01.tar" {
+		t.Fatalf("want the archive for January, got %v", event)
+	}
+
+	file, err := os.Open("foo.2020-01.tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var testData = []struct {
+		name     string
+		contents string
+	}{
+		{"foo.2020-01-30.bar", "30th"},
+		{"foo.2020-01-31.bar", "31st"},
+		{"foo.2020-01-31.bar.upper", "31ST"},
+	}
+	archive := tar.NewReader(file)
+	for _, td := range testData {
+		header, err := archive.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, _ := io.ReadAll(archive)
+		if header.Name != td.name || string(contents) != td.contents {
+			t.Errorf("want %s %q got %s %q", td.name, td.contents, header.Name, contents)
+		}
+		if _, err := os.Stat(td.name); !os.IsNotExist(err) {
+			t.Errorf("want %s deleted, got %v", td.name, err)
+		}
+	}
+	if _, err := archive.Next(); err != io.EOF {
+		t.Errorf("want the end of the archive, got %v", err)
+	}
+
+	if _, err := os.Stat("foo.2020-02-01.bar"); err != nil {
+		t.Errorf("want February's file kept - %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package dailylogger
+
+import (
+	"os"
+)
+
+// Checkpoint is a stronger form of Sync for applications that need to tie their
+// own commits to the durability of the log, without paying for a sync on every
+// write.  When it returns nil, everything written by Write calls that returned
+// before Checkpoint was called, including buffered and queued data, is on stable
+// storage.  As well as the log file, it commits the mirror file (see WithMirror),
+// the hex dump file (see WithHexDump), any old log files that are still open and
+// the log directory, so that a log file created since the last checkpoint
+// survives a crash.  A partial record held back by WithWholeLines or
+// WithRecordSplitter isn't included, since it hasn't been completed yet.
+func (dw *Writer) Checkpoint() error {
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	err := dw.flush()
+	if dw.asyncError != nil {
+		err = dw.asyncError
+		dw.asyncError = nil
+	}
+
+	if syncer, ok := dw.logWriter.(interface{ Sync() error }); ok && dw.logFile == nil {
+		// The log is written by a writer from a file factory.
+		if se := syncer.Sync(); se != nil && err == nil {
+			err = se
+		}
+	}
+
+	for _, file := range []*os.File{dw.logFile, dw.mirrorFile, dw.hexDumpFile} {
+		if file == nil || (file == dw.logFile && dw.stream) {
+			continue
+		}
+		if se := file.Sync(); se != nil && err == nil {
+			err = se
+		}
+	}
+
+	if se := dw.files.sync(); se != nil && err == nil {
+		err = se
+	}
+
+	directories := []string{dw.logDir}
+	if len(dw.mirrorDir) > 0 {
+		directories = append(directories, dw.mirrorDir)
+	}
+	for _, directory := range directories {
+		if se := syncDirectory(longPath(directory)); se != nil && err == nil {
+			err = se
+		}
+	}
+
+	return err
+}
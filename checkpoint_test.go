@@ -0,0 +1,47 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCheckpoint checks that Checkpoint writes out buffered and queued data to
+// the log file and its mirror, and that it fails once the Writer is closed.
+func TestCheckpoint(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, "logs", "foo.", ".bar", WithBuffering(100), WithAsync(10), WithMirror("mirror"))
+	writer.Write([]byte("committed"))
+
+	if err := writer.Checkpoint(); err != nil {
+		t.Error(err)
+	}
+
+	for _, filename := range []string{"logs/foo.2020-02-14.bar", "mirror/foo.2020-02-14.bar"} {
+		contents, err := os.ReadFile(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != "committed" {
+			t.Errorf("%s: want \"committed\" got \"%s\"", filename, string(contents))
+		}
+	}
+
+	writer.Close()
+	if err := writer.Checkpoint(); err != ErrClosed {
+		t.Errorf("want ErrClosed got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package dailylogger
+
+import "time"
+
+// clock supplies the time to the log rotator.  The real clock uses the system
+// time.  Unit tests supply a fake one so that they can control the passage of
+// time.
+type clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on
+	// the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock that uses the system time.
+type realClock struct{}
+
+// Now returns the system time.
+func (realClock) Now() time.Time { return time.Now() }
+
+// After returns time.After(d).
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// withClock sets the clock that the Writer uses.  It's used by unit tests.
+func withClock(c clock) Option {
+	return func(dw *Writer) {
+		dw.clock = c
+	}
+}
+
+// WithLocation sets the timezone that defines the start of each day.  The log
+// rolls over at midnight in that timezone and the datestamp in the file name is
+// the date in that timezone.  By default the timezone of the time given to New is
+// used.
+func WithLocation(location *time.Location) Option {
+	return func(dw *Writer) {
+		if location != nil {
+			dw.location = location
+		}
+	}
+}
+
+// now returns the current time in the Writer's timezone.
+func (dw *Writer) now() time.Time {
+	return dw.clock.Now().In(dw.location)
+}
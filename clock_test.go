@@ -0,0 +1,580 @@
+package dailylogger
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger/schedule"
+)
+
+// fakeClock is a clock whose time is controlled by the test.  Like the system
+// clock it has two parts.  The wall clock can be set to any time, including
+// backwards.  Timers run on the monotonic clock, which only moves forward, when
+// the test calls Advance.
+type fakeClock struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	wall     time.Time
+	mono     time.Duration
+	waiters  []fakeWaiter
+	sleepers int
+}
+
+// fakeWaiter is a timer created by fakeClock.After.
+type fakeWaiter struct {
+	deadline time.Duration
+	channel  chan time.Time
+}
+
+// newFakeClock creates a fakeClock showing the given wall clock time.
+func newFakeClock(now time.Time) *fakeClock {
+	fc := fakeClock{wall: now}
+	fc.cond = sync.NewCond(&fc.mutex)
+	return &fc
+}
+
+// Now returns the wall clock time.
+func (fc *fakeClock) Now() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.wall
+}
+
+// After returns a channel that receives the wall clock time once the monotonic
+// clock has been advanced by the given duration.
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	channel := make(chan time.Time, 1)
+	if d <= 0 {
+		channel <- fc.wall
+		return channel
+	}
+
+	fc.waiters = append(fc.waiters, fakeWaiter{deadline: fc.mono + d, channel: channel})
+	fc.sleepers++
+	fc.cond.Broadcast()
+	return channel
+}
+
+// Advance moves the wall and monotonic clocks forward by the given duration and
+// fires any timers that have expired.
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	fc.wall = fc.wall.Add(d)
+	fc.mono += d
+
+	var waiting []fakeWaiter
+	for _, w := range fc.waiters {
+		if w.deadline <= fc.mono {
+			w.channel <- fc.wall
+		} else {
+			waiting = append(waiting, w)
+		}
+	}
+	fc.waiters = waiting
+}
+
+// Set sets the wall clock without changing the monotonic clock, like an
+// administrator or NTP setting the system clock.
+func (fc *fakeClock) Set(now time.Time) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.wall = now
+}
+
+// WaitForSleepers blocks until After has been called the given number of times
+// in total.  The test uses it to wait until the log rotator has gone to sleep.
+func (fc *fakeClock) WaitForSleepers(n int) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	for fc.sleepers < n {
+		fc.cond.Wait()
+	}
+}
+
+// TestGetDurationToJustAfterMidnightNeverNegative checks that the wait is never
+// negative.
+func TestGetDurationToJustAfterMidnightNeverNegative(t *testing.T) {
+	locationUTC, _ := time.LoadLocation("UTC")
+	start := time.Date(2020, time.February, 14, 0, 0, 0, 0, locationUTC)
+
+	for i := 0; i < 48; i++ {
+		now := start.Add(time.Duration(i) * 30 * time.Minute)
+		got := getDurationToJustAfterMidnight(now)
+		if got <= 0 {
+			t.Errorf("%v: want a positive duration got %v", now, got)
+		}
+		if got > 24*time.Hour+extraDuration {
+			t.Errorf("%v: want at most a day got %v", now, got)
+		}
+	}
+}
+
+// TestSchedulerRotatesAtMidnight checks that the log rotator rotates after midnight
+// using the time after it wakes up, not the time when it went to sleep.
+func TestSchedulerRotatesAtMidnight(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename2 = "foo.2020-02-15.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc))
+	defer writer.Close()
+
+	// Wait for the rotator to go to sleep, wake it just after midnight and wait for
+	// it to go to sleep again.
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	fc.WaitForSleepers(2)
+
+	if _, err := os.Stat(wantFilename2); err != nil {
+		t.Errorf("want %s to exist - %v", wantFilename2, err)
+	}
+}
+
+// TestSchedulerClockSetBack checks that if the clock is set back while the log
+// rotator is asleep, it doesn't rotate when it wakes up but goes back to sleep
+// until the real midnight.
+func TestSchedulerClockSetBack(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename2 = "foo.2020-02-15.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc))
+	defer writer.Close()
+
+	// The rotator sleeps for an hour.  While it's asleep, set the clock back half an
+	// hour.  When it wakes up it's 23:30 so it should go back to sleep.
+	fc.WaitForSleepers(1)
+	fc.Set(time.Date(2020, time.February, 14, 22, 30, 0, 0, locationUTC))
+	fc.Advance(time.Hour + extraDuration)
+	fc.WaitForSleepers(2)
+
+	if _, err := os.Stat(wantFilename2); err == nil {
+		t.Errorf("want no rotation before midnight but %s exists", wantFilename2)
+		return
+	}
+
+	// Wake it up after midnight.  Now it should rotate.
+	fc.Advance(30 * time.Minute)
+	fc.WaitForSleepers(3)
+
+	if _, err := os.Stat(wantFilename2); err != nil {
+		t.Errorf("want %s to exist - %v", wantFilename2, err)
+	}
+}
+
+// TestSchedulerClockSetBackADay checks that if the clock is set back to the
+// previous day, the log rotator doesn't rotate at the next midnight, which is the
+// start of the day it's already logging.
+func TestSchedulerClockSetBackADay(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc))
+	defer writer.Close()
+
+	fc.WaitForSleepers(1)
+	fc.Set(time.Date(2020, time.February, 13, 23, 0, 0, 0, locationUTC))
+	fc.Advance(time.Hour + extraDuration)
+	fc.WaitForSleepers(2)
+
+	files, err := os.ReadDir(directoryName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(files) != 1 {
+		t.Errorf("want 1 file got %d", len(files))
+	}
+}
+
+// panickingInstrumentation is an Instrumentation that panics the first time an
+// operation starts.
+type panickingInstrumentation struct {
+	panicked bool
+}
+
+func (pi *panickingInstrumentation) Wrote(n int, err error) {}
+
+func (pi *panickingInstrumentation) StartOperation(name string) func(error) {
+	if !pi.panicked {
+		pi.panicked = true
+		panic("bad hook")
+	}
+	return func(error) {}
+}
+
+// TestSchedulerRecoversFromPanic checks that the log rotator survives a panic
+// during rotation, records it and rotates again the next night.
+func TestSchedulerRecoversFromPanic(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithInstrumentation(&panickingInstrumentation{}))
+	defer writer.Close()
+
+	// The first rotation panics.  The rotator recovers and goes back to sleep.
+	fc.WaitForSleepers(1)
+	fc.Advance(time.Hour)
+	fc.WaitForSleepers(2)
+
+	stats := writer.Stats()
+	if !strings.Contains(stats.LastRotatorError, "bad hook") {
+		t.Errorf("want the panic in LastRotatorError got \"%s\"", stats.LastRotatorError)
+	}
+
+	// The rotator tries again when it next wakes, which is within an hour.
+	fc.Advance(time.Hour)
+	fc.WaitForSleepers(3)
+
+	writer.Write([]byte("hello"))
+	if _, err := os.Stat("foo.2020-02-15.bar"); err != nil {
+		t.Errorf("want the log to be rotated after the panic - %v", err)
+	}
+}
+
+// panicOnFirstClose returns an event handler that panics the first time a log
+// file is closed, after the old file has been closed and before the new one
+// has been opened.
+func panicOnFirstClose() func(Event) {
+	panicked := false
+	return func(event Event) {
+		if event.Type == EventFileClosed && !panicked {
+			panicked = true
+			panic("bad handler")
+		}
+	}
+}
+
+// TestRotatorRecoversAfterClose checks that when the log rotator panics after
+// closing the old log file, the log is reopened for the new day, so later writes
+// land in a file rather than being thrown away.
+func TestRotatorRecoversAfterClose(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithLifecycleEvents(),
+		WithEventHandler(panicOnFirstClose()))
+	defer writer.Close()
+
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	fc.WaitForSleepers(2)
+
+	if !strings.Contains(writer.Stats().LastRotatorError, "bad handler") {
+		t.Errorf("want the panic in LastRotatorError got \"%s\"", writer.Stats().LastRotatorError)
+	}
+
+	if _, err := writer.Write([]byte("hello")); err != nil {
+		t.Errorf("want the write to succeed - %v", err)
+	}
+	contents, err := os.ReadFile("foo.2020-02-15.bar")
+	if err != nil || string(contents) != "hello" {
+		t.Errorf("want \"hello\" in the new day's file, got \"%s\", %v", contents, err)
+	}
+}
+
+// TestTriggerRotation checks that TriggerRotation rotates only when the day has
+// changed.
+func TestTriggerRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar")
+	defer writer.Close()
+
+	var testData = []struct {
+		description string
+		now         time.Time
+		want        bool
+		wantFile    string
+	}{
+		{"same day", now.Add(time.Hour), false, "foo.2020-02-14.bar"},
+		{"next day", now.Add(12 * time.Hour), true, "foo.2020-02-15.bar"},
+		{"again", now.Add(13 * time.Hour), false, "foo.2020-02-15.bar"},
+		{"earlier day", now, false, "foo.2020-02-15.bar"},
+	}
+
+	for _, td := range testData {
+		if got := writer.TriggerRotation(td.now); got != td.want {
+			t.Errorf("%s: want %v got %v", td.description, td.want, got)
+		}
+		if !strings.HasSuffix(writer.pathname, td.wantFile) {
+			t.Errorf("%s: want %s got %s", td.description, td.wantFile, writer.pathname)
+		}
+	}
+}
+
+// TestDateNeverGoesBack checks that rotating with a time on an earlier day than
+// the current log file's carries on with the current day and reports it.
+func TestDateNeverGoesBack(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 15, 0, 0, 1, 0, locationUTC)
+	earlier := time.Date(2020, time.February, 14, 23, 59, 59, 0, locationUTC)
+
+	var events []Event
+	handler := func(event Event) { events = append(events, event) }
+	writer := New(now, ".", "foo.", ".bar", WithEventHandler(handler))
+	defer writer.Close()
+
+	writer.Write([]byte("a"))
+	writer.rotateLogs(earlier)
+	writer.Write([]byte("b"))
+	writer.rotate(earlier)
+	writer.Write([]byte("c"))
+
+	if len(events) != 2 || events[0].Type != EventClockWentBack || events[1].Type != EventClockWentBack {
+		t.Errorf("want two %v events got %v", EventClockWentBack, events)
+	}
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-15.bar", "ab"},
+		{"foo.2020-02-15.1.bar", "c"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+
+	if _, err := os.Stat("foo.2020-02-14.bar"); !os.IsNotExist(err) {
+		t.Errorf("want no file for the earlier day, got %v", err)
+	}
+}
+
+// TestLazyRotation checks that in lazy mode Write rotates the log when the day
+// has changed and that there's no log rotator.
+func TestLazyRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 59, 59, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithLazyRotation())
+	defer writer.Close()
+
+	writer.Write([]byte("before"))
+	fc.Set(now.Add(time.Second))
+	writer.Write([]byte("after"))
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar", "before"},
+		{"foo.2020-02-15.bar", "after"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+
+	fc.mutex.Lock()
+	sleepers := fc.sleepers
+	fc.mutex.Unlock()
+	if sleepers != 0 {
+		t.Errorf("want no log rotator, got %d sleepers", sleepers)
+	}
+}
+
+// TestRotationSlack checks that the log rotator wakes up the given slack after
+// midnight, not before.
+func TestRotationSlack(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename2 = "foo.2020-02-15.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithRotationSlack(time.Second))
+	defer writer.Close()
+
+	// Just after midnight the rotator is still asleep.
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	if _, err := os.Stat(wantFilename2); err == nil {
+		t.Errorf("want no %s before the slack has passed", wantFilename2)
+	}
+
+	// After the slack it has rotated the log and gone back to sleep.
+	fc.Advance(time.Second)
+	fc.WaitForSleepers(2)
+	if _, err := os.Stat(wantFilename2); err != nil {
+		t.Errorf("want %s to exist - %v", wantFilename2, err)
+	}
+}
+
+// TestSchedulePolicy checks that the log rotator rotates the log exactly at the
+// boundary given by the Writer's schedule policy.
+func TestSchedulePolicy(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename2 = "foo.2020-02-15.bar"
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, time.February, 14, 22, 30, 0, 0, time.UTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithLocation(paris),
+		WithRotationSlack(time.Second))
+	defer writer.Close()
+
+	policy := writer.SchedulePolicy()
+	if policy.Location != paris || policy.Slack != time.Second || policy.Jitter != 0 {
+		t.Errorf("want Paris with a second's slack and no jitter, got %+v", policy)
+	}
+
+	boundary := schedule.NextBoundary(now, policy)
+	want := time.Date(2020, time.February, 15, 0, 0, 1, 0, paris)
+	if !boundary.Equal(want) {
+		t.Errorf("want the boundary at %v, got %v", want, boundary)
+	}
+	if got := schedule.BoundaryFor(want, policy); !got.Equal(boundary) {
+		t.Errorf("want BoundaryFor to give %v, got %v", boundary, got)
+	}
+
+	// Just before the boundary the rotator is still asleep.
+	fc.WaitForSleepers(1)
+	fc.Advance(boundary.Sub(now) - time.Nanosecond)
+	if _, err := os.Stat(wantFilename2); err == nil {
+		t.Errorf("want no %s before the boundary", wantFilename2)
+	}
+
+	// At the boundary it rotates the log and goes back to sleep.
+	fc.Advance(time.Nanosecond)
+	fc.WaitForSleepers(2)
+	if _, err := os.Stat(wantFilename2); err != nil {
+		t.Errorf("want %s to exist - %v", wantFilename2, err)
+	}
+}
@@ -0,0 +1,268 @@
+// Command dailystress is a soak and performance test for the daily log Writer.
+// It runs a number of goroutines that write numbered records for a given time,
+// reports the throughput as it goes and at the end reads the log files back to
+// check that each goroutine's records were all written, once each and in order.
+// It exits with status 1 if the check finds a problem and 2 if the test can't
+// be run.
+//
+// Usage:
+//
+//	dailystress [-dir directory] [-duration 1h] [-writers 8] [-size 128]
+//	            [-mode sync|buffered|async] [-rate 0] [-midnight 1m] [-report 10s]
+//
+// The -midnight flag runs the Writer in a timezone of its own, whose midnight
+// falls the given time after the start, so that a run crosses a day boundary
+// without waiting for the real one.  The -rate flag limits each goroutine to the
+// given number of records a second (0 means as fast as it can).  The log files
+// are left in the directory, which must not hold log files from an earlier run.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+)
+
+// counters are shared by the goroutines that write the records.
+type counters struct {
+	records    atomic.Int64
+	bytes      atomic.Int64
+	errors     atomic.Int64
+	maxLatency atomic.Int64
+}
+
+func main() {
+	dir := flag.String("dir", "stress", "the log directory")
+	duration := flag.Duration("duration", time.Hour, "how long to run")
+	writers := flag.Int("writers", 8, "the number of goroutines writing")
+	size := flag.Int("size", 128, "the size of each record in bytes")
+	mode := flag.String("mode", "buffered", "the mode of the Writer: sync, buffered or async")
+	rate := flag.Int("rate", 0, "the records a second written by each goroutine (0 means no limit)")
+	midnight := flag.Duration("midnight", 0, "put midnight this long after the start (0 means use the local timezone)")
+	report := flag.Duration("report", 10*time.Second, "the time between progress reports")
+	flag.Parse()
+
+	var options []any
+	switch *mode {
+	case "sync":
+	case "buffered":
+		options = append(options, dailylogger.WithBuffering(64*1024))
+	case "async":
+		options = append(options, dailylogger.WithBuffering(64*1024), dailylogger.WithAsync(1024))
+	default:
+		fmt.Fprintf(os.Stderr, "dailystress: unknown mode %q\n", *mode)
+		os.Exit(2)
+	}
+	if *writers < 1 || *size < 32 {
+		fmt.Fprintln(os.Stderr, "dailystress: there must be at least one writer and the size must be at least 32")
+		os.Exit(2)
+	}
+
+	location := time.Local
+	if *midnight > 0 {
+		location = zoneWithMidnightIn(*midnight)
+	}
+	options = append(options, dailylogger.WithLocation(location))
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	writer := dailylogger.New(time.Now(), *dir, "stress.", ".log", options...)
+
+	var counts counters
+	written := make([]int64, *writers)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := range *writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			written[w] = writeRecords(writer, w, *size, *rate, stop, &counts)
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(*report)
+	deadline := time.After(*duration)
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			progress(&counts, time.Since(start))
+		case <-deadline:
+			running = false
+		}
+	}
+	ticker.Stop()
+	close(stop)
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := writer.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "dailystress: error closing the Writer -", err)
+	}
+	progress(&counts, elapsed)
+
+	problems, files, err := check(writer, written)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	fmt.Printf("checked %d records in %d files, %d problems\n", counts.records.Load(), files, len(problems))
+	if len(problems) > 0 || counts.errors.Load() > 0 {
+		os.Exit(1)
+	}
+}
+
+// zoneWithMidnightIn returns a timezone in which the next midnight is the given
+// time from now, to the nearest second.
+func zoneWithMidnightIn(lead time.Duration) *time.Location {
+	const day = 24 * 60 * 60
+	now := time.Now().UTC()
+	sinceMidnight := now.Hour()*3600 + now.Minute()*60 + now.Second()
+	offset := ((day-int(lead.Seconds())-sinceMidnight)%day + day) % day
+	if offset > day/2 {
+		offset -= day
+	}
+
+	return time.FixedZone("stress", offset)
+}
+
+// writeRecords writes records from the given writer number until told to stop
+// and returns the number written successfully.  Each record holds the writer
+// number and its own number, padded to the size.
+func writeRecords(writer *dailylogger.Writer, w, size, rate int, stop <-chan struct{}, counts *counters) int64 {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+
+	record := make([]byte, 0, size)
+	next := time.Now()
+	for n := int64(0); ; {
+		select {
+		case <-stop:
+			return n
+		default:
+		}
+
+		record = fmt.Appendf(record[:0], "w%d n%d ", w, n)
+		for len(record) < size-1 {
+			record = append(record, 'x')
+		}
+		record = append(record, '\n')
+
+		start := time.Now()
+		_, err := writer.Write(record)
+		latency := int64(time.Since(start))
+		for latest := counts.maxLatency.Load(); latency > latest; latest = counts.maxLatency.Load() {
+			if counts.maxLatency.CompareAndSwap(latest, latency) {
+				break
+			}
+		}
+		if err != nil {
+			counts.errors.Add(1)
+		} else {
+			n++
+			counts.records.Add(1)
+			counts.bytes.Add(int64(len(record)))
+		}
+
+		if interval > 0 {
+			next = next.Add(interval)
+			time.Sleep(time.Until(next))
+		}
+	}
+}
+
+// progress reports the counts so far.
+func progress(counts *counters, elapsed time.Duration) {
+	seconds := max(elapsed.Seconds(), 0.001)
+	fmt.Printf("%v: %d records, %.1f records/s, %.2f MB/s, %d errors, max latency %v\n",
+		elapsed.Round(time.Second), counts.records.Load(), float64(counts.records.Load())/seconds,
+		float64(counts.bytes.Load())/seconds/1e6, counts.errors.Load(), time.Duration(counts.maxLatency.Load()))
+}
+
+// check reads the log files back and returns a description of each problem
+// found and the number of files.  Each writer's records should be numbered from
+// 0 to one less than the number written.
+func check(writer *dailylogger.Writer, written []int64) ([]string, int, error) {
+	logFiles, err := writer.List()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	const maxProblems = 20
+	var problems []string
+	next := make([]int64, len(written))
+	for _, logFile := range logFiles {
+		reader, err := writer.OpenLogFile(logFile)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(nil, 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			w, n, ok := parseRecord(scanner.Bytes())
+			switch {
+			case !ok || w >= len(written):
+				problems = append(problems, fmt.Sprintf("%s: line %d isn't a record", logFile.Name, line))
+			case n != next[w]:
+				problems = append(problems, fmt.Sprintf("%s: line %d: writer %d: want record %d got %d",
+					logFile.Name, line, w, next[w], n))
+				next[w] = n + 1
+			default:
+				next[w]++
+			}
+			if len(problems) >= maxProblems {
+				reader.Close()
+				return problems, len(logFiles), nil
+			}
+		}
+		err = scanner.Err()
+		reader.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", logFile.Name, err)
+		}
+	}
+
+	for w := range written {
+		if next[w] != written[w] {
+			problems = append(problems, fmt.Sprintf("writer %d: wrote %d records, found %d", w, written[w], next[w]))
+		}
+	}
+
+	return problems, len(logFiles), nil
+}
+
+// parseRecord returns the writer number and the record number from a record
+// written by writeRecords.
+func parseRecord(record []byte) (int, int64, bool) {
+	fields := bytes.Fields(record)
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+
+	w, err := strconv.Atoi(strings.TrimPrefix(string(fields[0]), "w"))
+	if err != nil || w < 0 {
+		return 0, 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(string(fields[1]), "n"), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return w, n, true
+}
@@ -0,0 +1,105 @@
+// Command dailyverify checks a log directory against the manifests written by a
+// daily log Writer (see dailylogger.WithManifest).  It reports each file that's
+// missing or doesn't match its manifest and, if given the public key, each
+// manifest whose signature is missing or wrong.  It exits with status 1 if it
+// finds a problem and 2 if it can't do the check.
+//
+// Usage:
+//
+//	dailyverify [-key public.key] directory
+//	dailyverify -generate name
+//
+// The keys are kept as base64 text.  The second form makes a new Ed25519 key
+// pair in the files name.key and name.pub.  The private key is given to
+// WithManifest by the logging program and the public key to dailyverify.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goblimey/dailylogger"
+)
+
+func main() {
+	keyFile := flag.String("key", "", "the file holding the public key (if not given, signatures aren't checked)")
+	generate := flag.String("generate", "", "make a key pair in the files `name`.key and name.pub")
+	flag.Parse()
+
+	if len(*generate) > 0 {
+		if err := generateKeys(*generate); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: dailyverify [-key public.key] directory")
+		os.Exit(2)
+	}
+
+	var publicKey ed25519.PublicKey
+	if len(*keyFile) > 0 {
+		key, err := readKey(*keyFile, ed25519.PublicKeySize)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		publicKey = key
+	}
+
+	problems, err := dailylogger.VerifyManifests(flag.Arg(0), publicKey)
+	for _, problem := range problems {
+		if len(problem.Name) > 0 {
+			fmt.Printf("%s: %s: %v\n", problem.Manifest, problem.Name, problem.Err)
+		} else {
+			fmt.Printf("%s: %v\n", problem.Manifest, problem.Err)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readKey reads a key of the given size from a file of base64 text.
+func readKey(pathname string, size int) ([]byte, error) {
+	encoded, err := os.ReadFile(pathname)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pathname, err)
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("%s: the key is %d bytes, not %d", pathname, len(key), size)
+	}
+
+	return key, nil
+}
+
+// generateKeys makes a new key pair and writes it to name.key and name.pub.  The
+// private key is only readable by its owner.
+func generateKeys(name string) error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(name+".key", []byte(base64.StdEncoding.EncodeToString(privateKey)+"\n"), 0600)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name+".pub", []byte(base64.StdEncoding.EncodeToString(publicKey)+"\n"), 0644)
+}
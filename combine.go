@@ -0,0 +1,150 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Combined is an io.WriteCloser that writes the same data to several
+// destinations, for example a daily Writer, os.Stdout and a network connection.
+// Unlike io.MultiWriter it carries on writing to the others when one fails, and
+// it passes Sync and Close on to the destinations that support them.  It's safe
+// for concurrent use, and every destination receives the writes in the same
+// order.  Create it with Combine.
+type Combined struct {
+	mutex        sync.Mutex
+	writers      []io.Writer
+	errorHandler func(io.Writer, error)
+	closed       bool
+}
+
+// Combine creates a Combined that writes to the given writers in the order given.
+// Nil writers are ignored.  Close closes each writer that has a Close method, so
+// a writer that should stay open, such as os.Stdout, should be wrapped with
+// NoClose.
+func Combine(writers ...io.Writer) *Combined {
+	combined := Combined{}
+	for _, writer := range writers {
+		if writer != nil {
+			combined.writers = append(combined.writers, writer)
+		}
+	}
+	return &combined
+}
+
+// WithErrorHandler sets a function that receives each error from a destination
+// along with the destination, for example to count the failures of each one.  It
+// returns the Combined so that it can be chained with Combine.
+func (c *Combined) WithErrorHandler(handler func(writer io.Writer, err error)) *Combined {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.errorHandler = handler
+	return c
+}
+
+// Write writes the buffer to every destination.  If all of them take all of it,
+// it returns the length of the buffer and nil.  Otherwise it still returns the
+// length of the buffer, since the data reached the other destinations, along
+// with the errors joined together.  A destination that writes less than the
+// whole buffer without an error counts as failing with io.ErrShortWrite.
+func (c *Combined) Write(buffer []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return 0, ErrClosed
+	}
+
+	return len(buffer), c.each(func(writer io.Writer) error {
+		n, err := writer.Write(buffer)
+		if err == nil && n < len(buffer) {
+			err = io.ErrShortWrite
+		}
+		return err
+	})
+}
+
+// Sync flushes the destinations that have a Sync method, such as a daily Writer
+// or an *os.File, and returns any errors joined together.
+func (c *Combined) Sync() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	return c.each(func(writer io.Writer) error {
+		if syncer, ok := writer.(interface{ Sync() error }); ok {
+			return syncer.Sync()
+		}
+		return nil
+	})
+}
+
+// Close closes the destinations that have a Close method, in the order given, and
+// returns any errors joined together.  Closing a Combined twice returns
+// ErrClosed.
+func (c *Combined) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.closed = true
+
+	return c.each(func(writer io.Writer) error {
+		if closer, ok := writer.(io.Closer); ok {
+			return closer.Close()
+		}
+		return nil
+	})
+}
+
+// each is a helper function that applies the operation to every destination,
+// reports each error to the error handler and returns the errors joined
+// together.  The caller must hold the lock.
+func (c *Combined) each(operation func(io.Writer) error) error {
+	var errs []error
+	for i, writer := range c.writers {
+		err := operation(writer)
+		if err == nil {
+			continue
+		}
+
+		if c.errorHandler != nil {
+			c.errorHandler(writer, err)
+		}
+		errs = append(errs, fmt.Errorf("destination %d: %w", i, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// NoClose returns a writer that passes writes and Sync calls on to the given
+// writer but has no Close method, so Combine leaves it open.
+func NoClose(writer io.Writer) io.Writer {
+	return noCloseWriter{writer}
+}
+
+// noCloseWriter hides the Close method of the writer it holds.
+type noCloseWriter struct {
+	writer io.Writer
+}
+
+// Write writes to the writer.
+func (nc noCloseWriter) Write(buffer []byte) (int, error) {
+	return nc.writer.Write(buffer)
+}
+
+// Sync syncs the writer, if it can be synced.
+func (nc noCloseWriter) Sync() error {
+	if syncer, ok := nc.writer.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// brokenWriter fails every write and counts the calls to Sync and Close.
+type brokenWriter struct {
+	syncs  int
+	closes int
+}
+
+func (bw *brokenWriter) Write(buffer []byte) (int, error) { return 0, errors.New("broken") }
+func (bw *brokenWriter) Sync() error                      { bw.syncs++; return nil }
+func (bw *brokenWriter) Close() error                     { bw.closes++; return nil }
+
+// TestCombine checks that a write reaches every destination even if one fails,
+// and that Sync and Close are passed on.
+func TestCombine(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithBuffering(1024))
+	var memory bytes.Buffer
+	broken := &brokenWriter{}
+	kept := &brokenWriter{}
+
+	var failed []io.Writer
+	combined := Combine(broken, writer, nil, &memory, NoClose(kept)).
+		WithErrorHandler(func(w io.Writer, err error) { failed = append(failed, w) })
+
+	n, err := combined.Write([]byte("hello\n"))
+	if n != 6 || err == nil {
+		t.Errorf("want 6 and an error got %d %v", n, err)
+	}
+	if len(failed) != 2 || failed[0] != broken {
+		t.Errorf("want the broken writers reported got %v", failed)
+	}
+
+	if err := combined.Sync(); err != nil {
+		t.Errorf("want no error from Sync got %v", err)
+	}
+	if broken.syncs != 1 || kept.syncs != 1 {
+		t.Errorf("want each writer synced once got %d and %d", broken.syncs, kept.syncs)
+	}
+
+	// The Writer is buffered, so the data is only in the file once it's synced.
+	contents, _ := os.ReadFile("foo.2020-02-14.bar")
+	if string(contents) != "hello\n" || memory.String() != "hello\n" {
+		t.Errorf("want \"hello\\n\" in the file and in memory got \"%s\" and \"%s\"",
+			string(contents), memory.String())
+	}
+
+	if err := combined.Close(); err != nil {
+		t.Errorf("want no error from Close got %v", err)
+	}
+	if broken.closes != 1 || kept.closes != 0 {
+		t.Errorf("want only the first broken writer closed got %d and %d", broken.closes, kept.closes)
+	}
+	if _, err := writer.Write([]byte("x")); !errors.Is(err, ErrClosed) {
+		t.Errorf("want the Writer closed got %v", err)
+	}
+	if _, err := combined.Write([]byte("x")); !errors.Is(err, ErrClosed) {
+		t.Errorf("want ErrClosed got %v", err)
+	}
+}
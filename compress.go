@@ -0,0 +1,201 @@
+package dailylogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// A Compressor compresses the log files once the Writer has finished with them
+// (see WithCompression).
+type Compressor interface {
+	// Extension returns the string added to the log file's name to make the
+	// compressed file's, for example ".gz".
+	Extension() string
+
+	// NewWriter returns a writer that compresses the data written to it and writes
+	// the result to dest.  Close flushes it.
+	NewWriter(dest io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decompresses the data from source.
+	NewReader(source io.Reader) (io.ReadCloser, error)
+}
+
+// GzipCompressor is a Compressor that produces gzip files with the extension
+// ".gz".
+type GzipCompressor struct {
+	level int // The compression level, as in compress/gzip.
+}
+
+// This is a compile-time check that GzipCompressor implements the Compressor
+// interface.
+var _ Compressor = (*GzipCompressor)(nil)
+
+// NewGzipCompressor creates a GzipCompressor with the given compression level,
+// from gzip.BestSpeed to gzip.BestCompression, or gzip.DefaultCompression.
+func NewGzipCompressor(level int) *GzipCompressor {
+	return &GzipCompressor{level: level}
+}
+
+// Extension returns ".gz".
+func (gc *GzipCompressor) Extension() string {
+	return ".gz"
+}
+
+// NewWriter returns a gzip writer.
+func (gc *GzipCompressor) NewWriter(dest io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(dest, gc.level)
+}
+
+// NewReader returns a gzip reader.
+func (gc *GzipCompressor) NewReader(source io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(source)
+}
+
+// WithCompression makes the Writer compress each log file once it has rotated
+// away from it, and delete the original once the compressed file is complete.
+// The compressed file has the name of the log file plus the Compressor's
+// extension, for example "foo.2020-02-14.bar.gz", and is written atomically (see
+// WithArtifact) after any artifacts are made, so the artifacts are made from the
+// original.  It's subject to WithArtifactWorkers, WithArtifactPriority and
+// WithArtifactProgress.  List includes the compressed files, with Compressed
+// set, so the retention rules apply to them as usual, and OpenLogFile reads them.
+// If compression fails the original is kept.  The option has no effect when
+// rotation is disabled.
+func WithCompression(compressor Compressor) Option {
+	return func(dw *Writer) {
+		dw.compressor = compressor
+	}
+}
+
+// OpenLogFile opens a log file returned by List for reading.  If the file has
+// been compressed (see WithCompression), the data is decompressed.
+func (dw *Writer) OpenLogFile(logFile LogFile) (io.ReadCloser, error) {
+	file, err := os.Open(longPath(logFile.Pathname))
+	if err != nil {
+		return nil, err
+	}
+	if !logFile.Compressed {
+		return file, nil
+	}
+	if dw.compressor == nil {
+		file.Close()
+		return nil, fmt.Errorf("OpenLogFile: %s is compressed but there is no Compressor", logFile.Pathname)
+	}
+
+	reader, err := dw.compressor.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("OpenLogFile: %s - %w", logFile.Pathname, err)
+	}
+
+	return &decompressingReader{ReadCloser: reader, file: file}, nil
+}
+
+// decompressingReader reads a compressed log file.  Close closes the file as well
+// as the decompressor.
+type decompressingReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+// Close closes the decompressor and the file.
+func (dr *decompressingReader) Close() error {
+	err := dr.ReadCloser.Close()
+	if fe := dr.file.Close(); fe != nil && err == nil {
+		err = fe
+	}
+	return err
+}
+
+// compressionArtifact is an Artifact that makes the compressed copy of a log
+// file, so that compression goes through the same machinery as the artifacts.
+type compressionArtifact struct {
+	compressor Compressor
+}
+
+// Suffix returns the Compressor's extension.
+func (ca compressionArtifact) Suffix() string {
+	return ca.compressor.Extension()
+}
+
+// Make compresses the log file.
+func (ca compressionArtifact) Make(dest io.Writer, logFile io.Reader) error {
+	writer, err := ca.compressor.NewWriter(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, logFile); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// compress is a helper function for makeArtifacts that compresses the log file
+// for the given day and deletes the original.  A file that is excluded (see
+// WithRetentionFilter and Hold) is left as it is.  With WithInUseCheck it waits
+// until no other program is using the file, and gives up if the Writer is
+// closed first.  It returns the path name of the compressed file, or of the
+// original if that's been kept.
+func (dw *Writer) compress(pathname string, day time.Time) string {
+	dw.logMutex.Lock()
+	name := strings.TrimPrefix(pathname, dw.logDir+"/")
+	excluded := dw.excluded(LogFile{Name: name, Pathname: pathname, Date: day})
+	dw.logMutex.Unlock()
+	if excluded {
+		return pathname
+	}
+
+	if dw.inUseCheck && !dw.waitUntilFree(pathname) {
+		return pathname
+	}
+
+	artifact := compressionArtifact{dw.compressor}
+	err := dw.makeArtifact(pathname, artifact)
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error compressing %s - %w", pathname, err))
+		dw.emitLifecycle(Event{Type: EventArtifactFailed, Path: pathname + artifact.Suffix(), Err: err})
+		return pathname
+	}
+	dw.emitLifecycle(Event{Type: EventArtifactMade, Path: pathname + artifact.Suffix()})
+
+	reason := fmt.Errorf("compressed into %s", pathname+artifact.Suffix())
+	if err := dw.removeFile(pathname, reason); err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error removing %s after compressing it - %w", pathname, err))
+	}
+
+	return pathname + artifact.Suffix()
+}
+
+// parseListedName is a helper function for listing that checks that the given
+// file name is of a log file, possibly compressed (see WithCompression).  If so
+// it returns the date and sequence number (see parseLogFileName), true if the
+// file is compressed and true.  Otherwise the last result is false.
+func (dw *Writer) parseListedName(name string) (time.Time, int, bool, bool) {
+	if dw.compressor != nil {
+		if base, ok := strings.CutSuffix(name, dw.compressor.Extension()); ok {
+			date, sequence, ok := dw.parseLogFileName(base)
+			return date, sequence, true, ok
+		}
+	}
+
+	date, sequence, ok := dw.parseLogFileName(name)
+	return date, sequence, false, ok
+}
+
+// uncompressedPathname returns the path name that the log file had before it
+// was compressed, or its path name if it isn't compressed.
+func (dw *Writer) uncompressedPathname(logFile LogFile) string {
+	if !logFile.Compressed {
+		return logFile.Pathname
+	}
+	return strings.TrimSuffix(logFile.Pathname, dw.compressor.Extension())
+}
@@ -0,0 +1,86 @@
+package dailylogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCompression checks that the old log files are compressed and the originals
+// deleted, that List and OpenLogFile understand the compressed files and that a
+// compressed file is never written to again.
+func TestCompression(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	compressor := NewGzipCompressor(gzip.BestSpeed)
+	writer := New(now, ".", "foo.", ".bar", WithCompression(compressor))
+	writer.Write([]byte("first\n"))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("second\n"))
+	writer.Close()
+
+	// Restarting on the first day mustn't reopen the compressed file.
+	writer = New(now, ".", "foo.", ".bar", WithCompression(compressor))
+	writer.Write([]byte("third\n"))
+	writer.Close()
+
+	if _, err := os.Stat("foo.2020-02-14.bar"); !os.IsNotExist(err) {
+		t.Errorf("want the original deleted, got %v", err)
+	}
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testData = []struct {
+		name       string
+		compressed bool
+		want       string
+	}{
+		{"foo.2020-02-14.bar.gz", true, "first\n"},
+		{"foo.2020-02-14.1.bar", false, "third\n"},
+		{"foo.2020-02-15.bar", false, "second\n"},
+	}
+
+	if len(logFiles) != len(testData) {
+		t.Fatalf("want %d files got %v", len(testData), logFiles)
+	}
+
+	for i, td := range testData {
+		logFile := logFiles[i]
+		if logFile.Name != td.name || logFile.Compressed != td.compressed {
+			t.Errorf("%d: want %s compressed %v got %s %v",
+				i, td.name, td.compressed, logFile.Name, logFile.Compressed)
+			continue
+		}
+
+		reader, err := writer.OpenLogFile(logFile)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		contents, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Error(err)
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want %q got %q", td.name, td.want, string(contents))
+		}
+	}
+}
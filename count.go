@@ -0,0 +1,145 @@
+package dailylogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CountLines returns the number of lines in the log files for the day containing
+// the given time.  If the day has a sidecar (see WithSidecar), the count comes
+// from there.  Otherwise the files are read, decompressing any that have been
+// compressed.  The current log file may have lines still in the Writer's
+// buffer, so call Sync before counting today's lines.
+func (dw *Writer) CountLines(day time.Time) (int64, error) {
+	if sidecar, ok := dw.readSidecar(day); ok {
+		return sidecar.Lines, nil
+	}
+
+	var lines int64
+	err := dw.scanDay(day, func(chunk []byte) {
+		lines += int64(bytes.Count(chunk, []byte{'\n'}))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("CountLines: %w", err)
+	}
+
+	return lines, nil
+}
+
+// Size returns the number of bytes in the log files for the day containing the
+// given time, before any compression.  If the day has a sidecar (see
+// WithSidecar), the size comes from there.  Otherwise it's the total size of
+// the files, except that any that have been compressed are read to find out how
+// big they were.
+func (dw *Writer) Size(day time.Time) (int64, error) {
+	if sidecar, ok := dw.readSidecar(day); ok {
+		return sidecar.Bytes, nil
+	}
+
+	logFiles, err := dw.dayFiles(day)
+	if err != nil {
+		return 0, fmt.Errorf("Size: %w", err)
+	}
+
+	var size int64
+	for _, logFile := range logFiles {
+		if !logFile.Compressed {
+			info, err := os.Stat(longPath(logFile.Pathname))
+			if err != nil {
+				return 0, fmt.Errorf("Size: %w", err)
+			}
+			size += info.Size()
+			continue
+		}
+
+		err := dw.scanFile(logFile, func(chunk []byte) { size += int64(len(chunk)) })
+		if err != nil {
+			return 0, fmt.Errorf("Size: %w", err)
+		}
+	}
+
+	return size, nil
+}
+
+// readSidecar returns the sidecar for the day containing the given time and
+// true, or false if there isn't one or it can't be read.
+func (dw *Writer) readSidecar(day time.Time) (Sidecar, bool) {
+	date := getLastMidnight(day.In(dw.location))
+	pathname := dw.logDir + "/" + filepath.Base(dw.fileNamer().Name(date, 0)) + sidecarExtension
+
+	var sidecar Sidecar
+	contents, err := os.ReadFile(longPath(pathname))
+	if err != nil || json.Unmarshal(contents, &sidecar) != nil {
+		return Sidecar{}, false
+	}
+
+	return sidecar, true
+}
+
+// dayFiles returns the log files for the day containing the given time.  If
+// there are none, the error wraps fs.ErrNotExist.
+func (dw *Writer) dayFiles(day time.Time) ([]LogFile, error) {
+	date := getLastMidnight(day.In(dw.location))
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []LogFile
+	for _, logFile := range logFiles {
+		if logFile.Date.Equal(date) {
+			found = append(found, logFile)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no log files for %s - %w", date.Format(time.DateOnly), fs.ErrNotExist)
+	}
+
+	return found, nil
+}
+
+// scanDay passes the contents of the log files for the day containing the given
+// time to the function, a chunk at a time.
+func (dw *Writer) scanDay(day time.Time, scan func([]byte)) error {
+	logFiles, err := dw.dayFiles(day)
+	if err != nil {
+		return err
+	}
+
+	for _, logFile := range logFiles {
+		if err := dw.scanFile(logFile, scan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanFile passes the contents of the log file, decompressed if necessary, to
+// the function a chunk at a time.
+func (dw *Writer) scanFile(logFile LogFile, scan func([]byte)) error {
+	reader, err := dw.OpenLogFile(logFile)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	buffer := make([]byte, 64*1024)
+	for {
+		n, err := reader.Read(buffer)
+		scan(buffer[:n])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s - %w", logFile.Pathname, err)
+		}
+	}
+}
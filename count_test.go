@@ -0,0 +1,61 @@
+package dailylogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCountLinesAndSize checks that CountLines and Size read the log files,
+// including compressed ones, when there is no sidecar and use the sidecar when
+// there is one.
+func TestCountLinesAndSize(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-02-12.bar", []byte("one\ntwo\n"), 0644)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("three\nfour\nfive\n"))
+	zw.Close()
+	os.WriteFile("foo.2020-02-12.1.bar.gz", compressed.Bytes(), 0644)
+
+	os.WriteFile("foo.2020-02-13.bar", []byte("six\n"), 0644)
+	os.WriteFile("foo.2020-02-13.bar.meta.json", []byte(`{"date":"2020-02-13","bytes":1000,"lines":100}`), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	writer := New(now, ".", "foo.", ".bar", WithCompression(NewGzipCompressor(gzip.DefaultCompression)))
+	defer writer.Close()
+
+	day := time.Date(2020, time.February, 12, 9, 0, 0, 0, locationUTC)
+	if lines, err := writer.CountLines(day); err != nil || lines != 5 {
+		t.Errorf("want 5 lines, got %d, %v", lines, err)
+	}
+	if size, err := writer.Size(day); err != nil || size != 24 {
+		t.Errorf("want 24 bytes, got %d, %v", size, err)
+	}
+
+	day = day.AddDate(0, 0, 1)
+	if lines, err := writer.CountLines(day); err != nil || lines != 100 {
+		t.Errorf("want 100 lines from the sidecar, got %d, %v", lines, err)
+	}
+	if size, err := writer.Size(day); err != nil || size != 1000 {
+		t.Errorf("want 1000 bytes from the sidecar, got %d, %v", size, err)
+	}
+
+	if _, err := writer.CountLines(day.AddDate(0, 0, -3)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("want fs.ErrNotExist for a day with no files, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package dailyecho supplies echo middleware that writes an access log line for
+// each request to a daily log Writer (see dailylogger.AccessLog).  It's a
+// separate module so that programs that don't use echo don't depend on it.
+// Typical use:
+//
+//	writer := dailylogger.New(time.Now(), logDir, "access.", ".log")
+//	e := echo.New()
+//	e.Use(dailyecho.AccessLog(writer, dailylogger.AccessLogJSON))
+package dailyecho
+
+import (
+	"io"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/labstack/echo/v4"
+)
+
+// AccessLog returns echo middleware that writes a line in the given format to the
+// writer once each request has been handled.  If the handler returns an error,
+// echo's error handler is called first, as echo's own logger does, so that the
+// line has the status that was sent.
+func AccessLog(writer io.Writer, format dailylogger.AccessLogFormat) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			response := c.Response()
+			record := dailylogger.NewAccessRecord(c.Request(), start)
+			record.Status = response.Status
+			record.Bytes = response.Size
+			record.Latency = time.Since(start)
+			writer.Write(record.Format(format))
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package dailyecho
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/labstack/echo/v4"
+)
+
+// TestAccessLog checks that a line is written for each request with its status
+// and size, including one that fails.
+func TestAccessLog(t *testing.T) {
+	var log bytes.Buffer
+
+	e := echo.New()
+	e.Use(AccessLog(&log, dailylogger.AccessLogCommon))
+	e.GET("/hello", func(c echo.Context) error { return c.String(http.StatusOK, "hello") })
+	e.GET("/teapot", func(c echo.Context) error { return echo.NewHTTPError(http.StatusTeapot) })
+
+	for _, path := range []string{"/hello", "/teapot"} {
+		request := httptest.NewRequest("GET", path, nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		e.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], `"GET /hello HTTP/1.1" 200 5`) ||
+		!strings.Contains(lines[1], `"GET /teapot HTTP/1.1" 418 `) {
+		t.Errorf("want the two requests with status 200 and 418 got %q", lines)
+	}
+}
@@ -0,0 +1,22 @@
+module github.com/goblimey/dailylogger/dailyecho
+
+go 1.24.1
+
+require (
+	github.com/goblimey/dailylogger v0.0.0-00010101000000-000000000000
+	github.com/labstack/echo/v4 v4.12.0
+)
+
+require (
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.22.0 // indirect
+	golang.org/x/net v0.24.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/goblimey/dailylogger => ../
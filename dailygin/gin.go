@@ -0,0 +1,32 @@
+// Package dailygin supplies gin middleware that writes an access log line for
+// each request to a daily log Writer (see dailylogger.AccessLog).  It's a
+// separate module so that programs that don't use gin don't depend on it.
+// Typical use:
+//
+//	writer := dailylogger.New(time.Now(), logDir, "access.", ".log")
+//	router := gin.New()
+//	router.Use(dailygin.AccessLog(writer, dailylogger.AccessLogCombined))
+package dailygin
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goblimey/dailylogger"
+)
+
+// AccessLog returns gin middleware that writes a line in the given format to the
+// writer once each request has been handled.
+func AccessLog(writer io.Writer, format dailylogger.AccessLogFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		record := dailylogger.NewAccessRecord(c.Request, start)
+		record.Status = c.Writer.Status()
+		record.Bytes = int64(max(c.Writer.Size(), 0))
+		record.Latency = time.Since(start)
+		writer.Write(record.Format(format))
+	}
+}
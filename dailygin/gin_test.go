@@ -0,0 +1,32 @@
+package dailygin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goblimey/dailylogger"
+)
+
+// TestAccessLog checks that a line is written for each request with its status
+// and size.
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var log bytes.Buffer
+
+	router := gin.New()
+	router.Use(AccessLog(&log, dailylogger.AccessLogCommon))
+	router.GET("/hello", func(c *gin.Context) { c.String(http.StatusCreated, "hello") })
+
+	request := httptest.NewRequest("GET", "/hello", nil)
+	request.RemoteAddr = "192.0.2.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	line := log.String()
+	if !strings.HasPrefix(line, "192.0.2.1 - - [") || !strings.HasSuffix(line, `"GET /hello HTTP/1.1" 201 5`+"\n") {
+		t.Errorf("want the request with status 201 and 5 bytes got %q", line)
+	}
+}
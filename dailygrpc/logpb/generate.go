@@ -0,0 +1,7 @@
+// Package logpb holds the gRPC service definition used by dailygrpc and the Go
+// code generated from it.  To regenerate the code after changing
+// logservice.proto, install protoc, protoc-gen-go and protoc-gen-go-grpc and
+// run "go generate".
+package logpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative logservice.proto
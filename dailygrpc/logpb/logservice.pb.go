@@ -0,0 +1,634 @@
+// The service that lets remote tools read a daily log.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.3
+// source: logservice.proto
+
+package logpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TailRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The amount of data to send from before the current end of the log file.
+	BacklogBytes int64 `protobuf:"varint,1,opt,name=backlog_bytes,json=backlogBytes,proto3" json:"backlog_bytes,omitempty"`
+}
+
+func (x *TailRequest) Reset() {
+	*x = TailRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailRequest) ProtoMessage() {}
+
+func (x *TailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailRequest.ProtoReflect.Descriptor instead.
+func (*TailRequest) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TailRequest) GetBacklogBytes() int64 {
+	if x != nil {
+		return x.BacklogBytes
+	}
+	return 0
+}
+
+type RangeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Any time in the first day to send.
+	From *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// Any time in the last day to send.
+	To *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *RangeRequest) Reset() {
+	*x = RangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeRequest) ProtoMessage() {}
+
+func (x *RangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeRequest.ProtoReflect.Descriptor instead.
+func (*RangeRequest) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RangeRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *RangeRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+// Chunk is part of a log file.
+type Chunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The path name of the log file on the server.
+	Pathname string `protobuf:"bytes,1,opt,name=pathname,proto3" json:"pathname,omitempty"`
+	// The offset of the data in the file.
+	Offset int64  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data   []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Chunk) GetPathname() string {
+	if x != nil {
+		return x.Pathname
+	}
+	return ""
+}
+
+func (x *Chunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{3}
+}
+
+// Drops counts writes that didn't reach the log file or the Shipper.
+type Drops struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Writes int64 `protobuf:"varint,1,opt,name=writes,proto3" json:"writes,omitempty"`
+	Bytes  int64 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+}
+
+func (x *Drops) Reset() {
+	*x = Drops{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Drops) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Drops) ProtoMessage() {}
+
+func (x *Drops) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Drops.ProtoReflect.Descriptor instead.
+func (*Drops) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Drops) GetWrites() int64 {
+	if x != nil {
+		return x.Writes
+	}
+	return 0
+}
+
+func (x *Drops) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+type StatsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BytesToday         int64  `protobuf:"varint,1,opt,name=bytes_today,json=bytesToday,proto3" json:"bytes_today,omitempty"`
+	Rotations          int64  `protobuf:"varint,2,opt,name=rotations,proto3" json:"rotations,omitempty"`
+	LastError          string `protobuf:"bytes,3,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	LastRotatorError   string `protobuf:"bytes,4,opt,name=last_rotator_error,json=lastRotatorError,proto3" json:"last_rotator_error,omitempty"`
+	Failed             *Drops `protobuf:"bytes,5,opt,name=failed,proto3" json:"failed,omitempty"`
+	Sampled            *Drops `protobuf:"bytes,6,opt,name=sampled,proto3" json:"sampled,omitempty"`
+	OverQuota          *Drops `protobuf:"bytes,7,opt,name=over_quota,json=overQuota,proto3" json:"over_quota,omitempty"`
+	PrimaryMissedBytes int64  `protobuf:"varint,8,opt,name=primary_missed_bytes,json=primaryMissedBytes,proto3" json:"primary_missed_bytes,omitempty"`
+	MirrorMissedBytes  int64  `protobuf:"varint,9,opt,name=mirror_missed_bytes,json=mirrorMissedBytes,proto3" json:"mirror_missed_bytes,omitempty"`
+	ReadOnlyLostBytes  int64  `protobuf:"varint,10,opt,name=read_only_lost_bytes,json=readOnlyLostBytes,proto3" json:"read_only_lost_bytes,omitempty"`
+	Unshipped          *Drops `protobuf:"bytes,11,opt,name=unshipped,proto3" json:"unshipped,omitempty"`
+}
+
+func (x *StatsReply) Reset() {
+	*x = StatsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logservice_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsReply) ProtoMessage() {}
+
+func (x *StatsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logservice_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsReply.ProtoReflect.Descriptor instead.
+func (*StatsReply) Descriptor() ([]byte, []int) {
+	return file_logservice_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StatsReply) GetBytesToday() int64 {
+	if x != nil {
+		return x.BytesToday
+	}
+	return 0
+}
+
+func (x *StatsReply) GetRotations() int64 {
+	if x != nil {
+		return x.Rotations
+	}
+	return 0
+}
+
+func (x *StatsReply) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *StatsReply) GetLastRotatorError() string {
+	if x != nil {
+		return x.LastRotatorError
+	}
+	return ""
+}
+
+func (x *StatsReply) GetFailed() *Drops {
+	if x != nil {
+		return x.Failed
+	}
+	return nil
+}
+
+func (x *StatsReply) GetSampled() *Drops {
+	if x != nil {
+		return x.Sampled
+	}
+	return nil
+}
+
+func (x *StatsReply) GetOverQuota() *Drops {
+	if x != nil {
+		return x.OverQuota
+	}
+	return nil
+}
+
+func (x *StatsReply) GetPrimaryMissedBytes() int64 {
+	if x != nil {
+		return x.PrimaryMissedBytes
+	}
+	return 0
+}
+
+func (x *StatsReply) GetMirrorMissedBytes() int64 {
+	if x != nil {
+		return x.MirrorMissedBytes
+	}
+	return 0
+}
+
+func (x *StatsReply) GetReadOnlyLostBytes() int64 {
+	if x != nil {
+		return x.ReadOnlyLostBytes
+	}
+	return 0
+}
+
+func (x *StatsReply) GetUnshipped() *Drops {
+	if x != nil {
+		return x.Unshipped
+	}
+	return nil
+}
+
+var File_logservice_proto protoreflect.FileDescriptor
+
+var file_logservice_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x6c, 0x6f, 0x67, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0e, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0x32, 0x0a, 0x0b, 0x54, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x62, 0x61, 0x63, 0x6b, 0x6c, 0x6f, 0x67, 0x5f, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x62, 0x61, 0x63, 0x6b, 0x6c,
+	0x6f, 0x67, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x6a, 0x0a, 0x0c, 0x52, 0x61, 0x6e, 0x67, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x2a, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x02, 0x74, 0x6f, 0x22, 0x4f, 0x0a, 0x05, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x61, 0x74, 0x68, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x61, 0x74, 0x68, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x22, 0x0e, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x35, 0x0a, 0x05, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x77,
+	0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x22, 0xf6, 0x03, 0x0a, 0x0a,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x5f, 0x74, 0x6f, 0x64, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x62, 0x79, 0x74, 0x65, 0x73, 0x54, 0x6f, 0x64, 0x61, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x72,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x72, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c,
+	0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x2c, 0x0a, 0x12, 0x6c, 0x61, 0x73, 0x74,
+	0x5f, 0x72, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x6f, 0x74, 0x61, 0x74, 0x6f,
+	0x72, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x2d, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x6c, 0x6f,
+	0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x52, 0x06, 0x66,
+	0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x2f, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x6c, 0x6f,
+	0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x52, 0x07, 0x73,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x12, 0x34, 0x0a, 0x0a, 0x6f, 0x76, 0x65, 0x72, 0x5f, 0x71,
+	0x75, 0x6f, 0x74, 0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x64, 0x61, 0x69,
+	0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x6f, 0x70,
+	0x73, 0x52, 0x09, 0x6f, 0x76, 0x65, 0x72, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x12, 0x30, 0x0a, 0x14,
+	0x70, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x62,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x70, 0x72, 0x69, 0x6d,
+	0x61, 0x72, 0x79, 0x4d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2e,
+	0x0a, 0x13, 0x6d, 0x69, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x6d, 0x69, 0x72,
+	0x72, 0x6f, 0x72, 0x4d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2f,
+	0x0a, 0x14, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x5f, 0x6c, 0x6f, 0x73, 0x74,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x72, 0x65,
+	0x61, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x4c, 0x6f, 0x73, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12,
+	0x33, 0x0a, 0x09, 0x75, 0x6e, 0x73, 0x68, 0x69, 0x70, 0x70, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x52, 0x09, 0x75, 0x6e, 0x73, 0x68, 0x69,
+	0x70, 0x70, 0x65, 0x64, 0x32, 0xcd, 0x01, 0x0a, 0x0a, 0x4c, 0x6f, 0x67, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x3c, 0x0a, 0x04, 0x54, 0x61, 0x69, 0x6c, 0x12, 0x1b, 0x2e, 0x64, 0x61,
+	0x69, 0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x69,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79,
+	0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30,
+	0x01, 0x12, 0x3e, 0x0a, 0x05, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x1c, 0x2e, 0x64, 0x61, 0x69,
+	0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79,
+	0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30,
+	0x01, 0x12, 0x41, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1c, 0x2e, 0x64, 0x61, 0x69,
+	0x6c, 0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x64, 0x61, 0x69, 0x6c, 0x79,
+	0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x62, 0x6c, 0x69, 0x6d, 0x65, 0x79, 0x2f, 0x64, 0x61, 0x69, 0x6c,
+	0x79, 0x6c, 0x6f, 0x67, 0x67, 0x65, 0x72, 0x2f, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x67, 0x72, 0x70,
+	0x63, 0x2f, 0x6c, 0x6f, 0x67, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_logservice_proto_rawDescOnce sync.Once
+	file_logservice_proto_rawDescData = file_logservice_proto_rawDesc
+)
+
+func file_logservice_proto_rawDescGZIP() []byte {
+	file_logservice_proto_rawDescOnce.Do(func() {
+		file_logservice_proto_rawDescData = protoimpl.X.CompressGZIP(file_logservice_proto_rawDescData)
+	})
+	return file_logservice_proto_rawDescData
+}
+
+var file_logservice_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_logservice_proto_goTypes = []any{
+	(*TailRequest)(nil),           // 0: dailylogger.v1.TailRequest
+	(*RangeRequest)(nil),          // 1: dailylogger.v1.RangeRequest
+	(*Chunk)(nil),                 // 2: dailylogger.v1.Chunk
+	(*StatsRequest)(nil),          // 3: dailylogger.v1.StatsRequest
+	(*Drops)(nil),                 // 4: dailylogger.v1.Drops
+	(*StatsReply)(nil),            // 5: dailylogger.v1.StatsReply
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_logservice_proto_depIdxs = []int32{
+	6, // 0: dailylogger.v1.RangeRequest.from:type_name -> google.protobuf.Timestamp
+	6, // 1: dailylogger.v1.RangeRequest.to:type_name -> google.protobuf.Timestamp
+	4, // 2: dailylogger.v1.StatsReply.failed:type_name -> dailylogger.v1.Drops
+	4, // 3: dailylogger.v1.StatsReply.sampled:type_name -> dailylogger.v1.Drops
+	4, // 4: dailylogger.v1.StatsReply.over_quota:type_name -> dailylogger.v1.Drops
+	4, // 5: dailylogger.v1.StatsReply.unshipped:type_name -> dailylogger.v1.Drops
+	0, // 6: dailylogger.v1.LogService.Tail:input_type -> dailylogger.v1.TailRequest
+	1, // 7: dailylogger.v1.LogService.Range:input_type -> dailylogger.v1.RangeRequest
+	3, // 8: dailylogger.v1.LogService.Stats:input_type -> dailylogger.v1.StatsRequest
+	2, // 9: dailylogger.v1.LogService.Tail:output_type -> dailylogger.v1.Chunk
+	2, // 10: dailylogger.v1.LogService.Range:output_type -> dailylogger.v1.Chunk
+	5, // 11: dailylogger.v1.LogService.Stats:output_type -> dailylogger.v1.StatsReply
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_logservice_proto_init() }
+func file_logservice_proto_init() {
+	if File_logservice_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_logservice_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*TailRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_logservice_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RangeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_logservice_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Chunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_logservice_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*StatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_logservice_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*Drops); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_logservice_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*StatsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_logservice_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_logservice_proto_goTypes,
+		DependencyIndexes: file_logservice_proto_depIdxs,
+		MessageInfos:      file_logservice_proto_msgTypes,
+	}.Build()
+	File_logservice_proto = out.File
+	file_logservice_proto_rawDesc = nil
+	file_logservice_proto_goTypes = nil
+	file_logservice_proto_depIdxs = nil
+}
@@ -0,0 +1,236 @@
+// Package dailygrpc serves a daily log Writer over gRPC, so that remote
+// debugging tools can read the log of a headless device without shell access.
+// The service, defined in logpb/logservice.proto, offers Tail, which follows the
+// log as it's written, Range, which sends the log files for a range of days, and
+// Stats.  It's a separate module so that programs that don't use gRPC don't
+// depend on it.  Typical use:
+//
+//	grpcServer := grpc.NewServer()
+//	logpb.RegisterLogServiceServer(grpcServer, dailygrpc.New(writer))
+//	go grpcServer.Serve(listener)
+package dailygrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/goblimey/dailylogger/dailygrpc/logpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chunkSize is the most data sent in one Chunk.
+const chunkSize = 64 << 10
+
+// Server implements the LogService for one Writer.
+type Server struct {
+	logpb.UnimplementedLogServiceServer
+
+	writer       *dailylogger.Writer
+	pollInterval time.Duration // The time between checks for new data while tailing.
+}
+
+// New creates a Server that serves the Writer's log.  While tailing it checks
+// for new data every quarter of a second.
+func New(writer *dailylogger.Writer) *Server {
+	return &Server{writer: writer, pollInterval: 250 * time.Millisecond}
+}
+
+// Tail sends the last part of the current log file and then follows the log.
+func (s *Server) Tail(request *logpb.TailRequest, stream grpc.ServerStreamingServer[logpb.Chunk]) error {
+	return s.tail(stream.Context(), request.GetBacklogBytes(), stream.Send)
+}
+
+// Range sends the log files for the days in the range, oldest first.
+func (s *Server) Range(request *logpb.RangeRequest, stream grpc.ServerStreamingServer[logpb.Chunk]) error {
+	if request.GetFrom() == nil || request.GetTo() == nil {
+		return status.Error(codes.InvalidArgument, "from and to must be set")
+	}
+
+	return s.sendRange(request.GetFrom().AsTime(), request.GetTo().AsTime(), stream.Send)
+}
+
+// Stats returns the Writer's counters.
+func (s *Server) Stats(ctx context.Context, request *logpb.StatsRequest) (*logpb.StatsReply, error) {
+	stats := s.writer.Stats()
+
+	return &logpb.StatsReply{
+		BytesToday:         stats.BytesToday,
+		Rotations:          stats.Rotations,
+		LastError:          stats.LastError,
+		LastRotatorError:   stats.LastRotatorError,
+		Failed:             drops(stats.Failed),
+		Sampled:            drops(stats.Sampled),
+		OverQuota:          drops(stats.OverQuota),
+		PrimaryMissedBytes: stats.PrimaryMissedBytes,
+		MirrorMissedBytes:  stats.MirrorMissedBytes,
+		ReadOnlyLostBytes:  stats.ReadOnlyLostBytes,
+		Unshipped:          drops(stats.Unshipped),
+	}, nil
+}
+
+// drops converts a dailylogger.Drops to a logpb.Drops.
+func drops(d dailylogger.Drops) *logpb.Drops {
+	return &logpb.Drops{Writes: d.Writes, Bytes: d.Bytes}
+}
+
+// sendRange is a helper function for Range that sends the files whose days
+// fall between the days of from and to, inclusive.
+func (s *Server) sendRange(from, to time.Time, send func(*logpb.Chunk) error) error {
+	logFiles, err := s.writer.List()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	for _, logFile := range logFiles {
+		// The Date is midnight at the start of the file's day in the Writer's
+		// timezone, so compare the days there.
+		location := logFile.Date.Location()
+		if logFile.Date.Before(startOfDay(from.In(location))) || logFile.Date.After(to.In(location)) {
+			continue
+		}
+
+		if err := s.sendLogFile(logFile, send); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendLogFile is a helper function for sendRange that sends the whole of a log
+// file, decompressing it if it has been compressed.
+func (s *Server) sendLogFile(logFile dailylogger.LogFile, send func(*logpb.Chunk) error) error {
+	if !logFile.Compressed {
+		_, err := sendFile(logFile.Pathname, 0, send)
+		return err
+	}
+
+	reader, err := s.writer.OpenLogFile(logFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The file has been purged.
+			return nil
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer reader.Close()
+
+	buffer := make([]byte, chunkSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(reader, buffer)
+		if n > 0 {
+			chunk := logpb.Chunk{Pathname: logFile.Pathname, Offset: offset, Data: buffer[:n]}
+			if err := send(&chunk); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// tail is a helper function for Tail that sends the end of the current log file
+// and then anything added to it, moving on to the next file when the log is
+// rotated, until the context is done.
+func (s *Server) tail(ctx context.Context, backlog int64, send func(*logpb.Chunk) error) error {
+	pathname, err := s.currentFile()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	if info, err := os.Stat(pathname); err == nil {
+		offset = max(0, info.Size()-backlog)
+	}
+
+	for {
+		offset, err = sendFile(pathname, offset, send)
+		if err != nil {
+			return err
+		}
+
+		// Move on if the log has been rotated, but only once the old file has been
+		// sent to the end.
+		latest, err := s.currentFile()
+		if err != nil {
+			return err
+		}
+		if latest != pathname {
+			offset, err = sendFile(pathname, offset, send)
+			if err != nil {
+				return err
+			}
+			pathname = latest
+			offset = 0
+			continue
+		}
+
+		select {
+		case <-time.After(s.pollInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// currentFile returns the path name of the newest log file.
+func (s *Server) currentFile() (string, error) {
+	logFiles, err := s.writer.List()
+	if err != nil {
+		return "", status.Error(codes.Internal, err.Error())
+	}
+	if len(logFiles) == 0 {
+		return "", status.Error(codes.NotFound, "there are no log files")
+	}
+
+	return logFiles[len(logFiles)-1].Pathname, nil
+}
+
+// sendFile sends the file from the offset to its current end and returns the
+// offset of the end.
+func sendFile(pathname string, offset int64, send func(*logpb.Chunk) error) (int64, error) {
+	file, err := os.Open(pathname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The file has been purged.
+			return offset, nil
+		}
+		return offset, status.Error(codes.Internal, err.Error())
+	}
+	defer file.Close()
+
+	buffer := make([]byte, chunkSize)
+	for {
+		n, err := file.ReadAt(buffer, offset)
+		if n > 0 {
+			chunk := logpb.Chunk{Pathname: pathname, Offset: offset, Data: buffer[:n]}
+			if err := send(&chunk); err != nil {
+				return offset, err
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// startOfDay returns midnight at the start of the day of t, in t's timezone.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,81 @@
+package dailygrpc
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/goblimey/dailylogger/dailygrpc/logpb"
+)
+
+// collector keeps the data sent to it.
+type collector struct {
+	data strings.Builder
+}
+
+func (c *collector) send(chunk *logpb.Chunk) error {
+	c.data.Write(chunk.Data)
+	return nil
+}
+
+// TestRange checks that Range sends the files for the days in the range.
+func TestRange(t *testing.T) {
+	directory := t.TempDir()
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	os.WriteFile(directory+"/foo.2020-02-12.bar", []byte("12\n"), 0644)
+	os.WriteFile(directory+"/foo.2020-02-13.bar", []byte("13\n"), 0644)
+	writer := dailylogger.New(now, directory, "foo.", ".bar")
+	defer writer.Close()
+	writer.Write([]byte("14\n"))
+
+	server := New(writer)
+	var c collector
+	if err := server.sendRange(now.AddDate(0, 0, -1), now, c.send); err != nil {
+		t.Fatal(err)
+	}
+	if c.data.String() != "13\n14\n" {
+		t.Errorf("want the 13th and 14th, got %q", c.data.String())
+	}
+}
+
+// TestTail checks that Tail sends the backlog and then what is written.
+func TestTail(t *testing.T) {
+	directory := t.TempDir()
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := dailylogger.New(now, directory, "foo.", ".bar")
+	defer writer.Close()
+	writer.Write([]byte("old\nrecent\n"))
+
+	server := New(writer)
+	server.pollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- server.tail(ctx, int64(len("recent\n")), func(chunk *logpb.Chunk) error {
+			received <- string(chunk.Data)
+			return nil
+		})
+	}()
+
+	if got := <-received; got != "recent\n" {
+		t.Errorf("want the backlog, got %q", got)
+	}
+	writer.Write([]byte("new\n"))
+	if got := <-received; got != "new\n" {
+		t.Errorf("want the new line, got %q", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/goblimey/dailylogger/dailykafka
+
+go 1.24.1
+
+require (
+	github.com/goblimey/dailylogger v0.0.0-00010101000000-000000000000
+	github.com/segmentio/kafka-go v0.4.47
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+)
+
+replace github.com/goblimey/dailylogger => ../
@@ -0,0 +1,62 @@
+// Package dailykafka ships the records written to a daily log Writer to Kafka,
+// keeping the daily log files as a local dated copy (see dailylogger.WithShipper).
+// It's a separate module so that programs that don't use Kafka don't depend on
+// it.  Typical use:
+//
+//	shipper := dailykafka.New([]string{"kafka1:9092", "kafka2:9092"}, "service-logs")
+//	defer shipper.Close()
+//	writer := dailylogger.New(time.Now(), logDir, "service.", ".log",
+//		dailylogger.WithAsync(1000), dailylogger.WithShipper(shipper))
+package dailykafka
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/segmentio/kafka-go"
+)
+
+// Shipper sends each record to a Kafka topic as a message whose value is the
+// record without its trailing newline and whose time is the time of the write.
+type Shipper struct {
+	writer  *kafka.Writer
+	timeout time.Duration // The limit on the time taken to send a record.
+}
+
+// This is a compile-time check that Shipper implements the dailylogger.Shipper
+// interface.
+var _ dailylogger.Shipper = (*Shipper)(nil)
+
+// New creates a Shipper that sends records to the topic via the given brokers.
+// It allows five seconds to send each record.
+func New(brokers []string, topic string) *Shipper {
+	return &Shipper{
+		writer: &kafka.Writer{
+			Addr:  kafka.TCP(brokers...),
+			Topic: topic,
+			// Each record is sent as it's written, so don't wait to fill a batch.
+			BatchSize:    1,
+			BatchTimeout: time.Millisecond,
+			RequiredAcks: kafka.RequireOne,
+		},
+		timeout: 5 * time.Second,
+	}
+}
+
+// Ship sends the record to Kafka and waits for a broker to acknowledge it.
+func (s *Shipper) Ship(record []byte, t time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// The record is only valid until Ship returns, so copy it.
+	value := bytes.Clone(bytes.TrimSuffix(record, []byte("\n")))
+	return s.writer.WriteMessages(ctx, kafka.Message{Value: value, Time: t})
+}
+
+// Close flushes any messages that are still being sent and closes the
+// connections to the brokers.
+func (s *Shipper) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,27 @@
+package dailykafka
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestShipWithoutBroker checks that Ship fails within its timeout when there is
+// no broker.
+func TestShipWithoutBroker(t *testing.T) {
+	// Find a port that nothing is listening on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	shipper := New([]string{address}, "test")
+	defer shipper.Close()
+	shipper.timeout = time.Second
+
+	if err := shipper.Ship([]byte("hello\n"), time.Now()); err == nil {
+		t.Error("want an error from Ship")
+	}
+}
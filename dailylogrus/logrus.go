@@ -0,0 +1,123 @@
+// Package dailylogrus supplies a logrus hook that writes the formatted entries to
+// a daily log Writer, optionally sending each level to a Writer of its own, for
+// example errors to one set of daily files and everything else to another.
+// Passing the Writer to logrus as its output works too, but loses the split by
+// level.  It's a separate module so that programs that don't use logrus don't
+// depend on it.  Typical use:
+//
+//	info := dailylogger.New(time.Now(), logDir, "service.", ".log")
+//	errs := dailylogger.New(time.Now(), logDir, "service-errors.", ".log")
+//	logger := logrus.New()
+//	logger.SetOutput(io.Discard)
+//	logger.AddHook(dailylogrus.New(info,
+//		dailylogrus.WithLevelWriter(logrus.ErrorLevel, errs),
+//		dailylogrus.WithLevelWriter(logrus.FatalLevel, errs),
+//		dailylogrus.WithLevelWriter(logrus.PanicLevel, errs)))
+package dailylogrus
+
+import (
+	"io"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook that writes each entry to a Writer chosen by its level.
+type Hook struct {
+	writer       io.Writer                  // Receives the levels without a Writer of their own (nil means none).
+	levelWriters map[logrus.Level]io.Writer // The Writers for particular levels.
+	levels       []logrus.Level             // The levels that the hook fires for (nil means every level that has a Writer).
+	formatter    logrus.Formatter           // Formats the entries (nil means the logger's formatter).
+}
+
+// This is a compile-time check that Hook implements the logrus.Hook interface.
+var _ logrus.Hook = (*Hook)(nil)
+
+// Option is a setting given to New.
+type Option func(*Hook)
+
+// WithLevelWriter sends the entries at the given level to the given Writer
+// instead of the one given to New.
+func WithLevelWriter(level logrus.Level, writer io.Writer) Option {
+	return func(h *Hook) {
+		if writer != nil {
+			h.levelWriters[level] = writer
+		}
+	}
+}
+
+// WithLevels limits the hook to the given levels, for example to leave the debug
+// entries out of the daily files.
+func WithLevels(levels ...logrus.Level) Option {
+	return func(h *Hook) {
+		h.levels = levels
+	}
+}
+
+// WithFormatter sets the formatter used for the entries.  By default the hook
+// uses the logger's formatter, so the files look like the logger's output.
+func WithFormatter(formatter logrus.Formatter) Option {
+	return func(h *Hook) {
+		h.formatter = formatter
+	}
+}
+
+// New creates a Hook that writes the entries to the given Writer, apart from the
+// levels given their own Writer by WithLevelWriter.  The Writer may be nil, in
+// which case only those levels are written.
+func New(writer io.Writer, options ...Option) *Hook {
+	h := Hook{
+		writer:       writer,
+		levelWriters: make(map[logrus.Level]io.Writer),
+	}
+	for _, option := range options {
+		option(&h)
+	}
+
+	return &h
+}
+
+// Levels returns the levels that the hook fires for.
+func (h *Hook) Levels() []logrus.Level {
+	if h.levels != nil {
+		return h.levels
+	}
+	if h.writer != nil {
+		return logrus.AllLevels
+	}
+
+	var levels []logrus.Level
+	for level := range h.levelWriters {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+	return levels
+}
+
+// Fire formats the entry and writes it to the Writer for its level.  The daily
+// Writer is safe for concurrent use, so the hook needs no lock of its own.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	writer, ok := h.levelWriters[entry.Level]
+	if !ok {
+		writer = h.writer
+	}
+	if writer == nil {
+		return nil
+	}
+
+	formatter := h.formatter
+	if formatter == nil && entry.Logger != nil {
+		formatter = entry.Logger.Formatter
+	}
+	if formatter == nil {
+		formatter = &logrus.TextFormatter{}
+	}
+
+	line, err := formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(line)
+	return err
+}
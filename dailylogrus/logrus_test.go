@@ -0,0 +1,67 @@
+package dailylogrus
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/sirupsen/logrus"
+)
+
+// TestHook checks that the entries go to the Writer for their level.
+func TestHook(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Now()
+	writer := dailylogger.New(now, directory, "service.", ".log")
+	var errs bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger.AddHook(New(writer, WithLevelWriter(logrus.ErrorLevel, &errs)))
+
+	logger.Info("started")
+	logger.Error("failed")
+	writer.Close()
+
+	contents, err := os.ReadFile(filepath.Join(directory, "service."+now.Format(time.DateOnly)+".log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "level=info msg=started\n" {
+		t.Errorf("want the info entry in the daily file got %q", string(contents))
+	}
+	if errs.String() != "level=error msg=failed\n" {
+		t.Errorf("want the error entry in its own writer got %q", errs.String())
+	}
+}
+
+// TestHookLevels checks the levels that the hook fires for.
+func TestHookLevels(t *testing.T) {
+	var buffer bytes.Buffer
+
+	hook := New(nil, WithLevelWriter(logrus.WarnLevel, &buffer), WithLevelWriter(logrus.ErrorLevel, &buffer))
+	if levels := hook.Levels(); len(levels) != 2 || levels[0] != logrus.ErrorLevel || levels[1] != logrus.WarnLevel {
+		t.Errorf("want error and warn got %v", levels)
+	}
+
+	hook = New(&buffer, WithLevels(logrus.InfoLevel), WithFormatter(&logrus.JSONFormatter{}))
+	if levels := hook.Levels(); len(levels) != 1 || levels[0] != logrus.InfoLevel {
+		t.Errorf("want info got %v", levels)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+	logger.Info("json")
+	logger.Warn("ignored")
+
+	if !strings.Contains(buffer.String(), `"msg":"json"`) || strings.Contains(buffer.String(), "ignored") {
+		t.Errorf("want just the info entry as JSON got %q", buffer.String())
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/goblimey/dailylogger/dailymqtt
+
+go 1.24.1
+
+require (
+	github.com/eclipse/paho.mqtt.golang v1.5.0
+	github.com/goblimey/dailylogger v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/gorilla/websocket v1.5.3 // indirect
+	golang.org/x/net v0.27.0 // indirect
+	golang.org/x/sync v0.7.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+)
+
+replace github.com/goblimey/dailylogger => ../
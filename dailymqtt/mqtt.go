@@ -0,0 +1,119 @@
+// Package dailymqtt publishes the lines written to a daily log Writer to an
+// MQTT topic, so that a dashboard can watch the log live while the full record
+// stays in the daily log files (see dailylogger.WithShipper).  It uses a client
+// that the program has already connected.  It's a separate module so that
+// programs that don't use MQTT don't depend on it.  Typical use:
+//
+//	shipper := dailymqtt.New(client, "stations/"+stationName+"/log",
+//		dailymqtt.WithQoS(1), dailymqtt.WithSampling(10))
+//	writer := dailylogger.New(time.Now(), logDir, "station.", ".log",
+//		dailylogger.WithAsync(1000), dailylogger.WithShipper(shipper))
+package dailymqtt
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/goblimey/dailylogger"
+)
+
+// Publisher publishes messages.  An mqtt.Client satisfies it.
+type Publisher interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+}
+
+// errTimeout is returned when the broker doesn't acknowledge a message in time.
+var errTimeout = errors.New("dailymqtt: timed out waiting for the broker")
+
+// Shipper publishes each line of each record as a message, without its trailing
+// newline.
+type Shipper struct {
+	publisher   Publisher
+	topic       string        // The topic of the messages.
+	qos         byte          // The MQTT quality of service (0, 1 or 2).
+	retained    bool          // True if the broker should keep the last message.
+	sampleEvery int           // Publish one line in this many (0 or 1 means every line).
+	timeout     time.Duration // The limit on the time to wait for an acknowledgement.
+	count       int           // The number of lines seen so far.
+}
+
+// This is a compile-time check that Shipper implements the dailylogger.Shipper
+// interface.
+var _ dailylogger.Shipper = (*Shipper)(nil)
+
+// Option is a setting given to New.
+type Option func(*Shipper)
+
+// WithQoS sets the MQTT quality of service of the messages, which is 0 by
+// default.  With 1 or 2, Ship waits for the broker to acknowledge each message.
+func WithQoS(qos byte) Option {
+	return func(s *Shipper) {
+		s.qos = qos
+	}
+}
+
+// WithRetained makes the broker keep the last message, so that a dashboard that
+// subscribes sees the last line at once.
+func WithRetained() Option {
+	return func(s *Shipper) {
+		s.retained = true
+	}
+}
+
+// WithSampling makes the Shipper publish only the first line in every n, to
+// save bandwidth.  The daily log files still get every line.
+func WithSampling(n int) Option {
+	return func(s *Shipper) {
+		s.sampleEvery = n
+	}
+}
+
+// WithTimeout sets the time that Ship waits for the broker to acknowledge a
+// message, which is five seconds by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Shipper) {
+		s.timeout = timeout
+	}
+}
+
+// New creates a Shipper that publishes to the topic via the publisher.
+func New(publisher Publisher, topic string, options ...Option) *Shipper {
+	s := Shipper{
+		publisher: publisher,
+		topic:     topic,
+		timeout:   5 * time.Second,
+	}
+	for _, option := range options {
+		option(&s)
+	}
+
+	return &s
+}
+
+// Ship publishes the lines of the record.  Ship is called by one goroutine at a
+// time, so the Shipper needs no lock of its own.
+func (s *Shipper) Ship(record []byte, t time.Time) error {
+	for _, line := range bytes.Split(bytes.TrimSuffix(record, []byte("\n")), []byte("\n")) {
+		s.count++
+		if s.sampleEvery > 1 && (s.count-1)%s.sampleEvery != 0 {
+			continue
+		}
+
+		// The record is only valid until Ship returns, so copy the line.
+		token := s.publisher.Publish(s.topic, s.qos, s.retained, bytes.Clone(line))
+		if s.qos == 0 {
+			// Nothing will be acknowledged, so don't wait.
+			continue
+		}
+		if !token.WaitTimeout(s.timeout) {
+			return errTimeout
+		}
+		if err := token.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package dailymqtt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// fakeToken is an mqtt.Token that has already completed.
+type fakeToken struct {
+	err error
+}
+
+func (ft fakeToken) Wait() bool                     { return true }
+func (ft fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (ft fakeToken) Done() <-chan struct{}          { return closed }
+func (ft fakeToken) Error() error                   { return ft.err }
+
+var closed = make(chan struct{})
+
+func init() {
+	close(closed)
+}
+
+// fakePublisher records the messages published.
+type fakePublisher struct {
+	err      error
+	qos      []byte
+	messages []string
+}
+
+func (fp *fakePublisher) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	fp.qos = append(fp.qos, qos)
+	fp.messages = append(fp.messages, string(payload.([]byte)))
+	return fakeToken{fp.err}
+}
+
+// TestShip checks that each line is published with the chosen QoS and that
+// sampling publishes the first line in every n.
+func TestShip(t *testing.T) {
+	publisher := fakePublisher{}
+	shipper := New(&publisher, "log", WithQoS(1), WithSampling(2))
+
+	shipper.Ship([]byte("a\nb\nc\n"), time.Now())
+	shipper.Ship([]byte("d\n"), time.Now())
+	shipper.Ship([]byte("e\n"), time.Now())
+
+	if len(publisher.messages) != 3 || publisher.messages[0] != "a" ||
+		publisher.messages[1] != "c" || publisher.messages[2] != "e" {
+		t.Errorf("want a, c and e published, got %q", publisher.messages)
+	}
+	for _, qos := range publisher.qos {
+		if qos != 1 {
+			t.Errorf("want QoS 1 got %d", qos)
+		}
+	}
+
+	publisher.err = errors.New("not connected")
+	if err := shipper.Ship([]byte("f\ng\n"), time.Now()); err != publisher.err {
+		t.Errorf("want the publish error, got %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/goblimey/dailylogger/dailyotel
+
+go 1.24.1
+
+require (
+	github.com/goblimey/dailylogger v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require golang.org/x/sys v0.39.0 // indirect
+
+replace github.com/goblimey/dailylogger => ../
@@ -0,0 +1,96 @@
+// Package dailyotel connects a daily log Writer to OpenTelemetry.  It records the
+// number of bytes written, the number of failed writes and the duration of each
+// rotation as metrics, and wraps each rotation in a span.  It's a separate module
+// so that programs that don't use OpenTelemetry don't depend on it.  Typical use:
+//
+//	instrumentation, err := dailyotel.New(otel.GetMeterProvider(), otel.GetTracerProvider())
+//	writer := dailylogger.New(time.Now(), logDir, "service.", ".log",
+//		dailylogger.WithInstrumentation(instrumentation))
+package dailyotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the meter and the tracer.
+const scopeName = "github.com/goblimey/dailylogger"
+
+// Instrumentation records measurements from a daily log Writer via OpenTelemetry.
+type Instrumentation struct {
+	tracer   trace.Tracer
+	bytes    metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// This is a compile-time check that Instrumentation implements the
+// dailylogger.Instrumentation interface.
+var _ dailylogger.Instrumentation = (*Instrumentation)(nil)
+
+// New creates an Instrumentation that uses the given providers.
+func New(meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (*Instrumentation, error) {
+	meter := meterProvider.Meter(scopeName)
+
+	bytes, err := meter.Int64Counter("dailylogger.write.bytes",
+		metric.WithUnit("By"),
+		metric.WithDescription("The number of bytes written to the log"))
+	if err != nil {
+		return nil, err
+	}
+
+	errors, err := meter.Int64Counter("dailylogger.write.errors",
+		metric.WithDescription("The number of writes to the log that failed"))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("dailylogger.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("The time taken by operations such as rotation"))
+	if err != nil {
+		return nil, err
+	}
+
+	instrumentation := Instrumentation{
+		tracer:   tracerProvider.Tracer(scopeName),
+		bytes:    bytes,
+		errors:   errors,
+		duration: duration,
+	}
+
+	return &instrumentation, nil
+}
+
+// Wrote records a write to the log.
+func (i *Instrumentation) Wrote(n int, err error) {
+	ctx := context.Background()
+	i.bytes.Add(ctx, int64(n))
+	if err != nil {
+		i.errors.Add(ctx, 1)
+	}
+}
+
+// StartOperation starts a span for the operation and returns a function that ends
+// it and records the duration.
+func (i *Instrumentation) StartOperation(name string) func(error) {
+	start := time.Now()
+	_, span := i.tracer.Start(context.Background(), "dailylogger."+name)
+
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		i.duration.Record(context.Background(), time.Since(start).Seconds(),
+			metric.WithAttributes(attribute.String("operation", name)))
+	}
+}
@@ -0,0 +1,23 @@
+package dailyotel
+
+import (
+	"errors"
+	"testing"
+
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestNew checks that an Instrumentation can be created and used.
+func TestNew(t *testing.T) {
+	instrumentation, err := New(metricnoop.NewMeterProvider(), tracenoop.NewTracerProvider())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	instrumentation.Wrote(5, nil)
+	instrumentation.Wrote(0, errors.New("disk full"))
+	end := instrumentation.StartOperation("rotate")
+	end(nil)
+}
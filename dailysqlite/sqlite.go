@@ -0,0 +1,102 @@
+// Package dailysqlite stores a daily log in SQLite databases rather than flat
+// files, one database per day, so that the log can be queried.  Each Write to
+// the daily log Writer becomes a row holding the time of the write and the data
+// written.  The Writer still names, rotates and purges the files, so retention
+// and the rest work as normal.  The package uses database/sql and doesn't choose
+// a SQLite driver, so the program must import one.  Typical use:
+//
+//	import _ "modernc.org/sqlite"
+//
+//	writer := dailylogger.New(time.Now(), logDir, "service.", ".db",
+//		dailylogger.WithFileFactory(dailysqlite.New("sqlite")))
+//
+// Each database has one table:
+//
+//	CREATE TABLE entries (id INTEGER PRIMARY KEY AUTOINCREMENT, time TEXT NOT NULL, entry BLOB NOT NULL)
+//
+// The time is in UTC in the form "2006-01-02 15:04:05.000000000", which sorts
+// correctly and which the SQLite date and time functions understand.
+package dailysqlite
+
+import (
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+)
+
+// timeLayout is the layout of the time column.
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// createTable creates the table if the database is new.
+const createTable = `CREATE TABLE IF NOT EXISTS entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time TEXT NOT NULL,
+	entry BLOB NOT NULL)`
+
+// insertEntry adds a row to the table.
+const insertEntry = `INSERT INTO entries (time, entry) VALUES (?, ?)`
+
+// New returns a dailylogger.FileFactory that opens each log file as a SQLite
+// database using the database/sql driver with the given name.
+func New(driverName string) dailylogger.FileFactory {
+	return newFactory(driverName, time.Now)
+}
+
+// newFactory is a helper function for New.  Unit tests call it to supply a fake
+// clock.
+func newFactory(driverName string, now func() time.Time) dailylogger.FileFactory {
+	return func(pathname string) (io.WriteCloser, error) {
+		db, err := sql.Open(driverName, pathname)
+		if err != nil {
+			return nil, err
+		}
+
+		// One connection, so that the rows are inserted in the order of the writes.
+		db.SetMaxOpenConns(1)
+
+		if _, err := db.Exec(createTable); err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		insert, err := db.Prepare(insertEntry)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		return &database{db: db, insert: insert, now: now}, nil
+	}
+}
+
+// database is one day's log.  It satisfies io.WriteCloser.
+type database struct {
+	db     *sql.DB
+	insert *sql.Stmt
+	now    func() time.Time
+}
+
+// Write adds the data to the table as one row.
+func (d *database) Write(data []byte) (int, error) {
+	// The Writer may reuse the buffer once Write returns, so copy it.
+	entry := append([]byte(nil), data...)
+	if _, err := d.insert.Exec(d.now().UTC().Format(timeLayout), entry); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// Sync does nothing, since each row is committed as it's inserted.  It's here so
+// that the Writer's Sync succeeds.
+func (d *database) Sync() error {
+	return nil
+}
+
+// Close closes the database.
+func (d *database) Close() error {
+	d.insert.Close()
+	return d.db.Close()
+}
@@ -0,0 +1,116 @@
+package dailysqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+)
+
+// fakeDriver is a database/sql driver that records the statements executed
+// against each database instead of running them.
+type fakeDriver struct {
+	mutex sync.Mutex
+	execs map[string][]fakeExec // The statements run against each database.
+}
+
+// fakeExec is a statement run by fakeDriver.
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d, name: name}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	name   string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.conn.driver
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.execs[s.conn.name] = append(d.execs[s.conn.name], fakeExec{s.query, args})
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
+
+// fake is the driver used by the tests.
+var fake = &fakeDriver{execs: make(map[string][]fakeExec)}
+
+func init() {
+	sql.Register("dailysqlitefake", fake)
+}
+
+// TestNew checks that each Write to a Writer that uses the factory becomes a
+// timestamped row in the database of the day.
+func TestNew(t *testing.T) {
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	const wantTime = "2020-02-14 12:00:00.000000000"
+
+	factory := newFactory("dailysqlitefake", func() time.Time { return now })
+	directory := t.TempDir()
+	pathname := directory + "/foo.2020-02-14.db"
+	writer := dailylogger.New(now, directory, "foo.", ".db", dailylogger.WithFileFactory(factory))
+
+	writer.Write([]byte("first\n"))
+	writer.Write([]byte("second\n"))
+	if err := writer.Sync(); err != nil {
+		t.Errorf("want Sync to succeed, got %v", err)
+	}
+	writer.Close()
+
+	fake.mutex.Lock()
+	execs := fake.execs[pathname]
+	fake.mutex.Unlock()
+
+	if len(execs) != 3 {
+		t.Fatalf("want 3 statements against %s, got %d", pathname, len(execs))
+	}
+	if execs[0].query != createTable {
+		t.Errorf("want the table created first, got %s", execs[0].query)
+	}
+	for i, want := range []string{"first\n", "second\n"} {
+		exec := execs[i+1]
+		if exec.query != insertEntry || len(exec.args) != 2 {
+			t.Errorf("want an insert, got %s %v", exec.query, exec.args)
+			continue
+		}
+		if exec.args[0] != wantTime {
+			t.Errorf("want time %s got %v", wantTime, exec.args[0])
+		}
+		if entry, _ := exec.args[1].([]byte); string(entry) != want {
+			t.Errorf("want entry %q got %q", want, entry)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package dailyzerolog supplies a zerolog.LevelWriter that writes the JSON
+// entries from zerolog to daily log Writers, optionally sending each level to a
+// Writer of its own and sampling the entries with one of zerolog's samplers.
+// It's a separate module so that programs that don't use zerolog don't depend on
+// it.  Typical use:
+//
+//	info := dailylogger.New(time.Now(), logDir, "service.", ".json")
+//	errs := dailylogger.New(time.Now(), logDir, "service-errors.", ".json")
+//	logger := zerolog.New(dailyzerolog.New(info,
+//		dailyzerolog.WithLevelWriter(zerolog.ErrorLevel, errs),
+//		dailyzerolog.WithSampler(zerolog.LevelSampler{DebugSampler: &zerolog.BasicSampler{N: 10}})))
+//
+// To send a level to more than one Writer, give it a dailylogger.Combine of them.
+package dailyzerolog
+
+import (
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelWriter is a zerolog.LevelWriter that writes each entry to a Writer chosen
+// by its level.
+type LevelWriter struct {
+	writer       io.Writer                   // Receives the levels without a Writer of their own (nil means none).
+	levelWriters map[zerolog.Level]io.Writer // The Writers for particular levels.
+	sampler      zerolog.Sampler             // Chooses the entries to keep (nil means all of them).
+}
+
+// This is a compile-time check that LevelWriter implements the
+// zerolog.LevelWriter interface.
+var _ zerolog.LevelWriter = (*LevelWriter)(nil)
+
+// Option is a setting given to New.
+type Option func(*LevelWriter)
+
+// WithLevelWriter sends the entries at the given level to the given Writer
+// instead of the one given to New.
+func WithLevelWriter(level zerolog.Level, writer io.Writer) Option {
+	return func(lw *LevelWriter) {
+		if writer != nil {
+			lw.levelWriters[level] = writer
+		}
+	}
+}
+
+// WithSampler keeps only the entries that the sampler chooses, for example a
+// zerolog.LevelSampler to sample the debug entries and keep everything else.
+// The entries that aren't kept are dropped without an error.  Unlike
+// zerolog.Logger.Sample, this samples what reaches the daily files, so a
+// program can give its console logger different sampling.
+func WithSampler(sampler zerolog.Sampler) Option {
+	return func(lw *LevelWriter) {
+		lw.sampler = sampler
+	}
+}
+
+// New creates a LevelWriter that writes the entries to the given Writer, apart
+// from the levels given their own Writer by WithLevelWriter.  The Writer may be
+// nil, in which case only those levels are written.
+func New(writer io.Writer, options ...Option) *LevelWriter {
+	lw := LevelWriter{
+		writer:       writer,
+		levelWriters: make(map[zerolog.Level]io.Writer),
+	}
+	for _, option := range options {
+		option(&lw)
+	}
+
+	return &lw
+}
+
+// Write writes an entry that has no level.
+func (lw *LevelWriter) Write(entry []byte) (int, error) {
+	return lw.WriteLevel(zerolog.NoLevel, entry)
+}
+
+// WriteLevel writes the entry to the Writer for its level, if the sampler keeps
+// it.  An entry that isn't written is reported as written, so that zerolog
+// doesn't treat it as an error.  The daily Writer is safe for concurrent use,
+// so the LevelWriter needs no lock of its own.
+func (lw *LevelWriter) WriteLevel(level zerolog.Level, entry []byte) (int, error) {
+	if lw.sampler != nil && !lw.sampler.Sample(level) {
+		return len(entry), nil
+	}
+
+	writer, ok := lw.levelWriters[level]
+	if !ok {
+		writer = lw.writer
+	}
+	if writer == nil {
+		return len(entry), nil
+	}
+
+	return writer.Write(entry)
+}
@@ -0,0 +1,57 @@
+package dailyzerolog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/rs/zerolog"
+)
+
+// TestLevelWriter checks that the entries go to the Writer for their level.
+func TestLevelWriter(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Now()
+	writer := dailylogger.New(now, directory, "service.", ".json")
+	var errs bytes.Buffer
+
+	logger := zerolog.New(New(writer, WithLevelWriter(zerolog.ErrorLevel, &errs)))
+	logger.Info().Msg("started")
+	logger.Error().Msg("failed")
+	logger.Log().Msg("no level")
+	writer.Close()
+
+	contents, err := os.ReadFile(filepath.Join(directory, "service."+now.Format(time.DateOnly)+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"level":"info","message":"started"}` + "\n" + `{"message":"no level"}` + "\n"
+	if string(contents) != want {
+		t.Errorf("want %q got %q", want, string(contents))
+	}
+	if errs.String() != `{"level":"error","message":"failed"}`+"\n" {
+		t.Errorf("want the error entry in its own writer got %q", errs.String())
+	}
+}
+
+// TestLevelWriterSampling checks that the sampler chooses the entries written.
+func TestLevelWriterSampling(t *testing.T) {
+	var buffer bytes.Buffer
+
+	sampler := zerolog.LevelSampler{DebugSampler: &zerolog.BasicSampler{N: 2}}
+	logger := zerolog.New(New(&buffer, WithSampler(sampler))).Level(zerolog.DebugLevel)
+	for i := 0; i < 4; i++ {
+		logger.Debug().Int("i", i).Msg("debug")
+	}
+	logger.Warn().Msg("kept")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"i":0`) || !strings.Contains(lines[1], `"i":2`) ||
+		!strings.Contains(lines[2], "kept") {
+		t.Errorf("want debug entries 0 and 2 and the warning got %q", lines)
+	}
+}
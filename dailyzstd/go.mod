@@ -0,0 +1,12 @@
+module github.com/goblimey/dailylogger/dailyzstd
+
+go 1.24.1
+
+require (
+	github.com/goblimey/dailylogger v0.0.0-00010101000000-000000000000
+	github.com/klauspost/compress v1.17.11
+)
+
+require golang.org/x/sys v0.39.0 // indirect
+
+replace github.com/goblimey/dailylogger => ../
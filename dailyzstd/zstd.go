@@ -0,0 +1,50 @@
+// Package dailyzstd supplies a Compressor that compresses the files of a daily
+// log Writer with Zstandard, which is much faster than gzip at a similar ratio
+// (see dailylogger.WithCompression).  It's a separate module so that programs
+// that don't use Zstandard don't depend on it.  Typical use:
+//
+//	writer := dailylogger.New(time.Now(), logDir, "service.", ".log",
+//		dailylogger.WithCompression(dailyzstd.New(3)))
+package dailyzstd
+
+import (
+	"io"
+
+	"github.com/goblimey/dailylogger"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor produces Zstandard files with the extension ".zst".
+type Compressor struct {
+	level zstd.EncoderLevel
+}
+
+// This is a compile-time check that Compressor implements the
+// dailylogger.Compressor interface.
+var _ dailylogger.Compressor = (*Compressor)(nil)
+
+// New creates a Compressor with the given compression level, from 1, the
+// fastest, to 22, the smallest, as in the zstd command.  The levels are mapped
+// to the nearest of the encoder's four speeds.
+func New(level int) *Compressor {
+	return &Compressor{level: zstd.EncoderLevelFromZstd(level)}
+}
+
+// Extension returns ".zst".
+func (c *Compressor) Extension() string {
+	return ".zst"
+}
+
+// NewWriter returns a Zstandard encoder.
+func (c *Compressor) NewWriter(dest io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(dest, zstd.WithEncoderLevel(c.level))
+}
+
+// NewReader returns a Zstandard decoder.
+func (c *Compressor) NewReader(source io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(source)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
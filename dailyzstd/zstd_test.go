@@ -0,0 +1,47 @@
+package dailyzstd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+)
+
+// TestCompression checks that a Writer compresses its old log files with
+// Zstandard and that they can be read back.
+func TestCompression(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Now()
+
+	writer := dailylogger.New(now, directory, "foo.", ".bar", dailylogger.WithCompression(New(3)))
+	writer.Write([]byte("hello\n"))
+	writer.Rotate()
+	writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 2 || !logFiles[0].Compressed || logFiles[1].Compressed {
+		t.Fatalf("want the first of two files compressed, got %v", logFiles)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(logFiles[0].Pathname, ".zst")); !os.IsNotExist(err) {
+		t.Errorf("want the original deleted, got %v", err)
+	}
+
+	reader, err := writer.OpenLogFile(logFiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "hello\n" {
+		t.Errorf("want \"hello\\n\" got %q", string(contents))
+	}
+}
@@ -0,0 +1,133 @@
+package dailylogger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goblimey/dailylogger/schedule"
+)
+
+// DateStyle controls how the date appears in the names of the log files.
+type DateStyle int
+
+const (
+	// DateISO is the ISO 8601 date, for example "2020-02-14".  It's the default.
+	DateISO DateStyle = iota
+	// DateDayOfYear is the year and the day of the year, for example "2020-045",
+	// as used by RINEX and other GNSS archives.
+	DateDayOfYear
+	// DateISOAndDayOfYear is the ISO date followed by the day of the year, for
+	// example "2020-02-14.045".
+	DateISOAndDayOfYear
+	// DateJulianDay is the Julian Day Number, the number of days since the 1st
+	// January 4713 BC in the Julian calendar, for example "2458894".
+	DateJulianDay
+	// DateGPSWeek is the GPS week number and the day of the week (0 for Sunday),
+	// for example "2092-5".  GPS weeks are counted from the 6th January 1980.
+	DateGPSWeek
+)
+
+// The starts of the day counts.
+var (
+	unixEpoch = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+	gpsEpoch  = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+)
+
+// julianDayOfUnixEpoch is the Julian Day Number of the 1st January 1970.
+const julianDayOfUnixEpoch = 2440588
+
+// WithDateStyle sets the form of the date in the names of the log files.  The
+// date is always the one at the start of the day in the Writer's timezone.  It
+// has no effect if a FileNamer is supplied.
+func WithDateStyle(style DateStyle) Option {
+	return func(dw *Writer) {
+		dw.dateStyle = style
+	}
+}
+
+// format returns the date of the given day in this style.
+func (style DateStyle) format(day time.Time) string {
+	switch style {
+	case DateDayOfYear:
+		return fmt.Sprintf("%04d-%03d", day.Year(), day.YearDay())
+	case DateISOAndDayOfYear:
+		return fmt.Sprintf("%04d-%02d-%02d.%03d", day.Year(), int(day.Month()), day.Day(), day.YearDay())
+	case DateJulianDay:
+		return strconv.Itoa(daysSince(unixEpoch, day) + julianDayOfUnixEpoch)
+	case DateGPSWeek:
+		days := daysSince(gpsEpoch, day)
+		return fmt.Sprintf("%04d-%d", days/7, days%7)
+	default:
+		return fmt.Sprintf("%04d-%02d-%02d", day.Year(), int(day.Month()), day.Day())
+	}
+}
+
+// length returns the length of a date in this style.  It assumes that the year
+// has four digits and so does the GPS week.
+func (style DateStyle) length() int {
+	switch style {
+	case DateDayOfYear:
+		return len("2020-045")
+	case DateISOAndDayOfYear:
+		return len("2020-02-14.045")
+	case DateJulianDay:
+		return len("2458894")
+	case DateGPSWeek:
+		return len("2092-5")
+	default:
+		return len("2020-02-14")
+	}
+}
+
+// parse parses a date in this style and returns midnight at the start of that
+// day in the given location.  It returns false if the date isn't valid or isn't
+// in the canonical form that format produces.
+func (style DateStyle) parse(s string, location *time.Location) (time.Time, bool) {
+	var date time.Time
+
+	switch style {
+	case DateJulianDay:
+		jd, err := strconv.Atoi(s)
+		if err != nil {
+			return time.Time{}, false
+		}
+		date = time.Date(1970, time.January, 1+jd-julianDayOfUnixEpoch, 0, 0, 0, 0, location)
+
+	case DateGPSWeek:
+		week, dayOfWeek, found := strings.Cut(s, "-")
+		w, we := strconv.Atoi(week)
+		d, de := strconv.Atoi(dayOfWeek)
+		if !found || we != nil || de != nil {
+			return time.Time{}, false
+		}
+		date = time.Date(1980, time.January, 6+7*w+d, 0, 0, 0, 0, location)
+
+	default:
+		layout := map[DateStyle]string{
+			DateISO:             "2006-01-02",
+			DateDayOfYear:       "2006-002",
+			DateISOAndDayOfYear: "2006-01-02.002",
+		}[style]
+		d, err := time.ParseInLocation(layout, s, location)
+		if err != nil {
+			return time.Time{}, false
+		}
+		date = d
+	}
+
+	// Reject anything that isn't in the canonical form, such as a day of the week
+	// greater than 6.
+	if style.format(date) != s {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// daysSince returns the number of calendar days from the epoch to the date of the
+// given day, ignoring the time and the timezone.
+func daysSince(epoch, day time.Time) int {
+	return schedule.DaysBetween(epoch, day)
+}
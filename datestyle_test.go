@@ -0,0 +1,52 @@
+package dailylogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDateStyles checks the log file names produced in each date style and that
+// they can be parsed again.
+func TestDateStyles(t *testing.T) {
+	locationParis, _ := time.LoadLocation("Europe/Paris")
+	day := time.Date(2020, time.February, 14, 0, 0, 0, 0, locationParis)
+
+	var testData = []struct {
+		style    DateStyle
+		wantName string
+		badName  string
+	}{
+		{DateISO, "foo.2020-02-14.bar", "foo.2020-2-14.bar"},
+		{DateDayOfYear, "foo.2020-045.bar", "foo.2020-367.bar"},
+		{DateISOAndDayOfYear, "foo.2020-02-14.045.bar", "foo.2020-02-14.046.bar"},
+		{DateJulianDay, "foo.2458894.bar", "foo.+458894.bar"},
+		{DateGPSWeek, "foo.2092-5.bar", "foo.2092-7.bar"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.wantName, func(t *testing.T) {
+			writer := Writer{logDir: ".", leader: "foo.", trailer: ".bar", startOfToday: day, dateStyle: td.style}
+
+			name := writer.getLogPathname(day, 0)[len("./"):]
+			if name != td.wantName {
+				t.Errorf("want %s got %s", td.wantName, name)
+				return
+			}
+
+			date, sequence, ok := writer.parseLogFileName(name)
+			if !ok || !date.Equal(day) || sequence != 0 {
+				t.Errorf("%s: want %v 0 true got %v %d %v", name, day, date, sequence, ok)
+			}
+
+			name = writer.getLogPathname(day, 3)[len("./"):]
+			date, sequence, ok = writer.parseLogFileName(name)
+			if !ok || !date.Equal(day) || sequence != 3 {
+				t.Errorf("%s: want %v 3 true got %v %d %v", name, day, date, sequence, ok)
+			}
+
+			if _, _, ok := writer.parseLogFileName(td.badName); ok {
+				t.Errorf("%s: want it to be rejected", td.badName)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package dailylogger
+
+import "os"
+
+// syncDirectory flushes the directory to the disk, so that the files created,
+// renamed or removed in it survive a crash.
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
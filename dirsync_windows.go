@@ -0,0 +1,10 @@
+//go:build windows
+
+package dailylogger
+
+// syncDirectory does nothing under Windows.  NTFS records changes to the
+// directory in its journal, and a directory can't be opened for flushing
+// without special privileges.
+func syncDirectory(directory string) error {
+	return nil
+}
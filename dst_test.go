@@ -0,0 +1,87 @@
+package dailylogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDSTDurations checks the time to wait for midnight on the days when daylight
+// saving time starts and ends.  In 2020 the clocks in Paris went forward at 02:00
+// on the 29th March (a 23 hour day) and back at 03:00 on the 25th October (a 25
+// hour day).
+func TestDSTDurations(t *testing.T) {
+	locationParis, _ := time.LoadLocation("Europe/Paris")
+
+	var testData = []struct {
+		description  string
+		start        time.Time
+		wantDuration time.Duration
+	}{
+		{"spring forward", time.Date(2020, time.March, 29, 0, 30, 0, 0, locationParis), 22*time.Hour + 30*time.Minute},
+		{"fall back", time.Date(2020, time.October, 25, 0, 30, 0, 0, locationParis), 24*time.Hour + 30*time.Minute},
+		{"evening before spring forward", time.Date(2020, time.March, 28, 23, 0, 0, 0, locationParis), time.Hour},
+		{"after spring forward", time.Date(2020, time.March, 29, 3, 0, 0, 0, locationParis), 21 * time.Hour},
+		{"after fall back", time.Date(2020, time.October, 25, 3, 0, 0, 0, locationParis), 21 * time.Hour},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			want := td.wantDuration + extraDuration
+			got := getDurationToJustAfterMidnight(td.start)
+			if got != want {
+				t.Errorf("%v: want %v got %v", td.start, want, got)
+			}
+		})
+	}
+}
+
+// TestMissingMidnight checks the case where the clocks go forward at midnight, so
+// the day starts at 01:00.  In 2020 that happened in Santiago de Chile on the 6th
+// September.
+func TestMissingMidnight(t *testing.T) {
+	locationSantiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skip("no timezone data for Santiago")
+	}
+
+	// The clocks went forward from 00:00 -04 to 01:00 -03, at 04:00 UTC.
+	wantStart := time.Date(2020, time.September, 6, 4, 0, 0, 0, time.UTC)
+
+	now := time.Date(2020, time.September, 6, 12, 0, 0, 0, locationSantiago)
+	got := getLastMidnight(now)
+	if !got.Equal(wantStart) {
+		t.Errorf("want last midnight %v got %v", wantStart, got)
+	}
+	if got.Day() != 6 {
+		t.Errorf("want the 6th got %v", got)
+	}
+
+	dayBefore := time.Date(2020, time.September, 5, 12, 0, 0, 0, locationSantiago)
+	gotNext := getNextMidnight(dayBefore)
+	if !gotNext.Equal(wantStart) {
+		t.Errorf("want next midnight %v got %v", wantStart, gotNext)
+	}
+
+	writer := Writer{logDir: ".", leader: "foo.", trailer: ".bar"}
+	const wantPathname = "./foo.2020-09-06.bar"
+	gotPathname := writer.getLogPathname(got, 0)
+	if gotPathname != wantPathname {
+		t.Errorf("want %s got %s", wantPathname, gotPathname)
+	}
+}
+
+// TestLocation checks that WithLocation controls the datestamp.  At 23:30 UTC on
+// the 14th it's already the 15th in Paris.
+func TestLocation(t *testing.T) {
+	locationParis, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, time.UTC)
+	fc := newFakeClock(now)
+
+	writer := Writer{location: locationParis, clock: fc}
+	got := getLastMidnight(writer.now())
+
+	want := time.Date(2020, time.February, 15, 0, 0, 0, 0, locationParis)
+	if !got.Equal(want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
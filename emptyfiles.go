@@ -0,0 +1,99 @@
+package dailylogger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WithEmptyFiles makes the Writer create an empty log file for each day on which
+// it didn't create one, for pipelines that treat a missing day's file as an
+// error.  The Writer creates each day's file when it rotates to the day, even if
+// nothing is written to it, but days can pass without rotation: while the
+// program isn't running, when rotation is lazy (see WithLazyRotation) and
+// nothing is written all day, or when a slow system rotates past a whole day.
+// With this option the Writer looks for the latest day that has a log file when
+// it starts and each time it rotates to a new day, and creates an empty file
+// for each day after that one and before the current day, going back at most
+// maxDays days.  If there are no log files at all, nothing is created, so a new
+// installation doesn't get a history of empty files.  The empty file is created
+// in the same way as the log file, for example through the FileFactory (see
+// WithFileFactory) or compressed (see WithCompressedLiveFile), and the artifacts
+// aren't made for it.  With lazy rotation the files for the quiet days only
+// appear at the next write.  The option has no effect when rotation is
+// disabled.
+func WithEmptyFiles(maxDays int) Option {
+	return func(dw *Writer) {
+		if maxDays > 0 {
+			dw.emptyFileDays = maxDays
+		}
+	}
+}
+
+// fillMissingDays is a helper function that creates the empty files for the days
+// before the current one that have no log file (see WithEmptyFiles).  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) fillMissingDays() {
+	if dw.emptyFileDays == 0 || dw.noRotation || dw.stream || dw.loggingDisabled {
+		return
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error looking for days with no log file - %w", err))
+		return
+	}
+
+	// Find the latest day before today that has a file.
+	var latest time.Time
+	for _, logFile := range logFiles {
+		if logFile.Date.Before(dw.startOfToday) && logFile.Date.After(latest) {
+			latest = logFile.Date
+		}
+	}
+	if latest.IsZero() {
+		return
+	}
+
+	earliest := dw.startOfToday.AddDate(0, 0, -dw.emptyFileDays)
+	for day := getNextMidnight(latest); day.Before(dw.startOfToday); day = getNextMidnight(day) {
+		if day.Before(earliest) {
+			continue
+		}
+		if err := dw.createEmptyFile(day); err != nil {
+			dw.reportError(fmt.Errorf("dailylogger: error creating an empty log file - %w", err))
+		}
+	}
+}
+
+// createEmptyFile is a helper function for fillMissingDays that creates the empty
+// log file for the given day.
+func (dw *Writer) createEmptyFile(day time.Time) error {
+	pathname := dw.getLogPathname(day, 0)
+	dw.createParent(dw.logDir, pathname)
+
+	var file io.WriteCloser
+	var err error
+	switch {
+	case dw.fileFactory != nil:
+		file, err = dw.fileFactory(pathname)
+	case dw.compressLive:
+		pathname += dw.compressor.Extension()
+		file, err = dw.openCompressed(pathname)
+	default:
+		var logFile *os.File
+		logFile, err = dw.openFile(pathname)
+		if logFile != nil {
+			file = logFile
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	dw.emitLifecycle(Event{Type: EventFileOpened, Path: pathname})
+	err = file.Close()
+	dw.emitLifecycle(Event{Type: EventFileClosed, Path: pathname})
+	return err
+}
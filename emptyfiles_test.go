@@ -0,0 +1,80 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestEmptyFiles checks that empty files are created for the days with none,
+// both when the Writer starts and when rotation skips days, within the limit.
+func TestEmptyFiles(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-02-08.bar", []byte("old\n"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// The program was down from the 9th to the 13th, but only four days are filled.
+	writer := New(now, ".", "foo.", ".bar", WithEmptyFiles(4))
+	defer writer.Close()
+
+	// Rotation skips the 15th and the 16th.
+	writer.rotateLogs(now.AddDate(0, 0, 3))
+
+	if _, err := os.Stat("foo.2020-02-09.bar"); err == nil {
+		t.Error("want no file for the 9th, which is beyond the limit")
+	}
+	for _, name := range []string{
+		"foo.2020-02-10.bar", "foo.2020-02-11.bar", "foo.2020-02-12.bar", "foo.2020-02-13.bar",
+		"foo.2020-02-15.bar", "foo.2020-02-16.bar",
+	} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("want an empty %s - %v", name, err)
+			continue
+		}
+		if info.Size() != 0 {
+			t.Errorf("%s: want an empty file got %d bytes", name, info.Size())
+		}
+	}
+
+	contents, _ := os.ReadFile("foo.2020-02-08.bar")
+	if string(contents) != "old\n" {
+		t.Errorf("want the old file unchanged got \"%s\"", string(contents))
+	}
+}
+
+// TestEmptyFilesNewInstallation checks that no empty files are created when
+// there are no log files.
+func TestEmptyFilesNewInstallation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithEmptyFiles(30))
+	logFiles, _ := writer.List()
+	writer.Close()
+
+	if len(logFiles) != 1 {
+		t.Errorf("want just today's file got %v", logFiles)
+	}
+}
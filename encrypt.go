@@ -0,0 +1,476 @@
+package dailylogger
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// encryptedMagic starts each encrypted stream.  The last but one byte is the
+// version of the format.
+var encryptedMagic = []byte("DLENC\x00\x02\n")
+
+// These define the encrypted format (see Encryptor).
+const (
+	encryptedChunkSize  = 64 * 1024 // The most plaintext sealed in one chunk.
+	encryptedPrefixSize = 8         // The size of the random part of each nonce.
+	dataKeySize         = 32        // The size of the key that encrypts a stream.
+	maxKeyIDSize        = 255       // The longest key ID.
+
+	// The size of a wrapped data key: the GCM nonce, the key and the GCM tag.
+	wrappedKeySize = 12 + dataKeySize + 16
+)
+
+// errEncryptedData is returned when an encrypted chunk can't be opened.
+var errEncryptedData = errors.New("dailylogger: the encrypted data is damaged or the key is wrong")
+
+// Key is one of the keys of an Encryptor (see NewKeyedEncryptor).
+type Key struct {
+	ID     string    // Names the key in the stream headers (up to 255 bytes).
+	Secret []byte    // The AES key, 16, 24 or 32 bytes long.
+	From   time.Time // The key is active from the start of this day, in its timezone.
+	Escrow bool      // The key can always read new streams, as well as the active key.
+}
+
+// keyEntry is a Key ready for use.
+type keyEntry struct {
+	id     string
+	from   time.Time // Midnight at the start of the day the key becomes active.
+	escrow bool
+	aead   cipher.AEAD
+}
+
+// Encryptor is a Compressor, or more precisely a stage of a Pipeline, that
+// encrypts the data with AES in GCM mode and adds the extension ".enc".  The data
+// is sealed in chunks of up to 64 KiB, so that it can be written as a stream and
+// flushed at any point.  Each stream has its own random data key and starts with
+// a header holding that key wrapped with each of the keys allowed to read it,
+// each labelled with its key ID, followed by a random nonce prefix.  Each
+// chunk's nonce is the prefix followed by the chunk's number.  The last chunk of
+// a stream is marked, so a reader can tell a stream that was cut short, by a
+// crash for example, from a complete one.  A file may hold several streams one
+// after another, as when the Writer restarts during the day with
+// WithCompressedLiveFile.  Any change to the data is detected when it's read.
+type Encryptor struct {
+	keys []keyEntry       // Sorted by the day they become active.
+	now  func() time.Time // Gives the time, to choose the active key.
+}
+
+// This is a compile-time check that Encryptor implements the Compressor
+// interface.
+var _ Compressor = (*Encryptor)(nil)
+
+// NewEncryptor creates an Encryptor that uses the given AES key, which must be
+// 16, 24 or 32 bytes long.  The key has an empty ID.
+func NewEncryptor(key []byte) (*Encryptor, error) {
+	return NewKeyedEncryptor(Key{Secret: key})
+}
+
+// NewKeyedEncryptor creates an Encryptor with several keys, so that the key can
+// be changed, for example every year on a long-lived device, without losing the
+// ability to read the old files.  Each new stream is encrypted for the active
+// key, which is the one that isn't an escrow key and whose From day is the
+// latest that has begun, and for all of the escrow keys that have begun, such
+// as a recovery key.  The keys that have been replaced are kept for reading the
+// streams made with them, which name them by their IDs.  With WithCompression
+// the file for a day is encrypted just after midnight, so it gets the key that
+// becomes active that day.  It returns an error if a key is the wrong size, if
+// two keys have the same ID or if there is no key other than escrow keys.
+func NewKeyedEncryptor(keys ...Key) (*Encryptor, error) {
+	encryptor := Encryptor{now: time.Now}
+	ids := make(map[string]bool)
+	active := false
+	for _, key := range keys {
+		if len(key.ID) > maxKeyIDSize {
+			return nil, fmt.Errorf("NewKeyedEncryptor: key ID %q is too long", key.ID)
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("NewKeyedEncryptor: there are two keys with ID %q", key.ID)
+		}
+		ids[key.ID] = true
+
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyedEncryptor: key %q - %w", key.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyedEncryptor: key %q - %w", key.ID, err)
+		}
+
+		from := key.From
+		if !from.IsZero() {
+			from = getLastMidnight(from)
+		}
+		encryptor.keys = append(encryptor.keys, keyEntry{id: key.ID, from: from, escrow: key.Escrow, aead: aead})
+		active = active || !key.Escrow
+	}
+	if !active {
+		return nil, errors.New("NewKeyedEncryptor: there is no key other than escrow keys")
+	}
+
+	sort.SliceStable(encryptor.keys, func(i, j int) bool {
+		return encryptor.keys[i].from.Before(encryptor.keys[j].from)
+	})
+
+	return &encryptor, nil
+}
+
+// Extension returns ".enc".
+func (e *Encryptor) Extension() string {
+	return ".enc"
+}
+
+// recipients returns the keys that a new stream is encrypted for.
+func (e *Encryptor) recipients() []keyEntry {
+	now := e.now()
+
+	var active *keyEntry
+	var escrow []keyEntry
+	for i, key := range e.keys {
+		if key.from.After(now) {
+			// The keys are sorted, so the rest haven't begun either.
+			break
+		}
+		if key.escrow {
+			escrow = append(escrow, key)
+		} else {
+			active = &e.keys[i]
+		}
+	}
+	if active == nil {
+		// No key has begun yet, so use the earliest.
+		for i, key := range e.keys {
+			if !key.escrow {
+				active = &e.keys[i]
+				break
+			}
+		}
+	}
+
+	return append([]keyEntry{*active}, escrow...)
+}
+
+// NewWriter writes the header of a new stream to dest and returns a writer that
+// encrypts the data written to it.
+func (e *Encryptor) NewWriter(dest io.Writer) (io.WriteCloser, error) {
+	dataKey := make([]byte, dataKeySize)
+	prefix := make([]byte, encryptedPrefixSize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	recipients := e.recipients()
+	header := append(bytes.Clone(encryptedMagic), byte(len(recipients)))
+	for _, key := range recipients {
+		nonce := make([]byte, key.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		header = append(header, byte(len(key.id)))
+		header = append(header, key.id...)
+		header = append(header, nonce...)
+		header = key.aead.Seal(header, nonce, dataKey, []byte(key.id))
+	}
+	header = append(header, prefix...)
+
+	aead, err := newDataCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dest.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptingWriter{aead: aead, dest: dest, prefix: prefix}, nil
+}
+
+// NewReader returns a reader that decrypts the streams in source, using
+// whichever of the keys each stream was encrypted for.
+func (e *Encryptor) NewReader(source io.Reader) (io.ReadCloser, error) {
+	return &decryptingReader{encryptor: e, source: source, needHeader: true}, nil
+}
+
+// newDataCipher returns the cipher that encrypts a stream with its data key.
+func newDataCipher(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readHeader is a helper function for decryptingReader that reads the header of
+// a stream, after the magic, and returns the cipher for the stream's data and
+// the nonce prefix.
+func (e *Encryptor) readHeader(source io.Reader) (cipher.AEAD, []byte, error) {
+	var count [1]byte
+	if _, err := io.ReadFull(source, count[:]); err != nil {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	var dataKey []byte
+	var ids []string
+	for range int(count[0]) {
+		var idLength [1]byte
+		if _, err := io.ReadFull(source, idLength[:]); err != nil {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		id := make([]byte, idLength[0])
+		if _, err := io.ReadFull(source, id); err != nil {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		ids = append(ids, string(id))
+
+		entry := make([]byte, wrappedKeySize)
+		if _, err := io.ReadFull(source, entry); err != nil {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+
+		if dataKey != nil {
+			continue
+		}
+		for _, key := range e.keys {
+			if key.id != string(id) {
+				continue
+			}
+			nonceSize := key.aead.NonceSize()
+			opened, err := key.aead.Open(nil, entry[:nonceSize], entry[nonceSize:], id)
+			if err != nil {
+				return nil, nil, errEncryptedData
+			}
+			dataKey = opened
+		}
+	}
+
+	prefix := make([]byte, encryptedPrefixSize)
+	if _, err := io.ReadFull(source, prefix); err != nil {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	if dataKey == nil {
+		return nil, nil, fmt.Errorf("dailylogger: the stream is encrypted for keys %q and none of them is known", ids)
+	}
+
+	aead, err := newDataCipher(dataKey)
+	return aead, prefix, err
+}
+
+// encryptingWriter encrypts a stream in chunks.
+type encryptingWriter struct {
+	aead    cipher.AEAD
+	dest    io.Writer
+	prefix  []byte // The random part of each nonce.
+	counter uint32 // The number of the next chunk.
+	pending []byte // The data not yet sealed.
+	scratch []byte // Reused to build each chunk.
+	closed  bool
+}
+
+// Write seals the data in chunks, keeping the last part until there is a full
+// chunk or the writer is flushed or closed.
+func (ew *encryptingWriter) Write(buffer []byte) (int, error) {
+	if ew.closed {
+		return 0, ErrClosed
+	}
+
+	ew.pending = append(ew.pending, buffer...)
+	for len(ew.pending) >= encryptedChunkSize {
+		if err := ew.seal(ew.pending[:encryptedChunkSize], false); err != nil {
+			return 0, err
+		}
+		ew.pending = ew.pending[:copy(ew.pending, ew.pending[encryptedChunkSize:])]
+	}
+
+	return len(buffer), nil
+}
+
+// Flush seals the data written so far.
+func (ew *encryptingWriter) Flush() error {
+	if ew.closed || len(ew.pending) == 0 {
+		return nil
+	}
+
+	err := ew.seal(ew.pending, false)
+	ew.pending = ew.pending[:0]
+	return err
+}
+
+// Close seals the rest of the data as the last chunk of the stream.
+func (ew *encryptingWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+
+	err := ew.seal(ew.pending, true)
+	ew.pending = nil
+	ew.closed = true
+	return err
+}
+
+// seal encrypts one chunk and writes it, preceded by its length.
+func (ew *encryptingWriter) seal(plaintext []byte, last bool) error {
+	if ew.counter == ^uint32(0) {
+		return errors.New("dailylogger: too many chunks in one encrypted stream")
+	}
+
+	nonce := binary.BigEndian.AppendUint32(bytes.Clone(ew.prefix), ew.counter)
+	ew.counter++
+
+	ew.scratch = binary.BigEndian.AppendUint32(ew.scratch[:0], uint32(len(plaintext)+ew.aead.Overhead()))
+	ew.scratch = ew.aead.Seal(ew.scratch, nonce, plaintext, chunkData(last))
+	_, err := ew.dest.Write(ew.scratch)
+	return err
+}
+
+// chunkData returns the additional data that marks a chunk as the last of its
+// stream or not.
+func chunkData(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// decryptingReader decrypts one or more encrypted streams.
+type decryptingReader struct {
+	encryptor  *Encryptor
+	aead       cipher.AEAD // Decrypts the current stream.
+	source     io.Reader
+	needHeader bool   // True if a stream header comes next.
+	prefix     []byte // The random part of the nonces of the current stream.
+	counter    uint32 // The number of the next chunk.
+	plaintext  []byte // The decrypted data not yet returned.
+	ciphertext []byte // Reused to read each chunk.
+}
+
+// Read returns the decrypted data.
+func (dr *decryptingReader) Read(buffer []byte) (int, error) {
+	for len(dr.plaintext) == 0 {
+		if err := dr.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(buffer, dr.plaintext)
+	dr.plaintext = dr.plaintext[n:]
+	return n, nil
+}
+
+// Close does nothing.  The source belongs to the caller.
+func (dr *decryptingReader) Close() error {
+	return nil
+}
+
+// next reads and decrypts the next chunk, reading the header of a new stream
+// first if one is due.  It returns io.EOF at the end of a complete stream and
+// io.ErrUnexpectedEOF if the last stream was cut short.
+func (dr *decryptingReader) next() error {
+	if dr.needHeader {
+		magic := make([]byte, len(encryptedMagic))
+		if _, err := io.ReadFull(dr.source, magic); err != nil {
+			if err == io.EOF {
+				return io.EOF
+			}
+			return io.ErrUnexpectedEOF
+		}
+		if !bytes.Equal(magic, encryptedMagic) {
+			return errors.New("dailylogger: not an encrypted stream")
+		}
+
+		aead, prefix, err := dr.encryptor.readHeader(dr.source)
+		if err != nil {
+			return err
+		}
+		dr.aead = aead
+		dr.prefix = prefix
+		dr.counter = 0
+		dr.needHeader = false
+	}
+
+	var length [4]byte
+	if _, err := io.ReadFull(dr.source, length[:]); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > encryptedChunkSize+uint32(dr.aead.Overhead()) {
+		return errEncryptedData
+	}
+
+	dr.ciphertext = append(dr.ciphertext[:0], make([]byte, size)...)
+	if _, err := io.ReadFull(dr.source, dr.ciphertext); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+
+	nonce := binary.BigEndian.AppendUint32(bytes.Clone(dr.prefix), dr.counter)
+	dr.counter++
+
+	plaintext, err := dr.aead.Open(nil, nonce, dr.ciphertext, chunkData(false))
+	if err != nil {
+		plaintext, err = dr.aead.Open(nil, nonce, dr.ciphertext, chunkData(true))
+		if err != nil {
+			return errEncryptedData
+		}
+		dr.needHeader = true
+	}
+
+	dr.plaintext = plaintext
+	return nil
+}
+
+// encryptedHeaders finds the headers of the encrypted streams in a file of the
+// given size.  For each header it returns the offset and length of the wrapped
+// data keys, which are all that's needed to make the stream unreadable.  It
+// returns false if the file doesn't start with an encrypted stream.  The streams
+// are found by following the chunk lengths.  A chunk length starts with a zero
+// byte, because a chunk is never bigger than 64 KiB plus the tag, and a header
+// starts with the magic, so the two can't be confused.
+func encryptedHeaders(file io.ReaderAt, size int64) ([][2]int64, bool, error) {
+	var headers [][2]int64
+	var offset int64
+	buffer := make([]byte, len(encryptedMagic)+1)
+	for offset < size {
+		if _, err := file.ReadAt(buffer[:4], offset); err != nil {
+			return headers, len(headers) > 0, nil
+		}
+
+		if buffer[0] == 0 {
+			// A chunk.
+			offset += 4 + int64(binary.BigEndian.Uint32(buffer[:4]))
+			continue
+		}
+
+		if _, err := file.ReadAt(buffer, offset); err != nil ||
+			!bytes.Equal(buffer[:len(encryptedMagic)], encryptedMagic) {
+			if len(headers) == 0 {
+				return nil, false, nil
+			}
+			return headers, true, errors.New("dailylogger: damaged encrypted stream")
+		}
+
+		// The key entries start after the magic and the count.
+		start := offset + int64(len(buffer))
+		position := start
+		for range int(buffer[len(encryptedMagic)]) {
+			var idLength [1]byte
+			if _, err := file.ReadAt(idLength[:], position); err != nil {
+				return headers, true, err
+			}
+			position += 1 + int64(idLength[0]) + wrappedKeySize
+		}
+		headers = append(headers, [2]int64{start, position - start})
+		offset = position + encryptedPrefixSize
+	}
+
+	return headers, len(headers) > 0, nil
+}
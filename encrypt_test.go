@@ -0,0 +1,151 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encryptForTest encrypts each of the parts as a separate stream.
+func encryptForTest(t *testing.T, encryptor *Encryptor, parts ...string) []byte {
+	t.Helper()
+
+	var file bytes.Buffer
+	for _, part := range parts {
+		writer, err := encryptor.NewWriter(&file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(part))
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return file.Bytes()
+}
+
+// decryptForTest decrypts the data and returns the result and the error.
+func decryptForTest(encryptor *Encryptor, data []byte) (string, error) {
+	reader, _ := encryptor.NewReader(bytes.NewReader(data))
+	plaintext, err := io.ReadAll(reader)
+	return string(plaintext), err
+}
+
+// TestEncryptor checks that the Encryptor's output can be decrypted, including
+// several streams in one file and chunks bigger than one, and that damage, a
+// wrong key and a stream cut short are detected.
+func TestEncryptor(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	encryptor, err := NewEncryptor(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	big := strings.Repeat("0123456789abcdef", encryptedChunkSize/8)
+	data := encryptForTest(t, encryptor, "hello\n", big, "")
+
+	plaintext, err := decryptForTest(encryptor, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "hello\n"+big {
+		t.Errorf("want %d bytes back, got %d", len("hello\n"+big), len(plaintext))
+	}
+
+	if bytes.Contains(data, []byte("hello")) {
+		t.Error("the plaintext appears in the encrypted data")
+	}
+
+	damaged := bytes.Clone(data)
+	damaged[len(damaged)/2] ^= 1
+	if _, err := decryptForTest(encryptor, damaged); !errors.Is(err, errEncryptedData) {
+		t.Errorf("damaged data: want errEncryptedData, got %v", err)
+	}
+
+	otherKey, _ := NewEncryptor(bytes.Repeat([]byte{8}, 32))
+	if _, err := decryptForTest(otherKey, data); !errors.Is(err, errEncryptedData) {
+		t.Errorf("wrong key: want errEncryptedData, got %v", err)
+	}
+
+	// A stream that was flushed but not closed, as after a crash.
+	var file bytes.Buffer
+	writer, _ := encryptor.NewWriter(&file)
+	writer.Write([]byte("flushed\n"))
+	writer.(interface{ Flush() error }).Flush()
+	writer.Write([]byte("lost\n"))
+	plaintext, err = decryptForTest(encryptor, file.Bytes())
+	if plaintext != "flushed\n" || err != io.ErrUnexpectedEOF {
+		t.Errorf("cut short: want \"flushed\\n\" and io.ErrUnexpectedEOF, got %q and %v", plaintext, err)
+	}
+
+	if _, err := NewEncryptor([]byte("short")); err == nil {
+		t.Error("want an error for a bad key")
+	}
+}
+
+// TestKeyRotation checks that each stream is encrypted for the key active on the
+// day and for the escrow key, and that the old streams can still be read with a
+// retired key.
+func TestKeyRotation(t *testing.T) {
+	locationUTC, _ := time.LoadLocation("UTC")
+	oldKey := Key{ID: "2019", Secret: bytes.Repeat([]byte{1}, 32),
+		From: time.Date(2019, time.January, 1, 0, 0, 0, 0, locationUTC)}
+	newKey := Key{ID: "2020", Secret: bytes.Repeat([]byte{2}, 32),
+		From: time.Date(2020, time.February, 15, 9, 0, 0, 0, locationUTC)}
+	escrowKey := Key{ID: "recovery", Secret: bytes.Repeat([]byte{3}, 16), Escrow: true}
+
+	encryptor, err := NewKeyedEncryptor(newKey, oldKey, escrowKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key becomes active at the start of its day, not at 9:00.
+	encryptor.now = func() time.Time { return time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC) }
+	before := encryptForTest(t, encryptor, "before\n")
+	encryptor.now = func() time.Time { return time.Date(2020, time.February, 15, 0, 0, 1, 0, locationUTC) }
+	after := encryptForTest(t, encryptor, "after\n")
+
+	// Each reader has only one of the keys.
+	oldKey.From = time.Time{}
+	escrowKey.Escrow = false
+	oldReader, _ := NewKeyedEncryptor(oldKey)
+	newReader, _ := NewKeyedEncryptor(newKey)
+	escrowReader, _ := NewKeyedEncryptor(escrowKey)
+
+	var testData = []struct {
+		description string
+		reader      *Encryptor
+		data        []byte
+		want        string
+	}{
+		{"old key, old stream", oldReader, before, "before\n"},
+		{"old key, new stream", oldReader, after, ""},
+		{"new key, old stream", newReader, before, ""},
+		{"new key, new stream", newReader, after, "after\n"},
+		{"escrow key, old stream", escrowReader, before, "before\n"},
+		{"escrow key, new stream", escrowReader, after, "after\n"},
+	}
+
+	for _, td := range testData {
+		plaintext, err := decryptForTest(td.reader, td.data)
+		if len(td.want) == 0 {
+			if err == nil || !strings.Contains(err.Error(), "none of them is known") {
+				t.Errorf("%s: want an unknown key error, got %v", td.description, err)
+			}
+			continue
+		}
+		if err != nil || plaintext != td.want {
+			t.Errorf("%s: want %q got %q and %v", td.description, td.want, plaintext, err)
+		}
+	}
+
+	if _, err := NewKeyedEncryptor(escrowKey, escrowKey); err == nil {
+		t.Error("want an error for two keys with the same ID")
+	}
+	if _, err := NewKeyedEncryptor(Key{ID: "escrow", Secret: escrowKey.Secret, Escrow: true}); err == nil {
+		t.Error("want an error for only escrow keys")
+	}
+}
@@ -0,0 +1,121 @@
+package dailylogger
+
+import (
+	"errors"
+	"log"
+)
+
+// The errors reported by the Writer, via the error handlers (see WithErrorHandler)
+// and the results of its methods, wrap one of these errors where it applies, so
+// that callers can test for the class of failure with errors.Is.  The message of
+// the reported error is unchanged.
+
+// ErrClosed is returned by the methods of a Writer that has been closed.
+var ErrClosed = errors.New("dailylogger: the Writer is closed")
+
+// ErrDirCreate means that the log directory, the mirror directory or one of the
+// subdirectories made by a FileNamer couldn't be created or given its
+// permissions.
+var ErrDirCreate = errors.New("dailylogger: cannot create directory")
+
+// ErrFileOpen means that a file, such as the log file, its mirror, the journal or
+// the lock file, couldn't be opened or created.
+var ErrFileOpen = errors.New("dailylogger: cannot open file")
+
+// ErrChown means that the owner and group of a file or directory couldn't be
+// set.
+var ErrChown = errors.New("dailylogger: cannot set owner and group")
+
+// ErrNotPosix means that the call only works under a POSIX system, not MS
+// Windows.
+var ErrNotPosix = errors.New("dailylogger: only supported under a POSIX system")
+
+// ErrQuotaExceeded is reported when the daily quota is exceeded (see
+// WithDailyQuota).
+var ErrQuotaExceeded = errors.New("dailylogger: daily quota exceeded")
+
+// ErrLocked means that another process holds the lock file in the log directory
+// (see WithLockFile).
+var ErrLocked = errors.New("dailylogger: the log directory is in use by another process")
+
+// ErrCorruptRecord is returned by a RecordReader when a framed record is
+// damaged.
+var ErrCorruptRecord = errors.New("dailylogger: corrupt record")
+
+// ErrManifestMismatch is reported by VerifyManifests when a file doesn't match
+// its entry in a manifest (see WithManifest).
+var ErrManifestMismatch = errors.New("dailylogger: file doesn't match the manifest")
+
+// ErrBadSignature is reported by VerifyManifests when the signature of a
+// manifest is missing or doesn't match it.
+var ErrBadSignature = errors.New("dailylogger: bad manifest signature")
+
+// ErrChainBroken is returned by VerifyDay when a line of a log file doesn't
+// carry on the hash chain (see WithHashChain) or one of the day's files is
+// missing.
+var ErrChainBroken = errors.New("dailylogger: the hash chain is broken")
+
+// ErrZone means that a timezone named by WithZone or LoadZone couldn't be loaded,
+// usually because the system has no timezone database.
+var ErrZone = errors.New("dailylogger: cannot load timezone")
+
+// errNoFile is the error recorded when the log file couldn't be opened.
+var errNoFile = errors.New("the log file is not open")
+
+// errReplaced is the error from Health when the log file has been replaced.
+var errReplaced = errors.New("the log file has been moved or replaced")
+
+// errReadOnly is the error from Health when the log is being kept in memory.
+var errReadOnly = errors.New("the filesystem is read-only - the log is being kept in memory")
+
+// classError gives an error a class, one of the exported errors, without
+// changing its message.
+type classError struct {
+	class error // The class of the error, for example ErrFileOpen.
+	err   error // The error itself.
+}
+
+// withClass returns an error with the same message as err that errors.Is matches
+// with the class as well as the errors that err wraps.  If err is nil it returns
+// nil.
+func withClass(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classError{class: class, err: err}
+}
+
+// Error returns the message of the error.
+func (ce *classError) Error() string {
+	return ce.err.Error()
+}
+
+// Unwrap returns the class and the error.
+func (ce *classError) Unwrap() []error {
+	return []error{ce.class, ce.err}
+}
+
+// WithErrorHandler supplies a function that receives reports of errors inside the
+// Writer, such as a failure to create or write to the log file.  It's useful when
+// nobody is watching the program's standard error stream, for example when it's
+// running as a service.  The function may be called from any of the Writer's
+// goroutines while the Writer's lock is held, so it must not call the Writer's
+// methods.  Several handlers may be supplied and they are all called.
+func WithErrorHandler(handler func(error)) Option {
+	return func(dw *Writer) {
+		if handler != nil {
+			dw.errorHandlers = append(dw.errorHandlers, handler)
+		}
+	}
+}
+
+// reportError writes the error to the standard log (we don't have a log file
+// that we can rely on) and passes it to any error handlers.
+func (dw *Writer) reportError(err error) {
+	log.Println(err)
+	dw.stats.LastError = err.Error()
+
+	for _, handler := range dw.errorHandlers {
+		handler(err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package dailylogger
+
+import "errors"
+
+// errWindows is the error inside the io.fs.PathError returned under MS Windows by
+// calls that only work under a POSIX system.  Those calls check the operating
+// system first, so under a POSIX system it's never returned.
+var errWindows = withClass(ErrNotPosix, errors.New("not supported by windows"))
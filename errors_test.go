@@ -0,0 +1,207 @@
+package dailylogger
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestErrorHandler checks that the error handlers hear about a log file that
+// can't be created and about the failed writes that follow.
+func TestErrorHandler(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	// Create a directory with the name of the log file, so the log file can't be
+	// created.
+	const logFilename = "foo.2020-02-14.bar"
+	os.Mkdir(logFilename, 0755)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var errors1, errors2 []error
+	writer := New(now, ".", "foo.", ".bar",
+		WithErrorHandler(func(e error) { errors1 = append(errors1, e) }),
+		WithErrorHandler(func(e error) { errors2 = append(errors2, e) }),
+		WithWindowsEventLog("dailylogger-test"))
+	defer writer.Close()
+
+	if len(errors1) != 1 || len(errors2) != 1 {
+		t.Errorf("want 1 error from New, got %d and %d", len(errors1), len(errors2))
+		return
+	}
+
+	_, err = writer.Write([]byte("hello"))
+	if err == nil {
+		t.Error("want an error from Write")
+	}
+
+	if len(errors1) != 2 || len(errors2) != 2 {
+		t.Errorf("want 2 errors after Write, got %d and %d", len(errors1), len(errors2))
+	}
+}
+
+// TestFlushErrorReported checks that the error handlers hear about buffered
+// data that couldn't be flushed when the log was rotated.
+func TestFlushErrorReported(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	var reported []error
+	writer := New(now, ".", "foo.", ".bar", withClock(newFakeClock(now)), WithBuffering(4096),
+		WithErrorHandler(func(e error) { reported = append(reported, e) }))
+	defer writer.Close()
+
+	writer.Write([]byte("hello\n"))
+
+	// Close the file under the buffer, so the flush fails.
+	writer.logMutex.Lock()
+	writer.logFile.Close()
+	writer.logMutex.Unlock()
+
+	writer.rotateLogs(tomorrow)
+
+	found := false
+	for _, err := range reported {
+		if strings.Contains(err.Error(), "error flushing ./foo.2020-02-14.bar") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("want the flush error reported, got %v", reported)
+	}
+}
+
+// TestStartupError checks that an error while New is setting up the Writer is
+// returned by the first call of Write, Sync or Health, and only by the first.
+func TestStartupError(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// A log directory that can't be created, because a file has its name.
+	os.WriteFile("blocker", nil, 0644)
+
+	var testData = []struct {
+		description string
+		call        func(*Writer) error
+	}{
+		{"Write", func(w *Writer) error { _, err := w.Write([]byte("hello")); return err }},
+		{"Sync", func(w *Writer) error { return w.Sync() }},
+		{"Health", func(w *Writer) error { return w.Health() }},
+	}
+
+	for _, td := range testData {
+		writer := New(now, "blocker/logs", "foo.", ".bar")
+
+		err := td.call(writer)
+		if err == nil || !strings.Contains(err.Error(), "starting up") {
+			t.Errorf("%s: want the startup error, got %v", td.description, err)
+		}
+
+		_, err = writer.Write([]byte("hello"))
+		if err != nil && strings.Contains(err.Error(), "starting up") {
+			t.Errorf("%s: want the startup error only once, got %v", td.description, err)
+		}
+
+		writer.Close()
+	}
+}
+
+// TestErrorClasses checks that the errors reported by the Writer match the
+// exported errors with errors.Is.
+func TestErrorClasses(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// A log directory that can't be created and a log file that can't be opened.
+	os.WriteFile("blocker", nil, 0644)
+	os.MkdirAll("logs/foo.2020-02-14.bar", 0755)
+
+	var testData = []struct {
+		description string
+		logDir      string
+		options     []any
+		write       string
+		want        error
+	}{
+		{"directory", "blocker/logs", nil, "", ErrDirCreate},
+		{"file", "logs", nil, "", ErrFileOpen},
+		{"quota", ".", []any{WithDailyQuota(4, 0)}, "hello", ErrQuotaExceeded},
+	}
+
+	for _, td := range testData {
+		var reported []error
+		handler := WithErrorHandler(func(e error) { reported = append(reported, e) })
+		writer := New(now, td.logDir, "foo.", ".bar", append(td.options, handler)...)
+		if len(td.write) > 0 {
+			writer.Write([]byte(td.write))
+		}
+		writer.Close()
+
+		found := false
+		for _, e := range reported {
+			found = found || errors.Is(e, td.want)
+		}
+		if !found {
+			t.Errorf("%s: want %v among %v", td.description, td.want, reported)
+		}
+	}
+
+	// The class doesn't change the message.
+	inner := errors.New("inner")
+	classed := withClass(ErrFileOpen, inner)
+	if classed.Error() != "inner" || !errors.Is(classed, inner) {
+		t.Errorf("want the message and the wrapped error to be kept, got %v", classed)
+	}
+	if withClass(ErrFileOpen, nil) != nil {
+		t.Error("want nil for a nil error")
+	}
+
+	if os.Getuid() > 0 {
+		err := SetFileUserAndGroup("blocker", "nobody", "nogroup")
+		if !errors.Is(err, ErrChown) {
+			t.Errorf("want ErrChown when not root, got %v", err)
+		}
+	}
+}
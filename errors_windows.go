@@ -0,0 +1,10 @@
+//go:build windows
+
+package dailylogger
+
+import "syscall"
+
+// errWindows is the error inside the io.fs.PathError returned under MS Windows by
+// calls that only work under a POSIX system.  It's a syscall.EWINDOWS error that
+// is also an ErrNotPosix.
+var errWindows = withClass(ErrNotPosix, syscall.EWINDOWS)
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package dailylogger
+
+// WithWindowsEventLog reports errors inside the Writer to the Windows Event Log.
+// This system is not Windows, so the option has no effect.
+func WithWindowsEventLog(source string) Option {
+	return func(dw *Writer) {}
+}
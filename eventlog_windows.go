@@ -0,0 +1,33 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"log"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventLogErrorID is the event ID used for errors reported to the Windows Event Log.
+const eventLogErrorID = 1
+
+// WithWindowsEventLog reports errors inside the Writer, such as a full disk or
+// access being denied, to the Windows Event Log under the given source name, so
+// that they are visible to standard monitoring tools when the program is running
+// as a service.  The source should have been registered, for example when the
+// service was installed (see golang.org/x/sys/windows/svc/eventlog.InstallAsEventCreate).
+// On other systems this option has no effect.
+func WithWindowsEventLog(source string) Option {
+	return func(dw *Writer) {
+		eventLog, err := eventlog.Open(source)
+		if err != nil {
+			log.Printf("WithWindowsEventLog: cannot open the event log for %s - %v\n", source, err)
+			return
+		}
+
+		dw.errorHandlers = append(dw.errorHandlers, func(e error) {
+			eventLog.Error(eventLogErrorID, e.Error())
+		})
+		dw.closers = append(dw.closers, eventLog.Close)
+	}
+}
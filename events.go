@@ -0,0 +1,202 @@
+package dailylogger
+
+import "time"
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+	// EventOwnershipApplied means that the owner and group of a file were set after
+	// one or more failed attempts.
+	EventOwnershipApplied EventType = iota
+	// EventOwnershipFailed means that the owner and group of a file could not be set
+	// and the Writer has given up trying.
+	EventOwnershipFailed
+	// EventLabelFailed means that the FileLabeler could not label a file.
+	EventLabelFailed
+	// EventReopened means that the log file was reopened after a write failed with
+	// a stale file handle or an I/O error, and the write then succeeded.
+	EventReopened
+	// EventReopenFailed means that the Writer could not recover from a stale file
+	// handle or an I/O error by reopening the log file.
+	EventReopenFailed
+	// EventMirrorSideFailed means that a write to one side of a mirrored log failed
+	// after earlier writes worked.  The Path is the directory of that side.
+	EventMirrorSideFailed
+	// EventMirrorSideRecovered means that a write to one side of a mirrored log
+	// worked after earlier writes failed.  The Path is the directory of that side.
+	EventMirrorSideRecovered
+	// EventSelfTestFailed means that the self-test record written to the log file
+	// didn't arrive there (see WithSelfTest).
+	EventSelfTestFailed
+	// EventReadOnly means that the filesystem holding the log file is read-only and
+	// the Writer is keeping the log in memory (see WithReadOnlyFallback).
+	EventReadOnly
+	// EventReadOnlyRecovered means that the log file can be written again and the
+	// data kept in memory has been written to it.
+	EventReadOnlyRecovered
+	// EventStaleLockRecovered means that the lock file was left behind by a process
+	// that is no longer running and the Writer has taken it over (see
+	// WithLockFile).  The Err describes the old owner.
+	EventStaleLockRecovered
+	// EventTornRecordRepaired means that the log file ended with a torn record,
+	// which has been moved out of the way (see WithRecordFraming).  The Err
+	// describes the damage.
+	EventTornRecordRepaired
+	// EventJournalReplayed means that data lost from memory by a crash has been
+	// recovered from the journal and written to the log file (see WithJournal).
+	// The Err says how many writes were recovered.
+	EventJournalReplayed
+	// EventClockWentBack means that the clock showed an earlier day than the
+	// current log file's at rotation, so the Writer carried on with the current
+	// day rather than going back.  The Err gives the times.
+	EventClockWentBack
+	// EventShippingStopped means that the Shipper failed to send a record after
+	// earlier records were sent (see WithShipper).  The Err is the Shipper's error.
+	EventShippingStopped
+	// EventShippingResumed means that the Shipper sent a record after earlier
+	// records failed.  The Err says how many writes were not shipped.
+	EventShippingResumed
+
+	// The remaining events are lifecycle events, which are only sent to the event
+	// handler if WithLifecycleEvents is given.
+
+	// EventFileOpened means that a log file was opened.  The Path is the file.
+	EventFileOpened
+	// EventFileClosed means that a log file was closed.  The Path is the file.
+	EventFileClosed
+	// EventRotated means that the log was rotated.  The Path is the new log file.
+	EventRotated
+	// EventFileDeleted means that an old log file was deleted.  The Path is the
+	// file and the Err gives the reason.
+	EventFileDeleted
+	// EventArtifactMade means that an artifact such as a compressed copy was made
+	// from a log file (see WithArtifact).  The Path is the artifact.
+	EventArtifactMade
+	// EventArtifactFailed means that an artifact could not be made.  The Path is
+	// the artifact and the Err is the reason.
+	EventArtifactFailed
+	// EventFileQuarantined means that an old log file was moved to the quarantine
+	// (see WithQuarantine).  The Path is its new path name and the Err gives the
+	// reason.
+	EventFileQuarantined
+	// EventFileRestored means that a log file was moved out of the quarantine by
+	// Restore.  The Path is its new path name.
+	EventFileRestored
+	// EventFileMovedToCold means that an old log file was moved to the cold
+	// directory (see WithColdTier).  The Path is its new path name.
+	EventFileMovedToCold
+	// EventBundleMade means that the log files for a month were bundled into a tar
+	// archive (see WithMonthlyBundle).  The Path is the archive.
+	EventBundleMade
+)
+
+// String returns the name of the event type.
+func (et EventType) String() string {
+	switch et {
+	case EventOwnershipApplied:
+		return "OwnershipApplied"
+	case EventOwnershipFailed:
+		return "OwnershipFailed"
+	case EventLabelFailed:
+		return "LabelFailed"
+	case EventReopened:
+		return "Reopened"
+	case EventReopenFailed:
+		return "ReopenFailed"
+	case EventMirrorSideFailed:
+		return "MirrorSideFailed"
+	case EventMirrorSideRecovered:
+		return "MirrorSideRecovered"
+	case EventSelfTestFailed:
+		return "SelfTestFailed"
+	case EventReadOnly:
+		return "ReadOnly"
+	case EventReadOnlyRecovered:
+		return "ReadOnlyRecovered"
+	case EventStaleLockRecovered:
+		return "StaleLockRecovered"
+	case EventTornRecordRepaired:
+		return "TornRecordRepaired"
+	case EventJournalReplayed:
+		return "JournalReplayed"
+	case EventClockWentBack:
+		return "ClockWentBack"
+	case EventShippingStopped:
+		return "ShippingStopped"
+	case EventShippingResumed:
+		return "ShippingResumed"
+	case EventFileOpened:
+		return "FileOpened"
+	case EventFileClosed:
+		return "FileClosed"
+	case EventRotated:
+		return "Rotated"
+	case EventFileDeleted:
+		return "FileDeleted"
+	case EventArtifactMade:
+		return "ArtifactMade"
+	case EventArtifactFailed:
+		return "ArtifactFailed"
+	case EventFileQuarantined:
+		return "FileQuarantined"
+	case EventFileRestored:
+		return "FileRestored"
+	case EventFileMovedToCold:
+		return "FileMovedToCold"
+	case EventBundleMade:
+		return "BundleMade"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event describes something that happened inside the Writer that the application
+// may want to know about.
+type Event struct {
+	Type EventType // The kind of event.
+	Time time.Time // When it happened.
+	Path string    // The file or directory concerned (if any).
+	Err  error     // The error that caused the event (if any).
+}
+
+// WithEventHandler supplies a function that receives the Writer's events.  The
+// function may be called from any of the Writer's goroutines, possibly while the
+// Writer's lock is held, so it should return quickly and it must not call the
+// Writer's methods.
+func WithEventHandler(handler func(Event)) Option {
+	return func(dw *Writer) {
+		dw.eventHandler = handler
+	}
+}
+
+// emit sends an event to the event handler and the event channel, if there are
+// any, and records it in the meta log, if there is one.
+func (dw *Writer) emit(event Event) {
+	if dw.eventHandler == nil && dw.eventChannel == nil && dw.metaLog == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = dw.now()
+	}
+
+	dw.writeMeta(event)
+	dw.deliver(event)
+}
+
+// deliver is a helper function for emit that sends an event to the event
+// handler and the event channel.
+func (dw *Writer) deliver(event Event) {
+	if dw.eventHandler != nil {
+		dw.eventHandler(event)
+	}
+
+	if dw.eventChannel != nil {
+		select {
+		case dw.eventChannel <- event:
+		default:
+			// The channel is full, so the event is dropped.
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+)
+
+// maxExclusiveAttempts is the number of names that the Writer tries before giving
+// up on finding a log file that no other process is using.
+const maxExclusiveAttempts = 100
+
+// WithExclusiveFiles stops two processes writing to the same log file, for
+// example a cron job and a daemon that use the same directory, leader and
+// trailer.  The Writer holds a lock on its log file.  If another process (or
+// another Writer) already holds the lock, the Writer moves on to the next
+// sequence number, for example from "foo.2020-02-14.bar" to
+// "foo.2020-02-14.1.bar", until it finds a file that it can lock.  A file left by
+// a process that has exited isn't locked, so a restarted program appends to it as
+// usual.  Under a POSIX system the locks are advisory, so they only affect
+// processes that use them.
+func WithExclusiveFiles() Option {
+	return func(dw *Writer) {
+		dw.exclusiveFiles = true
+	}
+}
+
+// openExclusive is a helper function for openLog that opens the log file with the
+// current sequence number and locks it, moving on to the next sequence number if
+// another process holds the lock.  It sets dw.pathname.  It doesn't apply the
+// lock, so it should only be called by a function that does.
+func (dw *Writer) openExclusive() (*os.File, error) {
+	for attempt := 0; attempt < maxExclusiveAttempts; attempt++ {
+		dw.pathname = dw.getLogPathname(dw.startOfToday, dw.sequence)
+		dw.createParent(dw.logDir, dw.pathname)
+
+		logFile, err := dw.openFile(dw.pathname)
+		if err != nil {
+			return nil, err
+		}
+
+		locked, err := tryLockFile(logFile)
+		if err != nil {
+			logFile.Close()
+			return nil, err
+		}
+		if locked {
+			return logFile, nil
+		}
+
+		// Another process is using the file.  Try the next one.
+		logFile.Close()
+		if dw.noRotation {
+			break
+		}
+		dw.sequence++
+	}
+
+	return nil, fmt.Errorf("all the log files are in use by other processes")
+}
@@ -0,0 +1,58 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestExclusiveFiles checks that a second Writer with the same file names uses
+// a different file while the first one is open, and that the first file can be
+// reused once it has been closed.
+func TestExclusiveFiles(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer1 := New(now, ".", "foo.", ".bar", WithExclusiveFiles())
+	writer2 := New(now, ".", "foo.", ".bar", WithExclusiveFiles())
+	writer1.Write([]byte("daemon"))
+	writer2.Write([]byte("cron"))
+	writer1.Close()
+	writer2.Close()
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar", "daemon"},
+		{"foo.2020-02-14.1.bar", "cron"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+
+	// Nobody is using the files now, so a new Writer carries on with the latest.
+	writer3 := New(now, ".", "foo.", ".bar", WithExclusiveFiles())
+	defer writer3.Close()
+	if writer3.sequence != 1 {
+		t.Errorf("want sequence 1 got %d", writer3.sequence)
+	}
+}
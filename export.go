@@ -0,0 +1,161 @@
+package dailylogger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ExportOption changes what Export does.
+type ExportOption func(*exportSettings)
+
+// exportSettings are the settings made by the ExportOptions.
+type exportSettings struct {
+	raw         bool                // True if the files are copied as they are.
+	skipCorrupt bool                // True if damaged framed records are skipped.
+	transform   func([]byte) []byte // Reformats each record (optional).
+}
+
+// ExportRaw makes Export copy the files exactly as they are stored, still
+// compressed, encrypted and framed, for example to hand them on to another
+// system.  The other ExportOptions then have no effect.
+func ExportRaw() ExportOption {
+	return func(es *exportSettings) {
+		es.raw = true
+	}
+}
+
+// ExportSkipCorrupt makes Export skip any damaged records in files written with
+// record framing (see RecordReader) rather than stopping at the first.
+func ExportSkipCorrupt() ExportOption {
+	return func(es *exportSettings) {
+		es.skipCorrupt = true
+	}
+}
+
+// ExportTransform supplies a function that Export passes each record through
+// before writing it, for example to turn JSON into a more readable form.  The
+// record is a line, including its newline, unless the Writer was given a split
+// function (see WithRecordSplitter) or frames its records, in which case it's
+// the data of one record.  The function may return the record itself, a new
+// slice or nil to leave the record out.  The record is only valid until the
+// function returns.
+func ExportTransform(transform func(record []byte) []byte) ExportOption {
+	return func(es *exportSettings) {
+		es.transform = transform
+	}
+}
+
+// Export writes the contents of the log files for the days from the day
+// containing the first time to the day containing the second, inclusive, to the
+// writer, in date and sequence order.  The files that have been compressed,
+// encrypted (see NewPipeline) or moved to the cold directory are included.  By
+// default the output is the plain text that was written: the files are
+// decompressed and decrypted, the framing (see WithRecordFraming) is removed
+// and so are the hashes at the start of the lines of a hash chain (see
+// WithHashChain).  The ExportOptions change that.  The current log file may have
+// data still in the Writer's buffer, so call Sync before exporting today.
+// Days with no files are skipped, but if there are none in the whole range the
+// error wraps fs.ErrNotExist.
+func (dw *Writer) Export(from, to time.Time, w io.Writer, opts ...ExportOption) error {
+	var settings exportSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	first := getLastMidnight(from.In(dw.location))
+	last := getLastMidnight(to.In(dw.location))
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return fmt.Errorf("Export: %w", err)
+	}
+
+	exported := 0
+	for _, logFile := range logFiles {
+		if logFile.Date.Before(first) || logFile.Date.After(last) {
+			continue
+		}
+		exported++
+
+		if err := dw.exportFile(logFile, w, settings); err != nil {
+			return fmt.Errorf("Export: %w", err)
+		}
+	}
+
+	if exported == 0 {
+		return fmt.Errorf("Export: no log files from %s to %s - %w",
+			first.Format(time.DateOnly), last.Format(time.DateOnly), fs.ErrNotExist)
+	}
+
+	return nil
+}
+
+// exportFile is a helper function for Export that writes the contents of one log
+// file.
+func (dw *Writer) exportFile(logFile LogFile, w io.Writer, settings exportSettings) error {
+	if settings.raw {
+		file, err := os.Open(longPath(logFile.Pathname))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(w, file)
+		return err
+	}
+
+	reader, err := dw.OpenLogFile(logFile)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	write := func(record []byte) error {
+		if dw.hashChain && len(record) > chainHashLength && record[chainHashLength] == ' ' {
+			record = record[chainHashLength+1:]
+		}
+		if settings.transform != nil {
+			record = settings.transform(record)
+		}
+		if len(record) == 0 {
+			return nil
+		}
+		_, err := w.Write(record)
+		return err
+	}
+
+	if dw.framing {
+		records := NewRecordReader(reader, dw.checksum, settings.skipCorrupt)
+		for {
+			record, err := records.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s - %w", logFile.Pathname, err)
+			}
+			if err := write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxRecordLength)
+	scanner.Split(dw.recordSplitFunc())
+	for scanner.Scan() {
+		if err := write(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s - %w", logFile.Pathname, err)
+	}
+
+	return nil
+}
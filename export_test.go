@@ -0,0 +1,130 @@
+package dailylogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExport checks that Export writes the days in the range in order,
+// decompressing the compressed files, and that the options work.
+func TestExport(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-02-11.bar", []byte("eleven\n"), 0644)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("twelve\n"))
+	zw.Close()
+	os.WriteFile("foo.2020-02-12.bar.gz", compressed.Bytes(), 0644)
+	os.WriteFile("foo.2020-02-13.bar", []byte("thirteen\n"), 0644)
+	os.WriteFile("foo.2020-02-13.1.bar", []byte("thirteen again\nno newline"), 0644)
+	os.WriteFile("foo.2020-02-15.bar", []byte("fifteen\n"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 16, 12, 0, 0, 0, locationUTC)
+	writer := New(now, ".", "foo.", ".bar", WithCompression(NewGzipCompressor(gzip.DefaultCompression)))
+	defer writer.Close()
+
+	from := time.Date(2020, time.February, 12, 15, 0, 0, 0, locationUTC)
+	to := time.Date(2020, time.February, 14, 9, 0, 0, 0, locationUTC)
+
+	var output bytes.Buffer
+	if err := writer.Export(from, to, &output); err != nil {
+		t.Fatal(err)
+	}
+	if want := "twelve\nthirteen\nthirteen again\nno newline"; output.String() != want {
+		t.Errorf("want %q, got %q", want, output.String())
+	}
+
+	// The transform sees one line at a time and can drop lines.
+	output.Reset()
+	transform := func(record []byte) []byte {
+		if bytes.HasPrefix(record, []byte("thirteen")) {
+			return nil
+		}
+		return bytes.ToUpper(record)
+	}
+	if err := writer.Export(from, to, &output, ExportTransform(transform)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "TWELVE\nNO NEWLINE"; output.String() != want {
+		t.Errorf("want %q, got %q", want, output.String())
+	}
+
+	// A raw export copies the compressed file as it is.
+	output.Reset()
+	if err := writer.Export(from, from, &output, ExportRaw(), ExportTransform(transform)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output.Bytes(), compressed.Bytes()) {
+		t.Error("want the compressed file unchanged")
+	}
+
+	if err := writer.Export(now.AddDate(0, 0, -10), now.AddDate(0, 0, -9), &output); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("want fs.ErrNotExist for a range with no files, got %v", err)
+	}
+}
+
+// TestExportPlainText checks that Export removes the record framing and the
+// hashes of a hash chain.
+func TestExportPlainText(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var testData = []struct {
+		description string
+		leader      string
+		option      Option
+	}{
+		{"framing", "framed.", WithRecordFraming()},
+		{"hash chain", "chained.", WithHashChain()},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			writer := New(now, ".", td.leader, ".bar", td.option)
+			defer writer.Close()
+			writer.Write([]byte("one\n"))
+			writer.Write([]byte("two\n"))
+			writer.Sync()
+
+			var output bytes.Buffer
+			if err := writer.Export(now, now, &output); err != nil {
+				t.Fatal(err)
+			}
+			if want := "one\ntwo\n"; output.String() != want {
+				t.Errorf("want %q, got %q", want, output.String())
+			}
+
+			// The file itself isn't plain text.
+			contents, _ := os.ReadFile(td.leader + "2020-02-14.bar")
+			if strings.HasPrefix(string(contents), "one") {
+				t.Errorf("want the file to be transformed, got %q", contents)
+			}
+		})
+	}
+}
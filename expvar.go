@@ -0,0 +1,57 @@
+package dailylogger
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvarMutex protects expvarWriters.
+var expvarMutex sync.Mutex
+
+// expvarWriters maps the names given to WithExpvar to the Writers that use them.
+var expvarWriters = make(map[string]*Writer)
+
+// WithExpvar publishes the state of the Writer via the expvar package, so that it
+// appears in /debug/vars along with the program's other variables.  The variables
+// are "dailylogger.<name>.bytesToday", "dailylogger.<name>.rotations",
+// "dailylogger.<name>.lastError" and "dailylogger.<name>.droppedWrites".  The
+// expvar package doesn't allow a variable to be removed, so if a later Writer uses
+// the same name it takes over the variables.
+func WithExpvar(name string) Option {
+	return func(dw *Writer) {
+		dw.expvarName = name
+	}
+}
+
+// publishExpvars publishes the variables for the given name, if that hasn't been
+// done already, and points them at the given Writer.
+func publishExpvars(name string, dw *Writer) {
+	expvarMutex.Lock()
+	defer expvarMutex.Unlock()
+
+	_, published := expvarWriters[name]
+	expvarWriters[name] = dw
+	if published {
+		return
+	}
+
+	prefix := "dailylogger." + name + "."
+	expvar.Publish(prefix+"bytesToday", expvarFunc(name, func(s Stats) any { return s.BytesToday }))
+	expvar.Publish(prefix+"rotations", expvarFunc(name, func(s Stats) any { return s.Rotations }))
+	expvar.Publish(prefix+"lastError", expvarFunc(name, func(s Stats) any { return s.LastError }))
+	expvar.Publish(prefix+"droppedWrites", expvarFunc(name, func(s Stats) any {
+		return s.Failed.Writes + s.Sampled.Writes + s.OverQuota.Writes
+	}))
+}
+
+// expvarFunc returns an expvar.Func that gets a value from the Stats of the Writer
+// with the given name.
+func expvarFunc(name string, value func(Stats) any) expvar.Func {
+	return func() any {
+		expvarMutex.Lock()
+		dw := expvarWriters[name]
+		expvarMutex.Unlock()
+
+		return value(dw.Stats())
+	}
+}
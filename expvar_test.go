@@ -0,0 +1,60 @@
+package dailylogger
+
+import (
+	"expvar"
+	"testing"
+	"time"
+)
+
+// TestExpvar checks that the Writer's state is published via expvar and that a
+// later Writer with the same name takes over the variables.
+func TestExpvar(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer1 := New(now, ".", "foo.", ".bar", WithExpvar("test"))
+	writer1.Write([]byte("hello"))
+	writer1.Close()
+
+	if got := expvar.Get("dailylogger.test.bytesToday").String(); got != "5" {
+		t.Errorf("want 5 bytes today got %s", got)
+	}
+
+	writer2 := New(now, ".", "foo.", ".bar", WithExpvar("test"))
+	defer writer2.Close()
+	writer2.Write([]byte("hi"))
+	writer2.rotate(now)
+	writer2.rotateLogs(now.AddDate(0, 0, 1))
+	writer2.Write([]byte("world"))
+
+	var testData = []struct {
+		variable string
+		want     string
+	}{
+		{"dailylogger.test.bytesToday", "5"},
+		{"dailylogger.test.rotations", "2"},
+		{"dailylogger.test.lastError", `""`},
+		{"dailylogger.test.droppedWrites", "0"},
+	}
+
+	for _, td := range testData {
+		v := expvar.Get(td.variable)
+		if v == nil {
+			t.Errorf("%s is not published", td.variable)
+			continue
+		}
+		if got := v.String(); got != td.want {
+			t.Errorf("%s: want %s got %s", td.variable, td.want, got)
+		}
+	}
+}
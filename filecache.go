@@ -0,0 +1,178 @@
+package dailylogger
+
+import (
+	"bufio"
+	"container/list"
+	"os"
+)
+
+// defaultOpenFileCacheSize is the number of files that the cache keeps open if
+// WithOpenFileCache isn't used.
+const defaultOpenFileCacheSize = 4
+
+// WithOpenFileCache sets the number of log files other than the current one
+// that the Writer keeps open, for example to append a line that was started
+// before a rotation to the old file (see WithWholeLines).  When the cache is
+// full, the file that was used least recently is flushed and closed.  The
+// default is 4.
+func WithOpenFileCache(size int) Option {
+	return func(dw *Writer) {
+		if size > 0 {
+			dw.openFileCacheSize = size
+		}
+	}
+}
+
+// fileCache holds a limited number of open files, closing the one that was used
+// least recently to make room for another.  It's not safe for concurrent use.
+type fileCache struct {
+	capacity   int                            // The maximum number of open files.
+	bufferSize int                            // The size of each file's write buffer (0 means unbuffered).
+	open       func(string) (*os.File, error) // Opens a file for appending.
+	order      *list.List                     // The files, most recently used first.
+	files      map[string]*list.Element       // The files, by path name.
+}
+
+// cachedFile is an open file in a fileCache.
+type cachedFile struct {
+	pathname string        // The path name of the file.
+	file     *os.File      // The open file.
+	buffer   *bufio.Writer // The write buffer (nil if unbuffered).
+}
+
+// newFileCache creates a fileCache that holds up to the given number of files
+// and opens them with the given function.
+func newFileCache(capacity, bufferSize int, open func(string) (*os.File, error)) *fileCache {
+	if capacity <= 0 {
+		capacity = defaultOpenFileCacheSize
+	}
+
+	fc := fileCache{
+		capacity:   capacity,
+		bufferSize: bufferSize,
+		open:       open,
+		order:      list.New(),
+		files:      make(map[string]*list.Element),
+	}
+
+	return &fc
+}
+
+// Write writes to the file via its buffer, if it has one.
+func (cf *cachedFile) Write(buffer []byte) (int, error) {
+	if cf.buffer != nil {
+		return cf.buffer.Write(buffer)
+	}
+
+	return cf.file.Write(buffer)
+}
+
+// flush writes any buffered data to the file.
+func (cf *cachedFile) flush() error {
+	if cf.buffer == nil {
+		return nil
+	}
+
+	return cf.buffer.Flush()
+}
+
+// close flushes and closes the file.
+func (cf *cachedFile) close() error {
+	err := cf.flush()
+	if ce := cf.file.Close(); ce != nil && err == nil {
+		err = ce
+	}
+
+	return err
+}
+
+// write appends the buffer to the file with the given path name, opening it if
+// it isn't already open.
+func (fc *fileCache) write(pathname string, buffer []byte) (int, error) {
+	cf, err := fc.get(pathname)
+	if err != nil {
+		return 0, err
+	}
+
+	return cf.Write(buffer)
+}
+
+// get returns the open file with the given path name, opening it if necessary.
+// If the cache is full, the least recently used file is closed first.
+func (fc *fileCache) get(pathname string) (*cachedFile, error) {
+	if element, ok := fc.files[pathname]; ok {
+		fc.order.MoveToFront(element)
+		return element.Value.(*cachedFile), nil
+	}
+
+	var err error
+	for fc.order.Len() >= fc.capacity {
+		if ee := fc.evict(fc.order.Back()); ee != nil && err == nil {
+			err = ee
+		}
+	}
+	if err != nil {
+		// Data was lost from the evicted file.
+		return nil, err
+	}
+
+	file, err := fc.open(pathname)
+	if err != nil {
+		return nil, err
+	}
+
+	cf := cachedFile{pathname: pathname, file: file}
+	if fc.bufferSize > 0 {
+		cf.buffer = bufio.NewWriterSize(file, fc.bufferSize)
+	}
+	fc.files[pathname] = fc.order.PushFront(&cf)
+
+	return &cf, nil
+}
+
+// evict flushes and closes a file and removes it from the cache.
+func (fc *fileCache) evict(element *list.Element) error {
+	cf := fc.order.Remove(element).(*cachedFile)
+	delete(fc.files, cf.pathname)
+
+	return cf.close()
+}
+
+// release flushes and closes the file with the given path name, if it's open,
+// and removes it from the cache.
+func (fc *fileCache) release(pathname string) error {
+	element, ok := fc.files[pathname]
+	if !ok {
+		return nil
+	}
+
+	return fc.evict(element)
+}
+
+// sync flushes the files' buffers and commits the files to the disk.
+func (fc *fileCache) sync() error {
+	var err error
+	for element := fc.order.Front(); element != nil; element = element.Next() {
+		cf := element.Value.(*cachedFile)
+		if fe := cf.flush(); fe != nil && err == nil {
+			err = fe
+		}
+		if se := cf.file.Sync(); se != nil && err == nil {
+			err = se
+		}
+	}
+
+	return err
+}
+
+// close flushes and closes all of the files.
+func (fc *fileCache) close() error {
+	var err error
+	for fc.order.Len() > 0 {
+		if ce := fc.evict(fc.order.Front()); ce != nil && err == nil {
+			err = ce
+		}
+	}
+
+	return err
+}
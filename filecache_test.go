@@ -0,0 +1,81 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+)
+
+// TestFileCache checks that the file cache keeps the most recently used files
+// open, and that it flushes a file's buffer when it closes the file to make room
+// for another.
+func TestFileCache(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	opened := make(map[string]int)
+	open := func(name string) (*os.File, error) {
+		opened[name]++
+		return os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+
+	cache := newFileCache(2, 64, open)
+	cache.write("a", []byte("1"))
+	cache.write("b", []byte("2"))
+	cache.write("a", []byte("3"))
+
+	// The buffers haven't been flushed yet.
+	if contents, _ := os.ReadFile("a"); len(contents) != 0 {
+		t.Errorf("want a to be empty got \"%s\"", string(contents))
+	}
+
+	// b is the least recently used, so it's flushed and closed to make room for c.
+	cache.write("c", []byte("4"))
+	if contents, _ := os.ReadFile("b"); string(contents) != "2" {
+		t.Errorf("want b to hold \"2\" got \"%s\"", string(contents))
+	}
+	if _, ok := cache.files["b"]; ok {
+		t.Error("want b to be evicted")
+	}
+
+	// Writing to b again opens it again.
+	cache.write("b", []byte("5"))
+
+	if err := cache.close(); err != nil {
+		t.Error(err)
+	}
+
+	var testData = []struct {
+		filename  string
+		want      string
+		wantOpens int
+	}{
+		{"a", "13", 1},
+		{"b", "25", 2},
+		{"c", "4", 1},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+		if opened[td.filename] != td.wantOpens {
+			t.Errorf("%s: want %d opens got %d", td.filename, td.wantOpens, opened[td.filename])
+		}
+	}
+
+	if cache.order.Len() != 0 || len(cache.files) != 0 {
+		t.Error("want the cache to be empty after close")
+	}
+}
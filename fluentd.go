@@ -0,0 +1,139 @@
+package dailylogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// FluentdShipper is a Shipper that sends each record to Fluentd or Fluent Bit
+// using the forward protocol, as an event with the given tag whose record has
+// one field, "message", holding the data without its trailing newline.
+//
+// It connects when the first record is shipped.  If the connection fails, it
+// waits for the retry interval before trying to connect again and until then
+// Ship fails at once with the last error, so a Writer doesn't stall while
+// Fluentd is down.
+type FluentdShipper struct {
+	network       string        // The network, for example "tcp" or "unix".
+	address       string        // The address of Fluentd on the network.
+	tag           string        // The tag given to each event.
+	timeout       time.Duration // The limit on the time taken to connect and to send.
+	retryInterval time.Duration // The time to wait after a failure before connecting again.
+
+	mutex   sync.Mutex
+	conn    net.Conn  // The connection to Fluentd (nil if not connected).
+	retryAt time.Time // The time after which it may connect again.
+	lastErr error     // The error that closed the last connection.
+	scratch []byte    // Reused to build each message.
+}
+
+// This is a compile-time check that FluentdShipper implements the Shipper
+// interface.
+var _ Shipper = (*FluentdShipper)(nil)
+
+// NewFluentdShipper creates a FluentdShipper that sends events with the given tag
+// to Fluentd at the given network address, for example "tcp" and
+// "localhost:24224".  It allows five seconds to connect or send and waits ten
+// seconds after a failure before trying again.
+func NewFluentdShipper(network, address, tag string) *FluentdShipper {
+	return &FluentdShipper{
+		network:       network,
+		address:       address,
+		tag:           tag,
+		timeout:       5 * time.Second,
+		retryInterval: 10 * time.Second,
+	}
+}
+
+// Ship sends the record to Fluentd.
+func (fs *FluentdShipper) Ship(record []byte, t time.Time) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.conn == nil {
+		if time.Now().Before(fs.retryAt) {
+			return fs.lastErr
+		}
+
+		conn, err := net.DialTimeout(fs.network, fs.address, fs.timeout)
+		if err != nil {
+			return fs.fail(err)
+		}
+		fs.conn = conn
+	}
+
+	fs.scratch = appendFluentdMessage(fs.scratch[:0], fs.tag, t, bytes.TrimSuffix(record, []byte("\n")))
+	fs.conn.SetWriteDeadline(time.Now().Add(fs.timeout))
+	if _, err := fs.conn.Write(fs.scratch); err != nil {
+		return fs.fail(err)
+	}
+
+	return nil
+}
+
+// Close closes the connection to Fluentd, if there is one.
+func (fs *FluentdShipper) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.conn == nil {
+		return nil
+	}
+
+	err := fs.conn.Close()
+	fs.conn = nil
+	return err
+}
+
+// fail is a helper function for Ship that drops the connection after an error
+// and returns the error.  It doesn't apply the lock, so it should only be called
+// by a function that does.
+func (fs *FluentdShipper) fail(err error) error {
+	if fs.conn != nil {
+		fs.conn.Close()
+		fs.conn = nil
+	}
+
+	fs.lastErr = fmt.Errorf("fluentd %s: %w", fs.address, err)
+	fs.retryAt = time.Now().Add(fs.retryInterval)
+	return fs.lastErr
+}
+
+// appendFluentdMessage appends a forward protocol message to the buffer.  The
+// message is the MessagePack array [tag, time, {"message": record}], where the
+// time is an EventTime, which has nanosecond resolution.
+func appendFluentdMessage(buffer []byte, tag string, t time.Time, record []byte) []byte {
+	buffer = append(buffer, 0x93) // An array of three.
+	buffer = appendMsgpackString(buffer, []byte(tag))
+
+	// EventTime is extension type 0 holding the seconds and nanoseconds.
+	buffer = append(buffer, 0xd7, 0x00)
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(t.Unix()))
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(t.Nanosecond()))
+
+	buffer = append(buffer, 0x81) // A map with one entry.
+	buffer = appendMsgpackString(buffer, []byte("message"))
+	return appendMsgpackString(buffer, record)
+}
+
+// appendMsgpackString appends the data to the buffer as a MessagePack string.
+func appendMsgpackString(buffer []byte, data []byte) []byte {
+	switch length := len(data); {
+	case length < 32:
+		buffer = append(buffer, 0xa0|byte(length))
+	case length < 1<<8:
+		buffer = append(buffer, 0xd9, byte(length))
+	case length < 1<<16:
+		buffer = append(buffer, 0xda)
+		buffer = binary.BigEndian.AppendUint16(buffer, uint16(length))
+	default:
+		buffer = append(buffer, 0xdb)
+		buffer = binary.BigEndian.AppendUint32(buffer, uint32(length))
+	}
+
+	return append(buffer, data...)
+}
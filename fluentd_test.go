@@ -0,0 +1,81 @@
+package dailylogger
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAppendMsgpackString checks the encoding of strings of each size.
+func TestAppendMsgpackString(t *testing.T) {
+	var testData = []struct {
+		length     int
+		wantHeader []byte
+	}{
+		{0, []byte{0xa0}},
+		{31, []byte{0xbf}},
+		{32, []byte{0xd9, 32}},
+		{255, []byte{0xd9, 0xff}},
+		{256, []byte{0xda, 0x01, 0x00}},
+		{1 << 16, []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+	}
+
+	for _, td := range testData {
+		data := bytes.Repeat([]byte("x"), td.length)
+		got := appendMsgpackString(nil, data)
+		want := append(append([]byte(nil), td.wantHeader...), data...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%d: want header % x got % x", td.length, td.wantHeader, got[:min(len(got), 5)])
+		}
+	}
+}
+
+// TestFluentdShipper checks that a FluentdShipper sends forward protocol
+// messages and fails quickly while Fluentd is down.
+func TestFluentdShipper(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan []byte)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	eventTime := time.Date(2020, time.February, 14, 12, 0, 0, 5, time.UTC)
+	shipper := NewFluentdShipper("tcp", listener.Addr().String(), "app")
+	if err := shipper.Ship([]byte("hello\n"), eventTime); err != nil {
+		t.Fatal(err)
+	}
+	shipper.Close()
+
+	want := []byte{0x93, 0xa3, 'a', 'p', 'p',
+		0xd7, 0x00, 0x5e, 0x46, 0x8b, 0xc0, 0x00, 0x00, 0x00, 0x05,
+		0x81, 0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa5, 'h', 'e', 'l', 'l', 'o'}
+	if got := <-received; !bytes.Equal(got, want) {
+		t.Errorf("want % x\ngot  % x", want, got)
+	}
+
+	// Fluentd goes away.
+	listener.Close()
+	err = shipper.Ship([]byte("lost\n"), eventTime)
+	if err == nil || !strings.Contains(err.Error(), "fluentd") {
+		t.Fatalf("want an error from fluentd, got %v", err)
+	}
+
+	// Until the retry interval has passed, the shipper doesn't try to connect.
+	shipper.network = "no such network"
+	if err2 := shipper.Ship([]byte("lost\n"), eventTime); err2 != err {
+		t.Errorf("want the last error again, got %v", err2)
+	}
+}
@@ -0,0 +1,267 @@
+package dailylogger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// frameOverhead is the number of bytes that framing with a CRC-32 adds to each
+// record: a four byte length before it and a four byte CRC after it.
+const frameOverhead = 8
+
+// tornScanWindow is the amount of data at the end of a framed log file whose
+// CRCs are checked on start up.  The frames before that are assumed to be good,
+// so a big file doesn't have to be read from the start.
+const tornScanWindow = 1 << 20
+
+// WithRecordFraming makes each Write a record of its own in the log file, framed
+// so that a reader can find the records and check them.  A frame is the length
+// of the data as a four byte big-endian number, then the data, then the CRC-32
+// (IEEE) of the length and the data as a four byte big-endian number.  The lines
+// written by features such as WithStatsSummary are framed too.  Framing takes
+// the place of WithWholeLines and WithRecordSplitter.  A RecordReader reads the
+// records back.
+//
+// A crash or a power failure can leave a torn record at the end of the file,
+// either incomplete or full of junk.  On start up, if today's log file already
+// exists, the Writer checks the records at the end of it.  If the last one is
+// torn, the Writer moves the damaged data to a file named after the log file
+// with the offset of the damage and ".torn" added, for example
+// "foo.2020-02-14.bar.1234.torn", truncates the log file to the end of the last
+// good record and emits an EventTornRecordRepaired.
+func WithRecordFraming() Option {
+	return func(dw *Writer) {
+		dw.framing = true
+	}
+}
+
+// Checksum identifies the checksum at the end of each framed record (see
+// WithRecordChecksum).
+type Checksum int
+
+const (
+	// ChecksumCRC32 is the CRC-32 (IEEE) as a four byte big-endian number.  It's
+	// the default.
+	ChecksumCRC32 Checksum = iota
+	// ChecksumCRC24Q is the CRC-24Q, as used by RTCM3, as a three byte big-endian
+	// number.
+	ChecksumCRC24Q
+)
+
+// WithRecordChecksum turns on record framing (see WithRecordFraming) with the
+// given checksum at the end of each frame in place of the CRC-32.  The checksum
+// covers the length and the data.  Use a RecordReader with the same checksum to
+// read the records back.
+func WithRecordChecksum(checksum Checksum) Option {
+	return func(dw *Writer) {
+		dw.framing = true
+		dw.checksum = checksum
+	}
+}
+
+// String returns the name of the checksum.
+func (c Checksum) String() string {
+	switch c {
+	case ChecksumCRC32:
+		return "CRC-32"
+	case ChecksumCRC24Q:
+		return "CRC-24Q"
+	default:
+		return fmt.Sprintf("Checksum(%d)", int(c))
+	}
+}
+
+// size returns the number of bytes that the checksum takes up.
+func (c Checksum) size() int {
+	if c == ChecksumCRC24Q {
+		return 3
+	}
+	return 4
+}
+
+// overhead returns the number of bytes that framing with the checksum adds to
+// each record.
+func (c Checksum) overhead() int {
+	return 4 + c.size()
+}
+
+// sum returns the checksum of the data.
+func (c Checksum) sum(data []byte) uint32 {
+	if c == ChecksumCRC24Q {
+		return crc24q(data)
+	}
+	return crc32.ChecksumIEEE(data)
+}
+
+// appendFrame appends the frame holding the record to the slice and returns
+// the result.
+func (c Checksum) appendFrame(frame, record []byte) []byte {
+	start := len(frame)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(record)))
+	frame = append(frame, record...)
+	sum := c.sum(frame[start:])
+	if c.size() == 3 {
+		return append(frame, byte(sum>>16), byte(sum>>8), byte(sum))
+	}
+	return binary.BigEndian.AppendUint32(frame, sum)
+}
+
+// frameLength returns the length of the record in the frame that starts with the
+// given header, or false if it's more than the limit.  The length is checked
+// before it's used, so a damaged header can't cause a huge allocation or, on a
+// 32-bit system, turn into a negative number.
+func frameLength(header []byte, limit int) (int, bool) {
+	length := binary.BigEndian.Uint32(header)
+	if uint64(length) > uint64(max(limit, 0)) {
+		return 0, false
+	}
+
+	return int(length), true
+}
+
+// frameValid returns true if the slice holds exactly one frame with the right
+// checksum.
+func (c Checksum) frameValid(frame []byte) bool {
+	if len(frame) < c.overhead() {
+		return false
+	}
+
+	body := frame[:len(frame)-c.size()]
+	if length, ok := frameLength(body, len(body)-4); !ok || length != len(body)-4 {
+		return false
+	}
+
+	var sum uint32
+	for _, b := range frame[len(body):] {
+		sum = sum<<8 | uint32(b)
+	}
+	return sum == c.sum(body)
+}
+
+// appendFrame appends the frame holding the record, with a CRC-32, to the slice
+// and returns the result.
+func appendFrame(frame, record []byte) []byte {
+	return ChecksumCRC32.appendFrame(frame, record)
+}
+
+// frameValid returns true if the slice holds exactly one frame with the right
+// CRC-32.
+func frameValid(frame []byte) bool {
+	return ChecksumCRC32.frameValid(frame)
+}
+
+// writeFrame is a helper function for writeToLog that writes the buffer as one
+// framed record.  It returns the number of bytes of the buffer that were
+// written.  It doesn't apply the lock, so it should only be called by a function
+// that does.
+func (dw *Writer) writeFrame(buffer []byte) (int, error) {
+	dw.frameScratch = dw.checksum.appendFrame(dw.frameScratch[:0], buffer)
+
+	n, err := dw.sink.Write(dw.frameScratch)
+	if cap(dw.frameScratch) > maxPooledBuffer {
+		// Don't hang on to the memory used by a big write.
+		dw.frameScratch = nil
+	}
+	if err != nil {
+		return min(max(n-4, 0), len(buffer)), err
+	}
+
+	return len(buffer), nil
+}
+
+// findTornTail returns the offset of the end of the last good frame in a file
+// of the given size framed with CRC-32s.  If the file ends with a torn frame,
+// that's less than the size.
+func findTornTail(file io.ReaderAt, size int64) (int64, error) {
+	return ChecksumCRC32.findTornTail(file, size)
+}
+
+// findTornTail returns the offset of the end of the last good frame in a file
+// of the given size framed with the checksum.  If the file ends with a torn
+// frame, that's less than the size.
+func (c Checksum) findTornTail(file io.ReaderAt, size int64) (int64, error) {
+	overhead := int64(c.overhead())
+	header := make([]byte, 4)
+	offset := int64(0)
+	for offset < size {
+		if size-offset < overhead {
+			return offset, nil
+		}
+
+		if _, err := file.ReadAt(header, offset); err != nil {
+			return 0, err
+		}
+		end := offset + overhead + int64(binary.BigEndian.Uint32(header))
+		if end > size {
+			return offset, nil
+		}
+
+		if size-offset <= tornScanWindow {
+			frame := make([]byte, end-offset)
+			if _, err := file.ReadAt(frame, offset); err != nil {
+				return 0, err
+			}
+			if !c.frameValid(frame) {
+				return offset, nil
+			}
+		}
+
+		offset = end
+	}
+
+	return offset, nil
+}
+
+// repairTornRecord is a helper function for newWriter that checks the end of
+// the log file for a torn record and removes it.  It doesn't apply the lock, so
+// it should only be called by a function that does.
+func (dw *Writer) repairTornRecord() {
+	err := dw.removeTornRecord()
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error repairing %s - %w", dw.pathname, err))
+	}
+}
+
+// removeTornRecord is a helper function for repairTornRecord that does the work.
+func (dw *Writer) removeTornRecord() error {
+	file, err := openLogFile(dw.pathname, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	good, err := dw.checksum.findTornTail(file, info.Size())
+	if err != nil || good == info.Size() {
+		return err
+	}
+
+	// Keep the damaged data for inspection and then remove it from the log.
+	quarantine := fmt.Sprintf("%s.%d.torn", dw.pathname, good)
+	err = dw.writeAtomically(quarantine, func(w io.Writer) error {
+		_, err := io.Copy(w, io.NewSectionReader(file, good, info.Size()-good))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := file.Truncate(good); err != nil {
+		return err
+	}
+
+	dw.emit(Event{
+		Type: EventTornRecordRepaired,
+		Path: dw.pathname,
+		Err:  fmt.Errorf("%d bytes of torn record moved to %s", info.Size()-good, quarantine),
+	})
+
+	return nil
+}
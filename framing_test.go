@@ -0,0 +1,152 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestAppendFrame checks the layout of a frame and that frameValid accepts it
+// and rejects damaged copies.
+func TestAppendFrame(t *testing.T) {
+	frame := appendFrame(nil, []byte("abc"))
+
+	if len(frame) != 3+frameOverhead {
+		t.Fatalf("want %d bytes got %d", 3+frameOverhead, len(frame))
+	}
+	if !bytes.Equal(frame[:7], []byte{0, 0, 0, 3, 'a', 'b', 'c'}) {
+		t.Errorf("want the length and the data, got %v", frame[:7])
+	}
+	if !frameValid(frame) {
+		t.Error("want the frame to be valid")
+	}
+
+	damaged := bytes.Clone(frame)
+	damaged[5] = 'x'
+	if frameValid(damaged) {
+		t.Error("want a damaged frame to be invalid")
+	}
+
+	// A run of zeros, as left by a power failure, isn't a valid empty frame.
+	if frameValid(make([]byte, frameOverhead)) {
+		t.Error("want zeros to be invalid")
+	}
+}
+
+// TestFindTornTail checks that findTornTail finds the end of the last good
+// frame.
+func TestFindTornTail(t *testing.T) {
+	good := appendFrame(appendFrame(nil, []byte("first")), []byte("second"))
+	last := appendFrame(nil, []byte("third"))
+
+	var testData = []struct {
+		description string
+		data        []byte
+		want        int
+	}{
+		{"empty", nil, 0},
+		{"all good", append(bytes.Clone(good), last...), len(good) + len(last)},
+		{"short header", append(bytes.Clone(good), 0, 0), len(good)},
+		{"short data", append(bytes.Clone(good), last[:9]...), len(good)},
+		{"zeros", append(bytes.Clone(good), make([]byte, 32)...), len(good)},
+		{"bad CRC", append(bytes.Clone(good), append(last[:len(last)-1], 0)...), len(good)},
+	}
+
+	for _, td := range testData {
+		got, err := findTornTail(bytes.NewReader(td.data), int64(len(td.data)))
+		if err != nil {
+			t.Errorf("%s: %v", td.description, err)
+			continue
+		}
+		if got != int64(td.want) {
+			t.Errorf("%s: want %d got %d", td.description, td.want, got)
+		}
+	}
+}
+
+// TestRecordFraming checks that writes are framed and that a torn record left at
+// the end of the file is moved out of the way on start up.
+func TestRecordFraming(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithRecordFraming())
+	writer.Write([]byte("first"))
+	writer.Close()
+
+	// Simulate a crash part of the way through the second record.
+	const filename = "foo.2020-02-14.bar"
+	torn := appendFrame(nil, []byte("second"))[:7]
+	file, _ := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	file.Write(torn)
+	file.Close()
+
+	var events []Event
+	handler := func(event Event) { events = append(events, event) }
+	writer = New(now, ".", "foo.", ".bar", WithRecordFraming(), WithEventHandler(handler))
+	writer.Write([]byte("third"))
+	writer.Close()
+
+	if len(events) != 1 || events[0].Type != EventTornRecordRepaired {
+		t.Fatalf("want one EventTornRecordRepaired, got %v", events)
+	}
+
+	want := appendFrame(appendFrame(nil, []byte("first")), []byte("third"))
+	got, _ := os.ReadFile(filename)
+	if !bytes.Equal(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	quarantined, err := os.ReadFile(filename + ".13.torn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(quarantined, torn) {
+		t.Errorf("want the torn record %v got %v", torn, quarantined)
+	}
+}
+
+// FuzzFindTornTail checks that findTornTail doesn't panic on any input and that
+// everything before the offset it returns is whole frames.
+func FuzzFindTornTail(f *testing.F) {
+	f.Add(appendFrame(appendFrame(nil, []byte("first")), []byte("second")))
+	f.Add(append(appendFrame(nil, []byte("first")), 0, 0, 0, 9, 'x'))
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		good, err := findTornTail(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if good < 0 || good > int64(len(data)) {
+			t.Fatalf("offset %d is outside the %d bytes", good, len(data))
+		}
+
+		reader := NewRecordReader(bytes.NewReader(data[:good]), ChecksumCRC32, false)
+		for {
+			_, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil && len(data) <= tornScanWindow {
+				t.Fatalf("the data before offset %d isn't whole frames - %v", good, err)
+			}
+			if err != nil {
+				break
+			}
+		}
+	})
+}
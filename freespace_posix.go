@@ -0,0 +1,27 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// diskFreeSpace returns the number of bytes available to an unprivileged user on
+// the device holding the given directory.
+func diskFreeSpace(directory string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(directory, &stat); err != nil {
+		return 0, &fs.PathError{Op: "statfs", Path: directory, Err: err}
+	}
+
+	// The types of the fields vary between systems.  Under FreeBSD the number of
+	// available blocks is signed and goes negative when the reserve is in use.
+	available := int64(stat.Bavail)
+	if available < 0 {
+		available = 0
+	}
+
+	return uint64(available) * uint64(stat.Bsize), nil
+}
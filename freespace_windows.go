@@ -0,0 +1,25 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// diskFreeSpace returns the number of bytes available to the calling user on the
+// volume holding the given directory.
+func diskFreeSpace(directory string) (uint64, error) {
+	name, err := windows.UTF16PtrFromString(longPath(directory))
+	if err != nil {
+		return 0, &fs.PathError{Op: "GetDiskFreeSpaceEx", Path: directory, Err: err}
+	}
+
+	var available, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &available, &total, &totalFree); err != nil {
+		return 0, &fs.PathError{Op: "GetDiskFreeSpaceEx", Path: directory, Err: err}
+	}
+
+	return available, nil
+}
@@ -0,0 +1,113 @@
+package dailylogger
+
+import "bytes"
+
+// The framing of RTCM3 messages.
+const (
+	rtcm3Preamble     = 0xd3 // The first byte of every message.
+	rtcm3HeaderLength = 3    // The preamble, 6 reserved bits and a 10 bit length.
+	rtcm3CRCLength    = 3    // The CRC-24Q at the end of the message.
+)
+
+// maxNMEALength is the longest NMEA sentence that SplitGNSS looks for.  The
+// standard limit is 82 characters, but some receivers send longer ones.
+const maxNMEALength = 256
+
+// SplitGNSS is a split function for WithRecordSplitter that finds the messages in
+// the data stream from a GNSS receiver, which is a mixture of binary RTCM3
+// messages and NMEA sentences.  An RTCM3 message starts with 0xd3 and is checked
+// using its length and CRC.  An NMEA sentence starts with '$' and ends with a
+// newline.  Anything else, including a damaged message, is returned one junk
+// record at a time, up to the start of the next possible message.
+func SplitGNSS(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	switch data[0] {
+	case rtcm3Preamble:
+		length, complete, ok := rtcm3Length(data)
+		if !ok {
+			return junk(data)
+		}
+		if !complete {
+			if atEOF {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		}
+		return length, data[:length], nil
+
+	case '$':
+		end := bytes.IndexByte(data[:min(len(data), maxNMEALength)], '\n')
+		if end >= 0 {
+			return end + 1, data[:end+1], nil
+		}
+		if len(data) >= maxNMEALength {
+			// Too long to be a sentence.
+			return junk(data)
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+
+	return junk(data)
+}
+
+// rtcm3Length checks the RTCM3 message at the start of the data.  If the header is
+// valid and the whole message is present, it checks the CRC.  It returns the
+// length of the message, whether it's all present and whether it's valid so far.
+func rtcm3Length(data []byte) (int, bool, bool) {
+	if len(data) < rtcm3HeaderLength {
+		return 0, false, true
+	}
+
+	if data[1]&0xfc != 0 {
+		// The reserved bits should be zero.
+		return 0, false, false
+	}
+
+	length := rtcm3HeaderLength + (int(data[1]&0x03)<<8 | int(data[2])) + rtcm3CRCLength
+	if len(data) < length {
+		return length, false, true
+	}
+
+	body := data[:length-rtcm3CRCLength]
+	crc := uint32(data[length-3])<<16 | uint32(data[length-2])<<8 | uint32(data[length-1])
+	if crc24q(body) != crc {
+		return 0, false, false
+	}
+
+	return length, true, true
+}
+
+// junk returns the junk at the start of the data as a record, up to the next
+// byte that could start a message.
+func junk(data []byte) (int, []byte, error) {
+	end := 1
+	for end < len(data) && data[end] != rtcm3Preamble && data[end] != '$' {
+		end++
+	}
+
+	return end, data[:end], nil
+}
+
+// crc24q returns the CRC-24Q checksum of the data, as used by RTCM3.
+func crc24q(data []byte) uint32 {
+	const polynomial = 0x1864cfb
+
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= polynomial
+			}
+		}
+	}
+
+	return crc & 0xffffff
+}
@@ -0,0 +1,109 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// rtcm3Message returns an RTCM3 message with the given payload and a valid CRC.
+func rtcm3Message(payload string) []byte {
+	message := []byte{rtcm3Preamble, byte(len(payload) >> 8), byte(len(payload))}
+	message = append(message, payload...)
+	crc := crc24q(message)
+	return append(message, byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+// TestCRC24Q checks the CRC against the standard check value.
+func TestCRC24Q(t *testing.T) {
+	const want = 0xcde703
+	if got := crc24q([]byte("123456789")); got != want {
+		t.Errorf("want %x got %x", want, got)
+	}
+}
+
+// TestSplitGNSS checks that SplitGNSS finds RTCM3 messages, NMEA sentences and junk.
+func TestSplitGNSS(t *testing.T) {
+	message := string(rtcm3Message("abc"))
+	damaged := message[:len(message)-rtcm3CRCLength] + "\x00\x00\x00"
+
+	var testData = []struct {
+		description string
+		data        string
+		atEOF       bool
+		want        int
+	}{
+		{"empty", "", false, 0},
+		{"rtcm3", message + "$GP", false, len(message)},
+		{"partial rtcm3", message[:5], false, 0},
+		{"partial rtcm3 at EOF", message[:5], true, 5},
+		{"partial header", message[:2], false, 0},
+		{"damaged rtcm3", damaged + message, false, len(damaged)},
+		{"bad reserved bits", "\xd3\xff\x00$", false, 3},
+		{"nmea", "$GPGGA,1*00\r\n$", false, 13},
+		{"partial nmea", "$GPGGA,1", false, 0},
+		{"junk", "xyz$GP", false, 3},
+		{"junk before rtcm3", "xy" + message, false, 2},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			got, _, err := SplitGNSS([]byte(td.data), td.atEOF)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got != td.want {
+				t.Errorf("want %d got %d", td.want, got)
+			}
+		})
+	}
+}
+
+// TestGNSSMessagesAcrossMidnight checks that a message split between two Write
+// calls either side of midnight lands entirely in the old file.
+func TestGNSSMessagesAcrossMidnight(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	message1 := string(rtcm3Message("first"))
+	message2 := string(rtcm3Message("second"))
+	const sentence = "$GPGGA,1*00\r\n"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithRecordSplitter(SplitGNSS))
+	writer.Write([]byte(message1[:4]))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte(message1[4:] + sentence + message2[:2]))
+	writer.Write([]byte(message2[2:]))
+	writer.Close()
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar", message1},
+		{"foo.2020-02-15.bar", sentence + message2},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want %q got %q", td.filename, td.want, string(contents))
+		}
+	}
+}
@@ -4,9 +4,7 @@ go 1.24.1
 
 require (
 	github.com/goblimey/go-tools/testsupport v0.0.0-20200820163708-11a15c624044
-	github.com/goblimey/portablesyscall v0.0.0-20260111231805-0c68a3fd59ea
-	github.com/goblimey/switchwriter v0.0.0-20260103122352-d7a30a22828f
 	github.com/google/uuid v1.6.0
 )
 
-require golang.org/x/sys v0.39.0 // indirect
+require golang.org/x/sys v0.39.0
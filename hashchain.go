@@ -0,0 +1,265 @@
+package dailylogger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// chainHashLength is the length of the hash at the start of each line, in hex.
+const chainHashLength = 2 * sha256.Size
+
+// WithHashChain makes the Writer start each line with a hash that chains it to
+// the line before, so that changing, removing or reordering any line of a log
+// file can be detected (see VerifyDay).  The line "hello" becomes the SHA-256
+// hash, in hex, of the hash of the previous line followed by "hello", then a
+// space and then "hello".  The chain of each file starts with the SHA-256 hash
+// of the file's name, so a file can't be passed off as another, and VerifyDay
+// checks that none of a day's files is missing.  The Writer's own lines, such as
+// the quota marker, are chained too.  Each Write should be one or more complete
+// lines, and a newline is added to any that isn't.  When the Writer reopens a
+// file, for example after a restart, it picks the chain up from the last line.
+// The chain shows that the lines haven't been changed since they were written,
+// not that the end of the file hasn't been cut off, so it's best combined with a
+// manifest (see WithManifest) once the file is finished.  The option isn't
+// combined with WithRecordFraming, which has checksums of its own, and it takes
+// the place of WithWholeLines and WithRecordSplitter.
+func WithHashChain() Option {
+	return func(dw *Writer) {
+		dw.hashChain = true
+	}
+}
+
+// checkHashChain is a helper function for newWriter that turns the hash chain
+// off if framing is in use.
+func (dw *Writer) checkHashChain() {
+	if !dw.hashChain || !dw.framing {
+		return
+	}
+
+	err := errors.New("WithHashChain: the hash chain can't be combined with record framing - not chaining the lines")
+	dw.reportError(err)
+	dw.recordStartupError(err)
+	dw.hashChain = false
+}
+
+// chainSeed returns the hash that starts the chain of the log file with the given
+// path name.
+func (dw *Writer) chainSeed(pathname string) [sha256.Size]byte {
+	if dw.compressor != nil {
+		pathname = strings.TrimSuffix(pathname, dw.compressor.Extension())
+	}
+	return sha256.Sum256([]byte(filepath.Base(pathname)))
+}
+
+// chainHash returns the hash of the line that follows the line with the given
+// hash.
+func chainHash(previous [sha256.Size]byte, line []byte) [sha256.Size]byte {
+	hash := sha256.New()
+	hash.Write(previous[:])
+	hash.Write(line)
+	var result [sha256.Size]byte
+	hash.Sum(result[:0])
+	return result
+}
+
+// writeChained is a helper function for writeToLogOnce and writeNoteBytes that
+// writes the buffer a line at a time, each with its hash.  It returns the number
+// of bytes of the buffer that were written, counting only whole lines.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) writeChained(buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+
+	// Build the output, noting where each line ends in it and in the buffer.
+	type lineEnd struct {
+		output, input int
+		hash          [sha256.Size]byte
+	}
+	var ends []lineEnd
+
+	output := dw.chainScratch[:0]
+	if dw.chainNewline {
+		// The file ends with a torn line, which mustn't swallow the next one.
+		output = append(output, '\n')
+	}
+	head := dw.chainHead
+	for start := 0; start < len(buffer); {
+		line := buffer[start:]
+		next := len(buffer)
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = start + i + 1
+		}
+
+		head = chainHash(head, line)
+		output = hex.AppendEncode(output, head[:])
+		output = append(output, ' ')
+		output = append(output, line...)
+		output = append(output, '\n')
+		ends = append(ends, lineEnd{len(output), next, head})
+		start = next
+	}
+	if cap(output) <= maxPooledBuffer {
+		// Keep the memory for next time, unless it's big.
+		dw.chainScratch = output
+	}
+
+	n, err := dw.sink.Write(output)
+
+	// Move the chain on past the lines that were written.
+	written := 0
+	for _, end := range ends {
+		if end.output > n {
+			break
+		}
+		dw.chainHead = end.hash
+		dw.chainNewline = false
+		written = end.input
+	}
+
+	return written, err
+}
+
+// loadChainHead is a helper function for openLog that finds the hash of the
+// last line of the newly opened log file, so that the chain carries on from it,
+// or the seed if the file is empty.  It doesn't apply the lock, so it should
+// only be called by a function that does.
+func (dw *Writer) loadChainHead() {
+	dw.chainHead = dw.chainSeed(dw.pathname)
+	dw.chainNewline = false
+
+	info, err := os.Stat(longPath(dw.pathname))
+	if err != nil || info.Size() == 0 {
+		return
+	}
+
+	reader, err := dw.openChained(dw.pathname, dw.compressLive)
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error reading the hash chain of %s - %w", dw.pathname, err))
+		return
+	}
+	defer reader.Close()
+
+	var last []byte
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			last = line
+			continue
+		}
+		if len(line) > 0 {
+			// A crash tore the last line.
+			dw.chainNewline = true
+		}
+		if err != nil && err != io.EOF {
+			dw.reportError(fmt.Errorf("dailylogger: error reading the hash chain of %s - %w", dw.pathname, err))
+		}
+		break
+	}
+
+	if last == nil {
+		return
+	}
+	var head [sha256.Size]byte
+	if len(last) < chainHashLength {
+		err = ErrChainBroken
+	} else {
+		_, err = hex.Decode(head[:], last[:chainHashLength])
+	}
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: %s - the last line has no hash - %w", dw.pathname, ErrChainBroken))
+		return
+	}
+	dw.chainHead = head
+}
+
+// openChained opens a log file for reading its hash chain, decompressing it if
+// necessary.
+func (dw *Writer) openChained(pathname string, compressed bool) (io.ReadCloser, error) {
+	return dw.OpenLogFile(LogFile{Pathname: pathname, Compressed: compressed})
+}
+
+// VerifyDay checks the hash chains (see WithHashChain) of the log files for the
+// day containing the given time, including any that have been compressed or
+// moved to the cold directory.  It returns nil if every line of every file
+// carries on the chain and none of the day's files is missing, and otherwise an
+// error, wrapping ErrChainBroken, that gives the first line that doesn't.  The
+// current log file may have lines still in the Writer's buffer, so call Sync
+// before checking today's files.
+func (dw *Writer) VerifyDay(day time.Time) error {
+	date := getLastMidnight(day.In(dw.location))
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return fmt.Errorf("VerifyDay: %w", err)
+	}
+
+	next := 0
+	for _, logFile := range logFiles {
+		if !logFile.Date.Equal(date) {
+			continue
+		}
+		if logFile.Sequence != next {
+			return fmt.Errorf("VerifyDay: file %d of %s is missing - %w",
+				next, date.Format(time.DateOnly), ErrChainBroken)
+		}
+		next++
+
+		if err := dw.verifyChain(logFile); err != nil {
+			return fmt.Errorf("VerifyDay: %w", err)
+		}
+	}
+
+	if next == 0 {
+		return fmt.Errorf("VerifyDay: no log files for %s - %w", date.Format(time.DateOnly), fs.ErrNotExist)
+	}
+
+	return nil
+}
+
+// verifyChain is a helper function for VerifyDay that checks the hash chain of
+// one log file.
+func (dw *Writer) verifyChain(logFile LogFile) error {
+	reader, err := dw.openChained(logFile.Pathname, logFile.Compressed)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	head := dw.chainSeed(logFile.Pathname)
+	buffered := bufio.NewReader(reader)
+	for number := 1; ; number++ {
+		line, err := buffered.ReadBytes('\n')
+		if len(line) == 0 && err == io.EOF {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("%s - %w", logFile.Pathname, err)
+		}
+		if line[len(line)-1] != '\n' {
+			return fmt.Errorf("%s: line %d is incomplete - %w", logFile.Pathname, number, ErrChainBroken)
+		}
+
+		if len(line) < chainHashLength+2 || line[chainHashLength] != ' ' {
+			return fmt.Errorf("%s: line %d has no hash - %w", logFile.Pathname, number, ErrChainBroken)
+		}
+
+		want := chainHash(head, line[chainHashLength+1:len(line)-1])
+		if string(line[:chainHashLength]) != hex.EncodeToString(want[:]) {
+			return fmt.Errorf("%s: line %d - %w", logFile.Pathname, number, ErrChainBroken)
+		}
+		head = want
+	}
+}
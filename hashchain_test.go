@@ -0,0 +1,130 @@
+package dailylogger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestHashChain checks that each line starts with its hash, that the chain
+// carries on when the file is reopened and that VerifyDay accepts the result.
+func TestHashChain(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithHashChain())
+	writer.Write([]byte("first\nsecond\n"))
+	writer.Close()
+
+	// Simulate a restart.
+	writer = New(now, ".", "foo.", ".bar", WithHashChain())
+	writer.Write([]byte("third"))
+	writer.Sync()
+
+	if err := writer.VerifyDay(now); err != nil {
+		t.Errorf("want the chain to verify, got %v", err)
+	}
+	writer.Close()
+
+	const filename = "foo.2020-02-14.bar"
+	head := sha256.Sum256([]byte(filename))
+	var want []byte
+	for _, line := range []string{"first", "second", "third"} {
+		head = chainHash(head, []byte(line))
+		want = append(want, hex.EncodeToString(head[:])+" "+line+"\n"...)
+	}
+	got, _ := os.ReadFile(filename)
+	if !bytes.Equal(got, want) {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+// TestVerifyDayTampered checks that VerifyDay finds a changed line and a day
+// with no files.
+func TestVerifyDayTampered(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithHashChain())
+	defer writer.Close()
+	writer.Write([]byte("pay alice 10\npay bob 20\n"))
+	writer.Sync()
+
+	const filename = "foo.2020-02-14.bar"
+	contents, _ := os.ReadFile(filename)
+	os.WriteFile(filename, bytes.Replace(contents, []byte("bob 20"), []byte("bob 90"), 1), 0644)
+
+	if err := writer.VerifyDay(now); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("want ErrChainBroken, got %v", err)
+	}
+
+	if err := writer.VerifyDay(now.AddDate(0, 0, -1)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("want fs.ErrNotExist for a day with no files, got %v", err)
+	}
+}
+
+// TestHashChainTornLine checks that a line torn by a crash doesn't swallow the
+// next one and that VerifyDay reports it.
+func TestHashChainTornLine(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithHashChain())
+	writer.Write([]byte("first"))
+	writer.Close()
+
+	const filename = "foo.2020-02-14.bar"
+	file, _ := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	file.Write([]byte("0123"))
+	file.Close()
+
+	writer = New(now, ".", "foo.", ".bar", WithHashChain())
+	defer writer.Close()
+	writer.Write([]byte("second"))
+	writer.Sync()
+
+	contents, _ := os.ReadFile(filename)
+	lines := bytes.Split(contents, []byte("\n"))
+	if len(lines) != 4 || string(lines[1]) != "0123" || !bytes.HasSuffix(lines[2], []byte(" second")) {
+		t.Fatalf("want the torn line on a line of its own, got\n%s", contents)
+	}
+
+	if err := writer.VerifyDay(now); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("want ErrChainBroken, got %v", err)
+	}
+}
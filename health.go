@@ -0,0 +1,95 @@
+package dailylogger
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Health returns nil if the Writer is working.  It returns an error if the Writer
+// has been closed, if something went wrong while it was starting up and the error
+// hasn't been returned already, if the last write or the last rotation failed, if the log is
+// being kept in memory because the filesystem is read-only, if another process
+// holds the lock file (see WithLockFile), or if the log file isn't open or has
+// been removed.  The checks are cheap, so Health can be called often, for
+// example by a liveness probe (see HealthHandler).
+func (dw *Writer) Health() error {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	if err := dw.takeStartupError(); err != nil {
+		return err
+	}
+
+	if dw.lockFileError != nil {
+		return dw.lockFileError
+	}
+
+	if dw.lastWriteError != nil {
+		return fmt.Errorf("dailylogger: the last write failed - %w", dw.lastWriteError)
+	}
+
+	if dw.readOnly {
+		return fmt.Errorf("dailylogger: %s - %w", dw.pathname, errReadOnly)
+	}
+
+	if dw.lastRotationError != nil {
+		return fmt.Errorf("dailylogger: the last rotation failed - %w", dw.lastRotationError)
+	}
+
+	return dw.probe()
+}
+
+// probe is a helper function for Health that checks that the log file is open
+// and that the open file is still the one with the log file's name.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) probe() error {
+	if dw.creationPending {
+		// The file hasn't been created yet (see WithLazyCreation).
+		return nil
+	}
+
+	if dw.logWriter == nil {
+		return fmt.Errorf("dailylogger: %s - %w", dw.pathname, errNoFile)
+	}
+
+	if dw.logFile == nil {
+		// The log is written by a writer from a file factory, which can't be checked.
+		return nil
+	}
+
+	openInfo, err := dw.logFile.Stat()
+	if err != nil {
+		return fmt.Errorf("dailylogger: %w", err)
+	}
+
+	info, err := os.Stat(longPath(dw.pathname))
+	if err != nil {
+		return fmt.Errorf("dailylogger: %w", err)
+	}
+
+	if !os.SameFile(openInfo, info) {
+		return fmt.Errorf("dailylogger: %s - %w", dw.pathname, errReplaced)
+	}
+
+	return nil
+}
+
+// HealthHandler returns an http.Handler that reports the result of Health, for
+// use as a Kubernetes liveness or readiness probe.  It responds with status 200
+// and "ok" if the Writer is healthy and with status 503 and the error otherwise.
+func (dw *Writer) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := dw.Health(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+}
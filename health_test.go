@@ -0,0 +1,98 @@
+package dailylogger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestHealth checks that Health reports the state of the Writer and that the
+// handler reports it over HTTP.
+func TestHealth(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar")
+	handler := writer.HealthHandler()
+
+	if err := writer.Health(); err != nil {
+		t.Errorf("want a healthy writer got %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("want status %d got %d", http.StatusOK, recorder.Code)
+	}
+
+	// Remove the log file.
+	os.Remove("foo.2020-02-14.bar")
+
+	if err := writer.Health(); err == nil {
+		t.Error("want an error after the log file is removed")
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("want status %d got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	// Reopening creates a new file.
+	writer.Reopen()
+	if err := writer.Health(); err != nil {
+		t.Errorf("want a healthy writer after Reopen got %v", err)
+	}
+
+	writer.Close()
+	if err := writer.Health(); !errors.Is(err, ErrClosed) {
+		t.Errorf("want ErrClosed got %v", err)
+	}
+}
+
+// TestHealthAfterFailure checks that Health reports a failed rotation and a
+// failed write.
+func TestHealthAfterFailure(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar")
+	defer writer.Close()
+
+	// Stop tomorrow's file from being created.
+	os.Mkdir("foo.2020-02-15.bar", 0755)
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+
+	if err := writer.Health(); err == nil {
+		t.Error("want an error after a failed rotation")
+	}
+
+	writer.Write([]byte("hello"))
+
+	if err := writer.Health(); err == nil {
+		t.Error("want an error after a failed write")
+	}
+}
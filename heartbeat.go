@@ -0,0 +1,66 @@
+package dailylogger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WithHeartbeat makes the Writer add a heartbeat line to the log at the given
+// interval, so that a quiet day still produces a log file with something in it
+// and a "no data received" alert downstream can tell that nothing happened from
+// the logger being broken.  The payload function returns the line for the given
+// time, and a newline is added if it hasn't got one.  If it's nil, the line is
+// "dailylogger: heartbeat " followed by the time in RFC 3339 format.  The
+// heartbeat is written whether or not anything else has been, after anything
+// written before it, and isn't subject to sampling or the daily quota.  With lazy
+// rotation (see WithLazyRotation) it rotates the log if the day has changed, so
+// the heartbeat goes in the right day's file.
+func WithHeartbeat(interval time.Duration, payload func(now time.Time) string) Option {
+	return func(dw *Writer) {
+		if interval > 0 {
+			dw.heartbeatInterval = interval
+			dw.heartbeatPayload = payload
+		}
+	}
+}
+
+// heartbeatMonitor writes the heartbeat at regular intervals until the Writer is
+// closed.  It should be run in a goroutine.
+func (dw *Writer) heartbeatMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.heartbeatInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		dw.heartbeat()
+	}
+}
+
+// heartbeat writes the heartbeat line.
+func (dw *Writer) heartbeat() {
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return
+	}
+
+	dw.rotateIfDue()
+
+	now := dw.now()
+	line := "dailylogger: heartbeat " + now.Format(time.RFC3339)
+	if dw.heartbeatPayload != nil {
+		line = dw.heartbeatPayload(now)
+	}
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	if err := dw.writeNote(line); err != nil {
+		dw.reportError(fmt.Errorf("heartbeat: %s - %w", dw.pathname, err))
+	}
+}
@@ -0,0 +1,90 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestHeartbeat checks that the heartbeat line is written at the interval.
+func TestHeartbeat(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "hello\n" +
+		"dailylogger: heartbeat 2020-02-14T12:01:00Z\n" +
+		"dailylogger: heartbeat 2020-02-14T12:02:00Z\n"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithHeartbeat(time.Minute, nil))
+	defer writer.Close()
+
+	writer.Write([]byte("hello\n"))
+
+	// The rotator and the heartbeat goroutine go to sleep.  Each time the heartbeat
+	// goroutine wakes it writes a heartbeat and goes back to sleep.
+	for i := 0; i < 2; i++ {
+		fc.WaitForSleepers(2 + i)
+		fc.Advance(time.Minute)
+	}
+	fc.WaitForSleepers(4)
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
+
+// TestHeartbeatLazyRotation checks that the heartbeat uses the given payload and
+// that with lazy rotation it goes into the file for the new day.
+func TestHeartbeatLazyRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 59, 30, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	payload := func(now time.Time) string { return "alive at " + now.Format(time.TimeOnly) }
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithLazyRotation(),
+		WithHeartbeat(time.Minute, payload))
+	defer writer.Close()
+
+	fc.WaitForSleepers(1)
+	fc.Advance(time.Minute)
+	fc.WaitForSleepers(2)
+
+	old, _ := os.ReadFile("foo.2020-02-14.bar")
+	if len(old) != 0 {
+		t.Errorf("want nothing in the old file got \"%s\"", string(old))
+	}
+
+	contents, err := os.ReadFile("foo.2020-02-15.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "alive at 00:00:30\n"
+	if string(contents) != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, string(contents))
+	}
+}
@@ -0,0 +1,122 @@
+package dailylogger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// hexDumpSuffix is added to the name of the log file to give the name of the
+// hex dump file.
+const hexDumpSuffix = ".hex"
+
+// WithHexDump makes the Writer produce a readable dump of binary data, in the
+// same form as "hexdump -C", with the given number of bytes on each line.  Each
+// line shows the offset in the log file, the bytes in hex and the printable
+// bytes as ASCII.  If only is false, the dump is written to a companion file
+// alongside each log file, named by adding ".hex" to the log file's name.  If
+// only is true, the dump is written to the log file instead of the raw data.
+// The lines follow the Write calls, so a short line ends each write.
+func WithHexDump(bytesPerLine int, only bool) Option {
+	return func(dw *Writer) {
+		if bytesPerLine > 0 {
+			dw.hexDumpBytesPerLine = bytesPerLine
+			dw.hexDumpOnly = only
+		}
+	}
+}
+
+// hexDumper is an io.Writer that writes a hex dump of the data to another writer.
+type hexDumper struct {
+	w            io.Writer
+	offset       int64 // The offset of the next byte in the raw data.
+	bytesPerLine int
+}
+
+// Write writes the dump of the buffer.
+func (hd *hexDumper) Write(buffer []byte) (int, error) {
+	var dump bytes.Buffer
+	for start := 0; start < len(buffer); start += hd.bytesPerLine {
+		line := buffer[start:min(start+hd.bytesPerLine, len(buffer))]
+		hd.dumpLine(&dump, hd.offset+int64(start), line)
+	}
+
+	if _, err := hd.w.Write(dump.Bytes()); err != nil {
+		return 0, err
+	}
+
+	hd.offset += int64(len(buffer))
+	return len(buffer), nil
+}
+
+// dumpLine adds one line of the dump to the buffer.
+func (hd *hexDumper) dumpLine(dump *bytes.Buffer, offset int64, line []byte) {
+	fmt.Fprintf(dump, "%08x ", offset)
+	for i := 0; i < hd.bytesPerLine; i++ {
+		if i%8 == 0 {
+			dump.WriteByte(' ')
+		}
+		if i < len(line) {
+			fmt.Fprintf(dump, "%02x ", line[i])
+		} else {
+			dump.WriteString("   ")
+		}
+	}
+
+	dump.WriteString(" |")
+	for _, b := range line {
+		if b < ' ' || b > '~' {
+			b = '.'
+		}
+		dump.WriteByte(b)
+	}
+	dump.WriteString("|\n")
+}
+
+// companionWriter writes the raw data to the log and a dump of it to the
+// companion file.
+type companionWriter struct {
+	dw     *Writer
+	raw    io.Writer
+	dumper *hexDumper
+}
+
+// Write writes the buffer to the log and then writes the dump.  A failure to
+// write the dump is reported but doesn't affect the result.
+func (cw *companionWriter) Write(buffer []byte) (int, error) {
+	n, err := cw.raw.Write(buffer)
+	if n > 0 {
+		if _, de := cw.dumper.Write(buffer[:n]); de != nil {
+			cw.dw.reportError(fmt.Errorf("hexDump: %w", de))
+		}
+	}
+
+	return n, err
+}
+
+// openHexDump is a helper function for openLog that sets up the hex dump and
+// returns the writer to use.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) openHexDump(dest io.Writer) io.Writer {
+	if dw.hexDumpOnly {
+		return &hexDumper{w: dest, bytesPerLine: dw.hexDumpBytesPerLine}
+	}
+
+	var offset int64
+	if dw.logFile != nil {
+		if info, err := dw.logFile.Stat(); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	pathname := dw.pathname + hexDumpSuffix
+	dumpFile, err := dw.openFile(pathname)
+	if err != nil {
+		dw.reportError(fmt.Errorf("openHexDump: error creating dump file %s - %w", pathname, err))
+		return dest
+	}
+	dw.hexDumpFile = dumpFile
+
+	dumper := hexDumper{w: dumpFile, offset: offset, bytesPerLine: dw.hexDumpBytesPerLine}
+	return &companionWriter{dw: dw, raw: dest, dumper: &dumper}
+}
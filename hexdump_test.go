@@ -0,0 +1,72 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestHexDump checks that the hex dump is written alongside the log file or
+// instead of it.
+func TestHexDump(t *testing.T) {
+
+	// This test uses the filestore.
+
+	const raw = "hello, world\n"
+	const dump = "00000000  68 65 6c 6c 6f 2c 20 77  |hello, w|\n" +
+		"00000008  6f 72 6c 64 0a           |orld.|\n" +
+		"0000000d  21                       |!|\n"
+
+	var testData = []struct {
+		description string
+		only        bool
+		wantLog     string
+		wantDump    string
+	}{
+		{"alongside", false, raw + "!", dump},
+		{"only", true, dump, ""},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				t.Errorf("createWorkingDirectory failed - %v", err)
+				return
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			locationUTC, _ := time.LoadLocation("UTC")
+			now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+			writer := New(now, ".", "foo.", ".bar", WithHexDump(8, td.only))
+			writer.Write([]byte(raw))
+			writer.Write([]byte("!"))
+			writer.Close()
+
+			contents, err := os.ReadFile("foo.2020-02-14.bar")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents) != td.wantLog {
+				t.Errorf("want log %q got %q", td.wantLog, string(contents))
+			}
+
+			contents, err = os.ReadFile("foo.2020-02-14.bar.hex")
+			if len(td.wantDump) == 0 {
+				if err == nil {
+					t.Error("want no dump file")
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents) != td.wantDump {
+				t.Errorf("want dump %q got %q", td.wantDump, string(contents))
+			}
+		})
+	}
+}
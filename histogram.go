@@ -0,0 +1,64 @@
+package dailylogger
+
+import (
+	"slices"
+	"time"
+)
+
+// defaultLatencyBounds are the upper bounds of the latency histogram buckets
+// unless WithLatencyBuckets is used.
+var defaultLatencyBounds = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram shows the distribution of a set of durations.  Counts[i] is the
+// number of durations no greater than Bounds[i] (and greater than Bounds[i-1]).
+// The last element of Counts, which has no bound, counts the durations greater
+// than all of the bounds.
+type Histogram struct {
+	Bounds []time.Duration // The upper bounds of the buckets, in increasing order.
+	Counts []int64         // The number of durations in each bucket.
+	Count  int64           // The total number of durations.
+	Sum    time.Duration   // The total of the durations.
+}
+
+// WithLatencyBuckets sets the upper bounds of the buckets of the latency
+// histograms in the Stats.  They are sorted into increasing order.  By default
+// the bounds are 10µs, 100µs, 1ms, 10ms, 100ms and 1s.
+func WithLatencyBuckets(bounds ...time.Duration) Option {
+	return func(dw *Writer) {
+		if len(bounds) > 0 {
+			dw.stats.WriteLatency = newHistogram(bounds)
+			dw.stats.RotationLatency = newHistogram(bounds)
+		}
+	}
+}
+
+// newHistogram creates an empty Histogram with the given bounds.
+func newHistogram(bounds []time.Duration) Histogram {
+	sorted := make([]time.Duration, len(bounds))
+	copy(sorted, bounds)
+	slices.Sort(sorted)
+
+	return Histogram{Bounds: sorted, Counts: make([]int64, len(sorted)+1)}
+}
+
+// observe adds the duration to the histogram.
+func (h *Histogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.Bounds, d)
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// clone returns a deep copy of the histogram.
+func (h Histogram) clone() Histogram {
+	h.Bounds = slices.Clone(h.Bounds)
+	h.Counts = slices.Clone(h.Counts)
+	return h
+}
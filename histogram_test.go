@@ -0,0 +1,71 @@
+package dailylogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHistogramObserve checks that durations are counted in the right buckets.
+func TestHistogramObserve(t *testing.T) {
+	h := newHistogram([]time.Duration{time.Second, time.Millisecond})
+
+	for _, d := range []time.Duration{0, time.Millisecond, 2 * time.Millisecond, time.Second, time.Minute} {
+		h.observe(d)
+	}
+
+	wantCounts := []int64{2, 2, 1}
+	for i := range wantCounts {
+		if h.Counts[i] != wantCounts[i] {
+			t.Errorf("want counts %v got %v", wantCounts, h.Counts)
+			break
+		}
+	}
+
+	if h.Count != 5 {
+		t.Errorf("want count 5 got %d", h.Count)
+	}
+
+	wantSum := time.Minute + time.Second + 3*time.Millisecond
+	if h.Sum != wantSum {
+		t.Errorf("want sum %v got %v", wantSum, h.Sum)
+	}
+}
+
+// TestLatencyStats checks that writes and rotations are timed.
+func TestLatencyStats(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithLatencyBuckets(time.Hour))
+	defer writer.Close()
+
+	writer.Write([]byte("hello"))
+	writer.Write([]byte("world"))
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+
+	stats := writer.Stats()
+
+	if stats.WriteLatency.Count != 2 || stats.WriteLatency.Counts[0] != 2 {
+		t.Errorf("want 2 writes under an hour got %+v", stats.WriteLatency)
+	}
+
+	if stats.RotationLatency.Count != 1 || stats.RotationLatency.Counts[0] != 1 {
+		t.Errorf("want 1 rotation under an hour got %+v", stats.RotationLatency)
+	}
+
+	// The Stats should be a copy.
+	stats.WriteLatency.Counts[0] = 100
+	if writer.Stats().WriteLatency.Counts[0] != 2 {
+		t.Error("Stats returned the Writer's own histogram")
+	}
+}
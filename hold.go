@@ -0,0 +1,127 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// holdExtension is added to the name of the first log file of a day to make the
+// name of the marker that puts the day on hold (see Hold).
+const holdExtension = ".hold"
+
+// WithRetentionFilter limits the log files that the retention rules, the
+// emergency purge, the cold tier, the monthly bundles and compression work on.
+// The patterns are those of path.Match and are matched against the name of the
+// file, as given by List, for example "foo.2020-02-14.bar" or, if it has been
+// compressed, "foo.2020-02-14.bar.gz".  If there are any include patterns, a
+// file must match one of them.  A file that matches any of the exclude patterns
+// is left alone.  A pattern that isn't valid is reported and ignored.
+func WithRetentionFilter(include, exclude []string) Option {
+	return func(dw *Writer) {
+		dw.retentionInclude = include
+		dw.retentionExclude = exclude
+	}
+}
+
+// checkRetentionFilter is a helper function for newWriter that drops the
+// patterns given to WithRetentionFilter that aren't valid.
+func (dw *Writer) checkRetentionFilter() {
+	dw.retentionInclude = dw.checkPatterns(dw.retentionInclude)
+	dw.retentionExclude = dw.checkPatterns(dw.retentionExclude)
+}
+
+// checkPatterns is a helper function for checkRetentionFilter that returns the
+// valid patterns, reporting the others.
+func (dw *Writer) checkPatterns(patterns []string) []string {
+	var valid []string
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("WithRetentionFilter: %q - %w - ignoring it", pattern, err)
+			dw.reportError(err)
+			dw.recordStartupError(err)
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+
+	return valid
+}
+
+// Hold puts the log files for the day containing the given time on hold, for
+// example to meet a legal hold, until Release is called.  While the day is on
+// hold, its files are never deleted, quarantined, compressed, moved to the cold
+// directory or bundled.  The hold is recorded by a marker file in the log
+// directory named after the day's first log file with ".hold" added, for example
+// "foo.2020-02-14.bar.hold", so it survives a restart and can be placed or
+// lifted by hand.  Holding a day that is already on hold does nothing.
+func (dw *Writer) Hold(day time.Time) error {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	pathname := dw.holdPath(getLastMidnight(day.In(dw.location)))
+	file, err := os.OpenFile(longPath(pathname), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("Hold: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Release lifts a hold placed by Hold on the day containing the given time.
+// Releasing a day that isn't on hold does nothing.
+func (dw *Writer) Release(day time.Time) error {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	pathname := dw.holdPath(getLastMidnight(day.In(dw.location)))
+	if err := os.Remove(longPath(pathname)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Release: %w", err)
+	}
+
+	return nil
+}
+
+// holdPath returns the path name of the marker that puts the day starting at
+// the given time on hold.
+func (dw *Writer) holdPath(day time.Time) string {
+	return dw.logDir + "/" + filepath.Base(dw.fileNamer().Name(day, 0)) + holdExtension
+}
+
+// excluded returns true if the log file is left out by WithRetentionFilter or
+// its day is on hold.  It doesn't apply the lock, so it should only be called by
+// a function that does.
+func (dw *Writer) excluded(logFile LogFile) bool {
+	if len(dw.retentionInclude) > 0 && !matchesAny(dw.retentionInclude, logFile.Name) {
+		return true
+	}
+	if matchesAny(dw.retentionExclude, logFile.Name) {
+		return true
+	}
+
+	_, err := os.Stat(longPath(dw.holdPath(logFile.Date)))
+	return err == nil
+}
+
+// matchesAny returns true if the name matches any of the patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRetentionFilter checks that the retention rules leave alone the files that
+// don't match the include patterns, those that match the exclude patterns and
+// those for days on hold, and that an invalid pattern is reported.
+func TestRetentionFilter(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	names := []string{
+		"foo.2020-02-09.bar", "foo.2020-02-10.bar", "foo.2020-02-11.bar", "foo.2020-02-12.bar",
+	}
+	for _, name := range names {
+		os.WriteFile(name, []byte("x\n"), 0644)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var errs []error
+	writer := New(now, ".", "foo.", ".bar",
+		withClock(newFakeClock(now)),
+		WithMaxAge(1),
+		WithRetentionFilter([]string{"foo.2020-02-1?.bar", "["}, []string{"*-11.bar"}),
+		WithRetentionDryRun(func([]Deletion) {}),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	defer writer.Close()
+
+	if len(errs) != 1 {
+		t.Errorf("want the invalid pattern reported, got %v", errs)
+	}
+
+	// The 9th isn't included and the 11th is excluded.
+	plan := mustPlan(t, writer)
+	if len(plan) != 2 || plan[0].Name != names[1] || plan[1].Name != names[3] {
+		t.Fatalf("want %s and %s, got %v", names[1], names[3], plan)
+	}
+
+	day := time.Date(2020, time.February, 10, 15, 0, 0, 0, locationUTC)
+	if err := writer.Hold(day); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("foo.2020-02-10.bar.hold"); err != nil {
+		t.Errorf("want the marker - %v", err)
+	}
+	plan = mustPlan(t, writer)
+	if len(plan) != 1 || plan[0].Name != names[3] {
+		t.Errorf("want only %s while the 10th is on hold, got %v", names[3], plan)
+	}
+
+	if err := writer.Release(day); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Release(day); err != nil {
+		t.Errorf("want releasing twice to work, got %v", err)
+	}
+	plan = mustPlan(t, writer)
+	if len(plan) != 2 {
+		t.Errorf("want the 10th back in the plan, got %v", plan)
+	}
+}
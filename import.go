@@ -0,0 +1,228 @@
+package dailylogger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxImportRecord is the length of the longest record that ImportStream accepts.
+const maxImportRecord = 1 << 20
+
+// maxImportBatch is the amount of data for one day that ImportStream collects
+// before writing it.
+const maxImportBatch = 64 * 1024
+
+// ImportStream reads records from the reader and writes each of them to the log
+// file for its day, so that data buffered during an outage, perhaps for weeks,
+// lands in the files that it would have gone into had it been written at the
+// time.  The records are split as Write splits them, into lines unless a split
+// function was given to WithRecordSplitter, and the extractor gives the time of
+// each record.  A record whose time is the zero time, for example because it's
+// the continuation of the one before, goes with the record before.
+//
+// A record for an earlier day than the current log file's is appended to the
+// last file for that day, or to a new file with the next sequence number if that
+// one has been compressed or moved to the cold directory, and the file is
+// created if necessary.  The records for the current day or later, or whose day
+// can't be found, are written with Write.  The manifests and sidecars (see
+// WithManifest and WithSidecar) of the days already finished aren't updated,
+// and the retention policy is applied to the new files as usual.  Other writes
+// carry on during the import, but the Writer's lock is taken for each batch of
+// records for an earlier day.
+//
+// The records are written as they are, so ImportStream can't be used when the
+// Writer transforms what it writes, with WithHashChain, WithRecordFraming,
+// WithRecordSequence or WithCompressedLiveFile, or when it doesn't write dated
+// files, with WithFileFactory, WithNoRotation or WithStream.  It returns the
+// number of records written and the first error, which stops the import.
+func (dw *Writer) ImportStream(r io.Reader, timestampExtractor func([]byte) time.Time) (int, error) {
+	if err := dw.checkImport(); err != nil {
+		return 0, err
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return 0, fmt.Errorf("ImportStream: %w", err)
+	}
+	imp := importer{dw: dw, logFiles: logFiles, paths: make(map[time.Time]string)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxImportRecord)
+	scanner.Split(dw.recordSplitFunc())
+
+	for scanner.Scan() {
+		record := scanner.Bytes()
+		if stamp := timestampExtractor(record); !stamp.IsZero() {
+			day := getLastMidnight(stamp.In(dw.location))
+			if !day.Equal(imp.day) {
+				if err := imp.flush(); err != nil {
+					return imp.written, err
+				}
+				imp.day = day
+			}
+		}
+
+		imp.batch = append(imp.batch, record...)
+		imp.count++
+		if len(imp.batch) >= maxImportBatch {
+			if err := imp.flush(); err != nil {
+				return imp.written, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return imp.written, fmt.Errorf("ImportStream: %w", err)
+	}
+
+	if err := imp.flush(); err != nil {
+		return imp.written, err
+	}
+
+	return imp.written, dw.syncImported()
+}
+
+// checkImport is a helper function for ImportStream that returns an error if the
+// Writer can't import records.
+func (dw *Writer) checkImport() error {
+	var conflict string
+	switch {
+	case dw.hashChain:
+		conflict = "WithHashChain"
+	case dw.framing:
+		conflict = "WithRecordFraming"
+	case dw.recordSequence:
+		conflict = "WithRecordSequence"
+	case dw.compressLive:
+		conflict = "WithCompressedLiveFile"
+	case dw.fileFactory != nil:
+		conflict = "WithFileFactory"
+	case dw.noRotation:
+		conflict = "WithNoRotation"
+	case dw.stream:
+		conflict = "WithStream"
+	}
+	if len(conflict) > 0 {
+		return fmt.Errorf("ImportStream: records can't be imported with %s", conflict)
+	}
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+	if dw.closed {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+// recordSplitFunc returns the split function that ImportStream and Export use to
+// find the records in a stream.  It's the one given to WithRecordSplitter, or
+// splitLines, adapted so that anything left at the end of the stream is a
+// record of its own.
+func (dw *Writer) recordSplitFunc() bufio.SplitFunc {
+	split := dw.splitter
+	if split == nil {
+		split = splitLines
+	}
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, _, err := split(data, false)
+		if err != nil || advance > len(data) {
+			advance = len(data)
+		}
+		if advance <= 0 {
+			if !atEOF || len(data) == 0 {
+				return 0, nil, nil
+			}
+			advance = len(data)
+		}
+		return advance, data[:advance], nil
+	}
+}
+
+// importer collects the records for ImportStream into batches, each for one day,
+// and writes them.
+type importer struct {
+	dw       *Writer
+	logFiles []LogFile            // The log files when the import started.
+	paths    map[time.Time]string // The file that each earlier day's records go to.
+	day      time.Time            // The day of the batch (zero until a record has a time).
+	batch    []byte               // The records waiting to be written.
+	count    int                  // The number of records in the batch.
+	written  int                  // The number of records written so far.
+}
+
+// flush writes the batch to the log file for its day.
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	if err := imp.dw.importBatch(imp.day, imp.batch, imp.path); err != nil {
+		return fmt.Errorf("ImportStream: %w", err)
+	}
+	imp.written += imp.count
+	imp.batch = imp.batch[:0]
+	imp.count = 0
+
+	return nil
+}
+
+// path returns the path name of the log file that the records for the given
+// earlier day go to.
+func (imp *importer) path(day time.Time) string {
+	if pathname, ok := imp.paths[day]; ok {
+		return pathname
+	}
+
+	// Append to the day's last file, unless it's finished with.
+	pathname := imp.dw.getLogPathname(day, 0)
+	for _, logFile := range imp.logFiles {
+		if !logFile.Date.Equal(day) {
+			continue
+		}
+		if logFile.Cold || logFile.Compressed {
+			pathname = imp.dw.getLogPathname(day, logFile.Sequence+1)
+		} else {
+			pathname = logFile.Pathname
+		}
+	}
+
+	imp.paths[day] = pathname
+	return pathname
+}
+
+// importBatch writes a batch of records for the given day.  If the day is before
+// the current log file's, the batch is appended to the file given by pathFor,
+// and otherwise it's written with Write.
+func (dw *Writer) importBatch(day time.Time, batch []byte, pathFor func(time.Time) string) error {
+	dw.logMutex.Lock()
+	if dw.closed {
+		dw.logMutex.Unlock()
+		return ErrClosed
+	}
+	if day.IsZero() || !day.Before(dw.startOfToday) {
+		dw.logMutex.Unlock()
+		_, err := dw.Write(batch)
+		return err
+	}
+	defer dw.logMutex.Unlock()
+
+	pathname := pathFor(day)
+	dw.createParent(dw.logDir, pathname)
+	_, err := dw.files.write(pathname, batch)
+	return err
+}
+
+// syncImported is a helper function for ImportStream that flushes the files for
+// earlier days to the disk.
+func (dw *Writer) syncImported() error {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if err := dw.files.sync(); err != nil {
+		return fmt.Errorf("ImportStream: %w", err)
+	}
+	return nil
+}
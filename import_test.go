@@ -0,0 +1,104 @@
+package dailylogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestImportStream checks that ImportStream sends each record to the file for
+// its day, appending to an existing file, creating a missing one and writing the
+// records for the current day and later to the current file.
+func TestImportStream(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-02-10.bar", []byte("old\n"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	writer := New(now, ".", "foo.", ".bar", withClock(newFakeClock(now)))
+	defer writer.Close()
+
+	// Each record starts with its time, apart from the continuation lines.
+	extractor := func(record []byte) time.Time {
+		stamp, _, _ := strings.Cut(string(record), " ")
+		t, err := time.Parse(time.RFC3339, stamp)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	}
+	input := "2020-02-10T23:00:00Z a\n" +
+		"2020-02-11T02:00:00+05:00 b\n" +
+		"  continued\n" +
+		"2020-02-12T08:00:00Z c\n" +
+		"2020-02-14T09:00:00Z d\n" +
+		"2020-02-16T09:00:00Z e\n" +
+		"2020-02-12T09:00:00Z f"
+
+	n, err := writer.ImportStream(strings.NewReader(input), extractor)
+	if err != nil || n != 7 {
+		t.Fatalf("want 7 records, got %d, %v", n, err)
+	}
+	writer.Sync()
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		// 02:00 +05:00 on the 11th is 21:00 UTC on the 10th.
+		{"foo.2020-02-10.bar", "old\n2020-02-10T23:00:00Z a\n2020-02-11T02:00:00+05:00 b\n  continued\n"},
+		{"foo.2020-02-12.bar", "2020-02-12T08:00:00Z c\n2020-02-12T09:00:00Z f"},
+		{"foo.2020-02-14.bar", "2020-02-14T09:00:00Z d\n2020-02-16T09:00:00Z e\n"},
+	}
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want %q, got %q", td.filename, td.want, contents)
+		}
+	}
+}
+
+// TestImportStreamRefused checks that ImportStream refuses when the Writer
+// transforms its records or has been closed.
+func TestImportStreamRefused(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	extractor := func([]byte) time.Time { return now }
+
+	writer := New(now, ".", "foo.", ".bar", WithHashChain())
+	_, err = writer.ImportStream(strings.NewReader("a\n"), extractor)
+	if err == nil || !strings.Contains(err.Error(), "WithHashChain") {
+		t.Errorf("want an error naming WithHashChain, got %v", err)
+	}
+	writer.Close()
+
+	writer = New(now, ".", "baz.", ".bar")
+	writer.Close()
+	if _, err := writer.ImportStream(strings.NewReader("a\n"), extractor); err != ErrClosed {
+		t.Errorf("want ErrClosed, got %v", err)
+	}
+}
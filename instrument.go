@@ -0,0 +1,60 @@
+package dailylogger
+
+import "time"
+
+// The names of the operations passed to Instrumentation.StartOperation.
+const (
+	OperationRotate = "rotate" // Closing the log file and opening the next one.
+	OperationReopen = "reopen" // Closing the log file and opening it again.
+)
+
+// Instrumentation receives measurements from the Writer, for example to feed a
+// metrics or tracing system.  The methods are called while the Writer's lock is
+// held, so they must be quick and must not call the Writer's methods.  The
+// dailyotel module contains an implementation for OpenTelemetry.
+type Instrumentation interface {
+	// Wrote is called after each write to the log file with the number of bytes
+	// written and the error, if any.
+	Wrote(n int, err error)
+
+	// StartOperation is called at the start of an operation such as rotation.  It
+	// returns a function that is called when the operation ends, with the error
+	// if it failed.
+	StartOperation(name string) func(err error)
+}
+
+// WithInstrumentation supplies an Instrumentation that receives measurements from
+// the Writer.
+func WithInstrumentation(instrumentation Instrumentation) Option {
+	return func(dw *Writer) {
+		dw.instrumentation = instrumentation
+	}
+}
+
+// startOperation tells the Instrumentation, if there is one, that an operation
+// is starting and returns the function to call when it ends.  That function also
+// records the time taken.
+func (dw *Writer) startOperation(name string) func(error) {
+	start := time.Now()
+
+	end := func(error) {}
+	if dw.instrumentation != nil {
+		end = dw.instrumentation.StartOperation(name)
+	}
+
+	return func(err error) {
+		dw.stats.RotationLatency.observe(time.Since(start))
+		dw.lastRotationError = err
+		end(err)
+	}
+}
+
+// openError returns an error if the log file isn't open.  It doesn't apply the
+// lock, so it should only be called by a function that does.
+func (dw *Writer) openError() error {
+	if dw.logWriter == nil && !dw.creationPending {
+		return errNoFile
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package dailylogger
+
+import (
+	"testing"
+	"time"
+)
+
+// recordingInstrumentation records the measurements that it receives.
+type recordingInstrumentation struct {
+	bytes      int
+	operations []string
+	errors     []error
+}
+
+func (ri *recordingInstrumentation) Wrote(n int, err error) {
+	ri.bytes += n
+}
+
+func (ri *recordingInstrumentation) StartOperation(name string) func(error) {
+	ri.operations = append(ri.operations, name)
+	return func(err error) { ri.errors = append(ri.errors, err) }
+}
+
+// TestInstrumentation checks that the Instrumentation hears about writes and
+// operations.
+func TestInstrumentation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var ri recordingInstrumentation
+	writer := New(now, ".", "foo.", ".bar", WithInstrumentation(&ri))
+	defer writer.Close()
+
+	writer.Write([]byte("hello"))
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+	writer.Reopen()
+	writer.Write([]byte("world!"))
+
+	if ri.bytes != 11 {
+		t.Errorf("want 11 bytes got %d", ri.bytes)
+	}
+
+	wantOperations := []string{OperationRotate, OperationReopen}
+	if len(ri.operations) != len(wantOperations) {
+		t.Errorf("want %v got %v", wantOperations, ri.operations)
+		return
+	}
+	for i := range wantOperations {
+		if ri.operations[i] != wantOperations[i] {
+			t.Errorf("want %v got %v", wantOperations, ri.operations)
+		}
+		if ri.errors[i] != nil {
+			t.Errorf("%s: want no error got %v", ri.operations[i], ri.errors[i])
+		}
+	}
+}
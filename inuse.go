@@ -0,0 +1,129 @@
+package dailylogger
+
+import (
+	"os"
+	"time"
+)
+
+// inUseInterval is how often compression checks whether a log file that another
+// program is using has been let go (see WithInUseCheck).
+const inUseInterval = time.Minute
+
+// WithInUseCheck makes the Writer leave alone a finished log file that another
+// program may still be using, for example a slow uploader.  The retention rules,
+// the emergency purge, the cold tier and the monthly bundles skip a file that
+// was modified less than the given grace period ago or that another process has
+// open, and try again at their next check.  Compression (see WithCompression)
+// waits until the file is free.
+//
+// A program can claim a file by opening it with OpenShared, which takes a
+// shared lock on it.  That works everywhere.  Under Linux the Writer also looks
+// for the file among the open files of the processes it can see, as fuser does,
+// and under Windows it tries to open the file for its own exclusive use, so a
+// program that doesn't cooperate is usually spotted too.  A grace period of zero
+// relies on those checks alone.  Under Linux the open files are gathered once
+// at the start of each check, before the Writer takes its lock, so a file
+// opened during the check may be missed until the next one.
+func WithInUseCheck(grace time.Duration) Option {
+	return func(dw *Writer) {
+		dw.inUseCheck = true
+		dw.inUseGrace = max(grace, 0)
+	}
+}
+
+// OpenShared opens a log file for reading and takes a shared lock on it.  While
+// the file is open, a Writer with WithInUseCheck won't delete, move or compress
+// it.  Closing the file releases the lock.
+func OpenShared(pathname string) (*os.File, error) {
+	file, err := os.Open(longPath(pathname))
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFileShared(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// openFileSet holds the files that other processes had open when they were last
+// scanned (see scanOpenFiles), by device and inode.  It's nil on the systems
+// that check each file as it's needed.
+type openFileSet map[fileKey]bool
+
+// fileKey identifies a file by its device and inode.
+type fileKey struct {
+	device uint64
+	inode  uint64
+}
+
+// refreshInUse scans the files that other processes have open, if
+// WithInUseCheck is in force, and keeps the result for the checks made by the
+// retention rules, the emergency purge, the cold tier and the monthly bundles
+// while they hold the lock.  The scan can take a while, so it's made once at the
+// start of each pass and without the lock, so that writing isn't held up.  It
+// applies the lock, so it should only be called by a function that doesn't
+// hold it.
+func (dw *Writer) refreshInUse() {
+	if !dw.inUseCheck {
+		return
+	}
+
+	open := scanOpenFiles()
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+	dw.inUse = open
+}
+
+// busy returns true if WithInUseCheck is in force and another program may be
+// using the file.  The set holds the files that other processes have open (see
+// scanOpenFiles).  It uses nothing that the lock protects, so the caller needn't
+// hold it.  A file that can't be examined is assumed to be free, so that
+// retention still deletes it if it's damaged.
+func (dw *Writer) busy(pathname string, open openFileSet) bool {
+	if !dw.inUseCheck {
+		return false
+	}
+
+	info, err := os.Stat(longPath(pathname))
+	if err != nil {
+		return false
+	}
+	if dw.now().Sub(info.ModTime()) < dw.inUseGrace {
+		// The file was written recently.
+		return true
+	}
+
+	file, err := os.Open(longPath(pathname))
+	if err != nil {
+		return false
+	}
+	locked, err := tryLockFile(file)
+	file.Close()
+	if err == nil && !locked {
+		// Somebody has claimed the file with OpenShared.
+		return true
+	}
+
+	return openElsewhere(pathname, info, open)
+}
+
+// waitUntilFree is a helper function for compress that waits until no other
+// program is using the file (see WithInUseCheck).  It returns false if the
+// Writer is closed first.
+func (dw *Writer) waitUntilFree(pathname string) bool {
+	for {
+		if !dw.busy(pathname, scanOpenFiles()) {
+			return true
+		}
+
+		select {
+		case <-dw.clock.After(inUseInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return false
+		}
+	}
+}
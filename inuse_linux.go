@@ -0,0 +1,62 @@
+//go:build linux
+
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// scanOpenFiles returns the files that the processes in /proc have open,
+// skipping any that it's not allowed to see.  It looks at every open file of
+// every process, so it can take a while on a busy system.
+func scanOpenFiles() openFileSet {
+	open := make(openFileSet)
+
+	processes, err := os.ReadDir("/proc")
+	if err != nil {
+		return open
+	}
+
+	for _, process := range processes {
+		if _, err := strconv.Atoi(process.Name()); err != nil {
+			continue
+		}
+
+		fdDir := "/proc/" + process.Name() + "/fd"
+		descriptors, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, descriptor := range descriptors {
+			target, err := os.Stat(fdDir + "/" + descriptor.Name())
+			if err != nil {
+				continue
+			}
+			if key, ok := keyOf(target); ok {
+				open[key] = true
+			}
+		}
+	}
+
+	return open
+}
+
+// openElsewhere returns true if the file is among the open files found by
+// scanOpenFiles.
+func openElsewhere(pathname string, info fs.FileInfo, open openFileSet) bool {
+	key, ok := keyOf(info)
+	return ok && open[key]
+}
+
+// keyOf returns the device and inode of a file.
+func keyOf(info fs.FileInfo) (fileKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+
+	return fileKey{device: uint64(stat.Dev), inode: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !windows
+
+package dailylogger
+
+import "io/fs"
+
+// scanOpenFiles returns nil on this system, which has no cheap way to find out
+// which files other processes have open.
+func scanOpenFiles() openFileSet {
+	return nil
+}
+
+// openElsewhere returns false on this system.  A program can still claim the
+// file with OpenShared.
+func openElsewhere(pathname string, info fs.FileInfo, open openFileSet) bool {
+	return false
+}
@@ -0,0 +1,145 @@
+package dailylogger
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestInUseCheck checks that the retention rules skip a file that was modified
+// recently and one that has been claimed with OpenShared.
+func TestInUseCheck(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	names := []string{"foo.2020-02-10.bar", "foo.2020-02-11.bar", "foo.2020-02-12.bar"}
+	for _, name := range names {
+		os.WriteFile(name, []byte("x\n"), 0644)
+		os.Chtimes(name, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1))
+	}
+
+	// The file for the 11th is claimed and the one for the 12th was written ten
+	// minutes ago.
+	held, err := OpenShared(names[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+	os.Chtimes(names[2], now.Add(-10*time.Minute), now.Add(-10*time.Minute))
+
+	writer := New(now, ".", "foo.", ".bar",
+		withClock(newFakeClock(now)),
+		WithMaxAge(1), WithInUseCheck(time.Hour),
+		WithRetentionDryRun(func([]Deletion) {}))
+	defer writer.Close()
+
+	plan := mustPlan(t, writer)
+	if len(plan) != 1 || plan[0].Name != names[0] {
+		t.Errorf("want only %s, got %v", names[0], plan)
+	}
+
+	// Once the file is let go, it's fair game.
+	held.Close()
+	plan = mustPlan(t, writer)
+	if len(plan) != 2 || plan[1].Name != names[1] {
+		t.Errorf("want %s and %s, got %v", names[0], names[1], plan)
+	}
+}
+
+// TestBusyOpenElsewhere checks that, under Linux and Windows, a file that is
+// open but hasn't been claimed with OpenShared counts as busy.
+func TestBusyOpenElsewhere(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("open files can't be found on this system")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const name = "foo.2020-02-10.bar"
+	os.WriteFile(name, []byte("x\n"), 0644)
+
+	writer := New(time.Now(), ".", "foo.", ".bar", WithInUseCheck(0))
+	defer writer.Close()
+
+	if writer.busy(name, scanOpenFiles()) {
+		t.Error("want the file free")
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !writer.busy(name, scanOpenFiles()) {
+		t.Error("want the open file busy")
+	}
+	file.Close()
+}
+
+// TestPlanRetentionOpenElsewhere checks that, under Linux and Windows, the
+// retention rules skip a file that is open but hasn't been claimed with
+// OpenShared.
+func TestPlanRetentionOpenElsewhere(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("open files can't be found on this system")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	names := []string{"foo.2020-02-10.bar", "foo.2020-02-11.bar"}
+	for _, name := range names {
+		os.WriteFile(name, []byte("x\n"), 0644)
+		os.Chtimes(name, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1))
+	}
+
+	file, err := os.Open(names[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := New(now, ".", "foo.", ".bar",
+		withClock(newFakeClock(now)),
+		WithMaxAge(1), WithInUseCheck(0),
+		WithRetentionDryRun(func([]Deletion) {}))
+	defer writer.Close()
+
+	plan := mustPlan(t, writer)
+	if len(plan) != 1 || plan[0].Name != names[0] {
+		t.Errorf("want only %s, got %v", names[0], plan)
+	}
+
+	// The open files are found again for each plan.
+	file.Close()
+	plan = mustPlan(t, writer)
+	if len(plan) != 2 {
+		t.Errorf("want %s and %s, got %v", names[0], names[1], plan)
+	}
+}
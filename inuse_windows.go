@@ -0,0 +1,36 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"errors"
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// scanOpenFiles returns nil under Windows, where each file is checked as it's
+// needed (see openElsewhere).
+func scanOpenFiles() openFileSet {
+	return nil
+}
+
+// openElsewhere returns true if a process has the file open.  It tries to open
+// the file without sharing it, which fails if anybody else has it open.
+func openElsewhere(pathname string, info fs.FileInfo, open openFileSet) bool {
+	name, err := windows.UTF16PtrFromString(longPath(pathname))
+	if err != nil {
+		return false
+	}
+
+	handle, err := windows.CreateFile(name, windows.GENERIC_READ, 0, nil,
+		windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if errors.Is(err, windows.ERROR_SHARING_VIOLATION) {
+		return true
+	}
+	if err == nil {
+		windows.CloseHandle(handle)
+	}
+
+	return false
+}
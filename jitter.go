@@ -0,0 +1,33 @@
+package dailylogger
+
+import "time"
+
+// WithRotationJitter delays each midnight rotation by a random duration between
+// zero and the given maximum.  When many collectors share a file server, this
+// spreads out the work that they do at the start of each day.  The datestamp of
+// the new file is still the new day but anything written between midnight and the
+// rotation goes into the old file.
+func WithRotationJitter(maximum time.Duration) Option {
+	return func(dw *Writer) {
+		if maximum > 0 {
+			dw.rotationJitter = maximum
+		}
+	}
+}
+
+// waitForJitter sleeps for a random duration up to the rotation jitter.  It
+// returns false if the Writer was closed while it was waiting.
+func (dw *Writer) waitForJitter() bool {
+	if dw.rotationJitter <= 0 {
+		return true
+	}
+
+	delay := time.Duration(dw.random(int64(dw.rotationJitter)))
+
+	select {
+	case <-dw.clock.After(delay):
+		return true
+	case <-dw.done:
+		return false
+	}
+}
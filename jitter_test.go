@@ -0,0 +1,52 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRotationJitter checks that with jitter enabled the rotation is delayed after
+// midnight but the new file still has the new day's datestamp.
+func TestRotationJitter(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename2 = "foo.2020-02-15.bar"
+	const jitter = 5 * time.Second
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	// The random delay is always the maximum minus one nanosecond.
+	maxRandom := func(n int64) int64 { return n - 1 }
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithRotationJitter(jitter),
+		func(dw *Writer) { dw.random = maxRandom })
+	defer writer.Close()
+
+	// Wake the rotator up just after midnight.  It should sleep again for the
+	// jitter delay.
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	fc.WaitForSleepers(2)
+
+	if _, err := os.Stat(wantFilename2); err == nil {
+		t.Errorf("want no rotation during the jitter delay but %s exists", wantFilename2)
+		return
+	}
+
+	fc.Advance(jitter)
+	fc.WaitForSleepers(3)
+
+	if _, err := os.Stat(wantFilename2); err != nil {
+		t.Errorf("want %s to exist - %v", wantFilename2, err)
+	}
+}
@@ -0,0 +1,246 @@
+package dailylogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// journalPreallocation is the space reserved for the journal when it's created,
+// so that writing to it doesn't have to allocate space on the disk.
+const journalPreallocation = 1 << 20
+
+// The kinds of record in the journal.
+const (
+	journalMark  = 'M' // The log file and its size when the journal was cleared.
+	journalWrite = 'W' // The data from a Write call.
+)
+
+// WithJournal protects the data held in memory in buffered and asynchronous
+// modes (see WithBuffering and WithAsync) against a crash.  Write copies the data
+// to a journal file in the log directory before buffering or queuing it.  The
+// journal is cleared whenever everything has been written to the log file, that
+// is when Sync, Checkpoint, Rotate or Reopen is called, at rotation and when
+// the Writer is closed, so a program that writes a lot should call Sync from
+// time to time to keep the journal small.
+//
+// If the program crashes, the journal is replayed when the Writer is next
+// created.  The log file that was current when the journal was last cleared is
+// truncated to the size that it had then and the data in the journal is appended
+// to it, so each write appears exactly once, even if the crash happened the day
+// before.  Anything written to the log file by the Writer itself since the
+// journal was cleared, such as a summary line, is lost.  When the journal has
+// been replayed, the Writer emits an EventJournalReplayed.
+//
+// The journal has the given name, or if the name is empty the leader followed by
+// "journal", so with the defaults it's "daily.journal".  The data reaches the
+// journal before Write returns, so it survives the program crashing.  To survive
+// a power failure too, use WithWriteThrough, which applies to the journal as
+// well.  If the data can't be written to the journal, Write returns an error and
+// the data isn't logged.  The option has no effect unless buffering or
+// asynchronous mode is enabled.
+func WithJournal(name string) Option {
+	return func(dw *Writer) {
+		dw.journalName = name
+		if len(dw.journalName) == 0 {
+			dw.journalName = dw.leader + "journal"
+		}
+	}
+}
+
+// openJournal is a helper function for newWriter that replays any journal left
+// by a crash and then clears it, ready for use.
+func (dw *Writer) openJournal() {
+	if dw.bufferSize == 0 && dw.queueLength == 0 {
+		// Nothing is held in memory, so there's no need for a journal.
+		return
+	}
+
+	pathname := dw.logDir + "/" + dw.journalName
+	flag := os.O_RDWR | os.O_CREATE
+	if dw.writeThrough {
+		flag |= writeThroughFlag
+	}
+	var file *os.File
+	var err error
+	dw.withUmask(func() {
+		file, err = openLogFile(pathname, flag, 0600)
+	})
+	if err != nil {
+		dw.reportError(withClass(ErrFileOpen, fmt.Errorf("dailylogger: cannot open journal %s - %w", pathname, err)))
+		return
+	}
+
+	if err := dw.replayJournal(file); err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error replaying journal %s - %w", pathname, err))
+	}
+
+	dw.journal = file
+	dw.markJournal()
+
+	dw.closers = append(dw.closers, func() error {
+		// Everything has been written to the log file.
+		err := dw.journal.Truncate(0)
+		if ce := dw.journal.Close(); ce != nil && err == nil {
+			err = ce
+		}
+		dw.journal = nil
+		return err
+	})
+}
+
+// journalData is a helper function for Write that adds the buffer to the
+// journal.  The caller must hold the lock that orders the writes, which is the
+// queue lock in asynchronous mode and the log lock otherwise.
+func (dw *Writer) journalData(buffer []byte) error {
+	// The frame is built in place: the length, the type, the data and the CRC.
+	scratch := binary.BigEndian.AppendUint32(dw.journalScratch[:0], uint32(len(buffer)+1))
+	scratch = append(scratch, journalWrite)
+	scratch = append(scratch, buffer...)
+	scratch = binary.BigEndian.AppendUint32(scratch, crc32.ChecksumIEEE(scratch))
+	if cap(scratch) <= maxPooledBuffer {
+		// Keep the memory for next time, unless it's big.
+		dw.journalScratch = scratch
+	}
+
+	_, err := dw.journal.Write(scratch)
+	return err
+}
+
+// markJournal clears the journal and records the current log file and its size
+// in it.  It's called when everything written so far is in the log file.  Any
+// held back partial record isn't, so that goes into the journal again.  It must
+// only be called while the Writer is fully locked (see barrier).
+func (dw *Writer) markJournal() {
+	err := dw.journal.Truncate(0)
+	if err == nil {
+		_, err = dw.journal.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error clearing journal - %w", err))
+		return
+	}
+
+	// Truncating the journal releases the space reserved for it.
+	preallocate(dw.journal, dw.journal.Name(), journalPreallocation)
+
+	if dw.logFile == nil {
+		// Nothing can be written to the log, so there's nothing to recover.
+		return
+	}
+
+	info, err := dw.logFile.Stat()
+	if err == nil {
+		record := binary.BigEndian.AppendUint64([]byte{journalMark}, uint64(info.Size()))
+		record = append(record, dw.pathname...)
+		_, err = dw.journal.Write(appendFrame(nil, record))
+	}
+	if err == nil && len(dw.partialLine) > 0 {
+		err = dw.journalData(dw.partialLine)
+	}
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error writing to the journal - %w", err))
+	}
+}
+
+// replayJournal is a helper function for openJournal that writes any data in the
+// journal to the log file that it belongs in.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) replayJournal(journal *os.File) error {
+	info, err := journal.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	// A crash may have left a torn record at the end.
+	good, err := findTornTail(journal, info.Size())
+	if err != nil {
+		return err
+	}
+	contents := make([]byte, good)
+	if _, err := journal.ReadAt(contents, 0); err != nil {
+		return err
+	}
+
+	var pathname string
+	var size int64 = -1
+	var writes [][]byte
+	for len(contents) > 0 {
+		length := binary.BigEndian.Uint32(contents)
+		record := contents[4 : 4+length]
+		contents = contents[frameOverhead+length:]
+
+		switch {
+		case len(record) >= 9 && record[0] == journalMark:
+			size = int64(binary.BigEndian.Uint64(record[1:9]))
+			pathname = string(record[9:])
+		case len(record) >= 1 && record[0] == journalWrite:
+			writes = append(writes, record[1:])
+		}
+	}
+
+	if size < 0 || len(writes) == 0 || len(pathname) == 0 {
+		// There's nothing to replay.
+		return nil
+	}
+
+	// Remove anything written since the journal was last cleared, since the
+	// journal holds all of it, and then append the journal.
+	var replay bytes.Buffer
+	for _, write := range writes {
+		if dw.framing {
+			replay.Write(appendFrame(nil, write))
+		} else {
+			replay.Write(write)
+		}
+	}
+
+	if pathname == dw.pathname {
+		// The journal belongs to the current log file.  Close it while it's
+		// repaired and then open it again.
+		dw.closeLog()
+		defer dw.openLog()
+	}
+
+	if err := dw.appendAt(pathname, size, replay.Bytes()); err != nil {
+		return err
+	}
+
+	dw.emit(Event{
+		Type: EventJournalReplayed,
+		Path: pathname,
+		Err:  fmt.Errorf("%d writes recovered from the journal", len(writes)),
+	})
+
+	return nil
+}
+
+// appendAt truncates the file to the given size, unless it's already smaller,
+// and then appends the data.
+func (dw *Writer) appendAt(pathname string, size int64, data []byte) error {
+	file, err := openLogFile(pathname, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < size {
+		size = info.Size()
+	}
+	if err := file.Truncate(size); err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt(data, size); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
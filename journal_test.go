@@ -0,0 +1,104 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestJournal checks that data lost from memory by a crash is recovered from
+// the journal exactly once, even if part of it reached the log file.
+func TestJournal(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const logName = "foo.2020-02-14.bar"
+	const journalName = "foo.journal"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithBuffering(16), WithJournal(""))
+	writer.Write([]byte("synced "))
+	writer.Sync()
+
+	// This is flushed part of the way through, when the buffer fills up.
+	writer.Write([]byte("lost in "))
+	writer.Write([]byte("the crash"))
+
+	// Save the state of the files at the time of the "crash".
+	crashLog, _ := os.ReadFile(logName)
+	crashJournal, _ := os.ReadFile(journalName)
+	if string(crashLog) == "synced lost in the crash" {
+		t.Fatal("want some of the data to be in the buffer at the time of the crash")
+	}
+
+	writer.Close()
+	if info, err := os.Stat(journalName); err != nil || info.Size() != 0 {
+		t.Errorf("want an empty journal after Close, got %v %v", info, err)
+	}
+
+	os.WriteFile(logName, crashLog, 0644)
+	os.WriteFile(journalName, crashJournal, 0600)
+
+	var events []Event
+	handler := func(event Event) { events = append(events, event) }
+	writer = New(now, ".", "foo.", ".bar", WithBuffering(16), WithJournal(""), WithEventHandler(handler))
+	writer.Write([]byte(" and after"))
+	writer.Close()
+
+	if len(events) != 1 || events[0].Type != EventJournalReplayed {
+		t.Errorf("want one EventJournalReplayed, got %v", events)
+	}
+
+	contents, err := os.ReadFile(logName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "synced lost in the crash and after"
+	if string(contents) != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, string(contents))
+	}
+}
+
+// TestJournalAsync checks that the journal clears when the queue is written
+// out, and that it's not used when nothing is held in memory.
+func TestJournalAsync(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithAsync(10), WithJournal("async.journal"))
+	writer.Write([]byte("hello"))
+	before, _ := os.Stat("async.journal")
+	writer.Sync()
+	after, _ := os.Stat("async.journal")
+	writer.Close()
+
+	if before == nil || after == nil || after.Size() >= before.Size() {
+		t.Errorf("want the journal to shrink when the queue is written out, got %v then %v", before, after)
+	}
+
+	synchronous := New(now, ".", "sync.", ".bar", WithJournal(""))
+	synchronous.Write([]byte("hello"))
+	synchronous.Close()
+	if _, err := os.Stat("sync.journal"); !os.IsNotExist(err) {
+		t.Errorf("want no journal for an unbuffered Writer, got %v", err)
+	}
+}
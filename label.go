@@ -0,0 +1,54 @@
+package dailylogger
+
+import "log"
+
+// FileLabeler applies a security label, such as an SELinux context, to a file or
+// directory.  SELinuxLabeler is an implementation.  Others can be supplied by the
+// application, for example one that uses a full SELinux library to look up the
+// default context for the path.
+type FileLabeler interface {
+	// Label applies the label to the given file or directory.
+	Label(pathname string) error
+}
+
+// WithFileLabeler supplies a FileLabeler that the Writer uses to label the log
+// directory when it's created and each log file when it's opened, including after
+// rotation.  If labelling fails, the Writer emits an EventLabelFailed and carries
+// on.
+func WithFileLabeler(labeler FileLabeler) Option {
+	return func(dw *Writer) {
+		dw.labeler = labeler
+	}
+}
+
+// SELinuxLabeler is a FileLabeler that sets the SELinux context of a file by
+// writing the security.selinux extended attribute, which is what chcon does.  For
+// example:
+//
+//	WithFileLabeler(SELinuxLabeler{Context: "system_u:object_r:var_log_t:s0"})
+//
+// It only works under Linux on a filesystem that supports extended attributes,
+// and the process must be allowed to relabel files.  Under Windows, Label returns
+// an error.
+type SELinuxLabeler struct {
+	Context string // The SELinux context, for example "system_u:object_r:var_log_t:s0".
+}
+
+// Label sets the SELinux context of the given file or directory.
+func (sl SELinuxLabeler) Label(pathname string) error {
+	return setSELinuxContext(pathname, sl.Context)
+}
+
+// applyLabel labels the given file or directory using the FileLabeler, if there
+// is one.
+func (dw *Writer) applyLabel(pathname string) {
+	if dw.labeler == nil {
+		return
+	}
+
+	err := dw.labeler.Label(pathname)
+	if err != nil {
+		log.Printf("applyLabel: error labelling %s - %v\n", pathname, err)
+		dw.emit(Event{Type: EventLabelFailed, Path: pathname, Err: err})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// selinuxAttribute is the extended attribute that holds a file's SELinux context.
+const selinuxAttribute = "security.selinux"
+
+// setSELinuxContext sets the SELinux context of the given file or directory.
+func setSELinuxContext(pathname, context string) error {
+	// The kernel expects the context to be null terminated.
+	err := unix.Setxattr(pathname, selinuxAttribute, append([]byte(context), 0), 0)
+	if err != nil {
+		return &fs.PathError{Op: "setxattr", Path: pathname, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package dailylogger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeLabeler records the files that it's asked to label.
+type fakeLabeler struct {
+	labelled []string
+	err      error
+}
+
+// Label records the path name and returns the configured error.
+func (fl *fakeLabeler) Label(pathname string) error {
+	fl.labelled = append(fl.labelled, pathname)
+	return fl.err
+}
+
+// TestFileLabeler checks that the labeler is applied to the log directory and to
+// each log file, including after rotation.
+func TestFileLabeler(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	wantLabelled := []string{"logs", "logs/foo.2020-02-14.bar", "logs/foo.2020-02-15.bar"}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	labeler := fakeLabeler{}
+	writer := New(now, "logs", "foo.", ".bar", WithFileLabeler(&labeler))
+	defer writer.Close()
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+
+	if len(labeler.labelled) != len(wantLabelled) {
+		t.Errorf("want %v got %v", wantLabelled, labeler.labelled)
+		return
+	}
+
+	for i := range wantLabelled {
+		if labeler.labelled[i] != wantLabelled[i] {
+			t.Errorf("want %s got %s", wantLabelled[i], labeler.labelled[i])
+		}
+	}
+}
+
+// TestFileLabelerFailure checks that a labelling failure produces an event.
+func TestFileLabelerFailure(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var events []Event
+	labeler := fakeLabeler{err: errors.New("permission denied")}
+	writer := New(now, "logs", "foo.", ".bar", WithFileLabeler(&labeler),
+		WithEventHandler(func(e Event) { events = append(events, e) }))
+	defer writer.Close()
+
+	if len(events) != 2 {
+		t.Errorf("want 2 events got %d", len(events))
+		return
+	}
+
+	for _, event := range events {
+		if event.Type != EventLabelFailed {
+			t.Errorf("want %v got %v", EventLabelFailed, event.Type)
+		}
+	}
+}
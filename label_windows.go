@@ -0,0 +1,10 @@
+//go:build windows
+
+package dailylogger
+
+import "io/fs"
+
+// setSELinuxContext always fails because Windows has no SELinux.
+func setSELinuxContext(pathname, context string) error {
+	return &fs.PathError{Op: "setSELinuxContext", Path: pathname, Err: errWindows}
+}
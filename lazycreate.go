@@ -0,0 +1,78 @@
+package dailylogger
+
+import (
+	"os"
+)
+
+// WithLazyCreation makes the Writer put off creating each log file until there is
+// something to write to it, so that a program that creates a Writer just in case
+// and never writes to it leaves no empty files behind.  New doesn't create the
+// log directory either, unless another option needs it from the start, such as
+// WithLockFile, WithJournal, WithStateFile, WithRecordSequence or
+// WithMetaLog.  Rotation closes the current file, if it was created, and
+// the next file is created by the first write after that.  The artifacts (see
+// WithArtifact) are only made from files that were created.  Until the file is
+// created, Health and the rotation counts treat the log as open.  A file that
+// already exists, for example after a restart, is opened as usual.  The option
+// has no effect on a stream (see WithStream) or with WithExclusiveFiles.
+func WithLazyCreation() Option {
+	return func(dw *Writer) {
+		dw.lazyCreation = true
+	}
+}
+
+// needsDirectoryAtStart returns true if New must create the log directory, which
+// is true unless file creation is lazy and nothing else goes there at the start.
+func (dw *Writer) needsDirectoryAtStart() bool {
+	return !dw.lazyCreation || len(dw.lockFileName) > 0 || len(dw.journalName) > 0 ||
+		len(dw.stateFile) > 0 || len(dw.metaLeader) > 0
+}
+
+// deferCreation is a helper function for openLog that sets things up so that
+// the log file is created by the first write.  It returns false if the file
+// should be opened now, because creation isn't lazy or the file already exists.
+// It doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) deferCreation() bool {
+	dw.creationPending = false
+	if !dw.lazyCreation || dw.stream || dw.exclusiveFiles {
+		return false
+	}
+
+	pathname := dw.getLogPathname(dw.startOfToday, dw.sequence)
+	if dw.compressLive && dw.fileFactory == nil {
+		pathname += dw.compressor.Extension()
+	}
+	if _, err := os.Stat(longPath(pathname)); err == nil {
+		return false
+	}
+
+	dw.pathname = pathname
+	dw.creationPending = true
+	dw.sink.SwitchTo(pendingFile{dw})
+	return true
+}
+
+// pendingFile takes the place of the log file until it's created (see
+// WithLazyCreation).  The hex dump and the mirror are set up with the file, so
+// they are put off too.
+type pendingFile struct {
+	dw *Writer
+}
+
+// Write creates the log file and then writes the buffer to it.  It's called with
+// the lock held.
+func (pf pendingFile) Write(buffer []byte) (int, error) {
+	dw := pf.dw
+	dw.creationPending = false
+
+	if !dw.needsDirectoryAtStart() {
+		dw.createDirectory(dw.logDir, dw.logDirPermissions)
+		dw.applyOwnership(dw.logDir)
+		dw.applyLabel(dw.logDir)
+	}
+
+	// This switches the sink away from the pendingFile, so the write can't come
+	// back here.
+	dw.openLogFile()
+	return dw.sink.Write(buffer)
+}
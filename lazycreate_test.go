@@ -0,0 +1,90 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestLazyCreation checks that neither the log directory nor a log file is
+// created until something is written, and that no artifact is made from a file
+// that was never created.
+func TestLazyCreation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	dayAfter := now.AddDate(0, 0, 2)
+
+	writer := New(now, "logs", "foo.", ".bar", WithLazyCreation(), WithArtifact(upperCaseArtifact{}))
+
+	if _, err := os.Stat("logs"); err == nil {
+		t.Error("want no log directory before the first write")
+	}
+	if err := writer.Health(); err != nil {
+		t.Errorf("want a healthy Writer before the first write got %v", err)
+	}
+
+	writer.Write([]byte("hello\n"))
+	writer.rotateLogs(tomorrow)
+	writer.rotateLogs(dayAfter)
+	writer.Write([]byte("again\n"))
+	writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 2 || logFiles[0].Name != "foo.2020-02-14.bar" || logFiles[1].Name != "foo.2020-02-16.bar" {
+		t.Errorf("want files for the 14th and the 16th got %v", logFiles)
+	}
+
+	if _, err := os.Stat("logs/foo.2020-02-14.bar.upper"); err != nil {
+		t.Errorf("want the artifact for the 14th - %v", err)
+	}
+	if _, err := os.Stat("logs/foo.2020-02-15.bar.upper"); err == nil {
+		t.Error("want no artifact for the 15th")
+	}
+
+	contents, _ := os.ReadFile("logs/foo.2020-02-16.bar")
+	if string(contents) != "again\n" {
+		t.Errorf("want \"again\\n\" got \"%s\"", string(contents))
+	}
+}
+
+// TestLazyCreationNeverWritten checks that a Writer that's never written to
+// leaves nothing behind.
+func TestLazyCreationNeverWritten(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithLazyCreation(), WithBuffering(64))
+	if err := writer.Sync(); err != nil {
+		t.Errorf("want no error from Sync got %v", err)
+	}
+	writer.Close()
+
+	entries, _ := os.ReadDir(".")
+	if len(entries) != 0 {
+		t.Errorf("want no files got %v", entries)
+	}
+}
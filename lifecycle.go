@@ -0,0 +1,94 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WithLifecycleEvents makes the Writer send its lifecycle events, such as
+// EventFileOpened, EventRotated and EventFileDeleted, to the event handler and
+// the event channel as well as the other events.
+func WithLifecycleEvents() Option {
+	return func(dw *Writer) {
+		dw.lifecycleEvents = true
+	}
+}
+
+// WithEventChannel makes the Writer send its events to the channel as well as to
+// any event handler.  The Writer doesn't wait, so if the channel is full the
+// event is dropped.
+func WithEventChannel(events chan<- Event) Option {
+	return func(dw *Writer) {
+		dw.eventChannel = events
+	}
+}
+
+// WithMetaLog makes the Writer record all of its events, including the lifecycle
+// events, in a separate daily log in the log directory, so that there is a
+// record of when each log file was created, rotated and deleted.  The meta log
+// has the given leader and trailer, which must differ from the Writer's, and the
+// same permissions, owner and group as the log files.  Each event is a line of
+// JSON, for example:
+//
+//	{"time":"2020-02-14T00:00:00.000001Z","event":"Rotated","path":"./foo.2020-02-14.bar"}
+//
+// The meta log is rotated when an event arrives on a new day.
+func WithMetaLog(leader, trailer string) Option {
+	return func(dw *Writer) {
+		dw.metaLeader = leader
+		dw.metaTrailer = trailer
+	}
+}
+
+// metaRecord is the form of an event in the meta log.
+type metaRecord struct {
+	Time  string `json:"time"`
+	Event string `json:"event"`
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// openMetaLog is a helper function for newWriter that creates the meta log.
+func (dw *Writer) openMetaLog(now time.Time) {
+	dw.metaLog = newWriter(now, dw.logDir, dw.metaLeader, dw.metaTrailer, dw.userName, dw.groupName,
+		dw.logDirPermissions, dw.logFilePermissions,
+		withClock(dw.clock), WithLocation(dw.location), WithLazyRotation())
+}
+
+// emitLifecycle records a lifecycle event in the meta log, if there is one, and
+// sends it to the event handler and the event channel if lifecycle events were
+// requested.
+func (dw *Writer) emitLifecycle(event Event) {
+	if dw.metaLog == nil && !dw.lifecycleEvents {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = dw.now()
+	}
+
+	dw.writeMeta(event)
+	if dw.lifecycleEvents {
+		dw.deliver(event)
+	}
+}
+
+// writeMeta is a helper function that writes the event to the meta log, if
+// there is one.
+func (dw *Writer) writeMeta(event Event) {
+	if dw.metaLog == nil {
+		return
+	}
+
+	record := metaRecord{
+		Time:  event.Time.Format(time.RFC3339Nano),
+		Event: event.Type.String(),
+		Path:  event.Path,
+	}
+	if event.Err != nil {
+		record.Error = event.Err.Error()
+	}
+
+	line, _ := json.Marshal(record)
+	dw.metaLog.Write(append(line, '\n'))
+}
@@ -0,0 +1,85 @@
+package dailylogger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestLifecycleEvents checks that the lifecycle events are sent to the event
+// channel and recorded in the meta log.
+func TestLifecycleEvents(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 10)
+	writer := New(now, ".", "foo.", ".bar", withClock(fc),
+		WithLifecycleEvents(), WithEventChannel(events), WithMetaLog("meta.", ".log"))
+	writer.Rotate()
+	writer.Close()
+	close(events)
+
+	var want = []struct {
+		eventType EventType
+		path      string
+	}{
+		{EventFileOpened, "./foo.2020-02-14.bar"},
+		{EventFileClosed, "./foo.2020-02-14.bar"},
+		{EventFileOpened, "./foo.2020-02-14.1.bar"},
+		{EventRotated, "./foo.2020-02-14.1.bar"},
+		{EventFileClosed, "./foo.2020-02-14.1.bar"},
+	}
+
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d events got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Type != want[i].eventType || got[i].Path != want[i].path || !got[i].Time.Equal(now) {
+			t.Errorf("%d: want %v %s got %v", i, want[i].eventType, want[i].path, got[i])
+		}
+	}
+
+	metaFile, err := os.Open("meta.2020-02-14.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metaFile.Close()
+
+	scanner := bufio.NewScanner(metaFile)
+	records := 0
+	for i := 0; scanner.Scan(); i++ {
+		records++
+		var record metaRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Errorf("unexpected record %s", scanner.Text())
+			continue
+		}
+		if record.Event != want[i].eventType.String() || record.Path != want[i].path ||
+			record.Time != "2020-02-14T12:00:00Z" {
+			t.Errorf("%d: want %v %s got %s", i, want[i].eventType, want[i].path, scanner.Text())
+		}
+	}
+	if records != len(want) {
+		t.Errorf("want %d records in the meta log got %d", len(want), records)
+	}
+}
@@ -0,0 +1,229 @@
+package dailylogger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+)
+
+// maxPartialRecord is the length beyond which a partial record is no longer held
+// back but written out as it is, so that data that never completes a record,
+// such as binary data with no newlines, still reaches the log.
+const maxPartialRecord = 1 << 20
+
+// WithWholeLines guarantees that each newline-terminated line lands entirely in
+// one log file, the one that was current when the first byte of the line was
+// written.  Data after the last newline of a Write is held back until the rest
+// of the line arrives.  If the log is rotated in the meantime, the old file is
+// kept open until the line is complete and the line is appended to it, and to
+// its mirror and hex dump (see WithMirror and WithHexDump), so a file never
+// starts with the end of a line.  That includes a file that is compressed as
+// it's written (see WithCompressedLiveFile) or that comes from a FileFactory,
+// which is closed once the line has been written to it.
+// A partial line is also held back by Sync, but Close writes it out.  A partial
+// line is only held back until it's 1 MiB long, and then what there is of it is
+// written out, so a line longer than that may be split between files.  The
+// option can't be combined with WithRecordFraming or WithHashChain, which write
+// each Write as it is, and New reports an error and turns it off if either is
+// given.
+func WithWholeLines() Option {
+	return WithRecordSplitter(splitLines)
+}
+
+// WithRecordSplitter is like WithWholeLines but for records of any form, for
+// example RTCM3 messages (see SplitGNSS).  The split function finds the first
+// record in the data.  Only the advance that it returns is used and it's always
+// called with atEOF false.  It should return 0 if the data doesn't yet hold a
+// complete record.  Any junk before a record should be returned as a record of
+// its own so that it's written out.  If the split function returns an error, all
+// the data is written as it is.  Like WithWholeLines, it's turned off if
+// WithRecordFraming or WithHashChain is given.
+func WithRecordSplitter(split bufio.SplitFunc) Option {
+	return func(dw *Writer) {
+		if split != nil {
+			dw.splitter = split
+		}
+	}
+}
+
+// checkRecordSplitter is a helper function for newWriter that turns off
+// WithWholeLines and WithRecordSplitter when the records are framed or chained,
+// which take their place.
+func (dw *Writer) checkRecordSplitter() {
+	if dw.splitter == nil {
+		return
+	}
+
+	var conflict string
+	switch {
+	case dw.framing:
+		conflict = "WithRecordFraming"
+	case dw.hashChain:
+		conflict = "WithHashChain"
+	default:
+		return
+	}
+
+	err := fmt.Errorf("WithWholeLines: whole records can't be combined with %s - turning them off", conflict)
+	dw.reportError(err)
+	dw.recordStartupError(err)
+	dw.splitter = nil
+}
+
+// splitLines is the split function for WithWholeLines.  A record is a line
+// including its newline.
+func splitLines(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return 0, nil, nil
+	}
+
+	return i + 1, data[:i+1], nil
+}
+
+// writeRecords is a helper function for writeToLog that writes only complete
+// records to the log, holding back any partial record.  It doesn't apply the
+// lock, so it should only be called by a function that does.
+func (dw *Writer) writeRecords(buffer []byte) (int, error) {
+
+	data := buffer
+
+	if len(dw.partialLine) > 0 {
+		// The buffer continues the held back partial record.
+		dw.partialLine = append(dw.partialLine, buffer...)
+		advance := dw.nextRecord(dw.partialLine)
+		if advance == 0 {
+			// The record is still incomplete.
+			if err := dw.writeLongPartialLine(); err != nil {
+				return 0, err
+			}
+			return len(buffer), nil
+		}
+
+		data = dw.partialLine[advance:]
+		dw.partialLine = dw.partialLine[:advance]
+		if err := dw.writePartialLine(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Find the end of the last complete record.
+	end := 0
+	for end < len(data) {
+		advance := dw.nextRecord(data[end:])
+		if advance == 0 {
+			break
+		}
+		end += advance
+	}
+
+	if end > 0 {
+		if _, err := dw.sink.Write(data[:end]); err != nil {
+			return 0, err
+		}
+	}
+
+	dw.holdPartialLine(data[end:])
+	if err := dw.writeLongPartialLine(); err != nil {
+		return 0, err
+	}
+
+	return len(buffer), nil
+}
+
+// writeLongPartialLine is a helper function for writeRecords that writes out the
+// held back partial record as it is if it has grown longer than
+// maxPartialRecord.  It doesn't apply the lock, so it should only be called by a
+// function that does.
+func (dw *Writer) writeLongPartialLine() error {
+	if len(dw.partialLine) <= maxPartialRecord {
+		return nil
+	}
+
+	return dw.writePartialLine()
+}
+
+// nextRecord returns the length of the first complete record in the data, or 0
+// if there isn't one.
+func (dw *Writer) nextRecord(data []byte) int {
+	advance, _, err := dw.splitter(data, false)
+	if err != nil || advance > len(data) {
+		return len(data)
+	}
+
+	if advance < 0 {
+		return 0
+	}
+
+	return advance
+}
+
+// holdPartialLine is a helper function that adds the buffer to the held back
+// partial line.
+func (dw *Writer) holdPartialLine(buffer []byte) {
+	if len(buffer) == 0 {
+		return
+	}
+
+	if len(dw.partialLine) == 0 {
+		// This is the start of a line, so it belongs in the current log file.
+		dw.partialLinePath = dw.pathname
+	}
+
+	dw.partialLine = append(dw.partialLine, buffer...)
+}
+
+// writePartialLine is a helper function that writes the held back line (which
+// may or may not be complete) to the log file that it belongs in.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) writePartialLine() error {
+	if len(dw.partialLine) == 0 {
+		return nil
+	}
+
+	line := dw.partialLine
+	dw.partialLine = nil
+
+	if dw.partialLinePath == dw.pathname {
+		_, err := dw.sink.Write(line)
+		return err
+	}
+
+	// The log has been rotated since the line was started.  Append it to the old
+	// file, which is then finished with.
+	var err error
+	if dw.heldLog != nil && dw.heldLog.pathname == dw.partialLinePath {
+		_, err = dw.heldLog.dest.Write(line)
+		dw.closeHandles(*dw.heldLog)
+		dw.heldLog = nil
+	} else {
+		_, err = dw.files.write(dw.partialLinePath, line)
+	}
+	if re := dw.releaseArtifacts(); re != nil && err == nil {
+		err = re
+	}
+	return err
+}
+
+// finishLog is a helper function for rotation that closes the log file, unless a
+// partial record that was started in it is being held back.  Then the file is
+// kept open, with its buffer, its compressor or the writer from its FileFactory,
+// its mirror and its hex dump, until the record is complete, so the end of the
+// record goes through the same writers as the start (see writePartialLine).
+// It doesn't apply the lock, so it should only be called by a function that
+// does.
+func (dw *Writer) finishLog() {
+	if len(dw.partialLine) == 0 || dw.partialLinePath != dw.pathname || dw.logWriter == nil {
+		dw.closeLog()
+		return
+	}
+
+	handles := dw.takeHandles()
+	dw.sink.SwitchTo(nil)
+	if handles.buffer != nil {
+		if err := handles.buffer.Flush(); err != nil {
+			dw.reportError(fmt.Errorf("closeLog: error flushing %s - %w", handles.pathname, err))
+		}
+	}
+	dw.heldLog = &handles
+}
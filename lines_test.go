@@ -0,0 +1,327 @@
+package dailylogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWholeLines checks that with whole lines enabled a line that straddles a
+// rotation goes into the old file.
+func TestWholeLines(t *testing.T) {
+
+	// This test uses the filestore.
+
+	var testData = []struct {
+		description   string
+		options       []any
+		wantContents1 string
+		wantContents2 string
+	}{
+		{"split", nil, "hello ", "world\nbye\n"},
+		{"whole lines", []any{WithWholeLines()}, "hello world\n", "bye\n"},
+		{"whole lines async", []any{WithWholeLines(), WithAsync(4)}, "hello world\n", "bye\n"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				t.Errorf("createWorkingDirectory failed - %v", err)
+				return
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			const wantFilename1 = "foo.2020-02-14.bar"
+			const wantFilename2 = "foo.2020-02-15.bar"
+
+			locationUTC, _ := time.LoadLocation("UTC")
+			now := time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC)
+			tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+			writer := New(now, ".", "foo.", ".bar", td.options...)
+			writer.Write([]byte("hello "))
+			writer.rotateLogs(tomorrow)
+			writer.Write([]byte("world\nb"))
+			writer.Write([]byte("ye\n"))
+			writer.Close()
+
+			contents1, err := os.ReadFile(wantFilename1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents1) != td.wantContents1 {
+				t.Errorf("%s: want \"%s\" got \"%s\"", wantFilename1, td.wantContents1, string(contents1))
+			}
+
+			contents2, err := os.ReadFile(wantFilename2)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents2) != td.wantContents2 {
+				t.Errorf("%s: want \"%s\" got \"%s\"", wantFilename2, td.wantContents2, string(contents2))
+			}
+		})
+	}
+}
+
+// TestWholeLinesClose checks that Close writes out a partial line.
+func TestWholeLinesClose(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const wantContents = "line 1\nline 2"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithWholeLines())
+	writer.Write([]byte(wantContents))
+
+	contents, _ := os.ReadFile(wantFilename)
+	if string(contents) != "line 1\n" {
+		t.Errorf("before Close want \"line 1\\n\" got \"%s\"", string(contents))
+	}
+
+	writer.Close()
+
+	contents, _ = os.ReadFile(wantFilename)
+	if string(contents) != wantContents {
+		t.Errorf("after Close want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
+
+// TestWholeLinesCompression checks that a log file isn't compressed until the
+// line that straddled the rotation has been completed in it, so the compressed
+// file holds the whole line and no uncompressed copy is left behind.
+func TestWholeLinesCompression(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithWholeLines(),
+		WithCompression(NewGzipCompressor(gzip.BestSpeed)))
+	writer.Write([]byte("first\nthe start of a "))
+	writer.rotateLogs(tomorrow)
+
+	// Give a compression that started too early time to finish.
+	time.Sleep(50 * time.Millisecond)
+
+	writer.Write([]byte("line\nsecond\n"))
+	writer.Close()
+
+	if _, err := os.Stat("foo.2020-02-14.bar"); !os.IsNotExist(err) {
+		t.Errorf("want no uncompressed file left, got %v", err)
+	}
+
+	file, err := os.Open("foo.2020-02-14.bar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nthe start of a line\n"; string(contents) != want {
+		t.Errorf("want %q, got %q", want, contents)
+	}
+
+	contents, _ = os.ReadFile("foo.2020-02-15.bar")
+	if want := "second\n"; string(contents) != want {
+		t.Errorf("want %q in the new file, got %q", want, contents)
+	}
+}
+
+// TestWholeLinesWrappedFiles checks that, when the log files are compressed as
+// they're written or come from a FileFactory, a line that straddles a rotation
+// is written through the old file's writer, which is then closed.
+func TestWholeLinesWrappedFiles(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	factory := func(pathname string) (io.WriteCloser, error) {
+		file, err := os.OpenFile(pathname, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return gzip.NewWriter(file), nil
+	}
+
+	var testData = []struct {
+		description string
+		leader      string
+		option      Option
+		filename    string
+	}{
+		{"live compression", "live.", WithCompressedLiveFile(NewGzipCompressor(gzip.BestSpeed), 0),
+			"live.2020-02-14.bar.gz"},
+		{"file factory", "factory.", WithFileFactory(factory), "factory.2020-02-14.bar"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			var reported []error
+			writer := New(now, ".", td.leader, ".bar", WithWholeLines(), td.option,
+				WithErrorHandler(func(err error) { reported = append(reported, err) }))
+			writer.Write([]byte("one\ntw"))
+			writer.rotateLogs(tomorrow)
+			writer.Write([]byte("o\nthree\n"))
+
+			// The old file is complete before Close.
+			file, err := os.Open(td.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			reader, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer.Close()
+
+			if string(contents) != "one\ntwo\n" {
+				t.Errorf("%s: want \"one\\ntwo\\n\" got %q", td.filename, contents)
+			}
+			if len(reported) > 0 {
+				t.Errorf("want no errors, got %v", reported)
+			}
+		})
+	}
+}
+
+// TestWholeLinesConflicts checks that whole lines are turned off, and the
+// conflict reported, when the records are framed or chained.
+func TestWholeLinesConflicts(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var testData = []struct {
+		description string
+		leader      string
+		option      Option
+		want        string
+	}{
+		{"framing", "framed.", WithRecordFraming(), "WithRecordFraming"},
+		{"hash chain", "chained.", WithHashChain(), "WithHashChain"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			var reported []error
+			writer := New(now, ".", td.leader, ".bar", WithWholeLines(), td.option,
+				WithErrorHandler(func(err error) { reported = append(reported, err) }))
+			defer writer.Close()
+
+			if writer.splitter != nil {
+				t.Error("want whole lines turned off")
+			}
+			if len(reported) != 1 || !strings.Contains(reported[0].Error(), td.want) {
+				t.Errorf("want the conflict with %s reported, got %v", td.want, reported)
+			}
+		})
+	}
+}
+
+// TestWholeLinesLongLine checks that a partial line that grows longer than
+// maxPartialRecord is written out rather than held back.
+func TestWholeLinesLongLine(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithWholeLines())
+	defer writer.Close()
+
+	// Up to the limit, the line is held back.
+	chunk := []byte(strings.Repeat("x", maxPartialRecord/2))
+	writer.Write([]byte("first\n"))
+	writer.Write(chunk)
+	writer.Write(chunk)
+	writer.Sync()
+	if info, _ := os.Stat(wantFilename); info.Size() != 6 {
+		t.Errorf("want the partial line held back, got %d bytes", info.Size())
+	}
+
+	// Beyond it, what there is of the line is written out.
+	writer.Write([]byte("y"))
+	writer.Sync()
+	if info, _ := os.Stat(wantFilename); info.Size() != int64(6+maxPartialRecord+1) {
+		t.Errorf("want %d bytes, got %d", 6+maxPartialRecord+1, info.Size())
+	}
+	if len(writer.partialLine) != 0 {
+		t.Errorf("want nothing held back, got %d bytes", len(writer.partialLine))
+	}
+
+	// The rest of the line follows it.
+	writer.Write([]byte("z\nsecond\n"))
+	writer.Sync()
+	contents, _ := os.ReadFile(wantFilename)
+	if !strings.HasSuffix(string(contents), "xyz\nsecond\n") {
+		t.Errorf("want the line completed, got %q", contents[len(contents)-20:])
+	}
+}
@@ -0,0 +1,196 @@
+package dailylogger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// LogFile describes one of the log files in the log directory.
+type LogFile struct {
+	Name       string    // The name of the file, for example "foo.2020-02-14.1.bar".
+	Pathname   string    // The path name of the file, including the log directory.
+	Date       time.Time // Midnight at the start of the day that the file covers.
+	Sequence   int       // The sequence number within the day (0 for the first file).
+	Cold       bool      // True if the file is in the cold directory (see WithColdTier).
+	Compressed bool      // True if the file has been compressed (see WithCompression).
+}
+
+// List returns the log files in the log directory that were produced by this
+// Writer (or by an earlier Writer with the same directory, leader and trailer),
+// sorted by date and then by sequence number.  Any other files in the directory
+// are ignored.  If a FileNamer was supplied, the subdirectories of the log
+// directory are searched too.  If there is a cold directory, the files that have
+// been moved there are included.
+func (dw *Writer) List() ([]LogFile, error) {
+
+	logFiles, err := dw.listDirectory(dw.logDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dw.coldDir) > 0 {
+		coldFiles, err := dw.listDirectory(dw.coldDir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, logFile := range coldFiles {
+			logFile.Cold = true
+			logFiles = append(logFiles, logFile)
+		}
+	}
+
+	sort.Slice(logFiles, func(i, j int) bool {
+		if !logFiles[i].Date.Equal(logFiles[j].Date) {
+			return logFiles[i].Date.Before(logFiles[j].Date)
+		}
+		return logFiles[i].Sequence < logFiles[j].Sequence
+	})
+
+	return logFiles, nil
+}
+
+// listDirectory is a helper function for List that returns the log files in the
+// given directory, in no particular order.
+func (dw *Writer) listDirectory(directory string) ([]LogFile, error) {
+	names, err := dw.listNames(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	logFiles := make([]LogFile, 0, len(names))
+	for _, name := range names {
+		date, sequence, compressed, ok := dw.parseListedName(name)
+		if !ok {
+			continue
+		}
+
+		logFile := LogFile{
+			Name:       name,
+			Pathname:   directory + "/" + name,
+			Date:       date,
+			Sequence:   sequence,
+			Compressed: compressed,
+		}
+		logFiles = append(logFiles, logFile)
+	}
+
+	return logFiles, nil
+}
+
+// listNames returns the names of the files in the directory.  If a FileNamer was
+// supplied, it includes the files in subdirectories, with names relative to the
+// directory.
+func (dw *Writer) listNames(directory string) ([]string, error) {
+
+	if dw.namer == nil {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		return names, nil
+	}
+
+	var names []string
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if len(dw.quarantineDir) > 0 && path == filepath.Join(directory, dw.quarantineDir) {
+				// The files in the quarantine are not log files any more.
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+
+	return names, err
+}
+
+// parseLogFileName checks that the given file name is of the form produced by
+// getLogPathname, for example "foo.2020-02-14.bar" or "foo.2020-02-14.1.bar".  If
+// so it returns midnight at the start of the date in the name (in the same timezone
+// as the Writer), the sequence number and true.  Otherwise it returns false.  If
+// rotation is disabled, the only valid name is the fixed one and the date is zero.
+func (dw *Writer) parseLogFileName(name string) (time.Time, int, bool) {
+
+	if dw.noRotation {
+		// There is only one log file and its name contains no date.
+		return time.Time{}, 0, name == dw.fixedName
+	}
+
+	parser, ok := dw.fileNamer().(FileNameParser)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	return parser.Parse(name)
+}
+
+// logFileExists returns true if the log file for the given day and sequence number
+// exists, or has been compressed (see WithCompression).
+func (dw *Writer) logFileExists(day time.Time, sequence int) bool {
+	pathname := dw.getLogPathname(day, sequence)
+	if _, err := os.Stat(pathname); err == nil {
+		return true
+	}
+	if dw.compressor == nil {
+		return false
+	}
+	_, err := os.Stat(pathname + dw.compressor.Extension())
+	return err == nil
+}
+
+// getLastSequence returns the highest sequence number of the existing log files
+// for the given day, or 0 if there are none.
+func (dw *Writer) getLastSequence(day time.Time) int {
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return 0
+	}
+
+	last := 0
+	for _, logFile := range logFiles {
+		if logFile.Date.Equal(getLastMidnight(day)) && logFile.Sequence > last {
+			last = logFile.Sequence
+		}
+	}
+
+	return last
+}
+
+// getAppendSequence returns the sequence number of the log file to carry on
+// writing for the given day.  That's the latest of the existing files, unless it
+// has been compressed after rotation (see WithCompression), in which case it's
+// the next one.
+func (dw *Writer) getAppendSequence(day time.Time) int {
+	last := dw.getLastSequence(day)
+	if dw.compressor == nil || dw.compressLive {
+		return last
+	}
+
+	pathname := dw.getLogPathname(day, last)
+	if _, err := os.Stat(pathname + dw.compressor.Extension()); err == nil {
+		return last + 1
+	}
+	return last
+}
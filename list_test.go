@@ -0,0 +1,154 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestParseLogFileName checks that parseLogFileName accepts the names produced by
+// getLogPathname and rejects others.
+func TestParseLogFileName(t *testing.T) {
+	locationUTC, _ := time.LoadLocation("UTC")
+	writer := Writer{leader: "foo.", trailer: ".bar", startOfToday: time.Date(2020, time.February, 14, 0, 0, 0, 0, locationUTC)}
+	wantDate := time.Date(2020, time.February, 14, 0, 0, 0, 0, locationUTC)
+
+	var testData = []struct {
+		description  string
+		name         string
+		wantOK       bool
+		wantSequence int
+	}{
+		{"first", "foo.2020-02-14.bar", true, 0},
+		{"sequence", "foo.2020-02-14.1.bar", true, 1},
+		{"big sequence", "foo.2020-02-14.123.bar", true, 123},
+		{"wrong leader", "fred.2020-02-14.bar", false, 0},
+		{"wrong trailer", "foo.2020-02-14.log", false, 0},
+		{"bad date", "foo.2020-02-31.bar", false, 0},
+		{"no date", "foo..bar", false, 0},
+		{"zero sequence", "foo.2020-02-14.0.bar", false, 0},
+		{"leading zero", "foo.2020-02-14.01.bar", false, 0},
+		{"negative sequence", "foo.2020-02-14.-1.bar", false, 0},
+		{"signed sequence", "foo.2020-02-14.+1.bar", false, 0},
+		{"no dot", "foo.2020-02-141.bar", false, 0},
+		{"overlap", "foo.bar", false, 0},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			date, sequence, ok := writer.parseLogFileName(td.name)
+			if ok != td.wantOK {
+				t.Errorf("%s: want %v got %v", td.name, td.wantOK, ok)
+				return
+			}
+			if !ok {
+				return
+			}
+			if !date.Equal(wantDate) {
+				t.Errorf("%s: want date %v got %v", td.name, wantDate, date)
+			}
+			if sequence != td.wantSequence {
+				t.Errorf("%s: want sequence %d got %d", td.name, td.wantSequence, sequence)
+			}
+		})
+	}
+}
+
+// TestRotateWithSequence checks that rotating during the day creates a fresh file
+// with the next sequence number and that List finds all of the files in order.
+func TestRotateWithSequence(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	wantNames := []string{
+		"foo.2020-02-14.bar",
+		"foo.2020-02-14.1.bar",
+		"foo.2020-02-14.2.bar",
+		"foo.2020-02-15.bar",
+	}
+	wantContents := []string{"a", "b", "c", "d"}
+
+	locationParis, _ := time.LoadLocation("Europe/Paris")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationParis)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationParis)
+
+	// Create a file that should be ignored.
+	os.WriteFile("foo.junk.bar", []byte("junk"), 0644)
+
+	writer := New(now, ".", "foo.", ".bar")
+	writer.Write([]byte(wantContents[0]))
+	writer.rotate(now)
+	writer.Write([]byte(wantContents[1]))
+	writer.rotate(now.Add(time.Hour))
+	writer.Write([]byte(wantContents[2]))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte(wantContents[3]))
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(logFiles) != len(wantNames) {
+		t.Errorf("want %d files got %d", len(wantNames), len(logFiles))
+		return
+	}
+
+	for i := range wantNames {
+		if logFiles[i].Name != wantNames[i] {
+			t.Errorf("want file %d to be %s got %s", i, wantNames[i], logFiles[i].Name)
+			continue
+		}
+
+		contents, err := os.ReadFile(logFiles[i].Pathname)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if string(contents) != wantContents[i] {
+			t.Errorf("%s: want \"%s\" got \"%s\"", logFiles[i].Name, wantContents[i], string(contents))
+		}
+	}
+
+	// A restart during the day should carry on with the latest file.
+	writer2 := New(now, ".", "foo.", ".bar")
+	if writer2.sequence != 2 {
+		t.Errorf("want sequence 2 after restart, got %d", writer2.sequence)
+	}
+}
+
+// FuzzParseListedName checks that parseListedName doesn't panic on any name and
+// that any name it accepts is that of a log file, possibly compressed.
+func FuzzParseListedName(f *testing.F) {
+	f.Add("foo.2020-02-14.bar")
+	f.Add("foo.2020-02-14.3.bar.gz")
+	f.Add("foo.2020-02-14.bar.gz.gz")
+	f.Add("foo..bar.gz")
+
+	dw := &Writer{leader: "foo.", trailer: ".bar", compressor: NewGzipCompressor(1), location: time.UTC}
+	namer := dw.fileNamer()
+
+	f.Fuzz(func(t *testing.T, name string) {
+		date, sequence, compressed, ok := dw.parseListedName(name)
+		if !ok {
+			return
+		}
+
+		want := namer.Name(date, sequence)
+		if compressed {
+			want += dw.compressor.Extension()
+		}
+		if name != want {
+			t.Errorf("%q parses as %v, %d, %v, which is named %q", name, date, sequence, compressed, want)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package dailylogger
+
+import (
+	"io"
+	"os"
+)
+
+// defaultFlushBlock is the amount of data written between flush points of a
+// compressed live file when the caller doesn't give one.
+const defaultFlushBlock = 64 * 1024
+
+// WithCompressedLiveFile makes the Writer compress the current log file as it's
+// written, rather than after rotation, so the files are produced compressed, for
+// example "foo.2020-02-14.bar.gz".  The Compressor's writer should have a Flush
+// method, as gzip and zstd writers do.  It's called after every block of the
+// given number of bytes (64 KiB if the size isn't positive) and by Sync, so a
+// crash loses at most the last block.  The data up to the last flush point can be
+// read back, but the reader then reports an unexpected end of file.
+//
+// When the program restarts during the day, a new compressed stream is appended
+// to the existing file, which gzip and zstd readers both read as one.  List
+// includes the files, with Compressed set, and OpenLogFile reads them.  Features
+// that work on the file itself, such as preallocation, the journal, record repair
+// and the checks done by Health, are skipped, as with WithFileFactory, and the
+// option has no effect if a FileFactory is supplied.
+func WithCompressedLiveFile(compressor Compressor, blockSize int) Option {
+	return func(dw *Writer) {
+		if compressor == nil {
+			return
+		}
+		if blockSize <= 0 {
+			blockSize = defaultFlushBlock
+		}
+		dw.compressor = compressor
+		dw.compressLive = true
+		dw.flushBlock = blockSize
+	}
+}
+
+// openCompressed is a helper function for openLog that opens the compressed log
+// file with the given path name and starts a new compressed stream at its end.
+func (dw *Writer) openCompressed(pathname string) (io.WriteCloser, error) {
+	file, err := dw.openFile(pathname)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := dw.compressor.NewWriter(file)
+	if err != nil {
+		file.Close()
+		return nil, withClass(ErrFileOpen, err)
+	}
+
+	return &compressingWriter{encoder: encoder, file: file, blockSize: dw.flushBlock}, nil
+}
+
+// compressingWriter compresses the data written to it into a file, flushing the
+// compressor after each block.
+type compressingWriter struct {
+	encoder   io.WriteCloser // Compresses the data into the file.
+	file      *os.File       // The compressed log file.
+	blockSize int            // The amount of data written between flush points.
+	unflushed int            // The amount written since the last flush point.
+}
+
+// Write compresses the data and flushes the compressor once a block has been
+// written.
+func (cw *compressingWriter) Write(buffer []byte) (int, error) {
+	n, err := cw.encoder.Write(buffer)
+	cw.unflushed += n
+	if err != nil {
+		return n, err
+	}
+
+	if cw.unflushed >= cw.blockSize {
+		if err := cw.flush(); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Sync flushes the compressor and then commits the file to the disk.
+func (cw *compressingWriter) Sync() error {
+	if err := cw.flush(); err != nil {
+		return err
+	}
+	return cw.file.Sync()
+}
+
+// Close ends the compressed stream and closes the file.
+func (cw *compressingWriter) Close() error {
+	err := cw.encoder.Close()
+	if fe := cw.file.Close(); fe != nil && err == nil {
+		err = fe
+	}
+	return err
+}
+
+// flush writes out the data held by the compressor, if it can.
+func (cw *compressingWriter) flush() error {
+	cw.unflushed = 0
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
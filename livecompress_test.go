@@ -0,0 +1,110 @@
+package dailylogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGzipPrefix returns the data that can be decompressed from the start of a
+// gzip file, which may end in a half-written block.
+func readGzipPrefix(t *testing.T, pathname string) string {
+	t.Helper()
+
+	contents, err := os.ReadFile(pathname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result bytes.Buffer
+	_, err = io.Copy(&result, reader)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+	return result.String()
+}
+
+// TestCompressedLiveFile checks that the current log file is written compressed,
+// that the data up to each flush point can be read back while the file is open,
+// and that a restart appends to the file.
+func TestCompressedLiveFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	const pathname = "foo.2020-02-14.bar.gz"
+
+	compressor := NewGzipCompressor(gzip.DefaultCompression)
+	writer := New(now, ".", "foo.", ".bar", WithCompressedLiveFile(compressor, 10))
+
+	// The first write is less than a block, so it's not flushed.
+	writer.Write([]byte("hello\n"))
+	if got := readGzipPrefix(t, pathname); got != "" {
+		t.Errorf("want nothing before the flush point, got %q", got)
+	}
+
+	// This completes the block.
+	writer.Write([]byte("world\n"))
+	if got := readGzipPrefix(t, pathname); got != "hello\nworld\n" {
+		t.Errorf("want the first block, got %q", got)
+	}
+
+	// Sync flushes whatever has been written.
+	writer.Write([]byte("more\n"))
+	if err := writer.Sync(); err != nil {
+		t.Error(err)
+	}
+	if got := readGzipPrefix(t, pathname); got != "hello\nworld\nmore\n" {
+		t.Errorf("want everything after Sync, got %q", got)
+	}
+	writer.Close()
+
+	// Restart and carry on with the same file.
+	writer = New(now, ".", "foo.", ".bar", WithCompressedLiveFile(compressor, 10))
+	writer.Write([]byte("again\n"))
+	writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 1 || logFiles[0].Name != pathname || !logFiles[0].Compressed {
+		t.Fatalf("want just %s compressed, got %v", pathname, logFiles)
+	}
+
+	reader, err := writer.OpenLogFile(logFiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hello\nworld\nmore\nagain\n"; string(contents) != want {
+		t.Errorf("want %q got %q", want, string(contents))
+	}
+
+	entries, _ := os.ReadDir(".")
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".bar") {
+			t.Errorf("want no uncompressed file, found %s", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile tries to take an exclusive advisory lock on the open file without
+// waiting.  It returns false if another open file holds the lock.  The lock is
+// released when the file is closed.
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &fs.PathError{Op: "flock", Path: file.Name(), Err: err}
+	}
+
+	return true, nil
+}
+
+// lockFileShared takes a shared advisory lock on the open file, waiting while
+// somebody else holds an exclusive one.  The lock is released when the file is
+// closed.
+func lockFileShared(file *os.File) error {
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_SH); err != nil {
+		return &fs.PathError{Op: "flock", Path: file.Name(), Err: err}
+	}
+
+	return nil
+}
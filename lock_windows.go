@@ -0,0 +1,48 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFile tries to take an exclusive lock on the open file without waiting.
+// It returns false if another open file holds the lock.  The lock covers a byte
+// far beyond the end of the file, so it doesn't stop anybody reading or writing
+// the data.  It's released when the file is closed.
+func tryLockFile(file *os.File) (bool, error) {
+	var overlapped windows.Overlapped
+	overlapped.Offset = 0xffffffff
+	overlapped.OffsetHigh = 0x7fffffff
+
+	err := windows.LockFileEx(windows.Handle(file.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &overlapped)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &fs.PathError{Op: "LockFileEx", Path: file.Name(), Err: err}
+	}
+
+	return true, nil
+}
+
+// lockFileShared takes a shared lock on the same byte as tryLockFile, waiting
+// while somebody else holds an exclusive one.  The lock is released when the
+// file is closed.
+func lockFileShared(file *os.File) error {
+	var overlapped windows.Overlapped
+	overlapped.Offset = 0xffffffff
+	overlapped.OffsetHigh = 0x7fffffff
+
+	err := windows.LockFileEx(windows.Handle(file.Fd()), 0, 0, 1, 0, &overlapped)
+	if err != nil {
+		return &fs.PathError{Op: "LockFileEx", Path: file.Name(), Err: err}
+	}
+
+	return nil
+}
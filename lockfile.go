@@ -0,0 +1,159 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockRecord is the content of a lock file.  It identifies the process that
+// holds the lock.
+type lockRecord struct {
+	PID     int       // The process ID of the owner.
+	Host    string    // The host that the owner runs on.
+	Started time.Time // When the owner took the lock.
+}
+
+// WithLockFile makes the Writer the only one that may write to the log files in
+// its directory with its leader and trailer.  On start up the Writer creates a
+// lock file in the log directory, locks it and writes a record identifying the
+// process into it.  The lock file has the given name, or if the name is empty the
+// leader followed by "lock", so with the defaults it's "daily.lock".
+//
+// If a live process already holds the lock, the Writer reports an ErrLocked, logging
+// is disabled and Health returns the error.  If the process that created the lock
+// file has died, for example because it crashed, the lock is stale.  The Writer
+// takes it over and emits an EventStaleLockRecovered.  Under a POSIX system the
+// operating system drops the lock when its owner dies.  If the filesystem doesn't
+// support locking, the Writer checks whether the process recorded in the file is
+// still running on this host.  The lock file is removed when the Writer is
+// closed.
+func WithLockFile(name string) Option {
+	return func(dw *Writer) {
+		dw.lockFileName = strings.TrimSpace(name)
+		if len(dw.lockFileName) == 0 {
+			dw.lockFileName = dw.leader + "lock"
+		}
+	}
+}
+
+// acquireLockFile is a helper function for newWriter that creates and locks the
+// lock file.  If another process holds the lock it disables logging.
+func (dw *Writer) acquireLockFile() {
+	pathname := dw.logDir + "/" + dw.lockFileName
+
+	var file *os.File
+	var err error
+	dw.withUmask(func() {
+		file, err = os.OpenFile(longPath(pathname), os.O_RDWR|os.O_CREATE, 0644)
+	})
+	if err != nil {
+		dw.disableLogging(withClass(ErrFileOpen, fmt.Errorf("dailylogger: cannot create lock file %s - %w", pathname, err)))
+		return
+	}
+
+	previous, havePrevious := readLockRecord(file)
+
+	locked, lockErr := tryLockFile(file)
+	if lockErr != nil {
+		// The filesystem doesn't support locking, so fall back on the process ID in
+		// the lock file.
+		locked = !havePrevious || !previous.alive()
+	}
+	if !locked {
+		file.Close()
+		owner := ""
+		if havePrevious {
+			owner = fmt.Sprintf(" (process %d on %s since %s)",
+				previous.PID, previous.Host, previous.Started.Format(time.RFC3339))
+		}
+		dw.disableLogging(fmt.Errorf("%w - %s%s", ErrLocked, pathname, owner))
+		return
+	}
+
+	if havePrevious {
+		// The lock file was left behind by a process that didn't close its Writer.
+		dw.emit(Event{
+			Type: EventStaleLockRecovered,
+			Path: pathname,
+			Err:  fmt.Errorf("process %d on %s is no longer running", previous.PID, previous.Host),
+		})
+	}
+
+	host, _ := os.Hostname()
+	record := lockRecord{PID: os.Getpid(), Host: host, Started: dw.now()}
+	if err := writeLockRecord(file, record); err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: cannot write lock file %s - %w", pathname, err))
+	}
+
+	dw.closers = append(dw.closers, func() error {
+		os.Remove(longPath(pathname))
+		return file.Close()
+	})
+}
+
+// disableLogging is a helper function for acquireLockFile that stops the Writer
+// from opening any log files and records the reason.
+func (dw *Writer) disableLogging(err error) {
+	dw.loggingDisabled = true
+	dw.lockFileError = err
+	dw.reportError(err)
+}
+
+// alive returns true if the process that wrote the lock record is running.  A
+// process on another host is assumed to be running, since there is no way to
+// check.
+func (record lockRecord) alive() bool {
+	host, _ := os.Hostname()
+	if record.Host != host {
+		return true
+	}
+	if record.PID == os.Getpid() {
+		// Another Writer in this process.
+		return true
+	}
+	return processAlive(record.PID)
+}
+
+// readLockRecord reads the lock record from the start of the lock file.  It
+// returns false if the file is empty or doesn't contain a valid record.
+func readLockRecord(file io.ReaderAt) (lockRecord, bool) {
+	contents, err := io.ReadAll(io.NewSectionReader(file, 0, 4096))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return lockRecord{}, false
+	}
+
+	var record lockRecord
+	for _, field := range strings.Fields(string(contents)) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "pid":
+			record.PID, _ = strconv.Atoi(value)
+		case "host":
+			record.Host = value
+		case "started":
+			record.Started, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+
+	return record, record.PID > 0
+}
+
+// writeLockRecord replaces the contents of the file with the lock record, for
+// example "pid=1234 host=example started=2020-02-14T12:00:00Z".
+func writeLockRecord(file *os.File, record lockRecord) error {
+	line := fmt.Sprintf("pid=%d host=%s started=%s\n",
+		record.PID, record.Host, record.Started.Format(time.RFC3339))
+
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt([]byte(line), 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,132 @@
+package dailylogger
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLockFile checks that a second Writer can't take the lock while the first
+// one holds it, and that the lock file is removed when the first one is closed.
+func TestLockFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer1 := New(now, ".", "foo.", ".bar", WithLockFile(""))
+	if err := writer1.Health(); err != nil {
+		t.Errorf("want the first Writer to be healthy, got %v", err)
+	}
+
+	contents, err := os.ReadFile("foo.lock")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	record, ok := readLockRecord(strings.NewReader(string(contents)))
+	if !ok || record.PID != os.Getpid() {
+		t.Errorf("want a lock record for process %d, got \"%s\"", os.Getpid(), string(contents))
+	}
+
+	writer2 := New(now, ".", "foo.", ".bar", WithLockFile(""))
+	defer writer2.Close()
+	if err := writer2.Health(); !errors.Is(err, ErrLocked) {
+		t.Errorf("want ErrLocked, got %v", err)
+	}
+	writer2.Write([]byte("refused"))
+
+	writer1.Write([]byte("owner"))
+	writer1.Close()
+
+	got, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(got) != "owner" {
+		t.Errorf("want \"owner\" got \"%s\"", string(got))
+	}
+
+	if _, err := os.Stat("foo.lock"); !os.IsNotExist(err) {
+		t.Errorf("want the lock file to be removed, got %v", err)
+	}
+}
+
+// TestStaleLockFile checks that a lock file left behind by a process that has
+// died is taken over.
+func TestStaleLockFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	// There is almost certainly no process with this ID.
+	host, _ := os.Hostname()
+	stale := "pid=2147483646 host=" + host + " started=2020-02-13T12:00:00Z\n"
+	if err := os.WriteFile("foo.lock", []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var events []Event
+	handler := func(event Event) { events = append(events, event) }
+	writer := New(now, ".", "foo.", ".bar", WithLockFile(""), WithEventHandler(handler))
+	defer writer.Close()
+
+	if err := writer.Health(); err != nil {
+		t.Errorf("want the Writer to be healthy, got %v", err)
+	}
+
+	if len(events) != 1 || events[0].Type != EventStaleLockRecovered {
+		t.Fatalf("want one EventStaleLockRecovered, got %v", events)
+	}
+	if !strings.Contains(events[0].Err.Error(), "2147483646") {
+		t.Errorf("want the event to name the old process, got %v", events[0].Err)
+	}
+
+	contents, _ := os.ReadFile("foo.lock")
+	record, ok := readLockRecord(strings.NewReader(string(contents)))
+	if !ok || record.PID != os.Getpid() {
+		t.Errorf("want a lock record for process %d, got \"%s\"", os.Getpid(), string(contents))
+	}
+}
+
+// TestLockRecordAlive checks that a dead process on this host is detected and
+// that processes on other hosts are assumed to be alive.
+func TestLockRecordAlive(t *testing.T) {
+	host, _ := os.Hostname()
+
+	var testData = []struct {
+		description string
+		record      lockRecord
+		want        bool
+	}{
+		{"this process", lockRecord{PID: os.Getpid(), Host: host}, true},
+		{"dead process", lockRecord{PID: 2147483646, Host: host}, false},
+		{"other host", lockRecord{PID: 2147483646, Host: host + ".example"}, true},
+	}
+
+	for _, td := range testData {
+		if got := td.record.alive(); got != td.want {
+			t.Errorf("%s: want %v got %v", td.description, td.want, got)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package dailylogger
+
+import "strings"
+
+// extendedLengthPath converts an absolute Windows path name to the extended-length
+// form, which is not limited to MAX_PATH (260) characters, for example
+// `C:\ProgramData\logs` becomes `\\?\C:\ProgramData\logs` and the UNC path
+// `\\server\share\logs` becomes `\\?\UNC\server\share\logs`.  The extended-length
+// form doesn't allow forward slashes or "." and ".." elements, so the path name
+// should be cleaned first.  Forward slashes are converted.  A path name that is
+// already in that form, or a device path such as `\\.\pipe\x`, is returned as it is.
+func extendedLengthPath(path string) string {
+	path = strings.ReplaceAll(path, "/", `\`)
+
+	switch {
+	case strings.HasPrefix(path, `\\?\`), strings.HasPrefix(path, `\\.\`):
+		return path
+	case strings.HasPrefix(path, `\\`):
+		// A UNC path.
+		return `\\?\UNC\` + path[2:]
+	default:
+		return `\\?\` + path
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package dailylogger
+
+// longPath returns the path name as it is.  POSIX systems don't have the Windows
+// limit on the length of a path name.
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,25 @@
+package dailylogger
+
+import "testing"
+
+// TestExtendedLengthPath checks the conversion of Windows path names to the
+// extended-length form.
+func TestExtendedLengthPath(t *testing.T) {
+	var testData = []struct {
+		path string
+		want string
+	}{
+		{`C:\ProgramData\logs`, `\\?\C:\ProgramData\logs`},
+		{`C:/ProgramData/logs/foo.2020-02-14.log`, `\\?\C:\ProgramData\logs\foo.2020-02-14.log`},
+		{`\\server\share\logs`, `\\?\UNC\server\share\logs`},
+		{`\\?\C:\logs`, `\\?\C:\logs`},
+		{`\\.\pipe\logs`, `\\.\pipe\logs`},
+	}
+
+	for _, td := range testData {
+		got := extendedLengthPath(td.path)
+		if got != td.want {
+			t.Errorf("%s: want %s got %s", td.path, td.want, got)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package dailylogger
+
+import "path/filepath"
+
+// longPath converts the path name to the Windows extended-length form so that
+// log directories can be nested deeper than MAX_PATH allows.  Callers of the
+// package don't need to know about the prefix.  If the path name can't be made
+// absolute, it's returned as it is.
+func longPath(path string) string {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return extendedLengthPath(absolute)
+}
@@ -0,0 +1,220 @@
+package dailylogger
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// These are the extensions of the manifest and its signature.
+const (
+	manifestExtension  = ".manifest"
+	signatureExtension = ".sig"
+)
+
+// Manifest lists the finished log files for one day (see WithManifest).
+type Manifest struct {
+	Date  string          `json:"date"`  // The day, for example "2020-02-14".
+	Files []ManifestEntry `json:"files"` // The files, in the order they were added.
+}
+
+// ManifestEntry describes one file in a Manifest.
+type ManifestEntry struct {
+	Name   string `json:"name"`   // The name of the file, relative to the manifest's directory.
+	Size   int64  `json:"size"`   // The size of the file in bytes.
+	SHA256 string `json:"sha256"` // The SHA-256 hash of the contents, in hex.
+}
+
+// ManifestProblem describes a problem found by VerifyManifests.
+type ManifestProblem struct {
+	Manifest string // The path name of the manifest.
+	Name     string // The file concerned (empty if it's the manifest itself).
+	Err      error  // What's wrong.
+}
+
+// WithManifest makes the Writer record each log file in a manifest for its day
+// once it has rotated away from the file.  The entry gives the file's name, size
+// and SHA-256 hash, after any compression (see WithCompression).  The manifest is
+// a JSON file named after the day's first log file plus ".manifest", for example
+// "foo.2020-02-14.bar.manifest", in the same directory.  It's rewritten
+// atomically (see WithArtifact) as each of the day's files is added.  If a
+// signing key is given, the manifest is signed with it and the signature, in
+// base64, goes in a file of the same name plus ".sig".  The hashing is done
+// by the goroutine that makes the artifacts, so rotation isn't held up.  The
+// option has no effect when rotation is disabled.
+//
+// The manifests record what was written.  They aren't changed when retention or
+// the cold tier (see WithColdTier) remove or move the files later, so
+// VerifyManifests reports those files as missing.
+func WithManifest(signingKey ed25519.PrivateKey) Option {
+	return func(dw *Writer) {
+		dw.manifest = true
+		dw.manifestKey = signingKey
+	}
+}
+
+// addToManifest is a helper function for makeArtifacts that adds the finished
+// log file to the manifest for the given day.
+func (dw *Writer) addToManifest(pathname string, day time.Time) {
+	entry, err := manifestEntryFor(pathname)
+	if err == nil {
+		err = dw.updateManifest(pathname, day, entry)
+	}
+	if err != nil {
+		dw.logMutex.Lock()
+		dw.reportError(fmt.Errorf("dailylogger: error adding %s to the manifest - %w", pathname, err))
+		dw.logMutex.Unlock()
+	}
+}
+
+// updateManifest is a helper function for addToManifest that adds the entry to
+// the manifest for the day in the file's directory, replacing any earlier entry
+// for the same file, and signs the result.
+func (dw *Writer) updateManifest(pathname string, day time.Time, entry ManifestEntry) error {
+	dw.manifestMutex.Lock()
+	defer dw.manifestMutex.Unlock()
+
+	directory := filepath.Dir(pathname)
+	manifestPath := directory + "/" + filepath.Base(dw.fileNamer().Name(day, 0)) + manifestExtension
+
+	manifest := Manifest{Date: day.Format(time.DateOnly)}
+	contents, err := os.ReadFile(longPath(manifestPath))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(contents, &manifest); err != nil {
+			return fmt.Errorf("%s - %w", manifestPath, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	files := manifest.Files[:0]
+	for _, existing := range manifest.Files {
+		if existing.Name != entry.Name {
+			files = append(files, existing)
+		}
+	}
+	manifest.Files = append(files, entry)
+
+	contents, err = json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	contents = append(contents, '\n')
+
+	err = dw.writeAtomically(manifestPath, func(w io.Writer) error {
+		_, err := w.Write(contents)
+		return err
+	})
+	if err != nil || dw.manifestKey == nil {
+		return err
+	}
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(dw.manifestKey, contents)) + "\n"
+	return dw.writeAtomically(manifestPath+signatureExtension, func(w io.Writer) error {
+		_, err := io.WriteString(w, signature)
+		return err
+	})
+}
+
+// manifestEntryFor returns the manifest entry for the file with the given path
+// name.
+func manifestEntryFor(pathname string) (ManifestEntry, error) {
+	file, err := os.Open(longPath(pathname))
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return ManifestEntry{
+		Name:   filepath.Base(pathname),
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// VerifyManifests checks the manifests in the directory and its subdirectories
+// (see WithManifest) against the files they list.  If a public key is given,
+// each manifest must have a signature made with the matching private key.  It
+// returns the problems found, each of which wraps ErrBadSignature,
+// ErrManifestMismatch or the error from opening the file, and an error if the
+// directory couldn't be searched.  No problems means that everything matched.
+func VerifyManifests(directory string, publicKey ed25519.PublicKey) ([]ManifestProblem, error) {
+	var problems []ManifestProblem
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, manifestExtension) {
+			return nil
+		}
+
+		problems = append(problems, verifyManifest(path, publicKey)...)
+		return nil
+	})
+
+	return problems, err
+}
+
+// verifyManifest is a helper function for VerifyManifests that checks one
+// manifest.
+func verifyManifest(pathname string, publicKey ed25519.PublicKey) []ManifestProblem {
+	problem := func(name string, err error) []ManifestProblem {
+		return []ManifestProblem{{Manifest: pathname, Name: name, Err: err}}
+	}
+
+	contents, err := os.ReadFile(pathname)
+	if err != nil {
+		return problem("", err)
+	}
+
+	if publicKey != nil {
+		encoded, err := os.ReadFile(pathname + signatureExtension)
+		if err != nil {
+			return problem("", fmt.Errorf("%w - %w", ErrBadSignature, err))
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil || !ed25519.Verify(publicKey, contents, signature) {
+			return problem("", ErrBadSignature)
+		}
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return problem("", fmt.Errorf("%w - %w", ErrManifestMismatch, err))
+	}
+
+	var problems []ManifestProblem
+	directory := filepath.Dir(pathname)
+	for _, want := range manifest.Files {
+		got, err := manifestEntryFor(filepath.Join(directory, want.Name))
+		switch {
+		case err != nil:
+			problems = append(problems, problem(want.Name, err)...)
+		case got.Size != want.Size:
+			problems = append(problems, problem(want.Name,
+				fmt.Errorf("%w - the size is %d, not %d", ErrManifestMismatch, got.Size, want.Size))...)
+		case got.SHA256 != want.SHA256:
+			problems = append(problems, problem(want.Name,
+				fmt.Errorf("%w - the SHA-256 hash differs", ErrManifestMismatch))...)
+		}
+	}
+
+	return problems
+}
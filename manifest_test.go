@@ -0,0 +1,90 @@
+package dailylogger
+
+import (
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestManifest checks that each finished log file is recorded in the manifest
+// for its day, that the manifest is signed and that VerifyManifests finds
+// changed files and a changed manifest.
+func TestManifest(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := New(now, ".", "foo.", ".bar", WithManifest(privateKey),
+		WithCompression(NewGzipCompressor(gzip.BestSpeed)))
+	writer.Write([]byte("first\n"))
+	writer.rotate(now)
+	writer.Write([]byte("second\n"))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("third\n"))
+	writer.Close()
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar.manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	// The files are added as they are finished, which may be in any order.
+	names := make(map[string]bool)
+	for _, entry := range manifest.Files {
+		names[entry.Name] = true
+	}
+	if manifest.Date != "2020-02-14" || len(manifest.Files) != 2 ||
+		!names["foo.2020-02-14.bar.gz"] || !names["foo.2020-02-14.1.bar.gz"] {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	// The current file is not finished, so there is no manifest for its day.
+	if _, err := os.Stat("foo.2020-02-15.bar.manifest"); !os.IsNotExist(err) {
+		t.Errorf("want no manifest for the current day, got %v", err)
+	}
+
+	problems, err := VerifyManifests(".", publicKey)
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("want no problems, got %v and %v", problems, err)
+	}
+
+	// Change one of the files.
+	os.WriteFile("foo.2020-02-14.1.bar.gz", []byte("changed"), 0644)
+	problems, _ = VerifyManifests(".", publicKey)
+	if len(problems) != 1 || problems[0].Name != "foo.2020-02-14.1.bar.gz" ||
+		!errors.Is(problems[0].Err, ErrManifestMismatch) {
+		t.Errorf("want a mismatch for the changed file, got %v", problems)
+	}
+
+	// Change the manifest, or check it with the wrong key.
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	os.WriteFile("foo.2020-02-14.bar.manifest", append(contents, ' '), 0644)
+	for _, key := range []ed25519.PublicKey{publicKey, otherKey} {
+		problems, _ = VerifyManifests(".", key)
+		if len(problems) != 1 || !errors.Is(problems[0].Err, ErrBadSignature) {
+			t.Errorf("want a bad signature, got %v", problems)
+		}
+	}
+}
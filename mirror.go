@@ -0,0 +1,106 @@
+package dailylogger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The sides of a mirrored log.
+const (
+	primarySide = 0
+	mirrorSide  = 1
+)
+
+// WithMirror makes the Writer keep an identical copy of each log file in a second
+// directory, for example on a different device.  Every buffer is written to both
+// files.  If a write to one side fails, the Writer carries on with the other and
+// Write still succeeds, so the two copies diverge.  The Writer emits an
+// EventMirrorSideFailed when a side starts failing and an EventMirrorSideRecovered
+// when it starts working again, and it counts the bytes that each side missed.
+// Write only fails if both sides fail.  The mirror directory is created with the
+// same permissions and ownership as the log directory.
+func WithMirror(directory string) Option {
+	return func(dw *Writer) {
+		dw.mirrorDir = strings.TrimSpace(directory)
+	}
+}
+
+// mirrorWriter writes to both sides of a mirrored log.
+type mirrorWriter struct {
+	dw        *Writer
+	primary   io.Writer // The log file or its buffer (nil if the file is not open).
+	secondary io.Writer // The copy of the log file (nil if it is not open).
+}
+
+// Write writes the buffer to both sides and succeeds if either of them succeeds.
+func (mw *mirrorWriter) Write(buffer []byte) (int, error) {
+	primaryErr := writeSide(mw.primary, buffer)
+	mirrorErr := writeSide(mw.secondary, buffer)
+
+	mw.dw.recordMirrorResult(primarySide, primaryErr, len(buffer))
+	mw.dw.recordMirrorResult(mirrorSide, mirrorErr, len(buffer))
+
+	if primaryErr != nil && mirrorErr != nil {
+		return 0, primaryErr
+	}
+
+	return len(buffer), nil
+}
+
+// writeSide writes the buffer to one side of the mirror.
+func writeSide(w io.Writer, buffer []byte) error {
+	if w == nil {
+		return errNoFile
+	}
+
+	_, err := w.Write(buffer)
+	return err
+}
+
+// openMirror is a helper function for openLog that opens the copy of the current
+// log file and returns a writer that writes to both.  It doesn't apply the lock,
+// so it should only be called by a function that does.
+func (dw *Writer) openMirror(primary io.Writer) io.Writer {
+	mw := mirrorWriter{dw: dw}
+
+	if dw.logWriter != nil {
+		mw.primary = primary
+	}
+
+	pathname := dw.mirrorDir + "/" + strings.TrimPrefix(dw.pathname, dw.logDir+"/")
+	dw.createParent(dw.mirrorDir, pathname)
+	mirrorFile, err := dw.openFile(pathname)
+	if err != nil {
+		dw.reportError(fmt.Errorf("openMirror: error creating log file %s - %w", pathname, err))
+	} else {
+		dw.mirrorFile = mirrorFile
+		mw.secondary = mirrorFile
+	}
+
+	return &mw
+}
+
+// recordMirrorResult keeps track of the state of one side of the mirror and emits
+// an event when it changes.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) recordMirrorResult(side int, err error, length int) {
+	directory := dw.logDir
+	if side == mirrorSide {
+		directory = dw.mirrorDir
+	}
+
+	if err != nil {
+		dw.mirrorMissedBytes[side] += int64(length)
+		if !dw.mirrorFailing[side] {
+			dw.mirrorFailing[side] = true
+			dw.emit(Event{Type: EventMirrorSideFailed, Path: directory, Err: err})
+		}
+		return
+	}
+
+	if dw.mirrorFailing[side] {
+		dw.mirrorFailing[side] = false
+		dw.emit(Event{Type: EventMirrorSideRecovered, Path: directory})
+	}
+}
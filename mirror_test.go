@@ -0,0 +1,137 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMirror checks that both copies of the log receive the same data.
+func TestMirror(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "hello world"
+	wantFilenames := []string{"flash/foo.2020-02-14.bar", "sd/foo.2020-02-14.bar"}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, "flash", "foo.", ".bar", WithMirror("sd"), WithBuffering(64))
+	writer.Write([]byte(wantContents))
+	writer.Close()
+
+	for _, filename := range wantFilenames {
+		contents, err := os.ReadFile(filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if string(contents) != wantContents {
+			t.Errorf("%s: want \"%s\" got \"%s\"", filename, wantContents, string(contents))
+		}
+	}
+}
+
+// TestMirrorWholeLines checks that, with whole lines, a line that straddles a
+// rotation goes into the old copy of the log as well as the old log file.
+func TestMirrorWholeLines(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 59, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, "flash", "foo.", ".bar", WithMirror("sd"), WithWholeLines(), WithBuffering(64))
+	writer.Write([]byte("one\ntw"))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("o\nthree\n"))
+	writer.Close()
+
+	want := map[string]string{"foo.2020-02-14.bar": "one\ntwo\n", "foo.2020-02-15.bar": "three\n"}
+	for name, wantContents := range want {
+		for _, directory := range []string{"flash", "sd"} {
+			filename := directory + "/" + name
+			contents, err := os.ReadFile(filename)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if string(contents) != wantContents {
+				t.Errorf("%s: want \"%s\" got \"%s\"", filename, wantContents, string(contents))
+			}
+		}
+	}
+}
+
+// TestMirrorSideFails checks that the Writer carries on when one side of the mirror
+// fails, reports it and reports when it recovers.
+func TestMirrorSideFails(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "hello"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// Stop the mirror file for the 14th from being created.
+	os.MkdirAll("sd/foo.2020-02-14.bar", 0755)
+
+	var events []Event
+	writer := New(now, "flash", "foo.", ".bar", WithMirror("sd"),
+		WithEventHandler(func(e Event) { events = append(events, e) }))
+	defer writer.Close()
+
+	n, err := writer.Write([]byte(wantContents))
+	if err != nil {
+		t.Errorf("want Write to succeed, got %v", err)
+		return
+	}
+	if n != len(wantContents) {
+		t.Errorf("want %d got %d", len(wantContents), n)
+	}
+
+	writer.Write([]byte(wantContents))
+
+	if len(events) != 1 || events[0].Type != EventMirrorSideFailed || events[0].Path != "sd" {
+		t.Errorf("want one %v event for sd, got %v", EventMirrorSideFailed, events)
+		return
+	}
+
+	if writer.Stats().MirrorMissedBytes != int64(2*len(wantContents)) {
+		t.Errorf("want %d missed bytes got %d", 2*len(wantContents), writer.Stats().MirrorMissedBytes)
+	}
+
+	// On the next day the mirror works again.
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+	writer.Write([]byte(wantContents))
+
+	if len(events) != 2 || events[1].Type != EventMirrorSideRecovered {
+		t.Errorf("want a %v event, got %v", EventMirrorSideRecovered, events)
+	}
+}
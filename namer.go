@@ -0,0 +1,244 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileNamer produces the names of the log files.  Name returns the name of the
+// log file for the day starting at the given time and the given sequence number
+// (0 for the first file of the day).  The name is relative to the log directory
+// and may include subdirectories, separated by "/", which are created as needed.
+//
+// If the FileNamer also implements FileNameParser, List and the Writer's other
+// uses of the existing log files work too.  Otherwise the Writer can't find the
+// existing files, so List returns nothing and after a restart the Writer starts
+// again at sequence number 0.
+type FileNamer interface {
+	Name(date time.Time, sequence int) string
+}
+
+// FileNameParser is implemented by a FileNamer that can recognise its own names.
+// Parse is given a name relative to the log directory, with any subdirectories
+// separated by "/".  If the name is one that the FileNamer produces, Parse returns
+// midnight at the start of the day in the Writer's timezone, the sequence number
+// and true.  Otherwise it returns false.
+type FileNameParser interface {
+	Parse(name string) (time.Time, int, bool)
+}
+
+// WithFileNamer supplies a FileNamer that produces the names of the log files,
+// replacing the usual scheme (see LeaderTrailerNamer).  It has no effect when
+// rotation is disabled.
+func WithFileNamer(namer FileNamer) Option {
+	return func(dw *Writer) {
+		if namer != nil {
+			dw.namer = namer
+		}
+	}
+}
+
+// LeaderTrailerNamer is the FileNamer that the Writer uses by default.  The name
+// is the leader, the date, a dot and the sequence number if it isn't 0, and the
+// trailer, for example "foo.2020-02-14.bar" and "foo.2020-02-14.1.bar".  If the
+// Host or PID is set, it comes after the leader, followed by a dot, for example
+// "foo.myhost.1234.2020-02-14.bar".  Parse accepts any PID.
+type LeaderTrailerNamer struct {
+	Leader    string         // The start of the name.
+	Trailer   string         // The end of the name.
+	DateStyle DateStyle      // The form of the date.
+	Location  *time.Location // The timezone of the dates returned by Parse.
+	Host      string         // The host name to include in the name (optional).
+	PID       int            // The process ID to include in the name (0 means none).
+}
+
+// This is a compile-time check that LeaderTrailerNamer implements the FileNamer
+// and FileNameParser interfaces.
+var (
+	_ FileNamer      = LeaderTrailerNamer{}
+	_ FileNameParser = LeaderTrailerNamer{}
+)
+
+// Name returns the name of the log file for the given day and sequence number.
+func (ltn LeaderTrailerNamer) Name(date time.Time, sequence int) string {
+	if sequence == 0 {
+		return ltn.start() + ltn.DateStyle.format(date) + ltn.Trailer
+	}
+
+	return fmt.Sprintf("%s%s.%d%s", ltn.start(), ltn.DateStyle.format(date), sequence, ltn.Trailer)
+}
+
+// start returns the part of the name before the date.
+func (ltn LeaderTrailerNamer) start() string {
+	start := ltn.prefix()
+	if ltn.PID != 0 {
+		start += strconv.Itoa(ltn.PID) + "."
+	}
+
+	return start
+}
+
+// prefix returns the part of the name before the process ID.
+func (ltn LeaderTrailerNamer) prefix() string {
+	if len(ltn.Host) > 0 {
+		return ltn.Leader + ltn.Host + "."
+	}
+
+	return ltn.Leader
+}
+
+// Parse checks that the given file name is of the form produced by Name, for
+// example "foo.2020-02-14.bar" or "foo.2020-02-14.1.bar".  If so it returns
+// midnight at the start of the date in the name, the sequence number and true.
+// Otherwise it returns false.  If the PID is set, a name with any process ID is
+// accepted, so that the files written by earlier runs of the program are still
+// found.
+func (ltn LeaderTrailerNamer) Parse(name string) (time.Time, int, bool) {
+
+	prefix := ltn.prefix()
+	if !strings.HasPrefix(name, prefix) {
+		return time.Time{}, 0, false
+	}
+	rest := name[len(prefix):]
+
+	if ltn.PID != 0 {
+		pid, after, found := strings.Cut(rest, ".")
+		if !found || !isProcessID(pid) {
+			return time.Time{}, 0, false
+		}
+		rest = after
+	}
+
+	if !strings.HasSuffix(rest, ltn.Trailer) {
+		// This also catches a trailer that overlaps the start.
+		return time.Time{}, 0, false
+	}
+
+	// The middle part should be a date, for example "yyyy-mm-dd", optionally
+	// followed by a sequence number, for example "yyyy-mm-dd.n".
+	middle := rest[:len(rest)-len(ltn.Trailer)]
+
+	dateLength := ltn.DateStyle.length()
+	if len(middle) < dateLength {
+		return time.Time{}, 0, false
+	}
+
+	location := ltn.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	date, ok := ltn.DateStyle.parse(middle[:dateLength], location)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	rest = middle[dateLength:]
+	if len(rest) == 0 {
+		return date, 0, true
+	}
+
+	// There should be a sequence number - a dot followed by a positive decimal number
+	// with no leading zeroes.
+	if len(rest) < 2 || rest[0] != '.' || rest[1] == '0' || rest[1] == '+' {
+		return time.Time{}, 0, false
+	}
+
+	sequence, err := strconv.Atoi(rest[1:])
+	if err != nil || sequence <= 0 {
+		return time.Time{}, 0, false
+	}
+
+	return date, sequence, true
+}
+
+// isProcessID returns true if the text is a process ID as Name writes it, a
+// positive decimal number with no leading zeroes.
+func isProcessID(text string) bool {
+	if len(text) == 0 || text[0] == '0' {
+		return false
+	}
+	for _, c := range text {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fileNamer returns the FileNamer to use, which is the default one unless
+// WithFileNamer was used.
+func (dw *Writer) fileNamer() FileNamer {
+	if dw.namer != nil {
+		return dw.namer
+	}
+
+	return LeaderTrailerNamer{
+		Leader:    dw.leader,
+		Trailer:   dw.trailer,
+		DateStyle: dw.dateStyle,
+		Location:  dw.startOfToday.Location(),
+		Host:      dw.hostInName,
+		PID:       dw.pidInName,
+	}
+}
+
+// WithHostnameInName puts the name of the host in the names of the log files,
+// so that several hosts can share a log directory, for example
+// "foo.myhost.2020-02-14.bar".  It has no effect if a FileNamer is supplied.
+func WithHostnameInName() Option {
+	return func(dw *Writer) {
+		host, err := os.Hostname()
+		if err != nil || len(host) == 0 {
+			host = "unknown"
+		}
+		dw.hostInName = strings.ReplaceAll(host, "/", "_")
+	}
+}
+
+// WithPIDInName puts the process ID in the names of the log files, so that
+// several processes can share a log directory, for example
+// "foo.1234.2020-02-14.bar".  The files written by earlier processes have
+// different names, so after a restart the Writer starts a new file, but it still
+// treats the old files as its own: List includes them, whatever their process
+// IDs, and the retention rules, the emergency purge, the cold tier, the quota
+// and RepairPermissions apply to them.  That goes for the files of any other
+// process writing to the directory with the same leader and trailer too.  It
+// has no effect if a FileNamer is supplied.
+func WithPIDInName() Option {
+	return func(dw *Writer) {
+		dw.pidInName = os.Getpid()
+	}
+}
+
+// createParent creates the directory holding the given file, if a FileNamer has
+// put it in a subdirectory of the given directory.
+func (dw *Writer) createParent(directory, pathname string) {
+	parent := filepath.Dir(pathname)
+	if dw.namer == nil || parent == filepath.Clean(directory) {
+		return
+	}
+
+	dw.makeParent(parent)
+}
+
+// makeParent creates the given directory and any missing parents with the
+// permissions of the log directory and reports any error.
+func (dw *Writer) makeParent(parent string) {
+	permissions := dw.logDirPermissions
+	if permissions&os.ModePerm == 0 {
+		permissions |= os.ModePerm
+	}
+
+	dw.withUmask(func() {
+		if err := os.MkdirAll(longPath(parent), permissions); err != nil {
+			dw.reportError(withClass(ErrDirCreate,
+				fmt.Errorf("createParent: cannot create directory %s - %w", parent, err)))
+		}
+	})
+}
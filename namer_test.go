@@ -0,0 +1,234 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// subdirectoryNamer is a FileNamer that puts each day's files in a directory of
+// their own, for example "2020/02/14/log.1.txt".
+type subdirectoryNamer struct {
+	location *time.Location
+}
+
+func (sn subdirectoryNamer) Name(date time.Time, sequence int) string {
+	return fmt.Sprintf("%s/log.%d.txt", date.Format("2006/01/02"), sequence)
+}
+
+func (sn subdirectoryNamer) Parse(name string) (time.Time, int, bool) {
+	var year, month, day, sequence int
+	n, err := fmt.Sscanf(name, "%04d/%02d/%02d/log.%d.txt", &year, &month, &day, &sequence)
+	if n != 4 || err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, sn.location), sequence, true
+}
+
+// nameOnlyNamer is a FileNamer that can't parse its own names.
+type nameOnlyNamer struct{}
+
+func (nameOnlyNamer) Name(date time.Time, sequence int) string {
+	return fmt.Sprintf("log-%s-%d", date.Format("20060102"), sequence)
+}
+
+// TestFileNamer checks that a FileNamer controls the names of the log files and
+// that List finds them.
+func TestFileNamer(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	wantNames := []string{"2020/02/14/log.0.txt", "2020/02/14/log.1.txt", "2020/02/15/log.0.txt"}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	namer := subdirectoryNamer{location: locationUTC}
+
+	writer := New(now, "logs", "foo.", ".bar", WithFileNamer(namer))
+	writer.Write([]byte("a"))
+	writer.rotate(now)
+	writer.Write([]byte("b"))
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+	writer.Write([]byte("c"))
+	writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(logFiles) != len(wantNames) {
+		t.Errorf("want %d files got %d", len(wantNames), len(logFiles))
+		return
+	}
+
+	for i, logFile := range logFiles {
+		if logFile.Name != wantNames[i] {
+			t.Errorf("want %s got %s", wantNames[i], logFile.Name)
+		}
+		if _, err := os.Stat(logFile.Pathname); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// A restart carries on with the latest file.
+	writer2 := New(now, "logs", "foo.", ".bar", WithFileNamer(namer))
+	defer writer2.Close()
+	if writer2.sequence != 1 {
+		t.Errorf("want sequence 1 after restart got %d", writer2.sequence)
+	}
+}
+
+// TestFileNamerWithoutParser checks that the Writer works with a FileNamer that
+// can't parse its names, but List finds nothing.
+func TestFileNamerWithoutParser(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithFileNamer(nameOnlyNamer{}))
+	defer writer.Close()
+	writer.Write([]byte("a"))
+
+	if _, err := os.Stat("log-20200214-0"); err != nil {
+		t.Error(err)
+	}
+
+	logFiles, _ := writer.List()
+	if len(logFiles) != 0 {
+		t.Errorf("want no files got %v", logFiles)
+	}
+}
+
+// TestHostnameAndPIDInName checks that the host name and process ID are put in
+// the log file name and that List recognises the name.
+func TestHostnameAndPIDInName(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	host, _ := os.Hostname()
+	wantName := fmt.Sprintf("foo.%s.%d.2020-02-14.bar", host, os.Getpid())
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// A file written by another host should be ignored.
+	os.WriteFile("foo.otherhost.1.2020-02-14.bar", []byte("other"), 0644)
+
+	writer := New(now, ".", "foo.", ".bar", WithHostnameInName(), WithPIDInName())
+	defer writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(logFiles) != 1 || logFiles[0].Name != wantName {
+		t.Errorf("want %s got %v", wantName, logFiles)
+	}
+}
+
+// TestPIDInNameRestart checks that, after a restart with a different process ID,
+// the files written under the earlier one are listed and the retention rules
+// delete them.
+func TestPIDInNameRestart(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	oldName := fmt.Sprintf("foo.%d.2020-02-10.bar", os.Getpid()+1)
+	os.WriteFile(oldName, []byte("old\n"), 0644)
+	// Names that only look like process IDs aren't log files.
+	os.WriteFile("foo.0123.2020-02-10.bar", []byte("junk\n"), 0644)
+	os.WriteFile("foo.x1.2020-02-10.bar", []byte("junk\n"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(newFakeClock(now)), WithPIDInName(), WithMaxAge(1))
+	defer writer.Close()
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 2 || logFiles[0].Name != oldName {
+		t.Errorf("want %s and today's file, got %v", oldName, logFiles)
+	}
+
+	writer.applyRetention()
+	if _, err := os.Stat(oldName); err == nil {
+		t.Errorf("want %s deleted", oldName)
+	}
+	if _, err := os.Stat("foo.0123.2020-02-10.bar"); err != nil {
+		t.Error("want the other files left alone")
+	}
+}
+
+// FuzzLeaderTrailerNamerParse checks that Parse doesn't panic on any name and
+// that any name it accepts is the one that Name produces for the date and
+// sequence number it returns.
+func FuzzLeaderTrailerNamerParse(f *testing.F) {
+	f.Add("foo.", ".bar", 0, "foo.2020-02-14.bar")
+	f.Add("foo.", ".bar", 0, "foo.2020-02-14.12.bar")
+	f.Add("foo.", ".bar", 0, "foo.2020-02-30.bar")
+	f.Add("foo.", ".bar", 1, "foo.2020-045.bar")
+	f.Add("foo.", ".bar", 2, "foo.2020-02-14.045.1.bar")
+	f.Add("foo.", ".bar", 3, "foo.2458894.bar")
+	f.Add("foo.", ".bar", 4, "foo.2092-5.+3.bar")
+	f.Add("", "", 0, "2020-02-14.9999999999999999999")
+	f.Add("a.", "", 4, "a.-001-9")
+
+	f.Fuzz(func(t *testing.T, leader, trailer string, style int, name string) {
+		namer := LeaderTrailerNamer{
+			Leader:    leader,
+			Trailer:   trailer,
+			DateStyle: DateStyle(style & 7),
+			Location:  time.UTC,
+		}
+
+		date, sequence, ok := namer.Parse(name)
+		if !ok {
+			return
+		}
+		if sequence < 0 {
+			t.Fatalf("%q: negative sequence number %d", name, sequence)
+		}
+		if got := namer.Name(date, sequence); got != name {
+			t.Errorf("%q parses as %v, %d, which is named %q", name, date, sequence, got)
+		}
+	})
+}
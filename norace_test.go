@@ -0,0 +1,7 @@
+//go:build !race
+
+package dailylogger
+
+// raceEnabled is true when the tests are run with the race detector, which
+// makes sync.Pool drop some of the buffers put into it.
+const raceEnabled = false
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package dailylogger
+
+import "os"
+
+// openLogFile opens a log file.  Under a POSIX system, other processes can read,
+// rename and delete an open file anyway, so this is just os.OpenFile.
+func openLogFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
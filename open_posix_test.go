@@ -0,0 +1,52 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestWriteThrough checks that under a POSIX system the log file is opened with
+// the write-through flag (O_DSYNC, or O_SYNC under FreeBSD) in write-through mode,
+// and without it otherwise.
+func TestWriteThrough(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var testData = []struct {
+		description string
+		options     []any
+		want        bool
+	}{
+		{"default", nil, false},
+		{"write-through", []any{WithWriteThrough()}, true},
+	}
+
+	for _, td := range testData {
+		writer := New(now, ".", td.description+".", ".bar", td.options...)
+
+		flags, err := unix.FcntlInt(writer.logFile.Fd(), unix.F_GETFL, 0)
+		writer.Close()
+		if err != nil {
+			t.Errorf("%s: %v", td.description, err)
+			continue
+		}
+
+		if got := flags&writeThroughFlag == writeThroughFlag; got != td.want {
+			t.Errorf("%s: want the write-through flag %v got %v", td.description, td.want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// writeThroughFlag is the flag that makes openLogFile open a file in write-through
+// mode (see WithWriteThrough).
+const writeThroughFlag = os.O_SYNC
+
+// openLogFile opens a log file.  os.OpenFile doesn't allow other processes to
+// delete or rename the file while it's open, which upsets tail-style tools, log
+// shippers and antivirus software.  This opens the file with all three sharing
+// modes (read, write and delete), which is as close as Windows gets to the POSIX
+// behaviour.  It supports the flags that the Writer uses: os.O_RDONLY, os.O_WRONLY,
+// os.O_RDWR, os.O_APPEND, os.O_CREATE, os.O_EXCL, os.O_TRUNC and os.O_SYNC, which
+// makes every write go straight through to the disk.  The path name is converted
+// to the extended-length form, so it may be longer than MAX_PATH.
+func openLogFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+
+	pathp, err := windows.UTF16PtrFromString(longPath(name))
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	var access uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		access = windows.GENERIC_READ
+	case os.O_WRONLY:
+		access = windows.GENERIC_WRITE
+	case os.O_RDWR:
+		access = windows.GENERIC_READ | windows.GENERIC_WRITE
+	}
+	if flag&os.O_APPEND != 0 {
+		// Writes always go to the end of the file.
+		access &^= windows.GENERIC_WRITE
+		access |= windows.FILE_APPEND_DATA
+	}
+
+	const shareMode = windows.FILE_SHARE_READ | windows.FILE_SHARE_WRITE | windows.FILE_SHARE_DELETE
+
+	var createMode uint32
+	switch {
+	case flag&(os.O_CREATE|os.O_EXCL) == (os.O_CREATE | os.O_EXCL):
+		createMode = windows.CREATE_NEW
+	case flag&(os.O_CREATE|os.O_TRUNC) == (os.O_CREATE | os.O_TRUNC):
+		createMode = windows.CREATE_ALWAYS
+	case flag&os.O_CREATE == os.O_CREATE:
+		createMode = windows.OPEN_ALWAYS
+	case flag&os.O_TRUNC == os.O_TRUNC:
+		createMode = windows.TRUNCATE_EXISTING
+	default:
+		createMode = windows.OPEN_EXISTING
+	}
+
+	var attributes uint32 = windows.FILE_ATTRIBUTE_NORMAL
+	if perm&0200 == 0 {
+		attributes = windows.FILE_ATTRIBUTE_READONLY
+	}
+	if flag&os.O_SYNC != 0 {
+		attributes |= windows.FILE_FLAG_WRITE_THROUGH
+	}
+
+	handle, err := windows.CreateFile(pathp, access, shareMode, nil, createMode, attributes, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return os.NewFile(uintptr(handle), name), nil
+}
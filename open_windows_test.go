@@ -0,0 +1,62 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestOpenFileSharing checks that under Windows the current log file can be
+// read and renamed by someone else while the Writer has it open.
+func TestOpenFileSharing(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const newFilename = "moved.bar"
+	const wantContents = "hello world"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar")
+	defer writer.Close()
+
+	writer.Write([]byte("hello "))
+
+	contents, err := os.ReadFile(wantFilename)
+	if err != nil {
+		t.Errorf("want to read the open file - %v", err)
+		return
+	}
+	if string(contents) != "hello " {
+		t.Errorf("want \"hello \" got \"%s\"", string(contents))
+	}
+
+	if err := os.Rename(wantFilename, newFilename); err != nil {
+		t.Errorf("want to rename the open file - %v", err)
+		return
+	}
+
+	// The Writer carries on writing to the renamed file.
+	writer.Write([]byte("world"))
+	writer.Sync()
+
+	contents, err = os.ReadFile(newFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
@@ -0,0 +1,114 @@
+package dailylogger
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// An Option configures an optional feature of a Writer.  Options are supplied to New
+// among its optional arguments.
+type Option func(*Writer)
+
+// splitOptions separates any Options from the other optional arguments of New.
+func splitOptions(args []any) ([]Option, []any) {
+	var options []Option
+	var rest []any
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case Option:
+			options = append(options, a)
+		case func(*Writer):
+			options = append(options, Option(a))
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return options, rest
+}
+
+// WithNoRotation disables rotation.  The Writer writes to a single file with the
+// given name in the log directory and doesn't start the goroutine that rotates
+// the log at midnight.  All the other features such as permissions and ownership
+// work as usual.  If the name is empty, the leader and trailer are used, so with
+// the defaults the file is "daily.log".
+func WithNoRotation(fileName string) Option {
+	return func(dw *Writer) {
+		dw.noRotation = true
+		dw.fixedName = strings.TrimSpace(fileName)
+		if len(dw.fixedName) == 0 {
+			dw.fixedName = strings.TrimSuffix(dw.leader, ".") + dw.trailer
+		}
+	}
+}
+
+// WithWriteThrough opens the log files in write-through mode, so that each write
+// is on the disk before it returns, without any need to call Sync.  Under a POSIX
+// system the files are opened with O_DSYNC and under MS Windows with
+// FILE_FLAG_WRITE_THROUGH.  It's meant for audit logs and the like, where every
+// record must survive a crash and the cost is acceptable.  Combined with
+// WithBuffering, the data is only durable when the buffer is flushed.
+func WithWriteThrough() Option {
+	return func(dw *Writer) {
+		dw.writeThrough = true
+	}
+}
+
+// WithLazyRotation makes Write rotate the log instead of a background goroutine.
+// Before each write, the Writer compares the time with the start of the next day
+// and, if the day has changed, rotates the log there and then, so the data goes
+// into the new day's file.  It's for programs that don't want hidden goroutines.
+// The cost is a check of the clock on every write, and if nothing is written for
+// a while after midnight, the old day's file stays open until the next write.
+// Jitter (see WithRotationJitter) doesn't apply.
+func WithLazyRotation() Option {
+	return func(dw *Writer) {
+		dw.lazyRotation = true
+	}
+}
+
+// WithRotationSlack sets how long after midnight the log rotator wakes up to
+// rotate the log.  The default is a microsecond.  On a heavily loaded virtual
+// machine, timers may fire a little early, so a bigger slack avoids waking while
+// the clock still shows the old day.  Whatever the slack, the rotator checks the
+// date when it wakes and goes back to sleep if midnight hasn't passed yet.
+func WithRotationSlack(slack time.Duration) Option {
+	return func(dw *Writer) {
+		if slack > 0 {
+			dw.rotationSlack = slack
+		}
+	}
+}
+
+// WithPermissionsReapplied makes the Writer set the permissions and the owner and
+// group of a log file every time it opens the file, for example when the program
+// restarts during the day.  By default they are only set when the file is
+// created, so that deliberate manual changes are left alone and an audit system
+// doesn't see a chmod on every start.
+func WithPermissionsReapplied() Option {
+	return func(dw *Writer) {
+		dw.reapplyPermissions = true
+	}
+}
+
+// FileFactory opens the log file with the given path name for writing (see
+// WithFileFactory).
+type FileFactory func(pathname string) (io.WriteCloser, error)
+
+// WithFileFactory makes the Writer call the given function to open each log file
+// instead of opening the file itself, so that the caller can insert compression,
+// encryption, a network sink or a test double below the naming and rotation
+// logic.  The Writer closes what the function returns when it rotates the log,
+// or, with WithWholeLines, once the record that straddled the rotation is
+// complete.
+// If it's an *os.File, everything works as normal.  Otherwise the function is
+// responsible for the permissions and ownership of the file, Sync calls its Sync
+// method if it has one, and features that work on the file itself, such as
+// preallocation, the journal, record repair and the checks done by Health, are
+// skipped.
+func WithFileFactory(factory FileFactory) Option {
+	return func(dw *Writer) {
+		dw.fileFactory = factory
+	}
+}
@@ -0,0 +1,212 @@
+package dailylogger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestSplitOptions checks that splitOptions separates the Options from the
+// positional arguments without disturbing their order.
+func TestSplitOptions(t *testing.T) {
+	const wantDirPermissions os.FileMode = 0700
+	args := []any{"bin", WithNoRotation(""), "daemon", wantDirPermissions}
+
+	options, rest := splitOptions(args)
+
+	if len(options) != 1 {
+		t.Errorf("want 1 option got %d", len(options))
+	}
+
+	userName, groupName, dirPermissions, _ := getLogFileDetails(rest...)
+	if userName != "bin" || groupName != "daemon" || dirPermissions != wantDirPermissions {
+		t.Errorf("want bin, daemon, 0%o got %s, %s, 0%o",
+			wantDirPermissions, userName, groupName, dirPermissions)
+	}
+}
+
+// TestNoRotation checks that when rotation is disabled the Writer uses a fixed
+// file name, even across a day boundary.
+func TestNoRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFileName = "foo.bar"
+	const wantContents = "helloworld"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 0, 0, 0, locationUTC)
+	tomorrow := now.Add(2 * time.Hour)
+
+	writer := New(now, ".", "foo.", ".bar", WithNoRotation(""))
+	writer.Write([]byte("hello"))
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("world"))
+
+	files, err := os.ReadDir(directoryName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(files) != 1 {
+		t.Errorf("want 1 file got %d", len(files))
+		return
+	}
+
+	if files[0].Name() != wantFileName {
+		t.Errorf("want %s got %s", wantFileName, files[0].Name())
+		return
+	}
+
+	contents, err := os.ReadFile(wantFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(logFiles) != 1 || logFiles[0].Name != wantFileName {
+		t.Errorf("want List to return just %s, got %v", wantFileName, logFiles)
+	}
+}
+
+// TestPermissionsOnlyAtCreation checks that by default the permissions of a log
+// file are only set when it's created, and with WithPermissionsReapplied every
+// time it's opened.
+func TestPermissionsOnlyAtCreation(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported under Windows")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const filename = "foo.2020-02-14.bar"
+	const changedPermissions = os.FileMode(0600)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var testData = []struct {
+		description string
+		options     []any
+		want        os.FileMode
+	}{
+		{"default", nil, changedPermissions},
+		{"reapplied", []any{WithPermissionsReapplied()}, 0640},
+	}
+
+	for _, td := range testData {
+		args := append([]any{"", "", os.FileMode(0), os.FileMode(0640)}, td.options...)
+
+		writer := New(now, ".", "foo.", ".bar", args...)
+		writer.Close()
+
+		// Change the permissions by hand and start again.
+		os.Chmod(filename, changedPermissions)
+		writer = New(now, ".", "foo.", ".bar", args...)
+		writer.Close()
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != td.want {
+			t.Errorf("%s: want %v got %v", td.description, td.want, info.Mode().Perm())
+		}
+
+		os.Remove(filename)
+	}
+}
+
+// recordingFile is a test double for a log file opened by a FileFactory.
+type recordingFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+// Close records that the file was closed.
+func (rf *recordingFile) Close() error {
+	rf.closed = true
+	return nil
+}
+
+// TestFileFactory checks that the Writer opens its log files via the factory,
+// with the usual names, and closes them on rotation.
+func TestFileFactory(t *testing.T) {
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	files := make(map[string]*recordingFile)
+	factory := func(pathname string) (io.WriteCloser, error) {
+		files[pathname] = new(recordingFile)
+		return files[pathname], nil
+	}
+
+	writer := New(now, ".", "foo.", ".bar", WithFileFactory(factory))
+	writer.Write([]byte("a"))
+	writer.rotateLogs(now.AddDate(0, 0, 1))
+	writer.Write([]byte("b"))
+
+	if err := writer.Health(); err != nil {
+		t.Errorf("want a healthy Writer, got %v", err)
+	}
+	if err := writer.Sync(); err != nil {
+		t.Errorf("want Sync to succeed, got %v", err)
+	}
+	writer.Close()
+
+	var testData = []struct {
+		pathname string
+		want     string
+	}{
+		{"./foo.2020-02-14.bar", "a"},
+		{"./foo.2020-02-15.bar", "b"},
+	}
+
+	if len(files) != len(testData) {
+		t.Errorf("want %d files got %d", len(testData), len(files))
+	}
+
+	for _, td := range testData {
+		file, ok := files[td.pathname]
+		if !ok {
+			t.Errorf("want %s to be opened", td.pathname)
+			continue
+		}
+		if file.String() != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.pathname, td.want, file.String())
+		}
+		if !file.closed {
+			t.Errorf("%s: want the file to be closed", td.pathname)
+		}
+	}
+}
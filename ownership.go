@@ -0,0 +1,154 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+)
+
+// WithOwnershipRetry makes the Writer retry setting the owner and group of the
+// log directory and log files if the first attempt fails, for example because
+// the user hasn't been created yet when the program starts.  The first retry is
+// after the given interval and the interval doubles after each failure.  When an
+// attempt succeeds the Writer emits an EventOwnershipApplied.  If all the attempts
+// fail it emits an EventOwnershipFailed.  Without this option, the Writer emits an
+// EventOwnershipFailed as soon as the first attempt fails.
+func WithOwnershipRetry(interval time.Duration, attempts int) Option {
+	return func(dw *Writer) {
+		if interval > 0 && attempts > 0 {
+			dw.ownershipRetryInterval = interval
+			dw.ownershipRetryAttempts = attempts
+		}
+	}
+}
+
+// OwnershipError is returned by SetFileUserAndGroup when a process that isn't
+// running as root could only make part of the change, or none of it.
+type OwnershipError struct {
+	Path     string // The file or directory.
+	UserSet  bool   // True if the file is owned by the requested user.
+	GroupSet bool   // True if the file's group was set to the requested group.
+	Err      error  // Why the rest of the change couldn't be made.
+}
+
+// Error describes which parts of the change were made.
+func (oe *OwnershipError) Error() string {
+	done := func(set bool) string {
+		if set {
+			return "set"
+		}
+		return "not set"
+	}
+
+	return fmt.Sprintf("SetFileUserAndGroup: %s - user %s, group %s - %v",
+		oe.Path, done(oe.UserSet), done(oe.GroupSet), oe.Err)
+}
+
+// Unwrap returns ErrChown and the underlying error.
+func (oe *OwnershipError) Unwrap() []error {
+	return []error{ErrChown, oe.Err}
+}
+
+// setOwnershipWithoutRoot is a helper function for SetFileUserAndGroup that does
+// what a process that isn't running as root is allowed to do.  That's to change
+// the group of a file that it owns to one of its groups.  The owner can't be
+// changed, so it's only as requested if the file already has that owner.
+func setOwnershipWithoutRoot(filename string, uid, gid int) error {
+	owner, err := fileOwner(filename)
+	if err != nil {
+		return withClass(ErrChown, err)
+	}
+
+	result := OwnershipError{Path: filename, UserSet: owner == uid}
+	if err := os.Chown(filename, -1, gid); err != nil {
+		result.Err = err
+	} else {
+		result.GroupSet = true
+	}
+
+	if result.UserSet && result.GroupSet {
+		return nil
+	}
+	if result.Err == nil {
+		result.Err = errors.New("only root can change the owner")
+	}
+
+	return &result
+}
+
+// applyOwnership sets the owner and group of the given file or directory, if
+// they were specified.  This only works under a POSIX system.  A process that
+// isn't running as root can only change the group (see SetFileUserAndGroup).  If
+// it fails it may start a goroutine to retry.
+func (dw *Writer) applyOwnership(pathname string) {
+
+	if len(dw.userName) == 0 || len(dw.groupName) == 0 {
+		return
+	}
+
+	if runtime.GOOS == "windows" {
+		// We can't change the owner.
+		return
+	}
+
+	err := dw.setOwnership(pathname, dw.userName, dw.groupName)
+	if err == nil {
+		return
+	}
+
+	if dw.ownershipRetryAttempts > 0 {
+		log.Printf("applyOwnership: error setting user and group on %s - %v\n", pathname, err)
+		go dw.retryOwnership(pathname)
+		return
+	}
+
+	reported := withClass(ErrChown, fmt.Errorf("applyOwnership: error setting user and group on %s - %w", pathname, err))
+	dw.reportError(reported)
+	dw.recordStartupError(reported)
+	dw.emit(Event{Type: EventOwnershipFailed, Path: pathname, Err: err})
+}
+
+// retryOwnership tries repeatedly to set the owner and group of the given file
+// or directory, with an increasing delay between attempts.  It should be run in
+// a goroutine.
+func (dw *Writer) retryOwnership(pathname string) {
+
+	delay := dw.ownershipRetryInterval
+	var err error
+
+	for attempt := 0; attempt < dw.ownershipRetryAttempts; attempt++ {
+		select {
+		case <-dw.clock.After(delay):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		err = dw.setOwnership(pathname, dw.userName, dw.groupName)
+		if err == nil {
+			dw.emit(Event{Type: EventOwnershipApplied, Path: pathname})
+			return
+		}
+
+		delay *= 2
+	}
+
+	log.Printf("retryOwnership: giving up setting user and group on %s - %v\n", pathname, err)
+	dw.emit(Event{Type: EventOwnershipFailed, Path: pathname, Err: err})
+}
+
+// WithGroupInheritance sets the setgid bit on the log directory so that new log
+// files inherit the directory's group, which is the usual arrangement for a shared
+// log directory.  The owner and group given to New are applied to the directory
+// but the Writer doesn't try to change the ownership of the log files, so they
+// are owned by the user running the program.  Under macOS and the BSDs new files
+// always take the group of their directory, so the setgid bit makes no
+// difference there.  Under Windows this option has no effect.
+func WithGroupInheritance() Option {
+	return func(dw *Writer) {
+		dw.groupInheritance = true
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package dailylogger
+
+import "os"
+
+// fileOwner returns the user ID of the owner of the file.
+func fileOwner(pathname string) (int, error) {
+	info, err := os.Stat(longPath(pathname))
+	if err != nil {
+		return 0, err
+	}
+
+	st, err := statFromInfo(info)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(st.Uid), nil
+}
@@ -0,0 +1,269 @@
+package dailylogger
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingOwnership returns a fake setOwnership function that fails the given
+// number of times for each path and then succeeds.
+func failingOwnership(failures int) func(string, string, string) error {
+	var mutex sync.Mutex
+	calls := make(map[string]int)
+	return func(filename, userName, groupName string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls[filename]++
+		if calls[filename] <= failures {
+			return errors.New("no such user")
+		}
+		return nil
+	}
+}
+
+// TestOwnershipRetry checks that a failure to set the owner is retried and that
+// an event is emitted when it succeeds.
+func TestOwnershipRetry(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 10)
+	writer := New(now, "logs", "foo.", ".bar", "bin", "daemon",
+		withClock(fc),
+		WithOwnershipRetry(time.Second, 3),
+		WithEventHandler(func(e Event) { events <- e }),
+		func(dw *Writer) { dw.setOwnership = failingOwnership(1) })
+	defer writer.Close()
+
+	// The rotator and the two retry goroutines (for the directory and the file)
+	// go to sleep.  When they wake, the retries succeed.
+	fc.WaitForSleepers(3)
+	fc.Advance(time.Second)
+
+	for i := 0; i < 2; i++ {
+		event := <-events
+		if event.Type != EventOwnershipApplied {
+			t.Errorf("want %v got %v", EventOwnershipApplied, event.Type)
+		}
+	}
+}
+
+// TestOwnershipRetryGivesUp checks that the Writer gives up after the given
+// number of retries and emits an event.
+func TestOwnershipRetryGivesUp(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 10)
+	writer := New(now, "logs", "foo.", ".bar", "bin", "daemon",
+		withClock(fc),
+		WithOwnershipRetry(time.Second, 2),
+		WithEventHandler(func(e Event) { events <- e }),
+		func(dw *Writer) { dw.setOwnership = failingOwnership(100) })
+	defer writer.Close()
+
+	// The first retry is after a second and the second after a further two.
+	fc.WaitForSleepers(3)
+	fc.Advance(time.Second)
+	fc.WaitForSleepers(5)
+	fc.Advance(2 * time.Second)
+
+	for i := 0; i < 2; i++ {
+		event := <-events
+		if event.Type != EventOwnershipFailed {
+			t.Errorf("want %v got %v", EventOwnershipFailed, event.Type)
+		}
+		if event.Err == nil {
+			t.Error("want an error")
+		}
+	}
+}
+
+// TestOwnershipNoRetry checks that without retries a failure emits an event
+// straight away.
+func TestOwnershipNoRetry(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var events []Event
+	writer := New(now, "logs", "foo.", ".bar", "nosuchuser", "nosuchgroup",
+		WithEventHandler(func(e Event) { events = append(events, e) }))
+	defer writer.Close()
+
+	// One event for the directory and one for the file.
+	if len(events) != 2 {
+		t.Errorf("want 2 events got %d", len(events))
+		return
+	}
+
+	for _, event := range events {
+		if event.Type != EventOwnershipFailed {
+			t.Errorf("want %v got %v", EventOwnershipFailed, event.Type)
+		}
+	}
+}
+
+// TestGroupInheritance checks that with group inheritance the log directory has
+// the setgid bit and the log file gets the directory's group without being
+// chowned.
+func TestGroupInheritance(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no setgid bit")
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const logDir = "logs"
+	const wantFilename = logDir + "/foo.2020-02-14.bar"
+	const wantDirPermissions os.FileMode = 0750
+	const user = "bin"
+	const group = "daemon"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, logDir, "foo.", ".bar", user, group, wantDirPermissions, os.FileMode(0),
+		WithGroupInheritance())
+	defer writer.Close()
+
+	dirInfo, err := os.Stat(logDir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if dirInfo.Mode()&os.ModeSetgid == 0 {
+		t.Errorf("want the setgid bit on %s, got mode %v", logDir, dirInfo.Mode())
+	}
+
+	if dirInfo.Mode()&os.ModePerm != wantDirPermissions {
+		t.Errorf("want 0%o got 0%o", wantDirPermissions, dirInfo.Mode()&os.ModePerm)
+	}
+
+	file, err := os.Open(wantFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+
+	stat, err := statFile(file)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	wantGroupID, err := getGroupIDFromName(group)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if int(stat.Gid) != wantGroupID {
+		t.Errorf("want group %d got %d", wantGroupID, stat.Gid)
+	}
+
+	// The file was not chowned, so it's still owned by root.
+	if stat.Uid != 0 {
+		t.Errorf("want the file to be owned by root, got %d", stat.Uid)
+	}
+}
+
+// TestSetOwnershipWithoutRoot checks that a process that isn't root sets the
+// group and reports precisely which parts of the change were made.
+func TestSetOwnershipWithoutRoot(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported under Windows")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const filename = "foo.bar"
+	os.WriteFile(filename, nil, 0644)
+
+	// The file is owned by this process, so only the group needs setting.
+	if err := setOwnershipWithoutRoot(filename, os.Getuid(), os.Getgid()); err != nil {
+		t.Errorf("want success for the owner, got %v", err)
+	}
+
+	// The owner can't be changed.
+	err = setOwnershipWithoutRoot(filename, os.Getuid()+1, os.Getgid())
+	var oe *OwnershipError
+	if !errors.As(err, &oe) {
+		t.Fatalf("want an OwnershipError got %v", err)
+	}
+	if oe.UserSet || !oe.GroupSet {
+		t.Errorf("want the group set and the user not, got %v", oe)
+	}
+	if !errors.Is(err, ErrChown) {
+		t.Errorf("want an ErrChown, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package dailylogger
+
+import "io/fs"
+
+// fileOwner always fails because Windows files have no POSIX owner.
+func fileOwner(pathname string) (int, error) {
+	return 0, &fs.PathError{Op: "fileOwner", Path: pathname, Err: errWindows}
+}
@@ -0,0 +1,148 @@
+package dailylogger
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// A Pipeline is a Compressor made of a stack of stages, each of which is a
+// Compressor, for example compression followed by encryption (see Encryptor).
+// The data written to the pipeline goes through the stages in the order given,
+// so the last stage writes to the file, and its extension is theirs in the same
+// order, for example ".gz.enc".  Reading reverses the order.  A Pipeline can be
+// given to WithCompression, so that the stages are applied to each file after
+// rotation and the result renamed into place atomically, or to
+// WithCompressedLiveFile, so that they are applied as the file is written.
+// Closing the pipeline's writer closes the stages from the first to the last, so
+// that each stage's final output goes through the stages after it, and flushing
+// it flushes them in the same order.
+type Pipeline struct {
+	stages []Compressor
+}
+
+// This is a compile-time check that Pipeline implements the Compressor
+// interface.
+var _ Compressor = (*Pipeline)(nil)
+
+// NewPipeline creates a Pipeline from the stages, first stage first.  Nil stages
+// are ignored.
+func NewPipeline(stages ...Compressor) *Pipeline {
+	pipeline := Pipeline{}
+	for _, stage := range stages {
+		if stage != nil {
+			pipeline.stages = append(pipeline.stages, stage)
+		}
+	}
+	return &pipeline
+}
+
+// Extension returns the extensions of the stages, first stage first.
+func (p *Pipeline) Extension() string {
+	var extension strings.Builder
+	for _, stage := range p.stages {
+		extension.WriteString(stage.Extension())
+	}
+	return extension.String()
+}
+
+// NewWriter returns a writer that passes the data through the stages and writes
+// the result to dest.
+func (p *Pipeline) NewWriter(dest io.Writer) (io.WriteCloser, error) {
+	// Build the stack from the bottom, so that each stage writes to the next.
+	writers := make([]io.WriteCloser, len(p.stages))
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		writer, err := p.stages[i].NewWriter(dest)
+		if err != nil {
+			// Close the stages already built.
+			for _, built := range writers[i+1:] {
+				built.Close()
+			}
+			return nil, err
+		}
+		writers[i] = writer
+		dest = writer
+	}
+
+	return &pipelineWriter{first: dest, writers: writers}, nil
+}
+
+// NewReader returns a reader that undoes the stages, last stage first.
+func (p *Pipeline) NewReader(source io.Reader) (io.ReadCloser, error) {
+	if len(p.stages) == 0 {
+		return io.NopCloser(source), nil
+	}
+
+	readers := make([]io.ReadCloser, 0, len(p.stages))
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		reader, err := p.stages[i].NewReader(source)
+		if err != nil {
+			for _, built := range readers {
+				built.Close()
+			}
+			return nil, err
+		}
+		readers = append(readers, reader)
+		source = reader
+	}
+
+	return &pipelineReader{readers: readers}, nil
+}
+
+// pipelineWriter writes to the first of a stack of writers, each of which writes
+// to the next.
+type pipelineWriter struct {
+	first   io.Writer        // The first stage, or the destination if there are no stages.
+	writers []io.WriteCloser // The stages, first stage first.
+}
+
+// Write writes to the first stage.
+func (pw *pipelineWriter) Write(buffer []byte) (int, error) {
+	return pw.first.Write(buffer)
+}
+
+// Flush flushes the stages that can be flushed, first stage first, so that the
+// data held by each reaches the next before that's flushed.
+func (pw *pipelineWriter) Flush() error {
+	for _, writer := range pw.writers {
+		if flusher, ok := writer.(interface{ Flush() error }); ok {
+			if err := flusher.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the stages, first stage first, and returns any errors.
+func (pw *pipelineWriter) Close() error {
+	var errs []error
+	for _, writer := range pw.writers {
+		if err := writer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pipelineReader reads from the last of a stack of readers, each of which reads
+// from the one before.
+type pipelineReader struct {
+	readers []io.ReadCloser // The stages, the one reading the file first.
+}
+
+// Read reads from the last stage.
+func (pr *pipelineReader) Read(buffer []byte) (int, error) {
+	return pr.readers[len(pr.readers)-1].Read(buffer)
+}
+
+// Close closes the stages.
+func (pr *pipelineReader) Close() error {
+	var errs []error
+	for i := len(pr.readers) - 1; i >= 0; i-- {
+		if err := pr.readers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package dailylogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPipeline checks that a Pipeline applies its stages in order, both after
+// rotation and to the live file, and that the files can be read back.
+func TestPipeline(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	encryptor, err := NewEncryptor(bytes.Repeat([]byte{7}, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := NewPipeline(NewGzipCompressor(gzip.BestSpeed), encryptor)
+	if pipeline.Extension() != ".gz.enc" {
+		t.Errorf("want extension .gz.enc got %s", pipeline.Extension())
+	}
+
+	// After rotation.
+	writer := New(now, ".", "foo.", ".bar", WithCompression(pipeline))
+	writer.Write([]byte("first\n"))
+	writer.rotateLogs(tomorrow)
+	writer.Close()
+
+	// Live, with a restart during the day.
+	for _, line := range []string{"second\n", "third\n"} {
+		writer = New(now, ".", "live.", ".bar", WithCompressedLiveFile(pipeline, 0))
+		writer.Write([]byte(line))
+		writer.Close()
+	}
+
+	var testData = []struct {
+		leader string
+		want   string
+	}{
+		{"foo.", "first\n"},
+		{"live.", "second\nthird\n"},
+	}
+
+	for _, td := range testData {
+		pathname := td.leader + "2020-02-14.bar.gz.enc"
+		contents, err := os.ReadFile(pathname)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		// The encryption is the last stage, so it's on the outside.
+		if !bytes.HasPrefix(contents, encryptedMagic) {
+			t.Errorf("%s: want the encrypted header first", pathname)
+		}
+
+		reader, err := pipeline.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		plaintext, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Error(err)
+		}
+		if string(plaintext) != td.want {
+			t.Errorf("%s: want %q got %q", pathname, td.want, string(plaintext))
+		}
+	}
+}
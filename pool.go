@@ -0,0 +1,34 @@
+package dailylogger
+
+import "sync"
+
+// maxPooledBuffer is the capacity of the biggest buffer that's returned to the
+// pool.  Bigger ones are left to the garbage collector, so that one big write
+// doesn't tie up a lot of memory.
+const maxPooledBuffer = 64 * 1024
+
+// bufferPool holds buffers for the copies of the data queued in asynchronous
+// and sharded modes, so that Write doesn't allocate memory.
+var bufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, 0, 512)
+		return &buffer
+	},
+}
+
+// copyToPooledBuffer returns a buffer from the pool holding a copy of the data.
+func copyToPooledBuffer(data []byte) *[]byte {
+	buffer := bufferPool.Get().(*[]byte)
+	*buffer = append((*buffer)[:0], data...)
+	return buffer
+}
+
+// releasePooledBuffer returns a buffer to the pool once its data has been
+// written.
+func releasePooledBuffer(buffer *[]byte) {
+	if cap(*buffer) > maxPooledBuffer {
+		return
+	}
+
+	bufferPool.Put(buffer)
+}
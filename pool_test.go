@@ -0,0 +1,60 @@
+package dailylogger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWriteAllocations checks that Write doesn't allocate memory in the common
+// modes.
+func TestWriteAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool allocate")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	var testData = []struct {
+		description string
+		options     []any
+	}{
+		{"unbuffered", nil},
+		{"buffered", []any{WithBuffering(4096)}},
+		{"async", []any{WithAsync(100)}},
+		{"sharded", []any{WithShardedQueue(4, 100)}},
+		{"framed", []any{WithRecordFraming()}},
+		{"whole lines", []any{WithWholeLines()}},
+		{"journal", []any{WithBuffering(4096), WithJournal("")}},
+	}
+
+	record := []byte("something happened\n")
+	for _, td := range testData {
+		writer := New(time.Now(), ".", "allocs.", ".log", td.options...)
+
+		allocations := testing.AllocsPerRun(1000, func() { writer.Write(record) })
+		writer.Close()
+
+		if allocations != 0 {
+			t.Errorf("%s: want no allocations got %v per Write", td.description, allocations)
+		}
+	}
+}
+
+// TestPooledBuffer checks that the pooled buffers hold a copy of the data.
+func TestPooledBuffer(t *testing.T) {
+	data := []byte("hello")
+	buffer := copyToPooledBuffer(data)
+	data[0] = 'j'
+
+	if string(*buffer) != "hello" {
+		t.Errorf("want \"hello\" got \"%s\"", string(*buffer))
+	}
+	releasePooledBuffer(buffer)
+}
@@ -0,0 +1,52 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+)
+
+// WithPreallocation makes the Writer reserve the given number of bytes of disk
+// space for each new log file when it creates it.  That avoids fragmentation and
+// means that a log partition that is running out of space fails when the file is
+// created rather than half way through the day.  The space is reserved beyond
+// the end of the file, so the file's size and contents are unaffected, and any
+// that isn't used is released when the file is closed at rotation.  Preallocation
+// is supported under Linux and MS Windows.  On other systems the option has no
+// effect.
+func WithPreallocation(size int64) Option {
+	return func(dw *Writer) {
+		if size > 0 {
+			dw.preallocateSize = size
+		}
+	}
+}
+
+// preallocateLog is a helper function for openLog that reserves the space for a
+// new log file.  It doesn't apply the lock, so it should only be called by a
+// function that does.
+func (dw *Writer) preallocateLog() {
+	info, err := dw.logFile.Stat()
+	if err != nil || info.Size() > 0 {
+		// The file isn't new.
+		return
+	}
+
+	if err := preallocate(dw.logFile, dw.pathname, dw.preallocateSize); err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: cannot reserve %d bytes for %s - %w",
+			dw.preallocateSize, dw.pathname, err))
+	}
+}
+
+// trimLog is a helper function for closeLog that releases the space reserved
+// beyond the end of the log file.  It doesn't apply the lock, so it should only
+// be called by a function that does.
+func (dw *Writer) trimLog(logFile *os.File, pathname string) {
+	info, err := logFile.Stat()
+	if err == nil {
+		err = trimPreallocation(logFile, info.Size())
+	}
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: cannot release the space reserved for %s - %w",
+			pathname, err))
+	}
+}
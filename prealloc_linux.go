@@ -0,0 +1,28 @@
+//go:build linux
+
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate reserves space beyond the end of the file without changing its
+// size.
+func preallocate(file *os.File, pathname string, size int64) error {
+	err := unix.Fallocate(int(file.Fd()), unix.FALLOC_FL_KEEP_SIZE, 0, size)
+	if err != nil {
+		return &fs.PathError{Op: "fallocate", Path: pathname, Err: err}
+	}
+
+	return nil
+}
+
+// trimPreallocation releases any space reserved beyond the given size, which is
+// the size of the file.  Truncating a file to its own size frees the blocks
+// beyond the end.
+func trimPreallocation(file *os.File, size int64) error {
+	return file.Truncate(size)
+}
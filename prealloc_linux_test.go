@@ -0,0 +1,64 @@
+//go:build linux
+
+package dailylogger
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestPreallocation checks that space is reserved for a new log file without
+// changing its size, and that the space is released at rotation.
+func TestPreallocation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const size = 1 << 20
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithPreallocation(size))
+	defer writer.Close()
+	writer.Write([]byte("hello"))
+
+	const filename = "foo.2020-02-14.bar"
+	allocated, length := allocation(t, filename)
+	if allocated < size {
+		// Not all filesystems support preallocation, for example tmpfs on
+		// older kernels.
+		t.Skipf("the filesystem doesn't support preallocation (%d bytes allocated)", allocated)
+	}
+	if length != 5 {
+		t.Errorf("want the file size to be 5 got %d", length)
+	}
+
+	writer.rotateLogs(tomorrow)
+
+	allocated, length = allocation(t, filename)
+	if allocated >= size {
+		t.Errorf("want the reserved space to be released, %d bytes still allocated", allocated)
+	}
+	if length != 5 {
+		t.Errorf("after rotation want the file size to be 5 got %d", length)
+	}
+}
+
+// allocation returns the disk space allocated to the file and its size.
+func allocation(t *testing.T, filename string) (int64, int64) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return info.Sys().(*syscall.Stat_t).Blocks * 512, info.Size()
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+
+package dailylogger
+
+import "os"
+
+// preallocate does nothing on this system, which has no portable way to reserve
+// space beyond the end of a file.
+func preallocate(file *os.File, pathname string, size int64) error {
+	return nil
+}
+
+// trimPreallocation does nothing on this system.
+func trimPreallocation(file *os.File, size int64) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// preallocate reserves space for the file without changing its size.  The log
+// file is opened for appending, which doesn't allow its allocation to be
+// changed, so this opens it again for writing.
+func preallocate(file *os.File, pathname string, size int64) error {
+	writable, err := openLogFile(pathname, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer writable.Close()
+
+	info := struct{ AllocationSize int64 }{size}
+	err = windows.SetFileInformationByHandle(windows.Handle(writable.Fd()), windows.FileAllocationInfo,
+		(*byte)(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		return &fs.PathError{Op: "SetFileInformationByHandle", Path: pathname, Err: err}
+	}
+
+	return nil
+}
+
+// trimPreallocation does nothing under Windows.  NTFS releases the space
+// reserved beyond the end of the file when the last handle is closed.
+func trimPreallocation(file *os.File, size int64) error {
+	return nil
+}
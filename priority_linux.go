@@ -0,0 +1,41 @@
+//go:build linux
+
+package dailylogger
+
+import "golang.org/x/sys/unix"
+
+// These define the I/O priority given to ioprio_set.  The class goes in the top
+// bits and the idle class has no levels.
+const (
+	ioprioClassShift = 13
+	ioprioClassIdle  = 3
+	ioprioWhoProcess = 1
+)
+
+// lowerThreadPriority adds the nice level to the calling thread's and, if idleIO
+// is true, gives it the idle I/O class.  On Linux each thread has its own priority, so the
+// rest of the program is unaffected.
+func lowerThreadPriority(nice int, idleIO bool) error {
+	tid := unix.Gettid()
+
+	if nice > 0 {
+		// The system call returns 20 minus the current nice level.
+		current, err := unix.Getpriority(unix.PRIO_PROCESS, tid)
+		if err != nil {
+			return err
+		}
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, min(20-current+nice, 19)); err != nil {
+			return err
+		}
+	}
+
+	if idleIO {
+		_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid),
+			ioprioClassIdle<<ioprioClassShift)
+		if errno != 0 {
+			return errno
+		}
+	}
+
+	return nil
+}
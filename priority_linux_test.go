@@ -0,0 +1,68 @@
+//go:build linux
+
+package dailylogger
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// priorityArtifact is an Artifact that records the priority of the thread that
+// makes it.
+type priorityArtifact struct {
+	priority int
+	err      error
+}
+
+func (pa *priorityArtifact) Suffix() string { return ".priority" }
+
+func (pa *priorityArtifact) Make(dest io.Writer, logFile io.Reader) error {
+	pa.priority, pa.err = unix.Getpriority(unix.PRIO_PROCESS, unix.Gettid())
+	_, err := io.Copy(dest, logFile)
+	return err
+}
+
+// TestArtifactPriority checks that WithArtifactPriority lowers the priority of
+// the thread making the artifacts and not that of the test.
+func TestArtifactPriority(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	before, err := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	artifact := &priorityArtifact{}
+	writer := New(now, ".", "foo.", ".bar", WithArtifact(artifact), WithArtifactPriority(5, false))
+	writer.Write([]byte("hello\n"))
+	writer.rotate(now)
+	writer.Close()
+
+	if artifact.err != nil {
+		t.Fatal(artifact.err)
+	}
+
+	// The system call returns 20 minus the nice level.
+	if artifact.priority != before-5 && before-5 > 0 {
+		t.Errorf("want priority %d got %d", before-5, artifact.priority)
+	}
+
+	after, _ := unix.Getpriority(unix.PRIO_PROCESS, 0)
+	if after != before {
+		t.Errorf("the process priority changed from %d to %d", before, after)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package dailylogger
+
+// lowerThreadPriority does nothing on this system, which has no portable way to
+// lower the priority of one thread.
+func lowerThreadPriority(nice int, idleIO bool) error {
+	return nil
+}
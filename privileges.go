@@ -0,0 +1,65 @@
+package dailylogger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"runtime"
+	"time"
+)
+
+// DropPrivileges switches the process from root to the given user and group.  The
+// supplementary groups are reduced to just that group.  It affects the whole process,
+// not just the Writer, and it can't be undone.  The process must be running as root
+// under a POSIX system.  Under Windows the call returns a syscall.EWINDOWS error
+// wrapped in an io.fs.PathError, which is also an ErrNotPosix.
+func DropPrivileges(userName, groupName string) error {
+
+	if runtime.GOOS == "windows" {
+		// We are running under Windows, so setuid etc will not work.
+		return &fs.PathError{Op: "DropPrivileges", Err: errWindows}
+	}
+
+	if os.Getuid() != 0 {
+		return errors.New("DropPrivileges: must be root")
+	}
+
+	uid, ue := getUserIDFromName(userName)
+	if ue != nil {
+		return errors.New("DropPrivileges: userName " + userName + " " + ue.Error())
+	}
+
+	gid, ge := getGroupIDFromName(groupName)
+	if ge != nil {
+		return errors.New("DropPrivileges: groupName " + groupName + " " + ge.Error())
+	}
+
+	return setUserAndGroup(uid, gid)
+}
+
+// NewAndDropPrivileges is for a program that starts as root only so that it can
+// set up its logging.  It creates a Writer as New does, with the log directory and
+// today's log file owned by the given user and group, and then switches the process
+// to that user and group (see DropPrivileges).  From then on the Writer creates
+// files as that user, so the user must be able to write to the log directory.  The
+// optional arguments are the log directory permissions and log file permissions
+// (both os.FileMode) and any Options.  For example:
+//
+//	NewAndDropPrivileges(time.Now(), "/var/log/collector", "collector.", ".log",
+//		"collector", "collector", os.FileMode(0750), os.FileMode(0640))
+//
+// If the privileges can't be dropped, the Writer is closed and the error is
+// returned.
+func NewAndDropPrivileges(now time.Time, logDir, leader, trailer, userName, groupName string,
+	args ...any) (*Writer, error) {
+
+	dw := New(now, logDir, leader, trailer, append([]any{userName, groupName}, args...)...)
+
+	err := DropPrivileges(userName, groupName)
+	if err != nil {
+		dw.Close()
+		return nil, err
+	}
+
+	return dw, nil
+}
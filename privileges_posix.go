@@ -0,0 +1,20 @@
+//go:build !windows
+
+package dailylogger
+
+import "syscall"
+
+// setUserAndGroup sets the supplementary groups, the group ID and the user ID of the
+// process.  The user ID must be set last because after that the process is no
+// longer allowed to change the others.
+func setUserAndGroup(uid, gid int) error {
+	if err := syscall.Setgroups([]int{gid}); err != nil {
+		return err
+	}
+
+	if err := syscall.Setgid(gid); err != nil {
+		return err
+	}
+
+	return syscall.Setuid(uid)
+}
@@ -0,0 +1,111 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestDropPrivilegesUnknownUser checks that DropPrivileges fails for a user that
+// doesn't exist, without changing anything.
+func TestDropPrivilegesUnknownUser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no setuid")
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	err := DropPrivileges("nosuchuser", "daemon")
+	if err == nil {
+		t.Error("want an error")
+	}
+
+	if os.Getuid() != 0 {
+		t.Error("want the process to still be root")
+	}
+}
+
+// TestNewAndDropPrivileges runs a copy of the test program that creates a Writer
+// and drops its privileges, writes to the log and then checks who it's running as.
+// It has to be done in a separate process because there's no way back to root.
+func TestNewAndDropPrivileges(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no setuid")
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("must be root to run this test")
+	}
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	// The temporary directory may not be searchable by the user.
+	os.Chmod(directoryName, 0755)
+
+	const wantFilename = "logs/foo.2020-02-14.bar"
+	const wantContents = "hello"
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperDropPrivileges")
+	cmd.Env = append(os.Environ(), "DAILYLOGGER_HELPER=1")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Errorf("helper failed - %v\n%s", err, string(output))
+		return
+	}
+
+	contents, err := os.ReadFile(wantFilename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
+
+// TestHelperDropPrivileges is not a real test.  It's run by TestNewAndDropPrivileges
+// in a separate process.
+func TestHelperDropPrivileges(t *testing.T) {
+	if os.Getenv("DAILYLOGGER_HELPER") != "1" {
+		return
+	}
+
+	const user = "bin"
+	const group = "daemon"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer, err := NewAndDropPrivileges(now, "logs", "foo.", ".bar", user, group,
+		os.FileMode(0755), os.FileMode(0644))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer writer.Close()
+
+	wantUserID, _ := getUserIDFromName(user)
+	if os.Getuid() != wantUserID {
+		fmt.Fprintf(os.Stderr, "want uid %d got %d\n", wantUserID, os.Getuid())
+		os.Exit(1)
+	}
+
+	if _, err := writer.Write([]byte("hello")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package dailylogger
+
+import "io/fs"
+
+// setUserAndGroup always fails because Windows has no setuid.
+func setUserAndGroup(uid, gid int) error {
+	return &fs.PathError{Op: "setUserAndGroup", Err: errWindows}
+}
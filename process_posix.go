@@ -0,0 +1,21 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// processAlive returns true if a process with the given ID is running on this
+// host.  Sending signal 0 checks that the process exists without disturbing it.
+// EPERM means that it exists but belongs to another user.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code that Windows reports for a process that hasn't
+// exited yet.
+const stillActive = 259
+
+// processAlive returns true if a process with the given ID is running on this
+// host.  If the process exists but we aren't allowed to look at it, it's
+// assumed to be running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return true
+	}
+	return exitCode == stillActive
+}
@@ -0,0 +1,145 @@
+package dailylogger
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// WithEmergencyPurge protects the rest of the system from a full disk.  Every
+// interval the Writer checks the free space on the device holding the log
+// directory.  If it has fallen below the watermark (in bytes), the Writer deletes
+// the log files for earlier days, oldest first, until the free space is above the
+// watermark again or there are no more to delete.  Today's log files are never
+// deleted.  Each deletion is logged and, if the callback is not nil, it's called
+// with the list of files that were removed.  The check is also made when the
+// Writer is created.  The option has no effect when rotation is disabled.  To
+// see what would be deleted without deleting it, use WithRetentionDryRun.
+func WithEmergencyPurge(watermark uint64, interval time.Duration, callback func([]LogFile)) Option {
+	return func(dw *Writer) {
+		if watermark > 0 && interval > 0 {
+			dw.purgeWatermark = watermark
+			dw.purgeInterval = interval
+			dw.purgeCallback = callback
+		}
+	}
+}
+
+// purgeMonitor checks the free space at regular intervals until the Writer is
+// closed.  It should be run in a goroutine.
+func (dw *Writer) purgeMonitor() {
+	for {
+		dw.purgeIfLow()
+
+		select {
+		case <-dw.clock.After(dw.purgeInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+	}
+}
+
+// purgeIfLow deletes old log files if the free space is below the watermark and
+// then calls the callback.  It returns the files that were removed.
+func (dw *Writer) purgeIfLow() []LogFile {
+	if dw.retentionDryRun != nil {
+		// Report what would be deleted instead.
+		dw.refreshInUse()
+		dw.reportRetention()
+		return nil
+	}
+
+	if free, err := dw.logFreeSpace(); err != nil || free < dw.purgeWatermark {
+		// Files may be deleted, so find the ones in use before taking the lock.
+		dw.refreshInUse()
+	}
+
+	removed := dw.purgeOldFiles()
+	if len(removed) > 0 && dw.purgeCallback != nil {
+		// The callback is called without the lock so that it can use the Writer.
+		dw.purgeCallback(removed)
+	}
+
+	return removed
+}
+
+// purgeOldFiles is a helper function for purgeIfLow that does the deleting.
+func (dw *Writer) purgeOldFiles() []LogFile {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed || dw.noRotation {
+		return nil
+	}
+
+	free, err := dw.logFreeSpace()
+	if err != nil {
+		dw.reportError(fmt.Errorf("purge: error getting free space for %s - %w", dw.logDir, err))
+		return nil
+	}
+	if free >= dw.purgeWatermark {
+		return nil
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		dw.reportError(fmt.Errorf("purge: error listing %s - %w", dw.logDir, err))
+		return nil
+	}
+
+	var removed []LogFile
+	if len(dw.quarantineDir) > 0 {
+		// The files in the quarantine go first.
+		free, removed = dw.purgeQuarantine(free)
+	}
+
+	for _, logFile := range logFiles {
+		if free >= dw.purgeWatermark || !logFile.Date.Before(dw.startOfToday) {
+			// Either there is enough space now or we have reached today's files,
+			// which are never removed.  The list is sorted, so we are done.
+			break
+		}
+
+		if dw.offLimits(logFile) || logFile.Cold {
+			// The file is still in use or it's on another filesystem.
+			continue
+		}
+
+		reason := fmt.Errorf("emergency purge - free space %d is below %d bytes", free, dw.purgeWatermark)
+		if err := dw.removeFile(logFile.Pathname, reason); err != nil {
+			dw.reportError(fmt.Errorf("purge: error removing %s - %w", logFile.Pathname, err))
+			continue
+		}
+
+		log.Printf("purge: free space %d is below %d bytes - removed %s\n",
+			free, dw.purgeWatermark, logFile.Pathname)
+		removed = append(removed, logFile)
+
+		free, err = dw.logFreeSpace()
+		if err != nil {
+			dw.reportError(fmt.Errorf("purge: error getting free space for %s - %w", dw.logDir, err))
+			break
+		}
+	}
+
+	return removed
+}
+
+// offLimits returns true if the Writer must leave the log file alone because
+// it's still being written, it's excluded (see WithRetentionFilter and Hold) or,
+// with WithInUseCheck, another program may be using it (see refreshInUse).  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) offLimits(logFile LogFile) bool {
+	return logFile.Pathname == dw.pathname || logFile.Pathname == dw.partialLinePath ||
+		dw.excluded(logFile) || dw.busy(logFile.Pathname, dw.inUse)
+}
+
+// logFreeSpace returns the free space on the log directory's filesystem,
+// counting the space taken by the files that are being destroyed (see
+// removeFile) as free, since it soon will be.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) logFreeSpace() (uint64, error) {
+	free, err := dw.freeSpace(dw.logDir)
+	return free + dw.shredding, err
+}
@@ -0,0 +1,72 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// filesFreeSpace returns a fake free space function under which each file in
+// the directory uses 100 bytes of a 1000 byte disk.
+func filesFreeSpace(directory string) (uint64, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	return 1000 - 100*uint64(len(entries)), nil
+}
+
+// TestEmergencyPurge checks that the oldest log files are removed until the free
+// space is above the watermark, that today's file is never removed and that the
+// check is repeated.
+func TestEmergencyPurge(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.Mkdir("logs", 0755)
+	for _, name := range []string{"foo.2020-02-11.bar", "foo.2020-02-12.bar", "foo.2020-02-13.bar"} {
+		os.WriteFile("logs/"+name, []byte("old"), 0644)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	// With today's file there are four files, leaving 600 bytes free.  Removing
+	// two of them gets above the watermark.
+	purged := make(chan []LogFile, 10)
+	writer := New(now, "logs", "foo.", ".bar",
+		withClock(fc),
+		WithEmergencyPurge(750, time.Minute, func(removed []LogFile) { purged <- removed }),
+		func(dw *Writer) { dw.freeSpace = filesFreeSpace })
+	defer writer.Close()
+
+	removed := <-purged
+	if len(removed) != 2 || removed[0].Name != "foo.2020-02-11.bar" || removed[1].Name != "foo.2020-02-12.bar" {
+		t.Errorf("want the files for the 11th and 12th to be removed, got %v", removed)
+		return
+	}
+
+	// Fill the disk.  At the next check, everything except today's file goes.
+	writer.purgeWatermark = 10000
+	fc.WaitForSleepers(2)
+	fc.Advance(time.Minute)
+
+	removed = <-purged
+	if len(removed) != 1 || removed[0].Name != "foo.2020-02-13.bar" {
+		t.Errorf("want the file for the 13th to be removed, got %v", removed)
+		return
+	}
+
+	if _, err := os.Stat("logs/foo.2020-02-14.bar"); err != nil {
+		t.Errorf("want today's file to be kept - %v", err)
+	}
+}
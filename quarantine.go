@@ -0,0 +1,254 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errNoQuarantine is returned by Restore when there is no quarantine.
+var errNoQuarantine = errors.New("dailylogger: no quarantine directory (see WithQuarantine)")
+
+// WithQuarantine gives the log files removed by the retention rule set by
+// WithMaxAge a grace period.  Instead of being deleted, each file is moved to the
+// given subdirectory of the log directory, where it stays for the given number
+// of days before it's deleted.  Until then Restore can move it back.  Each move
+// emits an EventFileQuarantined (see WithLifecycleEvents).
+//
+// The emergency purge (see WithEmergencyPurge) needs to free space, which moving
+// a file within the log directory doesn't do, so it deletes the files in the
+// quarantine first, oldest first, and only then deletes log files.  The files in
+// the cold directory (see WithColdTier) are quarantined in a subdirectory of the
+// same name there.
+func WithQuarantine(subdirectory string, days int) Option {
+	return func(dw *Writer) {
+		subdirectory = strings.Trim(strings.TrimSpace(subdirectory), "/")
+		if len(subdirectory) > 0 && days > 0 {
+			dw.quarantineDir = subdirectory
+			dw.quarantineDays = days
+		}
+	}
+}
+
+// Restore moves the log files for the day of the given time out of the
+// quarantine and back into the log directory, or the cold directory if that's
+// where they came from, and returns them.  The retention
+// rules leave the day's files alone from then on, until the Writer is closed.  It
+// returns an error if there is no quarantine.
+func (dw *Writer) Restore(date time.Time) ([]LogFile, error) {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return nil, ErrClosed
+	}
+	if len(dw.quarantineDir) == 0 {
+		return nil, errNoQuarantine
+	}
+
+	day := getLastMidnight(date.In(dw.location))
+	dw.restored[day.Format(time.DateOnly)] = true
+
+	quarantined, err := dw.listQuarantine()
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []LogFile
+	for _, logFile := range quarantined {
+		if !logFile.Date.Equal(day) {
+			continue
+		}
+
+		root := dw.tierDirectory(logFile)
+		pathname := root + "/" + logFile.Name
+		dw.createParent(root, pathname)
+		if err := os.Rename(longPath(logFile.Pathname), longPath(pathname)); err != nil {
+			return restored, fmt.Errorf("Restore: error moving %s - %w", logFile.Pathname, err)
+		}
+
+		logFile.Pathname = pathname
+		restored = append(restored, logFile)
+		dw.emitLifecycle(Event{Type: EventFileRestored, Path: pathname})
+	}
+
+	return restored, nil
+}
+
+// quarantinePath returns the path name of the quarantine directory for the files
+// in the given directory.  The files in the cold directory (see WithColdTier) have
+// a quarantine of their own, so that they stay on the same filesystem.
+func (dw *Writer) quarantinePath(directory string) string {
+	return directory + "/" + dw.quarantineDir
+}
+
+// quarantine is a helper function for retire that moves the log file to the
+// quarantine.  The modification time is set to now, so that the file stays in
+// the quarantine for the full grace period.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) quarantine(logFile LogFile, reason error) {
+	pathname := dw.quarantinePath(dw.tierDirectory(logFile)) + "/" + logFile.Name
+	dw.makeParent(filepath.Dir(pathname))
+	dw.releaseFile(logFile.Pathname)
+	if err := os.Rename(longPath(logFile.Pathname), longPath(pathname)); err != nil {
+		dw.reportError(fmt.Errorf("retention: error moving %s to the quarantine - %w", logFile.Pathname, err))
+		return
+	}
+
+	now := dw.now()
+	os.Chtimes(longPath(pathname), now, now)
+	dw.emitLifecycle(Event{Type: EventFileQuarantined, Path: pathname, Err: reason})
+}
+
+// expireQuarantine is a helper function for applyRetention that deletes the
+// files that have been in the quarantine for longer than the grace period.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) expireQuarantine() {
+	if len(dw.quarantineDir) == 0 {
+		return
+	}
+
+	quarantined, err := dw.listQuarantine()
+	if err != nil {
+		dw.reportError(fmt.Errorf("retention: error listing the quarantine - %w", err))
+		return
+	}
+
+	deadline := dw.now().AddDate(0, 0, -dw.quarantineDays)
+	for _, logFile := range quarantined {
+		info, err := os.Stat(longPath(logFile.Pathname))
+		if err != nil || info.ModTime().After(deadline) {
+			continue
+		}
+
+		dw.removeQuarantined(logFile, fmt.Errorf("in the quarantine for more than %d days", dw.quarantineDays))
+	}
+}
+
+// purgeQuarantine is a helper function for the emergency purge that deletes the
+// files in the quarantine, oldest first, until the free space reaches the
+// watermark.  It returns the free space and the files that were removed.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) purgeQuarantine(free uint64) (uint64, []LogFile) {
+	quarantined, err := dw.listQuarantine()
+	if err != nil {
+		dw.reportError(fmt.Errorf("purge: error listing the quarantine - %w", err))
+		return free, nil
+	}
+
+	var removed []LogFile
+	for _, logFile := range quarantined {
+		if free >= dw.purgeWatermark {
+			break
+		}
+		if logFile.Cold {
+			// Deleting it wouldn't free any space on the log directory's filesystem.
+			continue
+		}
+
+		reason := fmt.Errorf("emergency purge - free space %d is below %d bytes", free, dw.purgeWatermark)
+		if !dw.removeQuarantined(logFile, reason) {
+			continue
+		}
+		removed = append(removed, logFile)
+
+		free, err = dw.logFreeSpace()
+		if err != nil {
+			dw.reportError(fmt.Errorf("purge: error getting free space for %s - %w", dw.logDir, err))
+			break
+		}
+	}
+
+	return free, removed
+}
+
+// removeQuarantined deletes a file in the quarantine and returns true if that
+// worked.  A file that is excluded (see WithRetentionFilter and Hold) or that
+// another program is using (see WithInUseCheck) is left for later.  It doesn't apply the lock, so it should only be called by a
+// function that does.
+func (dw *Writer) removeQuarantined(logFile LogFile, reason error) bool {
+	if dw.excluded(logFile) || dw.busy(logFile.Pathname, dw.inUse) {
+		return false
+	}
+
+	if err := dw.removeFile(logFile.Pathname, reason); err != nil {
+		dw.reportError(fmt.Errorf("retention: error removing %s - %w", logFile.Pathname, err))
+		return false
+	}
+
+	return true
+}
+
+// listQuarantine returns the log files in the quarantine, including the cold
+// directory's, sorted by date and then by sequence number.
+func (dw *Writer) listQuarantine() ([]LogFile, error) {
+	logFiles, err := dw.listQuarantineIn(dw.logDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dw.coldDir) > 0 {
+		coldFiles, err := dw.listQuarantineIn(dw.coldDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, logFile := range coldFiles {
+			logFile.Cold = true
+			logFiles = append(logFiles, logFile)
+		}
+	}
+
+	sort.Slice(logFiles, func(i, j int) bool {
+		if !logFiles[i].Date.Equal(logFiles[j].Date) {
+			return logFiles[i].Date.Before(logFiles[j].Date)
+		}
+		return logFiles[i].Sequence < logFiles[j].Sequence
+	})
+
+	return logFiles, nil
+}
+
+// listQuarantineIn is a helper function for listQuarantine that returns the log
+// files in the quarantine of the given directory.
+func (dw *Writer) listQuarantineIn(root string) ([]LogFile, error) {
+	directory := dw.quarantinePath(root)
+
+	var logFiles []LogFile
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == directory {
+				// Nothing has been quarantined yet.
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+
+		date, sequence, compressed, ok := dw.parseListedName(name)
+		if ok {
+			logFiles = append(logFiles, LogFile{
+				Name:       name,
+				Pathname:   directory + "/" + name,
+				Date:       date,
+				Sequence:   sequence,
+				Compressed: compressed,
+			})
+		}
+		return nil
+	})
+
+	return logFiles, err
+}
@@ -0,0 +1,90 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestQuarantine checks that retention moves old files to the quarantine, that
+// Restore moves them back and that files are deleted from the quarantine after
+// the grace period.
+func TestQuarantine(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	for _, name := range []string{"foo.2020-02-10.bar", "foo.2020-02-11.bar", "foo.2020-02-13.bar"} {
+		os.WriteFile(name, []byte("old"), 0644)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	// Files more than 2 days old, the 10th and the 11th, are quarantined.
+	events := make(chan Event, 10)
+	writer := New(now, ".", "foo.", ".bar", withClock(fc),
+		WithMaxAge(2), WithQuarantine("quarantine", 3),
+		WithLifecycleEvents(), WithEventChannel(events))
+	defer writer.Close()
+
+	for _, want := range []string{"./quarantine/foo.2020-02-10.bar", "./quarantine/foo.2020-02-11.bar"} {
+		event := nextEvent(events, EventFileQuarantined)
+		if event.Path != want {
+			t.Errorf("want %s quarantined, got %v", want, event)
+		}
+		if _, err := os.Stat(want); err != nil {
+			t.Error(err)
+		}
+	}
+	if logFiles, _ := writer.List(); len(logFiles) != 2 {
+		t.Errorf("want the files for the 13th and 14th listed, got %v", logFiles)
+	}
+
+	restored, err := writer.Restore(now.AddDate(0, 0, -3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 1 || restored[0].Pathname != "./foo.2020-02-11.bar" {
+		t.Errorf("want the 11th restored, got %v", restored)
+	}
+	if _, err := os.Stat("foo.2020-02-11.bar"); err != nil {
+		t.Error(err)
+	}
+
+	// After the grace period the 10th is deleted.  The 11th was restored, so it's
+	// left alone.
+	longAgo := now.AddDate(0, 0, -4)
+	os.Chtimes("quarantine/foo.2020-02-10.bar", longAgo, longAgo)
+	writer.applyRetention()
+
+	if _, err := os.Stat("quarantine/foo.2020-02-10.bar"); !os.IsNotExist(err) {
+		t.Errorf("want the 10th deleted from the quarantine, got %v", err)
+	}
+	if _, err := os.Stat("foo.2020-02-11.bar"); err != nil {
+		t.Errorf("want the restored file kept - %v", err)
+	}
+}
+
+// nextEvent returns the next event of the given type from the channel, skipping
+// any others.  It gives up after ten seconds.
+func nextEvent(events <-chan Event, eventType EventType) Event {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			return Event{}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// WithDailyQuota limits the amount written to the log each day, so that runaway
+// output can't fill the disk.  Once a write would take the total for the day past
+// the limit (in bytes), the Writer writes a single marker line saying that the
+// quota has been exceeded.  After that, if sampleEvery is 0 or 1, all writes are
+// dropped until the next day.  Otherwise one write in every sampleEvery is kept
+// and the rest are dropped.  Dropped writes still appear to succeed, but they
+// are counted.  The total includes anything already in the day's log files when
+// the Writer starts.  Writes are never split, so every write is either logged in
+// full or dropped.  The day is the log day, so the quota starts again when the
+// log rotates to a new day rather than a day after the Writer started.
+func WithDailyQuota(limit int64, sampleEvery int) Option {
+	return func(dw *Writer) {
+		if limit > 0 {
+			dw.quotaLimit = limit
+			dw.quotaSampleEvery = sampleEvery
+		}
+	}
+}
+
+// quotaAllows is a helper function for writeToLog that returns true if the buffer
+// should be written.  If not, it counts the write as dropped.  When the quota is
+// first exceeded, it writes the marker line.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) quotaAllows(buffer []byte) bool {
+
+	day := dw.quotaDayNow()
+	if !day.Equal(dw.quotaDay) {
+		// It's a new day.
+		dw.quotaDay = day
+		dw.quotaBytes = dw.bytesWrittenOn(day)
+		dw.quotaExceeded = false
+		dw.quotaSkipped = 0
+	}
+
+	if !dw.quotaExceeded {
+		if dw.quotaBytes+int64(len(buffer)) <= dw.quotaLimit {
+			dw.quotaBytes += int64(len(buffer))
+			return true
+		}
+
+		dw.quotaExceeded = true
+		dw.writeNote(dw.quotaMarker())
+		dw.reportError(fmt.Errorf("%w - %d bytes", ErrQuotaExceeded, dw.quotaLimit))
+	}
+
+	if dw.quotaSampleEvery > 1 {
+		keep := dw.quotaSkipped%int64(dw.quotaSampleEvery) == 0
+		dw.quotaSkipped++
+		if keep {
+			dw.quotaBytes += int64(len(buffer))
+			return true
+		}
+	}
+
+	dw.stats.OverQuota.add(len(buffer))
+	dw.stats.Today.OverQuota.add(len(buffer))
+	return false
+}
+
+// quotaDayNow returns the day that the quota currently applies to.
+func (dw *Writer) quotaDayNow() time.Time {
+	if dw.noRotation {
+		// There is no date in the log file so the quota applies to the current day.
+		return getLastMidnight(dw.now())
+	}
+	return dw.startOfToday
+}
+
+// quotaMarker returns the line written to the log when the quota is exceeded.
+func (dw *Writer) quotaMarker() string {
+	if dw.quotaSampleEvery > 1 {
+		return fmt.Sprintf("dailylogger: daily quota of %d bytes exceeded - keeping 1 write in %d until tomorrow\n",
+			dw.quotaLimit, dw.quotaSampleEvery)
+	}
+
+	return fmt.Sprintf("dailylogger: daily quota of %d bytes exceeded - dropping writes until tomorrow\n",
+		dw.quotaLimit)
+}
+
+// bytesWrittenOn returns the total size of the existing log files for the given
+// day.
+func (dw *Writer) bytesWrittenOn(day time.Time) int64 {
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return 0
+	}
+
+	var total int64
+	for _, logFile := range logFiles {
+		if !logFile.Date.Equal(day) {
+			continue
+		}
+
+		info, err := os.Stat(longPath(logFile.Pathname))
+		if err == nil {
+			total += info.Size()
+		}
+	}
+
+	return total
+}
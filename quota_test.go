@@ -0,0 +1,109 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestDailyQuota checks that writes are dropped or sampled once the daily quota
+// is exceeded and that the quota is reset on the next day.
+func TestDailyQuota(t *testing.T) {
+
+	// This test uses the filestore.
+
+	var testData = []struct {
+		description     string
+		sampleEvery     int
+		wantContents    string
+		wantDropped     int64
+		wantDroppedSize int64
+	}{
+		{"drop", 0,
+			"a1\nb2\ndailylogger: daily quota of 6 bytes exceeded - dropping writes until tomorrow\n",
+			4, 12},
+		{"sample", 2,
+			"a1\nb2\ndailylogger: daily quota of 6 bytes exceeded - keeping 1 write in 2 until tomorrow\nc3\ne5\n",
+			2, 6},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				t.Errorf("createWorkingDirectory failed - %v", err)
+				return
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			locationUTC, _ := time.LoadLocation("UTC")
+			now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+			writer := New(now, ".", "foo.", ".bar", WithDailyQuota(6, td.sampleEvery))
+			defer writer.Close()
+
+			for _, line := range []string{"a1\n", "b2\n", "c3\n", "d4\n", "e5\n", "f6\n"} {
+				n, err := writer.Write([]byte(line))
+				if err != nil || n != len(line) {
+					t.Errorf("%s: want %d nil got %d %v", line, len(line), n, err)
+				}
+			}
+
+			contents, err := os.ReadFile("foo.2020-02-14.bar")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents) != td.wantContents {
+				t.Errorf("want \"%s\" got \"%s\"", td.wantContents, string(contents))
+			}
+
+			stats := writer.Stats()
+			if stats.OverQuota.Writes != td.wantDropped || stats.OverQuota.Bytes != td.wantDroppedSize {
+				t.Errorf("want %d writes and %d bytes dropped got %d and %d",
+					td.wantDropped, td.wantDroppedSize, stats.OverQuota.Writes, stats.OverQuota.Bytes)
+			}
+
+			// The next day, the quota starts again.
+			writer.rotateLogs(now.AddDate(0, 0, 1))
+			writer.Write([]byte("g7\n"))
+
+			contents, err = os.ReadFile("foo.2020-02-15.bar")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(contents) != "g7\n" {
+				t.Errorf("want \"g7\\n\" got \"%s\"", string(contents))
+			}
+		})
+	}
+}
+
+// TestDailyQuotaAfterRestart checks that the quota takes account of what was
+// written to today's log before the Writer started.
+func TestDailyQuotaAfterRestart(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.WriteFile("foo.2020-02-14.bar", []byte("12345"), 0644)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithDailyQuota(6, 0))
+	defer writer.Close()
+
+	writer.Write([]byte("ab"))
+
+	if writer.Stats().OverQuota.Writes != 1 {
+		t.Errorf("want 1 write dropped got %d", writer.Stats().OverQuota.Writes)
+	}
+}
@@ -0,0 +1,102 @@
+package dailylogger
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// WithReadOnlyFallback keeps the log going in memory when the filesystem holding
+// the log directory is remounted read-only, which is common after SD card errors.
+// When a write or an attempt to open the log file fails with EROFS, the Writer
+// emits an EventReadOnly and from then on keeps the most recent size bytes in
+// memory, discarding anything older.  At the given interval it tries to reopen the
+// log file.  When that works, it writes the saved data to the file and emits an
+// EventReadOnlyRecovered.  Stats shows how many bytes were discarded.
+func WithReadOnlyFallback(size int, retryInterval time.Duration) Option {
+	return func(dw *Writer) {
+		if size > 0 && retryInterval > 0 {
+			dw.readOnlyBufferSize = size
+			dw.readOnlyRetryInterval = retryInterval
+		}
+	}
+}
+
+// isReadOnlyError returns true if the error means that the filesystem is
+// read-only.
+func isReadOnlyError(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
+
+// enterReadOnly is a helper function that switches to keeping the log in memory.
+// It doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) enterReadOnly(err error) {
+	if dw.readOnly {
+		return
+	}
+
+	dw.readOnly = true
+	if dw.readOnlyBuffer == nil {
+		dw.readOnlyBuffer = newRingBuffer(dw.readOnlyBufferSize)
+	}
+	dw.emit(Event{Type: EventReadOnly, Path: dw.pathname, Err: err})
+}
+
+// keepInMemory is a helper function that adds the buffer to the data saved while
+// the filesystem is read-only.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) keepInMemory(buffer []byte) {
+	lost := dw.readOnlyBuffer.write(buffer)
+	dw.stats.ReadOnlyLostBytes += int64(lost)
+	dw.stats.Today.ReadOnlyLostBytes += int64(lost)
+}
+
+// readOnlyMonitor tries at regular intervals to write the saved data to the log
+// file until the Writer is closed.  It should be run in a goroutine.
+func (dw *Writer) readOnlyMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.readOnlyRetryInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		dw.leaveReadOnly()
+	}
+}
+
+// leaveReadOnly reopens the log file and writes the saved data to it.  It returns
+// true if the Writer is no longer keeping the log in memory.
+func (dw *Writer) leaveReadOnly() bool {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed || !dw.readOnly {
+		return !dw.readOnly
+	}
+
+	dw.closeLog()
+	dw.openLog()
+	if dw.openError() != nil {
+		// Still read-only.
+		return false
+	}
+
+	saved := dw.readOnlyBuffer.bytes()
+	n, err := dw.writeNoteBytes(saved)
+	if err == nil {
+		err = dw.flush()
+	}
+	if err != nil {
+		// Keep whatever wasn't written and try again later.
+		dw.readOnlyBuffer.reset()
+		dw.readOnlyBuffer.write(saved[n:])
+		return false
+	}
+
+	dw.readOnlyBuffer.reset()
+	dw.readOnly = false
+	dw.emit(Event{Type: EventReadOnlyRecovered, Path: dw.pathname})
+	return true
+}
@@ -0,0 +1,87 @@
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readOnlyWriter is an io.Writer that always fails with EROFS, as a file does
+// after the filesystem is remounted read-only.
+type readOnlyWriter struct{}
+
+// Write returns EROFS.
+func (readOnlyWriter) Write(buffer []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: "readonly", Err: syscall.EROFS}
+}
+
+// TestReadOnlyFallback checks that the Writer keeps the most recent data in memory
+// while the filesystem is read-only and writes it to the log file when it can.
+func TestReadOnlyFallback(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 10)
+	writer := New(now, ".", "foo.", ".bar", withClock(fc),
+		WithReadOnlyFallback(8, time.Minute),
+		WithEventHandler(func(e Event) { events <- e }))
+	defer writer.Close()
+
+	// Make the filesystem read-only.
+	writer.sink.SwitchTo(readOnlyWriter{})
+
+	for _, s := range []string{"hello", "world!"} {
+		n, err := writer.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Errorf("%s: want %d nil got %d %v", s, len(s), n, err)
+		}
+	}
+
+	if event := <-events; event.Type != EventReadOnly {
+		t.Errorf("want %v got %v", EventReadOnly, event.Type)
+	}
+
+	if writer.Health() == nil {
+		t.Error("want Health to fail while the filesystem is read-only")
+	}
+
+	// The rotator and the monitor go to sleep.  When the monitor wakes, it
+	// reopens the file and writes the saved data.
+	fc.WaitForSleepers(2)
+	fc.Advance(time.Minute)
+
+	if event := <-events; event.Type != EventReadOnlyRecovered {
+		t.Errorf("want %v got %v", EventReadOnlyRecovered, event.Type)
+	}
+
+	const wantContents = "loworld!"
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	if lost := writer.Stats().ReadOnlyLostBytes; lost != 3 {
+		t.Errorf("want 3 bytes lost got %d", lost)
+	}
+
+	if err := writer.Health(); err != nil {
+		t.Errorf("want a healthy writer got %v", err)
+	}
+}
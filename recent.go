@@ -0,0 +1,31 @@
+package dailylogger
+
+import "io"
+
+// WithRecentOutput makes the Writer keep a copy of the last size bytes written
+// to the log in memory, so that Dump can show recent activity, for example in a
+// crash handler or a status page, even if the log file is unavailable.  Writes
+// skipped by sampling or dropped by the quota aren't kept.
+func WithRecentOutput(size int) Option {
+	return func(dw *Writer) {
+		if size > 0 {
+			dw.recent = newRingBuffer(size)
+		}
+	}
+}
+
+// Dump writes the recent output kept by WithRecentOutput to w, oldest first.
+// Without that option it writes nothing.
+func (dw *Writer) Dump(w io.Writer) error {
+	dw.logMutex.Lock()
+	if dw.recent == nil {
+		dw.logMutex.Unlock()
+		return nil
+	}
+	recent := dw.recent.bytes()
+	dw.logMutex.Unlock()
+
+	// Write the copy without holding the lock, in case w is slow or is the Writer.
+	_, err := w.Write(recent)
+	return err
+}
@@ -0,0 +1,57 @@
+package dailylogger
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestDump checks that Dump writes the most recent output, even when the log
+// file couldn't be created.
+func TestDump(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// Create a directory with the name of the log file, so the log file can't be
+	// created.
+	os.Mkdir("foo.2020-02-14.bar", 0755)
+
+	writer := New(now, ".", "foo.", ".bar", WithRecentOutput(10))
+	defer writer.Close()
+
+	writer.Write([]byte("first line\n"))
+	writer.Write([]byte("second\n"))
+
+	var buffer bytes.Buffer
+	if err := writer.Dump(&buffer); err != nil {
+		t.Error(err)
+		return
+	}
+
+	const want = "ne\nsecond\n"
+	if buffer.String() != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, buffer.String())
+	}
+
+	// Without the option, Dump writes nothing.
+	writer2 := New(now, ".", "foo.", ".bar")
+	defer writer2.Close()
+	writer2.Write([]byte("hello"))
+
+	buffer.Reset()
+	writer2.Dump(&buffer)
+	if buffer.Len() != 0 {
+		t.Errorf("want nothing got \"%s\"", buffer.String())
+	}
+}
@@ -0,0 +1,128 @@
+package dailylogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxRecordLength is the default limit on the length of a record that a
+// RecordReader accepts.  A damaged length is usually much bigger than this.
+const maxRecordLength = 16 << 20
+
+// recordReadSize is the amount of data that a RecordReader reads at a time.
+const recordReadSize = 64 << 10
+
+// RecordReader reads the records from a log file written with record framing
+// (see WithRecordFraming and WithRecordChecksum) and checks their checksums.
+//
+// If a record is damaged, for example by bit rot on an SD card, Next returns an
+// error that wraps ErrCorruptRecord and gives the offset of the damage.  If the
+// reader was created to skip corrupt records, Next instead moves forward a byte
+// at a time until it finds a good record, counting the bytes that it passes over.
+type RecordReader struct {
+	source          io.Reader
+	checksum        Checksum
+	skipCorrupt     bool
+	maxRecordLength int
+
+	buffer  []byte // Data read from the source and not yet returned.
+	offset  int64  // The offset in the source of the start of the buffer.
+	skipped int64  // The number of bytes skipped because they were corrupt.
+	err     error  // The error from the last read of the source, if any.
+}
+
+// NewRecordReader creates a RecordReader that reads the records framed with the
+// given checksum from the source.  If skipCorrupt is true, damaged records are
+// skipped rather than reported.
+func NewRecordReader(source io.Reader, checksum Checksum, skipCorrupt bool) *RecordReader {
+	return &RecordReader{
+		source:          source,
+		checksum:        checksum,
+		skipCorrupt:     skipCorrupt,
+		maxRecordLength: maxRecordLength,
+	}
+}
+
+// Next returns the next good record.  The slice is only valid until the next
+// call.  At the end of the source it returns io.EOF.
+func (rr *RecordReader) Next() ([]byte, error) {
+	for {
+		if err := rr.fill(4); err != nil {
+			if len(rr.buffer) == 0 || !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			// A few bytes left over.
+			if rr.skipCorrupt {
+				rr.skip(len(rr.buffer))
+				continue
+			}
+			return nil, rr.corrupt()
+		}
+
+		length, ok := frameLength(rr.buffer, rr.maxRecordLength)
+		size := length + rr.checksum.overhead()
+		if ok && rr.fill(size) == nil && rr.checksum.frameValid(rr.buffer[:size]) {
+			record := rr.buffer[4 : 4+length]
+			rr.buffer = rr.buffer[size:]
+			rr.offset += int64(size)
+			return record, nil
+		}
+
+		if rr.err != nil && !errors.Is(rr.err, io.EOF) {
+			return nil, rr.err
+		}
+		if !rr.skipCorrupt {
+			return nil, rr.corrupt()
+		}
+		rr.skip(1)
+	}
+}
+
+// Offset returns the offset in the source of the next record.
+func (rr *RecordReader) Offset() int64 {
+	return rr.offset
+}
+
+// Skipped returns the number of bytes that have been skipped because they were
+// corrupt.
+func (rr *RecordReader) Skipped() int64 {
+	return rr.skipped
+}
+
+// fill reads from the source until the buffer holds at least n bytes.  It
+// returns io.EOF if the source runs out first.
+func (rr *RecordReader) fill(n int) error {
+	for len(rr.buffer) < n {
+		if rr.err != nil {
+			return rr.err
+		}
+
+		if cap(rr.buffer)-len(rr.buffer) < recordReadSize {
+			// Make room, moving the unread data to the front.
+			grown := make([]byte, len(rr.buffer), max(2*cap(rr.buffer), n+recordReadSize))
+			copy(grown, rr.buffer)
+			rr.buffer = grown
+		}
+
+		got, err := rr.source.Read(rr.buffer[len(rr.buffer):cap(rr.buffer)])
+		rr.buffer = rr.buffer[:len(rr.buffer)+got]
+		if err != nil {
+			rr.err = err
+		}
+	}
+
+	return nil
+}
+
+// skip passes over n corrupt bytes.
+func (rr *RecordReader) skip(n int) {
+	rr.buffer = rr.buffer[n:]
+	rr.offset += int64(n)
+	rr.skipped += int64(n)
+}
+
+// corrupt returns the error for a corrupt record at the current offset.
+func (rr *RecordReader) corrupt() error {
+	return fmt.Errorf("%w at offset %d", ErrCorruptRecord, rr.offset)
+}
@@ -0,0 +1,139 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// TestRecordReader checks that a RecordReader returns good records and either
+// reports or skips damaged ones.
+func TestRecordReader(t *testing.T) {
+	for _, checksum := range []Checksum{ChecksumCRC32, ChecksumCRC24Q} {
+		var data []byte
+		data = checksum.appendFrame(data, []byte("first"))
+		damageAt := len(data)
+		data = checksum.appendFrame(data, []byte("second"))
+		data[damageAt+6] ^= 0x01
+		data = checksum.appendFrame(data, []byte("third"))
+
+		// Report the damage.
+		reader := NewRecordReader(iotest.OneByteReader(bytes.NewReader(data)), checksum, false)
+		record, err := reader.Next()
+		if err != nil || string(record) != "first" {
+			t.Errorf("%v: want first got %q %v", checksum, record, err)
+		}
+		_, err = reader.Next()
+		if !errors.Is(err, ErrCorruptRecord) {
+			t.Errorf("%v: want ErrCorruptRecord got %v", checksum, err)
+		}
+		if reader.Offset() != int64(damageAt) {
+			t.Errorf("%v: want the damage at %d got %d", checksum, damageAt, reader.Offset())
+		}
+
+		// Skip it.
+		reader = NewRecordReader(bytes.NewReader(data), checksum, true)
+		var got []string
+		for {
+			record, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%v: %v", checksum, err)
+			}
+			got = append(got, string(record))
+		}
+		if len(got) != 2 || got[0] != "first" || got[1] != "third" {
+			t.Errorf("%v: want first and third got %v", checksum, got)
+		}
+		wantSkipped := int64(len("second") + checksum.overhead())
+		if reader.Skipped() != wantSkipped {
+			t.Errorf("%v: want %d bytes skipped got %d", checksum, wantSkipped, reader.Skipped())
+		}
+	}
+}
+
+// TestRecordChecksum checks that a Writer frames its records with the chosen
+// checksum and that a RecordReader can read them back.
+func TestRecordChecksum(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithRecordChecksum(ChecksumCRC24Q))
+	writer.Write([]byte("first"))
+	writer.Write([]byte("second"))
+	writer.Close()
+
+	file, err := os.Open("foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := NewRecordReader(file, ChecksumCRC24Q, false)
+	for _, want := range []string{"first", "second"} {
+		got, err := reader.Next()
+		if err != nil || string(got) != want {
+			t.Errorf("want %s got %q %v", want, got, err)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("want io.EOF got %v", err)
+	}
+}
+
+// FuzzRecordReader checks that a RecordReader doesn't panic or loop on any
+// input, that every record it returns is one that was framed properly and that,
+// when skipping, it accounts for every byte.
+func FuzzRecordReader(f *testing.F) {
+	good := appendFrame(appendFrame(nil, []byte("first")), []byte("second"))
+	f.Add(good, false, false)
+	f.Add(append(bytes.Clone(good[:9]), good...), true, false)
+	f.Add(ChecksumCRC24Q.appendFrame(nil, []byte("rtcm")), true, true)
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, false, false)
+	f.Add([]byte{0x80, 0, 0, 0, 1, 2, 3}, true, true)
+
+	f.Fuzz(func(t *testing.T, data []byte, skipCorrupt, crc24q bool) {
+		checksum := ChecksumCRC32
+		if crc24q {
+			checksum = ChecksumCRC24Q
+		}
+
+		reader := NewRecordReader(bytes.NewReader(data), checksum, skipCorrupt)
+		var returned int64
+		for range len(data) + 1 {
+			record, err := reader.Next()
+			if err != nil {
+				if !errors.Is(err, io.EOF) && !errors.Is(err, ErrCorruptRecord) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if skipCorrupt && errors.Is(err, io.EOF) && returned+reader.Skipped() != int64(len(data)) {
+					t.Errorf("returned %d bytes and skipped %d of %d", returned, reader.Skipped(), len(data))
+				}
+				return
+			}
+
+			frame := checksum.appendFrame(nil, record)
+			if !bytes.Contains(data, frame) {
+				t.Fatalf("record %q isn't framed in the input", record)
+			}
+			returned += int64(len(frame))
+		}
+		t.Fatal("want Next to finish")
+	})
+}
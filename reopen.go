@@ -0,0 +1,39 @@
+package dailylogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithPeriodicReopen makes the Writer close the log file and open it again at
+// the given interval, even if the day hasn't changed, so that no file handle is
+// held for very long.  Some filesystems, FUSE layers and antivirus software
+// misbehave when a file stays open for a day or more.  The reopen is done as by
+// Reopen, so nothing written is lost and writing carries on at the end of the
+// same file.
+func WithPeriodicReopen(interval time.Duration) Option {
+	return func(dw *Writer) {
+		if interval > 0 {
+			dw.reopenInterval = interval
+		}
+	}
+}
+
+// reopenMonitor runs until the Writer is closed, reopening the log file at the
+// interval given by WithPeriodicReopen.  It should be run in a goroutine.
+func (dw *Writer) reopenMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.reopenInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		if err := dw.Reopen(); err != nil && err != ErrClosed {
+			dw.logMutex.Lock()
+			dw.reportError(fmt.Errorf("dailylogger: periodic reopen failed - %w", err))
+			dw.logMutex.Unlock()
+		}
+	}
+}
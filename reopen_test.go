@@ -0,0 +1,58 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPeriodicReopen checks that the log file is reopened at the given interval
+// and that writing carries on at the end of the same file.
+func TestPeriodicReopen(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	var ri recordingInstrumentation
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithBuffering(100),
+		WithPeriodicReopen(30*time.Minute), WithInstrumentation(&ri))
+	defer writer.Close()
+
+	writer.Write([]byte("before "))
+
+	// The rotator and the reopen goroutine go to sleep.  When the reopen goroutine
+	// wakes it reopens the file and goes back to sleep.
+	fc.WaitForSleepers(2)
+	fc.Advance(30 * time.Minute)
+	fc.WaitForSleepers(3)
+
+	writer.logMutex.Lock()
+	operations := ri.operations
+	writer.logMutex.Unlock()
+	if len(operations) != 1 || operations[0] != OperationReopen {
+		t.Errorf("want one reopen got %v", operations)
+	}
+
+	writer.Write([]byte("after"))
+	writer.Sync()
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	const want = "before after"
+	if string(contents) != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, string(contents))
+	}
+}
@@ -0,0 +1,57 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// RepairResult is the outcome of repairing the permissions of one log file (see
+// RepairPermissions).
+type RepairResult struct {
+	Pathname string // The path name of the log file.
+	Err      error  // Why the file couldn't be repaired, or nil if it was.
+}
+
+// RepairPermissions sets the permissions, owner and group given to New on all of
+// the log files found by List, for example after the service account has been
+// changed.  The permissions, owner and group are only set under a POSIX system
+// and a program that isn't running as root can only change the group (see
+// SetFileUserAndGroup).  It returns a result for each file, in the order given
+// by List, and an error if the files couldn't be listed.  A failure with one
+// file doesn't stop the others being repaired.
+func (dw *Writer) RepairPermissions() ([]RepairResult, error) {
+	logFiles, err := dw.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RepairResult, 0, len(logFiles))
+	for _, logFile := range logFiles {
+		result := RepairResult{Pathname: logFile.Pathname, Err: dw.repairFile(logFile.Pathname)}
+		if result.Err != nil {
+			dw.reportError(fmt.Errorf("RepairPermissions: %w", result.Err))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// repairFile is a helper function for RepairPermissions that sets the
+// permissions, owner and group of one file.
+func (dw *Writer) repairFile(pathname string) error {
+	if dw.logFilePermissions != 0 && runtime.GOOS != "windows" {
+		if err := os.Chmod(longPath(pathname), dw.logFilePermissions); err != nil {
+			return err
+		}
+	}
+
+	if len(dw.userName) > 0 && len(dw.groupName) > 0 && runtime.GOOS != "windows" {
+		if err := dw.setOwnership(pathname, dw.userName, dw.groupName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
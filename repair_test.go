@@ -0,0 +1,80 @@
+package dailylogger
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestRepairPermissions checks that RepairPermissions sets the permissions, owner
+// and group of all of the log files.
+func TestRepairPermissions(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported under Windows")
+	}
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantPermissions = os.FileMode(0600)
+	wantNames := []string{"foo.2020-02-12.bar", "foo.2020-02-13.bar", "foo.2020-02-14.bar"}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// Old files created with the wrong permissions and one that isn't a log file.
+	os.WriteFile(wantNames[0], []byte("a"), 0644)
+	os.WriteFile(wantNames[1], []byte("b"), 0644)
+	os.WriteFile("foo.junk.bar", []byte("junk"), 0644)
+
+	writer := New(now, ".", "foo.", ".bar", "bin", "daemon", os.FileMode(0), wantPermissions)
+	defer writer.Close()
+
+	var owned []string
+	writer.setOwnership = func(filename, userName, groupName string) error {
+		owned = append(owned, filename)
+		return nil
+	}
+	os.Chmod(wantNames[2], 0644)
+
+	results, err := writer.RepairPermissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(wantNames) {
+		t.Fatalf("want %d results got %d", len(wantNames), len(results))
+	}
+
+	for i, result := range results {
+		if result.Pathname != "./"+wantNames[i] || result.Err != nil {
+			t.Errorf("want %s repaired, got %s %v", wantNames[i], result.Pathname, result.Err)
+		}
+
+		info, err := os.Stat(wantNames[i])
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if info.Mode().Perm() != wantPermissions {
+			t.Errorf("%s: want %v got %v", wantNames[i], wantPermissions, info.Mode().Perm())
+		}
+	}
+
+	if os.Getuid() == 0 && len(owned) != len(wantNames) {
+		t.Errorf("want the owner of %d files set, got %v", len(wantNames), owned)
+	}
+
+	info, _ := os.Stat("foo.junk.bar")
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("want the other file left alone, got %v", info.Mode().Perm())
+	}
+}
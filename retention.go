@@ -0,0 +1,243 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// retentionInterval is the time between applications of the retention rules set
+// by WithMaxAge, WithQuarantine and WithColdTier.
+const retentionInterval = time.Hour
+
+// Deletion describes a log file that the retention rules would delete (see
+// PlanRetention and WithRetentionDryRun).
+type Deletion struct {
+	LogFile
+	Size   int64  // The size of the file in bytes.
+	Reason string // Why the file would be deleted.
+}
+
+// WithMaxAge sets a retention rule that deletes the log files for days more than
+// the given number of days before today, so with 7 the files for today and the
+// 7 days before are kept.  The rule is applied when the Writer is created and
+// every hour after that.  Each deletion emits an EventFileDeleted (see
+// WithLifecycleEvents).  The option has no effect when rotation is disabled.
+func WithMaxAge(days int) Option {
+	return func(dw *Writer) {
+		if days > 0 {
+			dw.maxAge = days
+		}
+	}
+}
+
+// WithRetentionDryRun makes the retention rules (see WithMaxAge and
+// WithEmergencyPurge) report what they would delete instead of deleting it, so
+// that a new policy can be checked against a real log directory before it's
+// trusted with one.  Whenever the rules run and find files to delete, the report
+// function is called with them, in the order that they would be deleted.  The
+// Writer assumes that deleting a file would free its size on the disk.  The
+// function is called without the Writer's lock, so it may use the Writer.
+func WithRetentionDryRun(report func([]Deletion)) Option {
+	return func(dw *Writer) {
+		dw.retentionDryRun = report
+	}
+}
+
+// PlanRetention returns the log files that the retention rules would delete if
+// they ran now, in the order that they would be deleted, without deleting them.
+// It works whether or not a dry run was requested.
+func (dw *Writer) PlanRetention() ([]Deletion, error) {
+	dw.refreshInUse()
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return nil, ErrClosed
+	}
+
+	return dw.planRetention()
+}
+
+// planRetention is a helper function for PlanRetention that does the work.  The
+// files that are too old come first, followed by any that the emergency purge
+// would delete.  Files already in the quarantine are not included.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) planRetention() ([]Deletion, error) {
+	if dw.noRotation || (dw.maxAge == 0 && dw.purgeWatermark == 0) {
+		return nil, nil
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		return nil, fmt.Errorf("PlanRetention: error listing %s - %w", dw.logDir, err)
+	}
+
+	var plan []Deletion
+	planned := make(map[string]bool)
+	var freed uint64
+	for _, logFile := range dw.tooOld(logFiles) {
+		info, err := os.Stat(longPath(logFile.Pathname))
+		if err != nil {
+			continue
+		}
+
+		plan = append(plan, Deletion{
+			LogFile: logFile,
+			Size:    info.Size(),
+			Reason:  fmt.Sprintf("more than %d days old", dw.maxAge),
+		})
+		planned[logFile.Pathname] = true
+		freed += uint64(info.Size())
+	}
+
+	if dw.purgeWatermark == 0 {
+		return plan, nil
+	}
+
+	free, err := dw.logFreeSpace()
+	if err != nil {
+		return nil, fmt.Errorf("PlanRetention: error getting free space for %s - %w", dw.logDir, err)
+	}
+	free += freed
+
+	for _, logFile := range logFiles {
+		if free >= dw.purgeWatermark || !logFile.Date.Before(dw.startOfToday) {
+			break
+		}
+
+		if dw.offLimits(logFile) || logFile.Cold || planned[logFile.Pathname] {
+			continue
+		}
+
+		info, err := os.Stat(longPath(logFile.Pathname))
+		if err != nil {
+			continue
+		}
+
+		plan = append(plan, Deletion{
+			LogFile: logFile,
+			Size:    info.Size(),
+			Reason:  fmt.Sprintf("emergency purge - free space %d is below %d bytes", free, dw.purgeWatermark),
+		})
+		free += uint64(info.Size())
+	}
+
+	return plan, nil
+}
+
+// reportRetention is a helper function for the retention rules that reports what
+// they would delete in a dry run.  The caller should call refreshInUse first.
+func (dw *Writer) reportRetention() {
+	dw.logMutex.Lock()
+	if dw.closed {
+		dw.logMutex.Unlock()
+		return
+	}
+	plan, err := dw.planRetention()
+	if err != nil {
+		dw.reportError(err)
+	}
+	dw.logMutex.Unlock()
+
+	if len(plan) > 0 {
+		dw.retentionDryRun(plan)
+	}
+}
+
+// tooOld returns the log files that the rule set by WithMaxAge would delete,
+// leaving out any that have been restored from the quarantine.  It doesn't apply
+// the lock, so it should only be called by a function that does.
+func (dw *Writer) tooOld(logFiles []LogFile) []LogFile {
+	if dw.maxAge == 0 {
+		return nil
+	}
+
+	cutoff := dw.startOfToday.AddDate(0, 0, -dw.maxAge)
+
+	var old []LogFile
+	for _, logFile := range logFiles {
+		if !logFile.Date.Before(cutoff) {
+			// The list is sorted, so we are done.
+			break
+		}
+		if dw.offLimits(logFile) || dw.restored[logFile.Date.Format(time.DateOnly)] {
+			continue
+		}
+		old = append(old, logFile)
+	}
+
+	return old
+}
+
+// retentionMonitor applies the retention rules set by WithMaxAge, WithQuarantine
+// and WithColdTier and makes the archives for WithMonthlyBundle at regular
+// intervals until the Writer is closed.  It should be
+// run in a goroutine.
+func (dw *Writer) retentionMonitor() {
+	for {
+		dw.applyRetention()
+		if dw.monthlyBundle {
+			dw.applyBundling()
+		}
+
+		select {
+		case <-dw.clock.After(retentionInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+	}
+}
+
+// applyRetention moves the log files to the cold directory as they age, deletes
+// or quarantines the files that are too old and deletes the files that have been
+// in the quarantine for long enough.  In a dry
+// run it reports what it would do instead.
+func (dw *Writer) applyRetention() {
+	dw.refreshInUse()
+	dw.sweepShreds()
+
+	// Moving files to the cold directory isn't deletion, so it happens even in a
+	// dry run.
+	dw.applyTiering()
+
+	if dw.retentionDryRun != nil {
+		dw.reportRetention()
+		return
+	}
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed || dw.noRotation {
+		return
+	}
+
+	dw.expireQuarantine()
+
+	logFiles, err := dw.List()
+	if err != nil {
+		dw.reportError(fmt.Errorf("retention: error listing %s - %w", dw.logDir, err))
+		return
+	}
+
+	for _, logFile := range dw.tooOld(logFiles) {
+		dw.retire(logFile, fmt.Errorf("more than %d days old", dw.maxAge))
+	}
+}
+
+// retire is a helper function for applyRetention that moves the log file to the
+// quarantine, if there is one, or deletes it.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) retire(logFile LogFile, reason error) {
+	if len(dw.quarantineDir) > 0 {
+		dw.quarantine(logFile, reason)
+		return
+	}
+
+	if err := dw.removeFile(logFile.Pathname, reason); err != nil {
+		dw.reportError(fmt.Errorf("retention: error removing %s - %w", logFile.Pathname, err))
+	}
+}
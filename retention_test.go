@@ -0,0 +1,67 @@
+package dailylogger
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRetentionDryRun checks that a dry run reports what the emergency purge
+// would delete, without deleting it.
+func TestRetentionDryRun(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	names := []string{"foo.2020-02-11.bar", "foo.2020-02-12.bar", "foo.2020-02-13.bar"}
+	for _, name := range names {
+		os.WriteFile(name, bytes.Repeat([]byte("x"), 100), 0644)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	// 600 bytes are free.  Deleting two files would get above the watermark.
+	reports := make(chan []Deletion, 10)
+	writer := New(now, ".", "foo.", ".bar",
+		withClock(fc),
+		WithEmergencyPurge(750, time.Minute, func(removed []LogFile) { t.Errorf("want nothing removed, got %v", removed) }),
+		WithRetentionDryRun(func(plan []Deletion) { reports <- plan }),
+		func(dw *Writer) { dw.freeSpace = func(string) (uint64, error) { return 600, nil } })
+	defer writer.Close()
+
+	for _, plan := range [][]Deletion{<-reports, mustPlan(t, writer)} {
+		if len(plan) != 2 || plan[0].Name != names[0] || plan[1].Name != names[1] {
+			t.Errorf("want the files for the 11th and 12th, got %v", plan)
+			continue
+		}
+		for _, deletion := range plan {
+			if deletion.Size != 100 || len(deletion.Reason) == 0 {
+				t.Errorf("want the size and the reason, got %+v", deletion)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("want %s kept - %v", name, err)
+		}
+	}
+}
+
+// mustPlan calls PlanRetention and fails the test if it returns an error.
+func mustPlan(t *testing.T, writer *Writer) []Deletion {
+	plan, err := writer.PlanRetention()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
@@ -0,0 +1,61 @@
+package dailylogger
+
+// ringBuffer holds the most recent bytes written to it, up to a fixed size.
+type ringBuffer struct {
+	data   []byte // The storage, which is allocated at full size.
+	start  int    // The index of the oldest byte.
+	length int    // The number of bytes held.
+}
+
+// newRingBuffer creates a ringBuffer that holds up to size bytes.
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{data: make([]byte, size)}
+}
+
+// write adds the buffer to the ring, overwriting the oldest bytes if there isn't
+// room.  It returns the number of bytes that were overwritten.
+func (r *ringBuffer) write(buffer []byte) int {
+	size := len(r.data)
+	if size == 0 {
+		return len(buffer)
+	}
+
+	if len(buffer) >= size {
+		// The buffer replaces everything.
+		lost := r.length + len(buffer) - size
+		copy(r.data, buffer[len(buffer)-size:])
+		r.start = 0
+		r.length = size
+		return lost
+	}
+
+	// Copy the buffer to the end of the data, wrapping around if necessary.
+	end := (r.start + r.length) % size
+	n := copy(r.data[end:], buffer)
+	copy(r.data, buffer[n:])
+
+	r.length += len(buffer)
+	if r.length <= size {
+		return 0
+	}
+
+	// The oldest bytes have been overwritten.
+	lost := r.length - size
+	r.start = (r.start + lost) % size
+	r.length = size
+	return lost
+}
+
+// bytes returns a copy of the bytes held, oldest first.
+func (r *ringBuffer) bytes() []byte {
+	result := make([]byte, r.length)
+	n := copy(result, r.data[r.start:min(r.start+r.length, len(r.data))])
+	copy(result[n:], r.data[:r.length-n])
+	return result
+}
+
+// reset empties the ring.
+func (r *ringBuffer) reset() {
+	r.start = 0
+	r.length = 0
+}
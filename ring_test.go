@@ -0,0 +1,43 @@
+package dailylogger
+
+import "testing"
+
+// TestRingBuffer checks that the ring buffer keeps the most recent bytes and
+// counts the bytes that it overwrites.
+func TestRingBuffer(t *testing.T) {
+	var testData = []struct {
+		description string
+		writes      []string
+		want        string
+		wantLost    int
+	}{
+		{"empty", nil, "", 0},
+		{"fits", []string{"ab", "cd"}, "abcd", 0},
+		{"full", []string{"abc", "de"}, "abcde", 0},
+		{"wraps", []string{"abc", "def"}, "bcdef", 1},
+		{"wraps twice", []string{"abc", "def", "ghij"}, "fghij", 5},
+		{"too big", []string{"ab", "cdefghi"}, "efghi", 4},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			ring := newRingBuffer(5)
+			lost := 0
+			for _, w := range td.writes {
+				lost += ring.write([]byte(w))
+			}
+
+			if got := string(ring.bytes()); got != td.want {
+				t.Errorf("want \"%s\" got \"%s\"", td.want, got)
+			}
+			if lost != td.wantLost {
+				t.Errorf("want %d lost got %d", td.wantLost, lost)
+			}
+
+			ring.reset()
+			if got := string(ring.bytes()); got != "" {
+				t.Errorf("want an empty ring after reset got \"%s\"", got)
+			}
+		})
+	}
+}
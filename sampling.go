@@ -0,0 +1,67 @@
+package dailylogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithSampling limits the number of writes logged each minute without losing
+// sight of what was written.  Each minute, the first budget writes are logged in
+// full.  After that only one write in every sampleEvery is logged and the rest
+// are skipped.  At the start of the next minute in which something is written,
+// the Writer adds a line to the log saying how many writes were skipped, and the
+// budget starts again.  Skipped writes still appear to succeed, but they are
+// counted.  The budget also starts again when the log rotates to a new day.
+func WithSampling(budget, sampleEvery int) Option {
+	return func(dw *Writer) {
+		if budget >= 0 && sampleEvery > 1 {
+			dw.samplingBudget = budget
+			dw.samplingEvery = sampleEvery
+		}
+	}
+}
+
+// samplingAllows is a helper function for writeToLog that returns true if the
+// buffer should be written.  If not, it counts the write as skipped.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) samplingAllows(buffer []byte) bool {
+
+	window := dw.now().Truncate(time.Minute)
+	if !window.Equal(dw.samplingWindow) {
+		// It's a new minute.
+		dw.endSamplingWindow()
+		dw.samplingWindow = window
+	}
+
+	dw.samplingCount++
+	if dw.samplingCount <= dw.samplingBudget {
+		return true
+	}
+
+	if (dw.samplingCount-dw.samplingBudget-1)%dw.samplingEvery == 0 {
+		return true
+	}
+
+	dw.samplingSkipped++
+	dw.samplingSkippedBytes += int64(len(buffer))
+	dw.stats.Sampled.add(len(buffer))
+	dw.stats.Today.Sampled.add(len(buffer))
+	return false
+}
+
+// endSamplingWindow records what was skipped in the current minute and starts
+// the budget again.  Rotation to a new day calls it, so that the minute's count
+// goes in that day's log file and the next day starts with a full budget.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) endSamplingWindow() {
+	if dw.samplingSkipped > 0 {
+		dw.writeNote(fmt.Sprintf(
+			"dailylogger: sampling skipped %d writes (%d bytes) in the minute starting %s\n",
+			dw.samplingSkipped, dw.samplingSkippedBytes, dw.samplingWindow.Format("2006-01-02 15:04")))
+	}
+
+	dw.samplingWindow = time.Time{}
+	dw.samplingCount = 0
+	dw.samplingSkipped = 0
+	dw.samplingSkippedBytes = 0
+}
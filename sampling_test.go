@@ -0,0 +1,57 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSampling checks that writes beyond the budget are sampled and that the
+// number skipped is recorded in the log at the start of the next minute.
+func TestSampling(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "1\n2\n3\n6\n" +
+		"dailylogger: sampling skipped 3 writes (6 bytes) in the minute starting 2020-02-14 12:00\n" +
+		"8\n"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithSampling(2, 3))
+	defer writer.Close()
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n", "7\n"} {
+		n, err := writer.Write([]byte(line))
+		if err != nil || n != len(line) {
+			t.Errorf("%s: want %d nil got %d %v", line, len(line), n, err)
+		}
+	}
+
+	fc.Advance(time.Minute)
+	writer.Write([]byte("8\n"))
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	stats := writer.Stats()
+	if stats.Sampled.Writes != 3 || stats.Sampled.Bytes != 6 {
+		t.Errorf("want 3 writes and 6 bytes skipped got %d and %d",
+			stats.Sampled.Writes, stats.Sampled.Bytes)
+	}
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import "time"
+
+// Policy describes when a daily log Writer rotates, so that other work can be
+// lined up with it.  A Writer's own policy is returned by its SchedulePolicy
+// method.
+type Policy struct {
+	// Location is the timezone whose midnight ends each day.  Nil means the
+	// local timezone.
+	Location *time.Location
+
+	// Slack is how long after midnight the rotation happens (see
+	// dailylogger.WithRotationSlack).
+	Slack time.Duration
+
+	// Jitter is the most by which the rotation may be delayed at random beyond
+	// the boundary (see dailylogger.WithRotationJitter).  The boundaries don't
+	// include it, so a job that must start after the rotation has finished
+	// should wait this much longer.
+	Jitter time.Duration
+}
+
+// location returns the timezone of the policy.
+func (p Policy) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+// NextBoundary returns the instant after the given time at which a Writer with
+// the given policy rotates to the next day's log file: the slack after the start
+// of the next day in the policy's timezone.  Between a midnight and the end of
+// its slack, the boundary is the one that is about to happen.
+func NextBoundary(now time.Time, policy Policy) time.Time {
+	now = now.In(policy.location())
+	boundary := StartOfDay(now).Add(policy.Slack)
+	if boundary.After(now) {
+		return boundary
+	}
+	return NextDay(now).Add(policy.Slack)
+}
+
+// BoundaryFor returns the instant at which a Writer with the given policy
+// rotates to the log file for the given date.  Only the year, month and day of
+// the date are used, and they are taken as a date in the policy's timezone.  If
+// the timezone skipped that date altogether, it returns the boundary of the day
+// after.
+func BoundaryFor(date time.Time, policy Policy) time.Time {
+	// Noon is never in a daylight saving gap, so it's safely on the date.
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, policy.location())
+	return StartOfDay(noon).Add(policy.Slack)
+}
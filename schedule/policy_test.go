@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNextBoundary checks NextBoundary, including during the slack after
+// midnight.
+func TestNextBoundary(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := Policy{Location: london, Slack: time.Second}
+
+	var testData = []struct {
+		description string
+		now         time.Time
+		want        time.Time
+	}{
+		{"afternoon", time.Date(2020, time.February, 14, 15, 0, 0, 0, london),
+			time.Date(2020, time.February, 15, 0, 0, 1, 0, london)},
+		{"midnight", time.Date(2020, time.February, 15, 0, 0, 0, 0, london),
+			time.Date(2020, time.February, 15, 0, 0, 1, 0, london)},
+		{"in the slack", time.Date(2020, time.February, 15, 0, 0, 0, 500, london),
+			time.Date(2020, time.February, 15, 0, 0, 1, 0, london)},
+		{"end of the slack", time.Date(2020, time.February, 15, 0, 0, 1, 0, london),
+			time.Date(2020, time.February, 16, 0, 0, 1, 0, london)},
+		// The time is converted to the policy's timezone.
+		{"other timezone", time.Date(2020, time.July, 14, 23, 30, 0, 0, time.UTC),
+			time.Date(2020, time.July, 16, 0, 0, 1, 0, london)},
+		// The clocks went back on the 25th October 2020, a 25 hour day.
+		{"long day", time.Date(2020, time.October, 25, 0, 30, 0, 0, london),
+			time.Date(2020, time.October, 26, 0, 0, 1, 0, london)},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			if got := NextBoundary(td.now, policy); !got.Equal(td.want) {
+				t.Errorf("want %v, got %v", td.want, got)
+			}
+		})
+	}
+}
+
+// TestBoundaryFor checks that BoundaryFor uses the date in the policy's
+// timezone and agrees with NextBoundary.
+func TestBoundaryFor(t *testing.T) {
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apia, err := time.LoadLocation("Pacific/Apia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := Policy{Location: santiago, Slack: time.Microsecond}
+
+	// Late on the 13th in UTC is still the 13th as a date, whatever the timezone.
+	date := time.Date(2020, time.February, 13, 23, 0, 0, 0, time.UTC)
+	want := time.Date(2020, time.February, 13, 0, 0, 0, 1000, santiago)
+	if got := BoundaryFor(date, policy); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Santiago had no midnight on the 6th September 2020.
+	want = time.Date(2020, time.September, 6, 4, 0, 0, 1000, time.UTC)
+	if got := BoundaryFor(time.Date(2020, time.September, 6, 0, 0, 0, 0, time.UTC), policy); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got := NextBoundary(time.Date(2020, time.September, 5, 12, 0, 0, 0, santiago), policy); !got.Equal(want) {
+		t.Errorf("want NextBoundary %v, got %v", want, got)
+	}
+
+	// Apia skipped the 30th December 2011, so its boundary is the 31st's.
+	policy = Policy{Location: apia}
+	want = time.Date(2011, time.December, 31, 0, 0, 0, 0, apia)
+	if got := BoundaryFor(time.Date(2011, time.December, 30, 0, 0, 0, 0, time.UTC), policy); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// A nil location means the local timezone.
+	policy = Policy{}
+	want = time.Date(2020, time.February, 14, 0, 0, 0, 0, time.Local)
+	if got := BoundaryFor(want, policy); !got.Equal(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,87 @@
+// Package schedule does the calendar arithmetic behind the daily log Writer:
+// when a day starts, when the next one starts, how long it is until then and
+// what date a time belongs to.  The functions are pure, so they can be reused
+// and tested on their own.  Every time is interpreted in its own location, and
+// days that are 23 or 25 hours long because of daylight saving time, days whose
+// midnight is skipped because the clocks go forward at midnight and days that a
+// timezone skips altogether are all handled.
+//
+// For any time t the functions keep these invariants:
+//
+//   - StartOfDay(t) is not after t, has the same date as t and is the first
+//     instant with that date, so StartOfDay(StartOfDay(t)) == StartOfDay(t).
+//   - NextDay(t) is after t, it's the start of a day, so
+//     StartOfDay(NextDay(t)) == NextDay(t), and every instant from
+//     StartOfDay(t) up to but not including NextDay(t) has the date of t.
+//   - UntilNextDay(t, slack) is NextDay(t).Sub(t) + slack, or zero if that
+//     would be negative.
+//   - Stamp(t) is the date of t in the form "2006-01-02".
+//   - DaysBetween(a, b) is the number of calendar days from the date of a to the
+//     date of b, so DaysBetween(t, NextDay(t)) is 1 unless the timezone skips a
+//     day.
+package schedule
+
+import "time"
+
+// StartOfDay returns midnight at the beginning of the day of the given time.  In
+// a timezone where the clocks go forward at midnight, there is no midnight on
+// that day, so it returns the first instant of the day, for example 01:00.
+// Where the clocks go back to midnight, so that it happens twice, it returns the
+// first.
+func StartOfDay(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if midnight.Day() == t.Day() && midnight.Add(-time.Nanosecond).Day() != t.Day() {
+		return midnight
+	}
+
+	// Either midnight didn't happen on this day, in which case time.Date may return
+	// a time on the day before, or it happened twice, in which case time.Date may
+	// return the second.  The day started somewhere in the two days before the
+	// given time, the start of which is certainly on an earlier day.  Search for it.
+	before, after := t.Add(-48*time.Hour), t
+	for after.Sub(before) > time.Nanosecond {
+		middle := before.Add(after.Sub(before) / 2)
+		if middle.Day() == t.Day() {
+			after = middle
+		} else {
+			before = middle
+		}
+	}
+
+	return after
+}
+
+// NextDay returns midnight at the beginning of the day after the given time.
+func NextDay(t time.Time) time.Time {
+	// Find a time on the next day and get midnight at the start of it.  Because of
+	// daylight saving, a day may be 23 or 25 hours long, so we can't just add 24
+	// hours.  Adding a day to the given time with AddDate doesn't work either,
+	// because the result may be in a missing hour and time.Date moves such times,
+	// possibly to the day before.  Noon on the next day is always safe, so we use
+	// that.
+	noonTomorrow := time.Date(t.Year(), t.Month(), t.Day()+1, 12, 0, 0, 0, t.Location())
+	return StartOfDay(noonTomorrow)
+}
+
+// UntilNextDay returns the duration between the given time and the given slack
+// after the start of the next day.  A little slack removes any doubt about which
+// day a time exactly at midnight is in.  The result is never negative.
+func UntilNextDay(t time.Time, slack time.Duration) time.Duration {
+	return max(NextDay(t).Sub(t)+slack, 0)
+}
+
+// Stamp returns the date of the given time in its location, in the form
+// "2006-01-02".
+func Stamp(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// DaysBetween returns the number of calendar days from the date of a to the date
+// of b, each in its own location, ignoring the time of day.  It's negative if b
+// is on an earlier date.
+func DaysBetween(a, b time.Time) int {
+	const secondsPerDay = 24 * 60 * 60
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int((to.Unix() - from.Unix()) / secondsPerDay)
+}
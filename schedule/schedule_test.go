@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+	_ "time/tzdata"
+)
+
+// zones are the timezones that the properties are checked in.  Apart from UTC
+// they all have daylight saving time or have changed their offset.  The clocks
+// go forward at midnight in Santiago, Havana and Beirut, go back at midnight in
+// Beirut, change by half an hour on Lord Howe Island, and Apia skipped the 30th
+// December 2011 altogether.
+var zones = []string{
+	"UTC",
+	"Europe/London",
+	"Europe/Paris",
+	"America/New_York",
+	"America/Santiago",
+	"America/Havana",
+	"Asia/Beirut",
+	"Australia/Lord_Howe",
+	"Asia/Kathmandu",
+	"Pacific/Apia",
+}
+
+// loadZones loads the timezones.
+func loadZones(t *testing.T) []*time.Location {
+	var locations []*time.Location
+	for _, name := range zones {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locations = append(locations, location)
+	}
+
+	return locations
+}
+
+// checkInvariants checks the invariants in the package documentation for the
+// given time and returns a description of the first one broken, if any.
+func checkInvariants(t time.Time) string {
+	start := StartOfDay(t)
+	switch {
+	case start.After(t):
+		return "StartOfDay is after the time"
+	case Stamp(start) != Stamp(t):
+		return "StartOfDay " + start.String() + " has a different date"
+	case Stamp(start.Add(-time.Nanosecond)) == Stamp(t):
+		return "StartOfDay " + start.String() + " isn't the first instant of the day"
+	case !StartOfDay(start).Equal(start):
+		return "StartOfDay isn't idempotent"
+	}
+
+	next := NextDay(t)
+	switch {
+	case !next.After(t):
+		return "NextDay isn't after the time"
+	case !StartOfDay(next).Equal(next):
+		return "NextDay " + next.String() + " isn't the start of a day"
+	case Stamp(next.Add(-time.Nanosecond)) != Stamp(t):
+		return "the instant before NextDay " + next.String() + " has a different date"
+	case Stamp(next) == Stamp(t):
+		return "NextDay has the same date"
+	}
+
+	if got, want := UntilNextDay(t, time.Second), next.Sub(t)+time.Second; got != want {
+		return "UntilNextDay is " + got.String() + ", not " + want.String()
+	}
+	if UntilNextDay(t, -48*time.Hour) != 0 {
+		return "UntilNextDay is negative"
+	}
+
+	if days := DaysBetween(t, next); days < 1 || days > 2 {
+		return "DaysBetween the time and NextDay isn't 1 or 2"
+	}
+	if DaysBetween(t, start) != 0 || DaysBetween(next, t) != -DaysBetween(t, next) {
+		return "DaysBetween isn't consistent"
+	}
+
+	return ""
+}
+
+// TestInvariantsRandom checks the invariants at random times between 1900 and
+// 2100 in each of the timezones.
+func TestInvariantsRandom(t *testing.T) {
+	locations := loadZones(t)
+	low := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Second)
+	high := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Second)
+
+	property := func(seconds int64, nanoseconds int32, zone uint8) bool {
+		seconds = low + (seconds%(high-low)+(high-low))%(high-low)
+		location := locations[int(zone)%len(locations)]
+		now := time.Unix(seconds, int64(nanoseconds)%int64(time.Second)).In(location)
+		if problem := checkInvariants(now); len(problem) > 0 {
+			t.Errorf("%v: %s", now, problem)
+			return false
+		}
+		return true
+	}
+
+	config := quick.Config{MaxCount: 20000, Rand: rand.New(rand.NewSource(1))}
+	if err := quick.Check(property, &config); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestInvariantsAroundChanges checks the invariants either side of the start of
+// every day from 1970 to 2040 in each of the timezones and, on the days when the
+// offset from UTC changes, every quarter of an hour as well.
+func TestInvariantsAroundChanges(t *testing.T) {
+	for _, location := range loadZones(t) {
+		end := time.Date(2040, time.January, 1, 0, 0, 0, 0, location)
+		problems := 0
+		check := func(instant time.Time) {
+			if problem := checkInvariants(instant); len(problem) > 0 && problems < 5 {
+				t.Errorf("%s: %v: %s", location, instant, problem)
+				problems++
+			}
+		}
+
+		for start := StartOfDay(time.Date(1970, time.January, 1, 12, 0, 0, 0, location)); start.Before(end); {
+			next := NextDay(start)
+			check(start)
+			check(start.Add(-time.Nanosecond))
+			check(start.Add(time.Nanosecond))
+
+			_, startOffset := start.Zone()
+			_, endOffset := next.Add(-time.Nanosecond).Zone()
+			if startOffset != endOffset {
+				for now := start; now.Before(next); now = now.Add(15 * time.Minute) {
+					check(now)
+				}
+			}
+			start = next
+		}
+	}
+}
+
+// TestSkippedDay checks that Apia's missing 30th December 2011 is skipped.
+func TestSkippedDay(t *testing.T) {
+	apia, err := time.LoadLocation("Pacific/Apia")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2011, time.December, 29, 12, 0, 0, 0, apia)
+	next := NextDay(now)
+	if Stamp(next) != "2011-12-31" || DaysBetween(now, next) != 2 {
+		t.Errorf("want the next day to be the 31st, got %v", next)
+	}
+	if got := UntilNextDay(now, 0); got != 12*time.Hour {
+		t.Errorf("want 12 hours to the next day, got %v", got)
+	}
+}
+
+// TestMissingMidnight checks that a day whose midnight is skipped starts when
+// the clocks go forward.  In 2020 that happened in Santiago de Chile on the 6th
+// September, from 00:00 -04 to 01:00 -03, at 04:00 UTC.
+func TestMissingMidnight(t *testing.T) {
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2020, time.September, 6, 4, 0, 0, 0, time.UTC)
+	if got := StartOfDay(time.Date(2020, time.September, 6, 12, 0, 0, 0, santiago)); !got.Equal(want) {
+		t.Errorf("want the day to start at %v, got %v", want, got)
+	}
+	if got := NextDay(time.Date(2020, time.September, 5, 12, 0, 0, 0, santiago)); !got.Equal(want) {
+		t.Errorf("want the next day to start at %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,165 @@
+package dailylogger
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler rotates a group of Writers together.  Normally each Writer has its
+// own log rotator, which works out for itself when midnight is, so the Writers
+// in a process rotate at slightly different times and something written to two
+// of them at once may land on either side of the cut in each.  A Scheduler has
+// one log rotator for all of its Writers.  Just after midnight it takes the
+// lock of every Writer, so that nothing can be written to any of them, rotates
+// them all and then releases the locks.
+//
+// The Scheduler works out midnight in its own timezone, so its Writers should
+// use the same timezone.  Rotation jitter (see WithRotationJitter) doesn't
+// apply.
+type Scheduler struct {
+	mutex    sync.Mutex
+	clock    clock          // Supplies the time.
+	location *time.Location // The timezone that defines the start of each day.
+	writers  []*Writer      // The Writers to rotate, in the order they joined.
+	done     chan struct{}  // Closed by Close to stop the log rotator.
+	closed   bool           // True if Close has been called.
+}
+
+// NewScheduler creates a Scheduler that rotates its Writers at midnight in the
+// given timezone, or the local timezone if it's nil, and starts its log rotator.
+// Writers join it via WithScheduler.
+func NewScheduler(location *time.Location) *Scheduler {
+	return newScheduler(location, realClock{})
+}
+
+// newScheduler is a helper function for NewScheduler.  Unit tests call it to
+// supply a fake clock.
+func newScheduler(location *time.Location, c clock) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+
+	s := Scheduler{
+		clock:    c,
+		location: location,
+		done:     make(chan struct{}),
+	}
+
+	go s.logRotator()
+
+	return &s
+}
+
+// WithScheduler makes the Writer one of the group rotated by the given
+// Scheduler rather than starting a log rotator of its own.  The Writer leaves
+// the group when it's closed.
+func WithScheduler(s *Scheduler) Option {
+	return func(dw *Writer) {
+		dw.scheduler = s
+	}
+}
+
+// Close stops the Scheduler's log rotator.  It doesn't close the Writers, which
+// are no longer rotated at midnight.
+func (s *Scheduler) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.closed = true
+	close(s.done)
+	return nil
+}
+
+// add is a helper function for New that adds the Writer to the group.  The
+// Writer is removed when it's closed.
+func (s *Scheduler) add(dw *Writer) {
+	s.mutex.Lock()
+	s.writers = append(s.writers, dw)
+	s.mutex.Unlock()
+
+	dw.closers = append(dw.closers, func() error {
+		s.remove(dw)
+		return nil
+	})
+}
+
+// remove takes the Writer out of the group.
+func (s *Scheduler) remove(dw *Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, w := range s.writers {
+		if w == dw {
+			s.writers = append(s.writers[:i], s.writers[i+1:]...)
+			return
+		}
+	}
+}
+
+// logRotator runs until the Scheduler is closed, rotating the Writers at the end
+// of each day.
+func (s *Scheduler) logRotator() {
+
+	// This should be run in a goroutine.
+
+	today := getLastMidnight(s.now())
+	for {
+		if !waitToRotate(s.clock, s.now(), extraDuration, s.done) {
+			return
+		}
+
+		// The clock may have been changed while we were asleep, so check the day.
+		now := s.now()
+		if getLastMidnight(now).After(today) {
+			s.rotateAll(now)
+			today = getLastMidnight(now)
+		}
+	}
+}
+
+// now returns the current time in the Scheduler's timezone.
+func (s *Scheduler) now() time.Time {
+	return s.clock.Now().In(s.location)
+}
+
+// rotateAll takes the lock of each of the Writers in turn and, once it holds all
+// of them, rotates the Writers to the day of the given time.  A Writer that has
+// already moved to that day, for example via TriggerRotation, is left alone.
+func (s *Scheduler) rotateAll(now time.Time) {
+	s.mutex.Lock()
+	writers := append([]*Writer(nil), s.writers...)
+	s.mutex.Unlock()
+
+	// Take all of the locks before rotating any of the Writers, so that nothing is
+	// written to any of them between the first rotation and the last.
+	for _, dw := range writers {
+		unlock := dw.barrier()
+		defer unlock()
+	}
+
+	for _, dw := range writers {
+		if dw.closed || !getLastMidnight(now.In(dw.location)).After(dw.startOfToday) {
+			continue
+		}
+		dw.rotateSafely(now)
+	}
+}
+
+// rotateSafely is a helper function for rotateAll that rotates the Writer and
+// recovers if that panics, for example because of a bug in an event handler, so
+// that the other Writers are still rotated.  The panic is reported as an error
+// and recorded in the Stats.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) rotateSafely(now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			dw.recoverRotation(r)
+		}
+	}()
+
+	dw.rotateToDay(now)
+}
@@ -0,0 +1,120 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSharedScheduler checks that a Scheduler rotates all of its Writers at
+// midnight and that a Writer leaves the group when it's closed.
+func TestSharedScheduler(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	scheduler := newScheduler(locationUTC, fc)
+	defer scheduler.Close()
+
+	writer1 := New(now, ".", "foo.", ".bar", withClock(fc), WithScheduler(scheduler))
+	defer writer1.Close()
+	writer2 := New(now, ".", "fred.", ".bar", withClock(fc), WithScheduler(scheduler))
+
+	// Only the Scheduler's log rotator sleeps.  Wake it just after midnight and wait
+	// for it to go to sleep again.
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	fc.WaitForSleepers(2)
+
+	writer1.Write([]byte("a"))
+	writer2.Write([]byte("b"))
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar", ""},
+		{"fred.2020-02-14.bar", ""},
+		{"foo.2020-02-15.bar", "a"},
+		{"fred.2020-02-15.bar", "b"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+
+	writer2.Close()
+
+	scheduler.mutex.Lock()
+	writers := len(scheduler.writers)
+	scheduler.mutex.Unlock()
+	if writers != 1 {
+		t.Errorf("want 1 writer after close, got %d", writers)
+	}
+
+	if err := scheduler.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := scheduler.Close(); err != ErrClosed {
+		t.Errorf("want ErrClosed from a second close, got %v", err)
+	}
+}
+
+// TestSchedulerRecoversAfterClose checks that when rotating a Writer panics after
+// its old log file has been closed, the Writer's log is reopened for the new day
+// and the other Writers are still rotated.
+func TestSchedulerRecoversAfterClose(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 23, 30, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	scheduler := newScheduler(locationUTC, fc)
+	defer scheduler.Close()
+
+	writer1 := New(now, ".", "foo.", ".bar", withClock(fc), WithScheduler(scheduler),
+		WithLifecycleEvents(), WithEventHandler(panicOnFirstClose()))
+	defer writer1.Close()
+	writer2 := New(now, ".", "fred.", ".bar", withClock(fc), WithScheduler(scheduler))
+	defer writer2.Close()
+
+	fc.WaitForSleepers(1)
+	fc.Advance(30*time.Minute + extraDuration)
+	fc.WaitForSleepers(2)
+
+	writer1.Write([]byte("a"))
+	writer2.Write([]byte("b"))
+
+	for filename, want := range map[string]string{"foo.2020-02-15.bar": "a", "fred.2020-02-15.bar": "b"} {
+		contents, err := os.ReadFile(filename)
+		if err != nil || string(contents) != want {
+			t.Errorf("%s: want \"%s\" got \"%s\", %v", filename, want, contents, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package dailylogger
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeletionMode says how the Writer deletes the log files that have expired (see
+// WithSecureDeletion).
+type DeletionMode int
+
+const (
+	// DeleteNormally just removes the files.
+	DeleteNormally DeletionMode = iota
+	// DeleteOverwrite overwrites each file with zeros and flushes it to the disk
+	// before removing it.  This is best effort: a journalling or copy-on-write
+	// filesystem, or the wear levelling of a flash device, may keep the old data
+	// elsewhere.
+	DeleteOverwrite
+	// DeleteCryptographic destroys the keys of each encrypted file before removing
+	// it, so the data can't be read even if it survives on the device.  It needs
+	// the files to be encrypted (see Encryptor), and files that aren't are
+	// overwritten instead.
+	DeleteCryptographic
+)
+
+// WithSecureDeletion sets how the log files are deleted when they expire, by
+// retention (see WithMaxAge), the quarantine (see WithQuarantine) or the
+// emergency purge (see WithEmergencyPurge), for processing agreements that
+// require expired logs to be destroyed.  The original of a file that's
+// compressed or encrypted after rotation (see WithCompression) is deleted in
+// the same way, so no plain copy is left behind.  The files are destroyed in the
+// background, so that writing isn't held up, and Close waits until that's done.
+// Meanwhile each file has a hidden name ending in ".shred", and the
+// EventFileDeleted comes once it's gone.  If the program stops first, or a file
+// can't be destroyed, the Writer destroys it when it next starts and on each
+// pass of the retention rules.
+//
+// DeleteCryptographic only needs to change a few hundred bytes of each file,
+// which suits flash storage.  Each encrypted stream has its own data key, which
+// is only kept in the stream's header wrapped with the long-term keys (see
+// NewKeyedEncryptor), so overwriting the wrapped keys makes the stream
+// unreadable, whoever holds the long-term keys.  It needs an Encryptor as the
+// Compressor given to WithCompression or WithCompressedLiveFile, or as the last
+// stage of a Pipeline.  Otherwise New reports an error and the Writer overwrites
+// the files instead.
+func WithSecureDeletion(mode DeletionMode) Option {
+	return func(dw *Writer) {
+		dw.deletionMode = mode
+	}
+}
+
+// checkDeletionMode is a helper function for newWriter that checks that
+// cryptographic deletion is possible, and falls back to overwriting if it isn't.
+func (dw *Writer) checkDeletionMode() {
+	if dw.deletionMode != DeleteCryptographic || encrypts(dw.compressor) {
+		return
+	}
+
+	err := errors.New("WithSecureDeletion: cryptographic deletion needs an Encryptor as the last stage of the Compressor - overwriting the files instead")
+	dw.reportError(err)
+	dw.recordStartupError(err)
+	dw.deletionMode = DeleteOverwrite
+}
+
+// encrypts returns true if the output of the Compressor is encrypted, which
+// means that it's an Encryptor or a Pipeline whose last stage encrypts.
+func encrypts(compressor Compressor) bool {
+	switch c := compressor.(type) {
+	case *Encryptor:
+		return true
+	case *Pipeline:
+		return len(c.stages) > 0 && encrypts(c.stages[len(c.stages)-1])
+	default:
+		return false
+	}
+}
+
+// shredSuffix is added to the name of a file that's waiting to be destroyed
+// (see removeFile), which is also hidden by a dot at the start.
+const shredSuffix = ".shred"
+
+// removeFile deletes a log file in the way set by WithSecureDeletion and emits
+// an EventFileDeleted with the given reason once it's gone.  Destroying the
+// contents of a big file can take a while, so the file is only renamed to a
+// private name here, which takes it out of the listing, and a goroutine
+// destroys and deletes it (see shredFile).  Until then its space is counted as
+// free (see logFreeSpace).  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) removeFile(pathname string, reason error) error {
+	dw.releaseFile(pathname)
+
+	if dw.deletionMode == DeleteNormally {
+		if err := os.Remove(longPath(pathname)); err != nil {
+			return err
+		}
+		dw.emitLifecycle(Event{Type: EventFileDeleted, Path: pathname, Err: reason})
+		return nil
+	}
+
+	info, err := os.Stat(longPath(pathname))
+	if err != nil {
+		return err
+	}
+	private := filepath.ToSlash(filepath.Join(filepath.Dir(pathname), "."+filepath.Base(pathname)+shredSuffix))
+	if err := os.Rename(longPath(pathname), longPath(private)); err != nil {
+		return err
+	}
+
+	dw.startShred(pathname, private, info.Size(), reason)
+	return nil
+}
+
+// startShred is a helper function for removeFile and sweepShreds that starts a
+// goroutine to destroy a file that has been renamed to the given private name.
+// It doesn't apply the lock, so it should only be called by a function that
+// does.
+func (dw *Writer) startShred(pathname, private string, size int64, reason error) {
+	var freed uint64
+	if len(dw.coldDir) == 0 || !strings.HasPrefix(pathname, dw.coldDir+"/") {
+		// The space will be freed on the log directory's filesystem.
+		freed = uint64(size)
+	}
+	dw.shredding += freed
+	dw.shredsPending[private] = true
+
+	dw.artifactsPending.Add(1)
+	go dw.shredFile(pathname, private, freed, reason)
+}
+
+// shredFile destroys the contents of a log file that removeFile has renamed to
+// a private name and deletes it, without the lock, so that writing carries on
+// meanwhile, and then emits an EventFileDeleted.  If the contents can't be
+// destroyed, the error is reported and the file is left for the next sweep (see
+// sweepShreds).  It should be run in a goroutine.
+func (dw *Writer) shredFile(pathname, private string, freed uint64, reason error) {
+	defer dw.artifactsPending.Done()
+
+	var err error
+	if dw.deletionMode == DeleteCryptographic {
+		err = destroyKeys(private)
+	} else {
+		err = overwriteFile(private)
+	}
+	if err == nil {
+		err = os.Remove(longPath(private))
+	}
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	dw.shredding -= freed
+	delete(dw.shredsPending, private)
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error destroying %s - %w", pathname, err))
+		return
+	}
+	dw.emitLifecycle(Event{Type: EventFileDeleted, Path: pathname, Err: reason})
+}
+
+// sweepShreds destroys the files that removeFile renamed but that were never
+// destroyed, because the program stopped first or destroying them failed.  It
+// looks through the log directory and the cold directory, including the
+// quarantine.  It's called when the Writer starts and by each retention pass.
+// It applies the lock, so it should only be called by a function that doesn't
+// hold it.
+func (dw *Writer) sweepShreds() {
+	if dw.deletionMode == DeleteNormally || dw.noRotation || dw.stream {
+		return
+	}
+
+	directories := []string{dw.logDir}
+	if len(dw.coldDir) > 0 {
+		directories = append(directories, dw.coldDir)
+	}
+
+	// Looking through the directories is done without the lock.
+	var leftovers []string
+	for _, directory := range directories {
+		filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() &&
+				strings.HasPrefix(entry.Name(), ".") && strings.HasSuffix(entry.Name(), shredSuffix) {
+				leftovers = append(leftovers, filepath.ToSlash(path))
+			}
+			return nil
+		})
+	}
+	if len(leftovers) == 0 {
+		return
+	}
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return
+	}
+
+	for _, private := range leftovers {
+		if dw.shredsPending[private] {
+			// It's being destroyed already.
+			continue
+		}
+		info, err := os.Stat(longPath(private))
+		if err != nil {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(private), "."), shredSuffix)
+		pathname := filepath.ToSlash(filepath.Join(filepath.Dir(private), name))
+		dw.startShred(pathname, private, info.Size(), errors.New("left over from an earlier deletion"))
+	}
+}
+
+// overwriteFile overwrites the contents of the file with zeros and flushes it
+// to the disk.
+func overwriteFile(pathname string) error {
+	file, err := os.OpenFile(longPath(pathname), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, 64*1024)
+	for offset := int64(0); offset < info.Size(); offset += int64(len(zeros)) {
+		block := zeros[:min(int64(len(zeros)), info.Size()-offset)]
+		if _, err := file.WriteAt(block, offset); err != nil {
+			return err
+		}
+	}
+
+	return file.Sync()
+}
+
+// destroyKeys overwrites the wrapped data keys in the headers of an encrypted
+// file with random bytes and flushes it to the disk.  If the file isn't
+// encrypted, for example because encryption failed, it's overwritten instead.
+func destroyKeys(pathname string) error {
+	file, err := os.OpenFile(longPath(pathname), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	headers, encrypted, err := encryptedHeaders(file, info.Size())
+	if !encrypted {
+		file.Close()
+		return overwriteFile(pathname)
+	}
+
+	for _, header := range headers {
+		noise := make([]byte, header[1])
+		rand.Read(noise)
+		if _, we := file.WriteAt(noise, header[0]); we != nil {
+			return we
+		}
+	}
+	if se := file.Sync(); se != nil {
+		return se
+	}
+
+	if err != nil {
+		// The keys that were found are destroyed, but the rest of the file couldn't
+		// be followed, so overwrite it all to be sure.
+		file.Close()
+		return overwriteFile(pathname)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package dailylogger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOverwriteFile checks that overwriteFile replaces the contents with zeros
+// without changing the size.
+func TestOverwriteFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	secret := strings.Repeat("secret ", 20000)
+	os.WriteFile("victim", []byte(secret), 0644)
+
+	if err := overwriteFile("victim"); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := os.ReadFile("victim")
+	if !bytes.Equal(contents, make([]byte, len(secret))) {
+		t.Error("want the file full of zeros")
+	}
+}
+
+// TestDestroyKeys checks that destroyKeys makes every stream of an encrypted
+// file unreadable and overwrites a file that isn't encrypted.
+func TestDestroyKeys(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	encryptor, _ := NewKeyedEncryptor(
+		Key{ID: "active", Secret: bytes.Repeat([]byte{1}, 32)},
+		Key{ID: "recovery", Secret: bytes.Repeat([]byte{2}, 32), Escrow: true})
+	big := strings.Repeat("x", 3*encryptedChunkSize)
+	data := encryptForTest(t, encryptor, "first\n", big, "third\n")
+	os.WriteFile("encrypted", data, 0644)
+
+	if err := destroyKeys("encrypted"); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := os.ReadFile("encrypted")
+	if len(contents) != len(data) {
+		t.Errorf("want the size unchanged, got %d not %d", len(contents), len(data))
+	}
+
+	// Each stream must now be unreadable.  Find where the streams start in the
+	// original and try to read each from there.
+	starts := []int{0}
+	for i := 1; i < len(data); i++ {
+		if bytes.HasPrefix(data[i:], encryptedMagic) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) != 3 {
+		t.Fatalf("want 3 streams got %d", len(starts))
+	}
+	for _, start := range starts {
+		plaintext, err := decryptForTest(encryptor, contents[start:])
+		if err == nil || len(plaintext) > 0 {
+			t.Errorf("stream at %d: want an error and no data, got %q and %v", start, plaintext, err)
+		}
+	}
+
+	os.WriteFile("plain", []byte("secret"), 0644)
+	if err := destroyKeys("plain"); err != nil {
+		t.Fatal(err)
+	}
+	contents, _ = os.ReadFile("plain")
+	if !bytes.Equal(contents, make([]byte, 6)) {
+		t.Errorf("want a plain file overwritten, got %q", contents)
+	}
+}
+
+// TestSecureDeletionNeedsEncryption checks that cryptographic deletion without
+// encryption is reported and falls back to overwriting.
+func TestSecureDeletionNeedsEncryption(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	encryptor, _ := NewEncryptor(bytes.Repeat([]byte{1}, 32))
+	var testData = []struct {
+		description string
+		compressor  Compressor
+		want        DeletionMode
+	}{
+		{"none", nil, DeleteOverwrite},
+		{"gzip", NewGzipCompressor(1), DeleteOverwrite},
+		{"encrypt then gzip", NewPipeline(encryptor, NewGzipCompressor(1)), DeleteOverwrite},
+		{"encryptor", encryptor, DeleteCryptographic},
+		{"gzip then encrypt", NewPipeline(NewGzipCompressor(1), encryptor), DeleteCryptographic},
+	}
+
+	for _, td := range testData {
+		options := []any{WithSecureDeletion(DeleteCryptographic)}
+		if td.compressor != nil {
+			options = append(options, WithCompression(td.compressor))
+		}
+		writer := New(now, ".", "foo.", ".bar", options...)
+		err := writer.Sync()
+		writer.Close()
+
+		if writer.deletionMode != td.want {
+			t.Errorf("%s: want mode %d got %d", td.description, td.want, writer.deletionMode)
+		}
+		if (err != nil) != (td.want == DeleteOverwrite) {
+			t.Errorf("%s: unexpected error %v", td.description, err)
+		}
+	}
+}
+
+// TestRemoveFileSecurely checks that removeFile takes the file out of the
+// listing at once and that it's destroyed and deleted later, with its space
+// counted as free meanwhile and the deletion reported once it's done.
+func TestRemoveFileSecurely(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	const name = "foo.2020-02-10.bar"
+	os.WriteFile(name, []byte(strings.Repeat("secret ", 20000)), 0644)
+
+	events := make(chan Event, 10)
+	writer := New(now, ".", "foo.", ".bar", WithSecureDeletion(DeleteOverwrite),
+		WithLifecycleEvents(), WithEventChannel(events))
+	writer.freeSpace = func(string) (uint64, error) { return 1000, nil }
+
+	writer.logMutex.Lock()
+	if err := writer.removeFile(name, errors.New("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); err == nil {
+		t.Error("want the file gone from its name at once")
+	}
+	free, _ := writer.logFreeSpace()
+	if deletedEvents(events) != 0 {
+		t.Error("want no EventFileDeleted before the file is destroyed")
+	}
+	writer.logMutex.Unlock()
+
+	writer.Close()
+	after, _ := writer.logFreeSpace()
+
+	if free != 1000+140000 || after != 1000 {
+		t.Errorf("want the space counted as free until the file is deleted, got %d then %d", free, after)
+	}
+	if n := deletedEvents(events); n != 1 {
+		t.Errorf("want one EventFileDeleted, got %d", n)
+	}
+
+	entries, _ := os.ReadDir(".")
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "2020-02-10") {
+			t.Errorf("want %s deleted, found %s", name, entry.Name())
+		}
+	}
+}
+
+// TestSweepShreds checks that a file left behind by secure deletion, for
+// example because the program stopped, is destroyed when the Writer starts.
+func TestSweepShreds(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	const leftover = "logs/.foo.2020-02-10.bar.shred"
+	os.MkdirAll("logs", 0755)
+	os.WriteFile(leftover, []byte("secret\n"), 0644)
+
+	events := make(chan Event, 10)
+	writer := New(now, "logs", "foo.", ".bar", WithSecureDeletion(DeleteOverwrite),
+		WithLifecycleEvents(), WithEventChannel(events))
+	defer writer.Close()
+
+	// The sweep runs in the background.
+	timeout := time.After(5 * time.Second)
+	for deleted := false; !deleted; {
+		select {
+		case event := <-events:
+			deleted = event.Type == EventFileDeleted
+		case <-timeout:
+			t.Fatal("timed out waiting for EventFileDeleted")
+		}
+	}
+
+	if _, err := os.Stat(leftover); err == nil {
+		t.Errorf("want %s destroyed", leftover)
+	}
+}
+
+// deletedEvents returns the number of EventFileDeleted events waiting in the
+// channel, discarding the other events.
+func deletedEvents(events chan Event) int {
+	n := 0
+	for {
+		select {
+		case event := <-events:
+			if event.Type == EventFileDeleted {
+				n++
+			}
+		default:
+			return n
+		}
+	}
+}
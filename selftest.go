@@ -0,0 +1,90 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// WithSelfTest makes the Writer check at the given interval that what it writes
+// actually reaches the log file.  It writes a self-test record to the log,
+// flushes it and then checks that the file with the log file's name has grown by
+// at least the length of the record.  If not, for example because the file has
+// been deleted or the filesystem has been remounted read-only, it emits an
+// EventSelfTestFailed.
+func WithSelfTest(interval time.Duration) Option {
+	return func(dw *Writer) {
+		if interval > 0 {
+			dw.selfTestInterval = interval
+		}
+	}
+}
+
+// selfTestMonitor runs the self-test at regular intervals until the Writer is
+// closed.  It should be run in a goroutine.
+func (dw *Writer) selfTestMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.selfTestInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		dw.selfTest()
+	}
+}
+
+// selfTest writes the self-test record and checks that it arrived.  It returns
+// nil if it did.
+func (dw *Writer) selfTest() error {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	err := dw.verifiedWrite(fmt.Sprintf("dailylogger: self-test %s\n", dw.now().Format(time.RFC3339)))
+	if err != nil {
+		err = fmt.Errorf("selfTest: %s - %w", dw.pathname, err)
+		dw.reportError(err)
+		dw.emit(Event{Type: EventSelfTestFailed, Path: dw.pathname, Err: err})
+	}
+
+	return err
+}
+
+// verifiedWrite is a helper function for selfTest that writes the record and
+// checks that the log file grew.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) verifiedWrite(record string) error {
+	if err := dw.flush(); err != nil {
+		return err
+	}
+
+	before, err := os.Stat(longPath(dw.pathname))
+	if err != nil {
+		return err
+	}
+
+	if err := dw.writeNote(record); err != nil {
+		return err
+	}
+
+	if err := dw.flush(); err != nil {
+		return err
+	}
+
+	after, err := os.Stat(longPath(dw.pathname))
+	if err != nil {
+		return err
+	}
+
+	if after.Size() < before.Size()+int64(len(record)) {
+		return fmt.Errorf("the file grew by %d bytes rather than %d",
+			after.Size()-before.Size(), len(record))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package dailylogger
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSelfTest checks that the self-test record is written and that a failure
+// is reported when the log file has been removed.
+func TestSelfTest(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	events := make(chan Event, 10)
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithBuffering(100),
+		WithSelfTest(time.Minute),
+		WithEventHandler(func(e Event) { events <- e }))
+	defer writer.Close()
+
+	// The rotator and the self-test goroutine go to sleep.  When the self-test
+	// wakes it writes the record and goes back to sleep.
+	fc.WaitForSleepers(2)
+	fc.Advance(time.Minute)
+	fc.WaitForSleepers(3)
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	const want = "dailylogger: self-test 2020-02-14T12:01:00Z\n"
+	if string(contents) != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, string(contents))
+	}
+
+	if len(events) != 0 {
+		t.Errorf("want no events got %v", <-events)
+		return
+	}
+
+	// Remove the log file.  The next self-test fails.
+	os.Remove("foo.2020-02-14.bar")
+	fc.Advance(time.Minute)
+
+	event := <-events
+	if event.Type != EventSelfTestFailed || !strings.HasSuffix(event.Path, "foo.2020-02-14.bar") {
+		t.Errorf("want a %v event for the log file got %+v", EventSelfTestFailed, event)
+	}
+}
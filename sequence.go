@@ -0,0 +1,63 @@
+package dailylogger
+
+import (
+	"strconv"
+)
+
+// sequenceReserve is the number of sequence numbers reserved each time the state
+// file is saved, so that it's saved once per this many records.
+const sequenceReserve = 1000
+
+// WithRecordSequence makes the Writer start each record, that is the data of
+// each Write, with a sequence number and a space, for example "17 hello\n", so
+// that a consumer can detect missing records and records out of order.  With
+// WithRecordFraming the number goes inside the frame.  The numbers start at 1
+// and carry on across rotation and across restarts: the next number is kept in
+// the given state file in the log directory, which can also hold the counters
+// (see WithStateFile).
+//
+// So that the state file needn't be written on every record, the Writer reserves
+// numbers in blocks of a thousand and records the end of the block.  After a
+// clean Close the numbers carry on where they left off, but after a crash the
+// rest of the block is skipped, so there is a gap but never a number used twice.
+// A number is also used up by a write that fails, so a consumer sees the gap.
+// Records dropped by sampling or the quota (see WithSampling and WithDailyQuota)
+// are not numbered.  The option is meant for programs that write one record per
+// Write, and isn't combined with WithWholeLines or WithRecordSplitter.
+func WithRecordSequence(stateFile string) Option {
+	return func(dw *Writer) {
+		if len(stateFile) > 0 {
+			dw.stateFile = stateFile
+			dw.recordSequence = true
+		}
+	}
+}
+
+// startSequence is a helper function for startState that picks up the sequence
+// numbers where the last run left them.
+func (dw *Writer) startSequence(state writerState) {
+	dw.recordNumber = max(state.NextRecord, 1)
+	dw.reservedRecords = dw.recordNumber
+}
+
+// stampRecord is a helper function for writeToLog that returns the buffer with
+// the next sequence number in front of it and the length of the number.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) stampRecord(buffer []byte) ([]byte, int) {
+	if dw.recordNumber >= dw.reservedRecords {
+		dw.reservedRecords = dw.recordNumber + sequenceReserve
+		dw.saveStateOrReport()
+	}
+
+	scratch := strconv.AppendUint(dw.sequenceScratch[:0], dw.recordNumber, 10)
+	scratch = append(scratch, ' ')
+	prefix := len(scratch)
+	scratch = append(scratch, buffer...)
+	if cap(scratch) <= maxPooledBuffer {
+		// Keep the memory for next time, unless it's big.
+		dw.sequenceScratch = scratch
+	}
+	dw.recordNumber++
+
+	return scratch, prefix
+}
@@ -0,0 +1,95 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRecordSequence checks that the records are numbered, that the numbers carry
+// on after a restart, and that a crash leaves a gap rather than repeating them.
+func TestRecordSequence(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const logName = "foo.2020-02-14.bar"
+	const stateName = "foo.state"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithRecordSequence(stateName))
+	n, err := writer.Write([]byte("first\n"))
+	if n != 6 || err != nil {
+		t.Errorf("want 6 bytes written and no error, got %d %v", n, err)
+	}
+	writer.Write([]byte("second\n"))
+	writer.Close()
+
+	// A clean restart carries on where the last run stopped.
+	writer = New(now, ".", "foo.", ".bar", WithRecordSequence(stateName))
+	writer.Write([]byte("third\n"))
+
+	// Save the state file at the time of the "crash".
+	crashState, err := os.ReadFile(stateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	os.WriteFile(stateName, crashState, 0644)
+
+	// After the crash, the rest of the reserved block is skipped.
+	writer = New(now, ".", "foo.", ".bar", WithRecordSequence(stateName))
+	writer.Write([]byte("fourth\n"))
+	writer.Close()
+
+	contents, err := os.ReadFile(logName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "1 first\n2 second\n3 third\n1003 fourth\n"
+	if string(contents) != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, string(contents))
+	}
+}
+
+// TestRecordSequenceBadState checks that a corrupt state file is reported and
+// the numbering starts again.
+func TestRecordSequenceBadState(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	os.WriteFile("foo.state", []byte("junk"), 0644)
+
+	var errs []error
+	handler := func(err error) { errs = append(errs, err) }
+	writer := New(now, ".", "foo.", ".bar", WithErrorHandler(handler), WithRecordSequence("foo.state"))
+	writer.Write([]byte("first\n"))
+	writer.Close()
+
+	if len(errs) != 1 {
+		t.Errorf("want one error, got %v", errs)
+	}
+
+	contents, _ := os.ReadFile("foo.2020-02-14.bar")
+	if string(contents) != "1 first\n" {
+		t.Errorf("want \"1 first\\n\" got \"%s\"", string(contents))
+	}
+}
@@ -0,0 +1,146 @@
+package dailylogger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// WithShardedQueue makes the Writer asynchronous, like WithAsync, but with the
+// queue split into the given number of shards, each holding up to shardLength
+// writes.  Each Write takes a sequence number and puts a copy of the data into
+// one of the shards, chosen by the sequence number, so concurrent writers
+// seldom compete for the same lock.  A single goroutine takes the data from the
+// shards and writes it to the log in sequence number order, which is the order
+// in which the Write calls started.  If a shard is full, Write waits until there
+// is room.  Rotation, Sync and Close wait until everything written before they
+// were called has been written to the log.  Errors are reported as in
+// asynchronous mode.  WithShardedQueue takes the place of WithAsync and the
+// journal (see WithJournal) doesn't cover the data in the shards.
+func WithShardedQueue(shards, shardLength int) Option {
+	return func(dw *Writer) {
+		if shards > 0 && shardLength > 0 {
+			dw.shardCount = shards
+			dw.shardLength = shardLength
+		}
+	}
+}
+
+// shard is one part of the sharded queue.
+type shard struct {
+	mutex   sync.Mutex
+	entries []shardEntry // The queued writes.
+	room    *sync.Cond   // Signalled when the entries are taken.
+}
+
+// shardEntry is a write in the sharded queue.
+type shardEntry struct {
+	sequence uint64  // The order of the write.
+	data     *[]byte // A copy of the data, from the pool.
+}
+
+// startShards is a helper function for newWriter that creates the shards and
+// starts the goroutine that empties them.
+func (dw *Writer) startShards() {
+	dw.shards = make([]*shard, dw.shardCount)
+	for i := range dw.shards {
+		s := shard{entries: make([]shardEntry, 0, dw.shardLength)}
+		s.room = sync.NewCond(&s.mutex)
+		dw.shards[i] = &s
+	}
+	dw.shardNotify = make(chan struct{}, 1)
+	dw.shardProgress = sync.NewCond(&dw.logMutex)
+
+	go dw.drainShards()
+}
+
+// writeSharded queues a copy of the buffer in one of the shards.
+func (dw *Writer) writeSharded(buffer []byte) (int, error) {
+	if dw.shardsClosed.Load() {
+		return 0, ErrClosed
+	}
+
+	// The caller may reuse the buffer as soon as Write returns, so queue a copy.
+	entry := shardEntry{sequence: dw.shardSequence.Add(1) - 1, data: copyToPooledBuffer(buffer)}
+
+	s := dw.shards[entry.sequence%uint64(len(dw.shards))]
+	s.mutex.Lock()
+	for len(s.entries) >= dw.shardLength && !dw.shardsClosed.Load() {
+		s.room.Wait()
+	}
+	if dw.shardsClosed.Load() {
+		s.mutex.Unlock()
+		return 0, ErrClosed
+	}
+	s.entries = append(s.entries, entry)
+	s.mutex.Unlock()
+
+	// Wake the goroutine that empties the shards, unless it's already awake.
+	select {
+	case dw.shardNotify <- struct{}{}:
+	default:
+	}
+
+	return len(buffer), nil
+}
+
+// drainShards runs until the Writer is closed, taking the writes from the shards
+// and writing them to the log in order.  It should be run in a goroutine.
+func (dw *Writer) drainShards() {
+
+	// held contains the writes that have been taken from the shards but can't be
+	// written yet because an earlier write hasn't arrived.
+	var held []shardEntry
+
+	for {
+		select {
+		case <-dw.shardNotify:
+		case <-dw.done:
+			// The Writer has been closed.  Release any writers waiting for room.
+			for _, s := range dw.shards {
+				s.mutex.Lock()
+				s.room.Broadcast()
+				s.mutex.Unlock()
+			}
+			return
+		}
+
+		for _, s := range dw.shards {
+			s.mutex.Lock()
+			held = append(held, s.entries...)
+			s.entries = s.entries[:0]
+			s.room.Broadcast()
+			s.mutex.Unlock()
+		}
+		sort.Slice(held, func(i, j int) bool { return held[i].sequence < held[j].sequence })
+
+		dw.logMutex.Lock()
+		written := 0
+		for written < len(held) && held[written].sequence == dw.shardWritten {
+			_, err := dw.writeToLog(*held[written].data)
+			releasePooledBuffer(held[written].data)
+			if err != nil {
+				dw.reportError(fmt.Errorf("writeSharded: %w", err))
+				if dw.asyncError == nil {
+					dw.asyncError = err
+				}
+			}
+			dw.shardWritten++
+			written++
+		}
+		dw.shardProgress.Broadcast()
+		dw.logMutex.Unlock()
+
+		held = append(held[:0], held[written:]...)
+	}
+}
+
+// waitForShards is a helper function for barrier that waits until everything
+// written to the shards before the given sequence number has been written to
+// the log.  The log lock must be held.  Once the Writer is closed, nothing more
+// is written, so it doesn't wait.
+func (dw *Writer) waitForShards(sequence uint64) {
+	for dw.shardWritten < sequence && !dw.closed {
+		dw.shardProgress.Wait()
+	}
+}
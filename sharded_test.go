@@ -0,0 +1,117 @@
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestShardedQueue checks that writes from many goroutines all reach the log
+// and that each goroutine's writes stay in order.
+func TestShardedQueue(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const writers = 8
+	const writesEach = 200
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// The shards are small, so the writers have to wait for room.
+	writer := New(now, ".", "foo.", ".bar", WithShardedQueue(4, 2))
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writesEach; i++ {
+				fmt.Fprintf(writer, "%d %d\n", w, i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("late")); err != ErrClosed {
+		t.Errorf("want ErrClosed got %v", err)
+	}
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	if len(lines) != writers*writesEach {
+		t.Fatalf("want %d lines got %d", writers*writesEach, len(lines))
+	}
+
+	next := make([]int, writers)
+	for _, line := range lines {
+		var w, i int
+		fmt.Sscanf(line, "%d %d", &w, &i)
+		if i != next[w] {
+			t.Fatalf("writer %d: want write %d got %d", w, next[w], i)
+		}
+		next[w]++
+	}
+}
+
+// TestShardedQueueRotation checks that everything written before a rotation
+// goes into the old file.
+func TestShardedQueueRotation(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := time.Date(2020, time.February, 15, 0, 0, 0, 1000, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar", WithShardedQueue(4, 100))
+	for i := 0; i < 100; i++ {
+		writer.Write([]byte("a"))
+	}
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("b"))
+	writer.Close()
+
+	var testData = []struct {
+		filename string
+		want     string
+	}{
+		{"foo.2020-02-14.bar", strings.Repeat("a", 100)},
+		{"foo.2020-02-15.bar", "b"},
+	}
+
+	for _, td := range testData {
+		contents, err := os.ReadFile(td.filename)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(contents) != td.want {
+			t.Errorf("%s: want \"%s\" got \"%s\"", td.filename, td.want, string(contents))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package dailylogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Shipper sends records to a central logging service such as Kafka or Fluentd
+// (see WithShipper and FluentdShipper).
+type Shipper interface {
+	// Ship sends one record, written at the given time.  It's called with the
+	// Writer's lock held, so when the service is down it should fail quickly
+	// rather than wait for it to come back.  The record is only valid until Ship
+	// returns.
+	Ship(record []byte, t time.Time) error
+}
+
+// WithShipper makes the Writer send each record to a central logging service
+// via the Shipper as well as writing it to the daily log file, which becomes a
+// local dated copy of what was shipped.  Writes skipped by sampling or dropped
+// by the quota are not shipped either.
+//
+// If the Shipper fails, the record is still written to the log file, so the
+// file acts as a spool.  The Writer marks the gap with a line in the log file
+// when shipping stops and another, giving the number of writes that were not
+// shipped, when it resumes.  The writes between the two lines can be sent on
+// later from the file.  The Writer also emits an EventShippingStopped and an
+// EventShippingResumed and counts the writes that were not shipped in the Stats.
+//
+// Ship is called by whichever goroutine writes to the log file, so with WithAsync
+// a slow service doesn't hold up Write.  The Writer doesn't close the Shipper.
+func WithShipper(shipper Shipper) Option {
+	return func(dw *Writer) {
+		dw.shipper = shipper
+	}
+}
+
+// ship is a helper function for writeToLog that sends the buffer via the Shipper
+// and keeps track of any gap.  It doesn't apply the lock, so it should only be
+// called by a function that does.
+func (dw *Writer) ship(buffer []byte) {
+	now := dw.now()
+	err := dw.shipper.Ship(buffer, now)
+	if err != nil {
+		if !dw.shippingStopped {
+			dw.shippingStopped = true
+			dw.shippingGap = Drops{}
+			dw.writeNote(fmt.Sprintf(
+				"dailylogger: shipping stopped at %s - %v - the writes that follow are not shipped\n",
+				now.Format("2006-01-02 15:04:05"), err))
+			dw.emit(Event{Type: EventShippingStopped, Err: err})
+			dw.reportError(fmt.Errorf("ship: %w", err))
+		}
+		dw.shippingGap.add(len(buffer))
+		dw.stats.Unshipped.add(len(buffer))
+		dw.stats.Today.Unshipped.add(len(buffer))
+		return
+	}
+
+	if dw.shippingStopped {
+		dw.shippingStopped = false
+		gap := fmt.Errorf("%d writes (%d bytes) were not shipped", dw.shippingGap.Writes, dw.shippingGap.Bytes)
+		dw.writeNote(fmt.Sprintf("dailylogger: shipping resumed at %s - %v\n",
+			now.Format("2006-01-02 15:04:05"), gap))
+		dw.emit(Event{Type: EventShippingResumed, Err: gap})
+	}
+}
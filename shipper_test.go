@@ -0,0 +1,87 @@
+package dailylogger
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingShipper is a Shipper that keeps the records it's given and fails
+// while down is true.
+type recordingShipper struct {
+	down    bool
+	records []string
+}
+
+func (rs *recordingShipper) Ship(record []byte, t time.Time) error {
+	if rs.down {
+		return errors.New("connection refused")
+	}
+	rs.records = append(rs.records, string(record))
+	return nil
+}
+
+// TestShipper checks that each record is shipped and written to the log file,
+// and that a gap in the shipping is marked in the log file.
+func TestShipper(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	shipper := recordingShipper{}
+	var events []Event
+	handler := func(event Event) { events = append(events, event) }
+	writer := New(now, ".", "foo.", ".bar", WithShipper(&shipper), WithEventHandler(handler))
+
+	writer.Write([]byte("a\n"))
+	shipper.down = true
+	writer.Write([]byte("bb\n"))
+	writer.Write([]byte("cc\n"))
+	shipper.down = false
+	writer.Write([]byte("d\n"))
+	stats := writer.Stats()
+	writer.Close()
+
+	if strings.Join(shipper.records, "") != "a\nd\n" {
+		t.Errorf("want a and d shipped, got %q", shipper.records)
+	}
+
+	if stats.Unshipped.Writes != 2 || stats.Unshipped.Bytes != 6 {
+		t.Errorf("want 2 writes (6 bytes) unshipped, got %+v", stats.Unshipped)
+	}
+
+	if len(events) != 2 || events[0].Type != EventShippingStopped || events[1].Type != EventShippingResumed {
+		t.Errorf("want EventShippingStopped and EventShippingResumed, got %v", events)
+	}
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("want 6 lines got %q", lines)
+	}
+	if lines[0] != "a" || lines[2] != "bb" || lines[3] != "cc" || lines[5] != "d" {
+		t.Errorf("want every record in the log file, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[1], "dailylogger: shipping stopped at ") ||
+		!strings.HasSuffix(lines[1], "connection refused - the writes that follow are not shipped") {
+		t.Errorf("want the start of the gap marked, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "dailylogger: shipping resumed at ") ||
+		!strings.HasSuffix(lines[4], "2 writes (6 bytes) were not shipped") {
+		t.Errorf("want the end of the gap marked, got %q", lines[4])
+	}
+}
@@ -0,0 +1,119 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// sidecarExtension is added to the name of the first log file of a day to make
+// the name of the day's sidecar (see WithSidecar).
+const sidecarExtension = ".meta.json"
+
+// Sidecar is the metadata for one day written by WithSidecar.
+type Sidecar struct {
+	Date       string         `json:"date"`                 // The day, for example "2020-02-14".
+	Start      time.Time      `json:"start,omitzero"`       // The time of the first write of the day.
+	End        time.Time      `json:"end,omitzero"`         // The time of the last write of the day.
+	Bytes      int64          `json:"bytes"`                // The number of bytes written.
+	Lines      int64          `json:"lines"`                // The number of lines written.
+	Files      int            `json:"files"`                // The number of log files for the day.
+	Host       string         `json:"host,omitempty"`       // The name of the host.
+	AppVersion string         `json:"appVersion,omitempty"` // The version of the program's main module.
+	Fields     map[string]any `json:"fields,omitempty"`     // The fields supplied by the caller.
+}
+
+// WithSidecar makes the Writer write a small JSON file describing each day
+// (see Sidecar) when it rotates away from the day, so that an ingestion
+// pipeline needn't scan the log files to find out what they hold.  The sidecar
+// is named after the day's first log file plus ".meta.json", for example
+// "foo.2020-02-14.bar.meta.json", and goes in the log directory.  The counts
+// are those of Stats.Today, so they only cover a restart during the day if the
+// counters are kept in a state file (see WithStateFile).  The app version comes
+// from the program's build information.  If the fields function isn't nil, it's
+// called with the day and what it returns goes in the sidecar too.  It's
+// called by the goroutine that makes the artifacts, so rotation isn't held up,
+// and it mustn't call the Writer.  If it panics, the panic is reported as an
+// error (see WithErrorHandler) and the sidecar is written without the fields.
+// The option has no effect when rotation is disabled.
+func WithSidecar(fields func(day time.Time) map[string]any) Option {
+	return func(dw *Writer) {
+		dw.sidecar = true
+		dw.sidecarFields = fields
+	}
+}
+
+// startSidecar is a helper function for rotation that writes the sidecar for
+// the current day, in a goroutine, if the log is moving on to the given day.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) startSidecar(day time.Time) {
+	if !dw.sidecar || dw.noRotation || day.Equal(dw.startOfToday) {
+		return
+	}
+
+	sidecar := Sidecar{
+		Date:  dw.startOfToday.Format(time.DateOnly),
+		Start: dw.stats.Today.FirstWrite,
+		End:   dw.stats.Today.LastWrite,
+		Bytes: dw.stats.BytesToday,
+		Lines: dw.stats.Today.Lines,
+		Files: dw.sequence + 1,
+	}
+	pathname := dw.logDir + "/" + filepath.Base(dw.fileNamer().Name(dw.startOfToday, 0)) + sidecarExtension
+
+	dw.artifactsPending.Add(1)
+	go dw.writeSidecar(pathname, dw.startOfToday, sidecar)
+}
+
+// writeSidecar fills in the rest of the sidecar and writes it atomically (see
+// WithArtifact).  It should be run in a goroutine.
+func (dw *Writer) writeSidecar(pathname string, day time.Time, sidecar Sidecar) {
+	defer dw.artifactsPending.Done()
+
+	sidecar.Host, _ = os.Hostname()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		sidecar.AppVersion = info.Main.Version
+	}
+	sidecar.Fields = dw.callSidecarFields(day)
+
+	contents, err := json.MarshalIndent(sidecar, "", "\t")
+	if err == nil {
+		err = dw.writeAtomically(pathname, func(w io.Writer) error {
+			_, err := w.Write(append(contents, '\n'))
+			return err
+		})
+	}
+
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+	if err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error writing %s - %w", pathname, err))
+		return
+	}
+	dw.emitLifecycle(Event{Type: EventArtifactMade, Path: pathname})
+}
+
+// callSidecarFields calls the fields function given to WithSidecar, if there is
+// one, and returns what it returns.  If the function panics, the panic is
+// reported as an error and the sidecar is written without the fields, rather
+// than the panic killing the program.
+func (dw *Writer) callSidecarFields(day time.Time) (fields map[string]any) {
+	if dw.sidecarFields == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			dw.logMutex.Lock()
+			dw.reportError(fmt.Errorf("dailylogger: the sidecar fields function panicked - %v", r))
+			dw.logMutex.Unlock()
+			fields = nil
+		}
+	}()
+
+	return dw.sidecarFields(day)
+}
@@ -0,0 +1,112 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSidecar checks that the sidecar for a day is written when the log moves on
+// to the next day and holds the day's counts and the caller's fields.
+func TestSidecar(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	fields := func(day time.Time) map[string]any {
+		return map[string]any{"service": "billing", "day": day.Format(time.DateOnly)}
+	}
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithSidecar(fields))
+	writer.Write([]byte("first\nsecond\n"))
+	fc.Advance(time.Hour)
+	writer.Write([]byte("third\n"))
+
+	// Rotating during the day doesn't finish it.
+	writer.rotate(fc.Now())
+	if _, err := os.Stat("foo.2020-02-14.bar.meta.json"); err == nil {
+		t.Fatal("want no sidecar before the day is over")
+	}
+
+	writer.rotate(time.Date(2020, time.February, 15, 0, 0, 0, 0, locationUTC))
+	writer.Close()
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar.meta.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sidecar Sidecar
+	if err := json.Unmarshal(contents, &sidecar); err != nil {
+		t.Fatal(err)
+	}
+
+	if sidecar.Date != "2020-02-14" || sidecar.Bytes != 19 || sidecar.Lines != 3 || sidecar.Files != 2 {
+		t.Errorf("want 19 bytes, 3 lines and 2 files on 2020-02-14, got %+v", sidecar)
+	}
+	if !sidecar.Start.Equal(now) || !sidecar.End.Equal(now.Add(time.Hour)) {
+		t.Errorf("want the first write at %v and the last at %v, got %v and %v",
+			now, now.Add(time.Hour), sidecar.Start, sidecar.End)
+	}
+	if sidecar.Fields["service"] != "billing" || sidecar.Fields["day"] != "2020-02-14" {
+		t.Errorf("want the caller's fields, got %v", sidecar.Fields)
+	}
+	if len(sidecar.Host) == 0 {
+		t.Error("want the host name")
+	}
+}
+
+// TestSidecarFieldsPanic checks that a panic in the caller's fields function is
+// reported and the sidecar is written without the fields.
+func TestSidecarFieldsPanic(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	fields := func(day time.Time) map[string]any {
+		panic("no fields today")
+	}
+	var reported []error
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithSidecar(fields),
+		WithErrorHandler(func(e error) { reported = append(reported, e) }))
+	writer.Write([]byte("first\n"))
+
+	writer.rotate(time.Date(2020, time.February, 15, 0, 0, 0, 0, locationUTC))
+	writer.Close()
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar.meta.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sidecar Sidecar
+	if err := json.Unmarshal(contents, &sidecar); err != nil {
+		t.Fatal(err)
+	}
+	if sidecar.Date != "2020-02-14" || sidecar.Lines != 1 || sidecar.Fields != nil {
+		t.Errorf("want 1 line on 2020-02-14 and no fields, got %+v", sidecar)
+	}
+
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "no fields today") {
+		t.Errorf("want the panic reported, got %v", reported)
+	}
+}
@@ -0,0 +1,43 @@
+package dailylogger
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// sink is an io.Writer that passes the data on to a destination that can be
+// switched at any time, for example from one day's log file to the next.  If
+// there is no destination, the data is thrown away but reported as written, so
+// a Writer without a log file doesn't fail.  The destination is held in an
+// atomic pointer, so Write doesn't need a lock of its own.
+type sink struct {
+	dest atomic.Pointer[sinkDest]
+}
+
+// sinkDest holds the destination of a sink.  An interface value can't be stored
+// in an atomic pointer directly.
+type sinkDest struct {
+	w io.Writer
+}
+
+// SwitchTo makes the writer the destination of future writes.  A nil writer
+// means that there is no destination.
+func (s *sink) SwitchTo(w io.Writer) {
+	if w == nil {
+		s.dest.Store(nil)
+		return
+	}
+
+	s.dest.Store(&sinkDest{w: w})
+}
+
+// Write writes the buffer to the current destination, if there is one.
+func (s *sink) Write(buffer []byte) (int, error) {
+	dest := s.dest.Load()
+	if dest == nil {
+		// Don't write, but pretend that all of the buffer was written.
+		return len(buffer), nil
+	}
+
+	return dest.w.Write(buffer)
+}
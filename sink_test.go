@@ -0,0 +1,31 @@
+package dailylogger
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSink checks that the sink writes to its current destination and throws
+// the data away when it has none.
+func TestSink(t *testing.T) {
+	var s sink
+	var first, second bytes.Buffer
+
+	if n, err := s.Write([]byte("nowhere")); n != 7 || err != nil {
+		t.Errorf("with no destination want 7, nil got %d, %v", n, err)
+	}
+
+	s.SwitchTo(&first)
+	s.Write([]byte("one"))
+	s.SwitchTo(&second)
+	s.Write([]byte("two"))
+	s.SwitchTo(nil)
+	s.Write([]byte("three"))
+
+	if first.String() != "one" {
+		t.Errorf("want \"one\" got \"%s\"", first.String())
+	}
+	if second.String() != "two" {
+		t.Errorf("want \"two\" got \"%s\"", second.String())
+	}
+}
@@ -0,0 +1,54 @@
+package dailylogger
+
+import (
+	"errors"
+	"syscall"
+)
+
+// WithStaleHandleRecovery makes the Writer recover from a stale file handle, which
+// happens when the log directory is on NFS and the server restarts.  After that,
+// writes fail with ESTALE (or sometimes EIO) until the file is opened again.  When
+// a write fails with one of those errors, the Writer reopens the log file and
+// tries the rest of the write again, up to the given number of times.  It emits an
+// EventReopened if that works and an EventReopenFailed if it doesn't.  In buffered
+// mode, anything in the buffer when the error happened is lost.
+func WithStaleHandleRecovery(attempts int) Option {
+	return func(dw *Writer) {
+		if attempts > 0 {
+			dw.staleHandleRetries = attempts
+		}
+	}
+}
+
+// isStaleHandleError returns true if the error means that the open file is no
+// longer usable but reopening it by name may work.
+func isStaleHandleError(err error) bool {
+	return errors.Is(err, syscall.ESTALE) || errors.Is(err, syscall.EIO)
+}
+
+// recoverFromStaleHandle reopens the log file and writes the part of the buffer
+// that hasn't been written yet.  n is the number of bytes already written and
+// err is the error from the failed write.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) recoverFromStaleHandle(buffer []byte, n int, err error) (int, error) {
+
+	for attempt := 0; attempt < dw.staleHandleRetries && isStaleHandleError(err); attempt++ {
+		dw.closeLog()
+		dw.openLog()
+		if dw.openError() != nil {
+			// The file couldn't be opened.  Try again.
+			continue
+		}
+
+		var m int
+		m, err = dw.writeToLogOnce(buffer[n:])
+		n += m
+		if err == nil {
+			dw.emit(Event{Type: EventReopened, Path: dw.pathname})
+			return n, nil
+		}
+	}
+
+	dw.emit(Event{Type: EventReopenFailed, Path: dw.pathname, Err: err})
+	return n, err
+}
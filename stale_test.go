@@ -0,0 +1,103 @@
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// staleWriter is an io.Writer that always fails with a stale file handle error,
+// as a file on NFS does after the server restarts.
+type staleWriter struct{}
+
+// Write returns ESTALE.
+func (staleWriter) Write(buffer []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: "stale", Err: syscall.ESTALE}
+}
+
+// TestStaleHandleRecovery checks that the Writer reopens the log file after a stale
+// handle error and that the write goes into the file.
+func TestStaleHandleRecovery(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantFilename = "foo.2020-02-14.bar"
+	const wantContents = "hello"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var events []Event
+	writer := New(now, ".", "foo.", ".bar", WithStaleHandleRecovery(2),
+		WithEventHandler(func(e Event) { events = append(events, e) }))
+	defer writer.Close()
+
+	// Make the file handle stale.
+	writer.sink.SwitchTo(staleWriter{})
+
+	n, err := writer.Write([]byte(wantContents))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if n != len(wantContents) {
+		t.Errorf("want %d got %d", len(wantContents), n)
+	}
+
+	contents, _ := os.ReadFile(wantFilename)
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	if len(events) != 1 || events[0].Type != EventReopened {
+		t.Errorf("want one %v event, got %v", EventReopened, events)
+	}
+}
+
+// TestStaleHandleRecoveryFails checks that the Writer gives up if the file can't
+// be reopened.
+func TestStaleHandleRecoveryFails(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const logFilename = "foo.2020-02-14.bar"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	var events []Event
+	writer := New(now, ".", "foo.", ".bar", WithStaleHandleRecovery(2),
+		WithEventHandler(func(e Event) { events = append(events, e) }))
+	defer writer.Close()
+
+	// Make the file handle stale and stop the file from being reopened.
+	writer.sink.SwitchTo(staleWriter{})
+	os.Remove(logFilename)
+	os.Mkdir(logFilename, 0755)
+
+	_, err = writer.Write([]byte("hello"))
+	if err == nil {
+		t.Error("want an error")
+	}
+
+	if len(events) != 1 || events[0].Type != EventReopenFailed {
+		t.Errorf("want one %v event, got %v", EventReopenFailed, events)
+	}
+}
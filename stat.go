@@ -0,0 +1,11 @@
+package dailylogger
+
+// fileStat holds the parts of the status of a file that the ownership code uses.
+// The fields of syscall.Stat_t have different types on different systems, for
+// example the mode is 32 bits under Linux and 16 bits under macOS and FreeBSD,
+// so they are converted to the same types everywhere.
+type fileStat struct {
+	Uid  uint32 // The user ID of the owner.
+	Gid  uint32 // The group ID.
+	Mode uint32 // The file type and permissions, as in syscall.Stat_t.
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// statFile returns the owner, group and mode of the open file.
+func statFile(file *os.File) (*fileStat, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return statFromInfo(info)
+}
+
+// statFromInfo returns the owner, group and mode from the result of Stat.
+func statFromInfo(info os.FileInfo) (*fileStat, error) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, fmt.Errorf("no ownership information for %s", info.Name())
+	}
+
+	return &fileStat{Uid: uint32(st.Uid), Gid: uint32(st.Gid), Mode: uint32(st.Mode)}, nil
+}
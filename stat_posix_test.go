@@ -0,0 +1,68 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+// TestStatFile checks that statFile returns the owner, group and mode of a file
+// in the same form on every POSIX system.
+func TestStatFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const filename = "foo.bar"
+	os.WriteFile(filename, nil, 0600)
+	os.Chmod(filename, 0640)
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stat, err := statFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int(stat.Uid) != os.Getuid() {
+		t.Errorf("want owner %d got %d", os.Getuid(), stat.Uid)
+	}
+	// Under Linux the file has the group of the process.  Under macOS and the BSDs
+	// it has the group of the directory.
+	wantGroup := uint32(os.Getgid())
+	if runtime.GOOS != "linux" {
+		directory, err := os.Open(".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer directory.Close()
+		dirStat, err := statFile(directory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantGroup = dirStat.Gid
+	}
+	if stat.Gid != wantGroup {
+		t.Errorf("want group %d got %d", wantGroup, stat.Gid)
+	}
+	if os.FileMode(stat.Mode)&os.ModePerm != 0640 {
+		t.Errorf("want permissions 0640 got 0%o", stat.Mode&0777)
+	}
+
+	owner, err := fileOwner(filename)
+	if err != nil || owner != os.Getuid() {
+		t.Errorf("want owner %d got %d %v", os.Getuid(), owner, err)
+	}
+}
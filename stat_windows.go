@@ -0,0 +1,13 @@
+//go:build windows
+
+package dailylogger
+
+import (
+	"io/fs"
+	"os"
+)
+
+// statFile always fails because Windows files have no POSIX owner.
+func statFile(file *os.File) (*fileStat, error) {
+	return nil, &fs.PathError{Op: "stat", Path: file.Name(), Err: errWindows}
+}
@@ -0,0 +1,210 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// writerState is the part of the Writer's state that's kept in the state file,
+// so that it survives a restart.
+type writerState struct {
+	// NextRecord is the sequence number that the next record may have (see
+	// WithRecordSequence).  While the Writer is running it's ahead of the number
+	// actually used, so that it needn't be saved on every write.
+	NextRecord uint64 `json:"nextRecord,omitempty"`
+
+	// These are the counters (see WithStateFile).
+	Day          string    `json:"day,omitempty"`          // The day that BytesToday applies to, for example "2020-02-14".
+	BytesToday   int64     `json:"bytesToday,omitempty"`   // See Stats.
+	BytesTotal   int64     `json:"bytesTotal,omitempty"`   // See Stats.
+	Today        DayStats  `json:"today,omitzero"`         // See Stats.
+	Rotations    int64     `json:"rotations,omitempty"`    // See Stats.
+	LastRotation time.Time `json:"lastRotation,omitzero"`  // See Stats.
+	Failed       Drops     `json:"failed,omitzero"`        // See Stats.
+	Sampled      Drops     `json:"sampled,omitzero"`       // See Stats.
+	OverQuota    Drops     `json:"overQuota,omitzero"`     // See Stats.
+	Unshipped    Drops     `json:"unshipped,omitzero"`     // See Stats.
+	ReadOnlyLost int64     `json:"readOnlyLost,omitempty"` // See Stats.
+
+	// These record how much of the daily quota has been used (see WithDailyQuota).
+	QuotaDay      string `json:"quotaDay,omitempty"`      // The day that the quota applies to.
+	QuotaBytes    int64  `json:"quotaBytes,omitempty"`    // The bytes written that day.
+	QuotaExceeded bool   `json:"quotaExceeded,omitempty"` // True if the quota was exceeded that day.
+	QuotaSkipped  int64  `json:"quotaSkipped,omitempty"`  // The writes since the quota was exceeded.
+}
+
+// WithStateFile makes the Writer keep its counters in a small JSON state file
+// with the given name in the log directory, so that a restart part of the way
+// through the day carries on counting instead of starting again from zero.  The
+// file holds the counters returned by Stats, apart from the latencies and the
+// mirror counts, the time of the last rotation and how much of the daily quota
+// has been used (see WithDailyQuota), so a restarted Writer doesn't write the
+// quota marker a second time.  The counts for the day are only picked up if the
+// file is for the current day.  The file is written atomically (see
+// WithArtifact) at each rotation, on Close and, if the interval is positive, at
+// that interval, so a crash loses at most the counts since the last save.  If
+// records are numbered (see WithRecordSequence) the numbers are kept in the same
+// file, and the name given last is used.
+func WithStateFile(name string, interval time.Duration) Option {
+	return func(dw *Writer) {
+		if len(name) > 0 {
+			dw.stateFile = name
+			dw.keepCounters = true
+			dw.stateInterval = max(interval, 0)
+		}
+	}
+}
+
+// startState is a helper function for newWriter that reads the state file and
+// picks up where the last run left off.
+func (dw *Writer) startState() {
+	state, err := dw.loadState()
+	if err != nil {
+		// Carry on from scratch.
+		dw.reportError(fmt.Errorf("dailylogger: error reading the state file - %w", err))
+	}
+
+	if dw.recordSequence {
+		dw.startSequence(state)
+	}
+
+	if dw.keepCounters {
+		dw.restoreCounters(state)
+	}
+
+	dw.closers = append(dw.closers, func() error {
+		// Give back the record numbers reserved but not used.
+		dw.reservedRecords = dw.recordNumber
+		return dw.saveState()
+	})
+}
+
+// restoreCounters is a helper function for startState that sets the counters
+// from the state.
+func (dw *Writer) restoreCounters(state writerState) {
+	dw.stats.Rotations = state.Rotations
+	dw.stats.LastRotation = state.LastRotation
+	dw.stats.Failed = state.Failed
+	dw.stats.Sampled = state.Sampled
+	dw.stats.OverQuota = state.OverQuota
+	dw.stats.Unshipped = state.Unshipped
+	dw.stats.ReadOnlyLostBytes = state.ReadOnlyLost
+
+	dw.stats.BytesTotal = state.BytesTotal
+
+	if state.Day == dw.startOfToday.Format(time.DateOnly) {
+		dw.stats.BytesToday = state.BytesToday
+		dw.stats.Today = state.Today
+		dw.stats.Today.Date = dw.startOfToday
+	}
+
+	if dw.quotaLimit > 0 && len(state.QuotaDay) > 0 {
+		day, err := time.ParseInLocation(time.DateOnly, state.QuotaDay, dw.location)
+		if err == nil && day.Equal(dw.quotaDayNow()) {
+			// The files may have grown since the state was saved.
+			dw.quotaDay = day
+			dw.quotaBytes = max(state.QuotaBytes, dw.bytesWrittenOn(day))
+			dw.quotaExceeded = state.QuotaExceeded
+			dw.quotaSkipped = state.QuotaSkipped
+		}
+	}
+}
+
+// currentState returns the state to be saved.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) currentState() writerState {
+	state := writerState{NextRecord: dw.reservedRecords}
+	if !dw.keepCounters {
+		return state
+	}
+
+	state.Day = dw.startOfToday.Format(time.DateOnly)
+	state.BytesToday = dw.stats.BytesToday
+	state.BytesTotal = dw.stats.BytesTotal
+	state.Today = dw.stats.Today
+	state.Rotations = dw.stats.Rotations
+	state.LastRotation = dw.stats.LastRotation
+	state.Failed = dw.stats.Failed
+	state.Sampled = dw.stats.Sampled
+	state.OverQuota = dw.stats.OverQuota
+	state.Unshipped = dw.stats.Unshipped
+	state.ReadOnlyLost = dw.stats.ReadOnlyLostBytes
+
+	if !dw.quotaDay.IsZero() {
+		state.QuotaDay = dw.quotaDay.Format(time.DateOnly)
+		state.QuotaBytes = dw.quotaBytes
+		state.QuotaExceeded = dw.quotaExceeded
+		state.QuotaSkipped = dw.quotaSkipped
+	}
+
+	return state
+}
+
+// stateMonitor saves the state file at intervals until the Writer is closed.  It
+// should be run in a goroutine.
+func (dw *Writer) stateMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.stateInterval):
+		case <-dw.done:
+			// The Writer has been closed, which saves the state.
+			return
+		}
+
+		dw.logMutex.Lock()
+		if !dw.closed {
+			dw.saveStateOrReport()
+		}
+		dw.logMutex.Unlock()
+	}
+}
+
+// statePathname returns the path name of the state file.
+func (dw *Writer) statePathname() string {
+	return dw.logDir + "/" + dw.stateFile
+}
+
+// loadState reads the state file, if there is one.  A missing file gives the
+// zero state.
+func (dw *Writer) loadState() (writerState, error) {
+	var state writerState
+	contents, err := os.ReadFile(longPath(dw.statePathname()))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return state, err
+	}
+
+	if err := json.Unmarshal(contents, &state); err != nil {
+		return writerState{}, fmt.Errorf("%s - %w", dw.statePathname(), err)
+	}
+	return state, nil
+}
+
+// saveState writes the state file atomically (see WithArtifact).  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) saveState() error {
+	contents, err := json.Marshal(dw.currentState())
+	if err != nil {
+		return err
+	}
+
+	return dw.writeAtomically(dw.statePathname(), func(w io.Writer) error {
+		_, err := w.Write(append(contents, '\n'))
+		return err
+	})
+}
+
+// saveStateOrReport saves the state file and reports any error.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) saveStateOrReport() {
+	if err := dw.saveState(); err != nil {
+		dw.reportError(fmt.Errorf("dailylogger: error saving the state file - %w", err))
+	}
+}
@@ -0,0 +1,115 @@
+package dailylogger
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStateFile checks that the counters and the quota carry on after a restart
+// on the same day, and that only the counters for the day are reset on the next.
+func TestStateFile(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc),
+		WithStateFile("foo.state", 0), WithDailyQuota(10, 0))
+	writer.Write([]byte("12345\n"))
+	writer.Write([]byte("dropped\n"))
+	writer.rotate(now)
+	before := writer.Stats()
+	writer.Close()
+
+	// A restart on the same day carries on counting and doesn't repeat the marker.
+	writer = New(now, ".", "foo.", ".bar", withClock(fc),
+		WithStateFile("foo.state", 0), WithDailyQuota(10, 0))
+	writer.Write([]byte("dropped\n"))
+	after := writer.Stats()
+	writer.Close()
+
+	if after.BytesToday != before.BytesToday {
+		t.Errorf("want %d bytes today got %d", before.BytesToday, after.BytesToday)
+	}
+	if after.Rotations != 1 || !after.LastRotation.Equal(now) {
+		t.Errorf("want 1 rotation at %v got %d at %v", now, after.Rotations, after.LastRotation)
+	}
+	if after.OverQuota.Writes != 2 {
+		t.Errorf("want 2 writes over quota got %d", after.OverQuota.Writes)
+	}
+
+	var markers int
+	for _, name := range []string{"foo.2020-02-14.bar", "foo.2020-02-14.1.bar"} {
+		contents, _ := os.ReadFile(name)
+		markers += strings.Count(string(contents), "quota")
+	}
+	if markers != 1 {
+		t.Errorf("want one quota marker got %d", markers)
+	}
+
+	// A restart on a later day keeps the totals but not the counts for the day.
+	tomorrow := now.AddDate(0, 0, 1)
+	writer = New(tomorrow, ".", "foo.", ".bar", withClock(newFakeClock(tomorrow)),
+		WithStateFile("foo.state", 0), WithDailyQuota(10, 0))
+	writer.Write([]byte("kept\n"))
+	stats := writer.Stats()
+	writer.Close()
+
+	if stats.BytesToday != 5 || stats.OverQuota.Writes != 2 || stats.Rotations != 1 {
+		t.Errorf("want 5 bytes today, 2 writes over quota and 1 rotation, got %d, %d and %d",
+			stats.BytesToday, stats.OverQuota.Writes, stats.Rotations)
+	}
+}
+
+// TestStateFileInterval checks that the state file is saved at the given
+// interval as well as on Close.
+func TestStateFileInterval(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithStateFile("foo.state", time.Minute))
+	defer writer.Close()
+
+	writer.Write([]byte("12345\n"))
+
+	// The rotator and the state goroutine go to sleep.  The state goroutine wakes,
+	// saves the file and goes back to sleep.
+	fc.WaitForSleepers(2)
+	fc.Advance(time.Minute)
+	fc.WaitForSleepers(3)
+
+	contents, err := os.ReadFile("foo.state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state writerState
+	if err := json.Unmarshal(contents, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Day != "2020-02-14" || state.BytesToday != 6 {
+		t.Errorf("want 6 bytes on 2020-02-14 got %d on %s", state.BytesToday, state.Day)
+	}
+}
@@ -0,0 +1,189 @@
+package dailylogger
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Drops counts writes that didn't reach the log file.
+type Drops struct {
+	Writes int64 // The number of writes.
+	Bytes  int64 // The number of bytes in those writes.
+}
+
+// Stats reports what the Writer has logged and what it has failed to log since
+// it was created, or since the state file was started if the counters are kept
+// there (see WithStateFile).  Today holds the same counts for the current log
+// day, which start again from zero when the log rotates to a new day, so a
+// long-running daemon can report its daily accounting.  In asynchronous mode
+// Write waits when the queue is full rather than dropping anything, so there is
+// no count for that.
+type Stats struct {
+	BytesToday int64  // The number of bytes written to the log files for the current day.
+	BytesTotal int64  // The number of bytes written to the log files in total.
+	Rotations  int64  // The number of times the log has been rotated.
+	LastError  string // The last error reported by the Writer (empty if none).
+
+	LastRotatorError string // The last panic recovered in the log rotator (empty if none).
+
+	LastRotation time.Time // When the log was last rotated (zero if it hasn't been).
+
+	Failed    Drops // Writes that failed because of an error from the log file.
+	Sampled   Drops // Writes skipped by sampling (see WithSampling).
+	OverQuota Drops // Writes dropped because the daily quota was exceeded (see WithDailyQuota).
+
+	// These count the bytes that were written to one side of a mirrored log but
+	// not the other (see WithMirror).
+	PrimaryMissedBytes int64 // Bytes that only reached the mirror directory.
+	MirrorMissedBytes  int64 // Bytes that only reached the log directory.
+
+	ReadOnlyLostBytes int64 // Bytes discarded while the filesystem was read-only (see WithReadOnlyFallback).
+
+	Unshipped Drops // Writes kept in the log file that the Shipper failed to send (see WithShipper).
+
+	Today DayStats // The counts for the current log day.
+
+	// These show how long things take (see WithLatencyBuckets).
+	WriteLatency    Histogram // The time taken to write each buffer to the log file.
+	RotationLatency Histogram // The time taken to close the log file and open the next one (or reopen it).
+}
+
+// DayStats holds the counts for one log day (see Stats).
+type DayStats struct {
+	Date time.Time // Midnight at the start of the day.
+
+	Failed            Drops // Writes that failed because of an error from the log file.
+	Sampled           Drops // Writes skipped by sampling.
+	OverQuota         Drops // Writes dropped because the daily quota was exceeded.
+	Unshipped         Drops // Writes kept in the log file that the Shipper failed to send.
+	ReadOnlyLostBytes int64 // Bytes discarded while the filesystem was read-only.
+
+	Lines      int64     // The number of lines written to the log files.
+	FirstWrite time.Time // The time of the first write to the log files (zero if none).
+	LastWrite  time.Time // The time of the last write to the log files (zero if none).
+}
+
+// Stats returns the Writer's counters.
+func (dw *Writer) Stats() Stats {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	return dw.currentStats()
+}
+
+// currentStats is a helper function that gathers the counters.  It doesn't apply
+// the lock, so it should only be called by a function that does.
+func (dw *Writer) currentStats() Stats {
+	stats := dw.stats
+	stats.WriteLatency = dw.stats.WriteLatency.clone()
+	stats.RotationLatency = dw.stats.RotationLatency.clone()
+	stats.PrimaryMissedBytes = dw.mirrorMissedBytes[primarySide]
+	stats.MirrorMissedBytes = dw.mirrorMissedBytes[mirrorSide]
+
+	return stats
+}
+
+// dropCounts holds the counts of dropped writes from a Stats.
+type dropCounts struct {
+	Failed             Drops
+	Sampled            Drops
+	OverQuota          Drops
+	PrimaryMissedBytes int64
+	MirrorMissedBytes  int64
+	ReadOnlyLostBytes  int64
+}
+
+// drops returns the counts of dropped writes.
+func (s Stats) drops() dropCounts {
+	return dropCounts{
+		Failed:             s.Failed,
+		Sampled:            s.Sampled,
+		OverQuota:          s.OverQuota,
+		PrimaryMissedBytes: s.PrimaryMissedBytes,
+		MirrorMissedBytes:  s.MirrorMissedBytes,
+		ReadOnlyLostBytes:  s.ReadOnlyLostBytes,
+	}
+}
+
+// startDay is a helper function for rotation that starts the counts for the day
+// beginning at the given midnight.  It doesn't apply the lock, so it should only
+// be called by a function that does.
+func (dw *Writer) startDay(startOfToday time.Time) {
+	dw.stats.BytesToday = 0
+	dw.stats.Today = DayStats{Date: startOfToday}
+}
+
+// countToday is a helper function for writeToLog that counts the lines written
+// to the log file for the day and notes the time.  It doesn't apply the lock, so
+// it should only be called by a function that does.
+func (dw *Writer) countToday(written []byte) {
+	dw.stats.Today.Lines += int64(bytes.Count(written, []byte{'\n'}))
+
+	now := dw.now()
+	if dw.stats.Today.FirstWrite.IsZero() {
+		dw.stats.Today.FirstWrite = now
+	}
+	dw.stats.Today.LastWrite = now
+}
+
+// add adds the write to the count.
+func (d *Drops) add(bytes int) {
+	d.Writes++
+	d.Bytes += int64(bytes)
+}
+
+// WithStatsSummary makes the Writer add a line to the log at the given interval
+// summarising the writes that were dropped since the last summary, so that any
+// gaps in the log explain themselves.  Nothing is written if nothing was dropped.
+func WithStatsSummary(interval time.Duration) Option {
+	return func(dw *Writer) {
+		if interval > 0 {
+			dw.summaryInterval = interval
+		}
+	}
+}
+
+// summaryMonitor writes the summary line at regular intervals until the Writer
+// is closed.  It should be run in a goroutine.
+func (dw *Writer) summaryMonitor() {
+	for {
+		select {
+		case <-dw.clock.After(dw.summaryInterval):
+		case <-dw.done:
+			// The Writer has been closed.
+			return
+		}
+
+		dw.writeSummary()
+	}
+}
+
+// writeSummary writes the summary line if anything has been dropped since the
+// last one.
+func (dw *Writer) writeSummary() {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed {
+		return
+	}
+
+	stats := dw.currentStats().drops()
+	last := dw.lastSummary
+	if stats == last {
+		return
+	}
+	dw.lastSummary = stats
+
+	line := fmt.Sprintf("dailylogger: since the last summary, dropped writes (bytes): "+
+		"failed %d (%d), sampled %d (%d), over quota %d (%d); mirror missed bytes: primary %d, mirror %d; "+
+		"read-only lost bytes: %d\n",
+		stats.Failed.Writes-last.Failed.Writes, stats.Failed.Bytes-last.Failed.Bytes,
+		stats.Sampled.Writes-last.Sampled.Writes, stats.Sampled.Bytes-last.Sampled.Bytes,
+		stats.OverQuota.Writes-last.OverQuota.Writes, stats.OverQuota.Bytes-last.OverQuota.Bytes,
+		stats.PrimaryMissedBytes-last.PrimaryMissedBytes, stats.MirrorMissedBytes-last.MirrorMissedBytes,
+		stats.ReadOnlyLostBytes-last.ReadOnlyLostBytes)
+
+	dw.writeNote(line)
+}
@@ -0,0 +1,146 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestStatsCountsFailedWrites checks that writes that fail are counted.
+func TestStatsCountsFailedWrites(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	// Create a directory with the name of the log file, so the log file can't be
+	// created.
+	os.Mkdir("foo.2020-02-14.bar", 0755)
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	writer := New(now, ".", "foo.", ".bar")
+	defer writer.Close()
+
+	writer.Write([]byte("hello"))
+	writer.Write([]byte("world!"))
+
+	want := Drops{Writes: 2, Bytes: 11}
+	if got := writer.Stats().Failed; got != want {
+		t.Errorf("want %+v got %+v", want, got)
+	}
+}
+
+// TestStatsSummary checks that the summary line is written when something has
+// been dropped since the last one, and not otherwise.
+func TestStatsSummary(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "a\n" +
+		"dailylogger: since the last summary, dropped writes (bytes): " +
+		"failed 0 (0), sampled 0 (0), over quota 2 (4); mirror missed bytes: primary 0, mirror 0; read-only lost bytes: 0\n"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc),
+		WithDailyQuota(200, 0), WithStatsSummary(time.Minute))
+	defer writer.Close()
+
+	// Use up the quota without writing the marker line, so that the next writes are
+	// dropped.
+	writer.Write([]byte("a\n"))
+	writer.quotaExceeded = true
+	writer.Write([]byte("b\n"))
+	writer.Write([]byte("c\n"))
+
+	// The rotator and the summary goroutine go to sleep.  Each time the summary
+	// goroutine wakes it writes a summary line and goes back to sleep.  The first
+	// summary is written and the second isn't.
+	for i := 0; i < 2; i++ {
+		fc.WaitForSleepers(2 + i)
+		fc.Advance(time.Minute)
+	}
+	fc.WaitForSleepers(4)
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+}
+
+// TestStatsToday checks that the counts for the day, the quota and the sampling
+// budget start again when the log rotates to a new day, and that the lifetime
+// counts carry on.
+func TestStatsToday(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const wantContents = "1\n2\n" +
+		"dailylogger: daily quota of 4 bytes exceeded - dropping writes until tomorrow\n" +
+		"dailylogger: sampling skipped 1 writes (2 bytes) in the minute starting 2020-02-14 12:00\n"
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	fc := newFakeClock(now)
+
+	writer := New(now, ".", "foo.", ".bar", withClock(fc), WithSampling(2, 1000), WithDailyQuota(4, 0))
+	defer writer.Close()
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		writer.Write([]byte(line))
+	}
+
+	// Sampling keeps the first three and the quota drops the third.  The rotation
+	// happens in the same minute as the writes.
+	writer.rotateLogs(tomorrow)
+	writer.Write([]byte("5\n"))
+
+	contents, err := os.ReadFile("foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	stats := writer.Stats()
+	if !stats.Today.Date.Equal(time.Date(2020, time.February, 15, 0, 0, 0, 0, locationUTC)) {
+		t.Errorf("want today to be 2020-02-15 got %v", stats.Today.Date)
+	}
+	if stats.Today.OverQuota.Writes != 0 || stats.Today.Sampled.Writes != 0 || stats.BytesToday != 2 {
+		t.Errorf("want nothing dropped and 2 bytes today, got %d over quota, %d sampled and %d bytes",
+			stats.Today.OverQuota.Writes, stats.Today.Sampled.Writes, stats.BytesToday)
+	}
+	if stats.OverQuota.Writes != 1 || stats.Sampled.Writes != 1 || stats.BytesTotal != 6 {
+		t.Errorf("want 1 write over quota, 1 sampled and 6 bytes in total, got %d, %d and %d",
+			stats.OverQuota.Writes, stats.Sampled.Writes, stats.BytesTotal)
+	}
+}
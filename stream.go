@@ -0,0 +1,21 @@
+package dailylogger
+
+import "strings"
+
+// WithStream makes the Writer write to an existing named pipe (FIFO) or device
+// with the given path name, for example a FIFO read by another process or a
+// serial port.  The log directory, leader and trailer aren't used for its name.
+// The Writer treats it as a stream: there is no rotation, the file isn't
+// created, positioned, preallocated or given permissions and ownership, Sync and
+// Checkpoint don't ask the operating system to commit it, and Rotate and Reopen
+// simply close it and open it again.  Under a POSIX system, opening a FIFO for
+// writing waits until another process opens it for reading, so New and Rotate
+// can block.  Features that need a regular file, such as the journal and record
+// repair, shouldn't be used.
+func WithStream(pathname string) Option {
+	return func(dw *Writer) {
+		dw.noRotation = true
+		dw.stream = true
+		dw.fixedName = strings.TrimSpace(pathname)
+	}
+}
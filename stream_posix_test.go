@@ -0,0 +1,80 @@
+//go:build !windows
+
+package dailylogger
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestStream checks that the Writer can feed a FIFO read by another process and
+// write to a character device.
+func TestStream(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	const fifo = "pipe"
+	const want = "hello"
+	if err := unix.Mkfifo(fifo, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+	// The reader stands in for the other process.
+	received := make(chan string)
+	go func() {
+		reader, err := os.Open(fifo)
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer reader.Close()
+		data, _ := io.ReadAll(reader)
+		received <- string(data)
+	}()
+
+	writer := New(now, ".", "foo.", ".bar", os.FileMode(0), os.FileMode(0640), WithStream(fifo))
+	if _, err := writer.Write([]byte(want)); err != nil {
+		t.Errorf("want Write to succeed, got %v", err)
+	}
+	if err := writer.Sync(); err != nil {
+		t.Errorf("want Sync to succeed, got %v", err)
+	}
+	writer.Close()
+
+	if got := <-received; got != want {
+		t.Errorf("want \"%s\" got \"%s\"", want, got)
+	}
+
+	// The FIFO is left alone.
+	info, err := os.Stat(fifo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("want the FIFO unchanged, got mode %v", info.Mode())
+	}
+
+	// A character device.
+	writer = New(now, ".", "foo.", ".bar", WithStream(os.DevNull))
+	defer writer.Close()
+	if _, err := writer.Write([]byte(want)); err != nil {
+		t.Errorf("%s: want Write to succeed, got %v", os.DevNull, err)
+	}
+	if err := writer.Health(); err != nil {
+		t.Errorf("%s: want a healthy Writer, got %v", os.DevNull, err)
+	}
+}
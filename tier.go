@@ -0,0 +1,107 @@
+package dailylogger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// WithColdTier moves the log files for days more than the given number of days
+// before today from the log directory to another directory, for example from a
+// fast SSD to a bulk HDD.  The files keep their names.  List, and so everything
+// built on it, finds them in either directory, and the retention rules (see
+// WithMaxAge) apply to them as before, except that the emergency purge leaves
+// them alone because deleting them wouldn't free space in the log directory.  The
+// directory is created if necessary, with the same permissions and ownership as
+// the log directory.  If it's on a different filesystem, each file is copied and
+// then the original is deleted.  The files are moved when the Writer is created
+// and every hour after that.  Each move emits an EventFileMovedToCold (see
+// WithLifecycleEvents).  The option has no effect when rotation is disabled.
+func WithColdTier(directory string, days int) Option {
+	return func(dw *Writer) {
+		directory = strings.TrimSpace(directory)
+		if len(directory) > 0 && days > 0 {
+			dw.coldDir = directory
+			dw.coldDays = days
+		}
+	}
+}
+
+// applyTiering is a helper function for applyRetention that moves the log files
+// that are old enough to the cold directory.
+func (dw *Writer) applyTiering() {
+	dw.logMutex.Lock()
+	defer dw.logMutex.Unlock()
+
+	if dw.closed || dw.noRotation || len(dw.coldDir) == 0 {
+		return
+	}
+
+	logFiles, err := dw.List()
+	if err != nil {
+		dw.reportError(fmt.Errorf("tiering: error listing %s - %w", dw.logDir, err))
+		return
+	}
+
+	cutoff := dw.startOfToday.AddDate(0, 0, -dw.coldDays)
+	for _, logFile := range logFiles {
+		if !logFile.Date.Before(cutoff) {
+			// The list is sorted, so we are done.
+			break
+		}
+		if logFile.Cold || dw.offLimits(logFile) {
+			continue
+		}
+
+		pathname := dw.coldDir + "/" + logFile.Name
+		dw.createParent(dw.coldDir, pathname)
+		if err := dw.moveFile(logFile.Pathname, pathname); err != nil {
+			dw.reportError(fmt.Errorf("tiering: error moving %s to %s - %w", logFile.Pathname, pathname, err))
+			continue
+		}
+
+		dw.emitLifecycle(Event{Type: EventFileMovedToCold, Path: pathname})
+	}
+}
+
+// tierDirectory returns the directory that holds the log file, which is the cold
+// directory if the file has been moved there.
+func (dw *Writer) tierDirectory(logFile LogFile) string {
+	if logFile.Cold {
+		return dw.coldDir
+	}
+
+	return dw.logDir
+}
+
+// moveFile moves a file.  If it can't be renamed, for example because the
+// destination is on another filesystem, it's copied, keeping its modification
+// time, and then the original is deleted.  The copy is made atomically (see
+// writeAtomically), so a crash can't leave a half-written file behind.
+func (dw *Writer) moveFile(from, to string) error {
+	dw.releaseFile(from)
+
+	if err := os.Rename(longPath(from), longPath(to)); err == nil {
+		return nil
+	}
+
+	source, err := os.Open(longPath(from))
+	if err != nil {
+		return err
+	}
+	info, err := source.Stat()
+	if err == nil {
+		err = dw.writeAtomically(to, func(w io.Writer) error {
+			_, err := io.Copy(w, source)
+			return err
+		})
+	}
+	source.Close()
+	if err != nil {
+		return err
+	}
+
+	os.Chtimes(longPath(to), info.ModTime(), info.ModTime())
+	return os.Remove(longPath(from))
+}
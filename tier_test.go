@@ -0,0 +1,69 @@
+package dailylogger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestColdTier checks that old log files are moved to the cold directory and that
+// List still finds them.
+func TestColdTier(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	os.Mkdir("logs", 0755)
+	for _, name := range []string{"foo.2020-02-11.bar", "foo.2020-02-12.bar", "foo.2020-02-13.bar"} {
+		os.WriteFile("logs/"+name, []byte("old"), 0644)
+	}
+
+	locationUTC, _ := time.LoadLocation("UTC")
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+	fc := newFakeClock(now)
+
+	// Files more than 2 days old, just the 11th, are moved.
+	events := make(chan Event, 10)
+	writer := New(now, "logs", "foo.", ".bar", withClock(fc),
+		WithColdTier("cold", 2), WithLifecycleEvents(), WithEventChannel(events))
+	defer writer.Close()
+
+	event := nextEvent(events, EventFileMovedToCold)
+	if event.Path != "cold/foo.2020-02-11.bar" {
+		t.Errorf("want the 11th moved, got %v", event)
+	}
+	if contents, err := os.ReadFile("cold/foo.2020-02-11.bar"); err != nil || string(contents) != "old" {
+		t.Errorf("want the file in the cold directory, got %q %v", contents, err)
+	}
+	if _, err := os.Stat("logs/foo.2020-02-11.bar"); !os.IsNotExist(err) {
+		t.Errorf("want the file gone from the log directory, got %v", err)
+	}
+
+	logFiles, err := writer.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testData = []struct {
+		pathname string
+		cold     bool
+	}{
+		{"cold/foo.2020-02-11.bar", true},
+		{"logs/foo.2020-02-12.bar", false},
+		{"logs/foo.2020-02-13.bar", false},
+		{"logs/foo.2020-02-14.bar", false},
+	}
+	if len(logFiles) != len(testData) {
+		t.Fatalf("want %d files got %v", len(testData), logFiles)
+	}
+	for i, td := range testData {
+		if logFiles[i].Pathname != td.pathname || logFiles[i].Cold != td.cold {
+			t.Errorf("%d: want %s cold %v got %+v", i, td.pathname, td.cold, logFiles[i])
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build dailylogger_tzdata
+
+package dailylogger
+
+// Building with the dailylogger_tzdata tag embeds the timezone database in the
+// program, so that WithZone and LoadZone work on a system that has none.
+import _ "time/tzdata"
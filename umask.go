@@ -0,0 +1,31 @@
+package dailylogger
+
+import "os"
+
+// WithUmask sets the process's umask to the given value while the Writer creates
+// the log directory and the log files, and restores the old value afterwards.
+// The umask applies to the whole process, so any files created by other goroutines
+// at the same moment are affected too.  Under Windows there is no umask and this
+// option has no effect.
+//
+// Without this option, new files are created with the requested permissions minus
+// any bits that are set in the umask, and the permissions are then set in full.  So
+// a file is never visible with wider permissions than the ones requested.
+func WithUmask(mask os.FileMode) Option {
+	return func(dw *Writer) {
+		dw.umask = int(mask & os.ModePerm)
+		dw.umaskSet = true
+	}
+}
+
+// withUmask runs the given function with the umask set by WithUmask, if any.
+func (dw *Writer) withUmask(f func()) {
+	if !dw.umaskSet {
+		f()
+		return
+	}
+
+	oldMask := setUmask(dw.umask)
+	defer setUmask(oldMask)
+	f()
+}
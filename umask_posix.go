@@ -0,0 +1,10 @@
+//go:build !windows
+
+package dailylogger
+
+import "syscall"
+
+// setUmask sets the process's umask and returns the old one.
+func setUmask(mask int) int {
+	return syscall.Umask(mask)
+}
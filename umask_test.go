@@ -0,0 +1,60 @@
+package dailylogger
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestUmask checks that files are created with the requested permissions and that
+// WithUmask controls the permissions of a file created with the default ones.
+func TestUmask(t *testing.T) {
+
+	// This test uses the filestore.
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no umask")
+	}
+
+	var testData = []struct {
+		description     string
+		options         []any
+		wantPermissions os.FileMode
+	}{
+		{"defaults", []any{WithUmask(022)}, 0644},
+		{"umask", []any{WithUmask(077)}, 0600},
+		{"requested", []any{"", "", os.FileMode(0), os.FileMode(0660), WithUmask(022)}, 0660},
+		{"requested with process umask", []any{"", "", os.FileMode(0), os.FileMode(0640)}, 0640},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			directoryName, err := CreateWorkingDirectory()
+			if err != nil {
+				t.Errorf("createWorkingDirectory failed - %v", err)
+				return
+			}
+			defer RemoveWorkingDirectory(directoryName)
+
+			const wantFilename = "foo.2020-02-14.bar"
+
+			locationUTC, _ := time.LoadLocation("UTC")
+			now := time.Date(2020, time.February, 14, 12, 0, 0, 0, locationUTC)
+
+			writer := New(now, ".", "foo.", ".bar", td.options...)
+			defer writer.Close()
+
+			info, err := os.Stat(wantFilename)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			got := info.Mode() & os.ModePerm
+			if got != td.wantPermissions {
+				t.Errorf("want 0%o got 0%o", td.wantPermissions, got)
+			}
+		})
+	}
+}
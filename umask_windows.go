@@ -0,0 +1,8 @@
+//go:build windows
+
+package dailylogger
+
+// setUmask does nothing because Windows has no umask.  It returns the given value.
+func setUmask(mask int) int {
+	return mask
+}
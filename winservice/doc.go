@@ -0,0 +1,10 @@
+// Package winservice connects a daily log Writer to the Windows service control
+// manager, so that the log is flushed and closed when the service is stopped or
+// the system shuts down, flushed when the service is paused and optionally
+// reopened when it's continued.  It only does anything under Windows.
+//
+// A service that uses golang.org/x/sys/windows/svc wraps its handler like so:
+//
+//	writer := dailylogger.New(time.Now(), logDir, "service.", ".log")
+//	svc.Run(serviceName, winservice.Wrap(handler, writer, true))
+package winservice
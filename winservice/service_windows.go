@@ -0,0 +1,85 @@
+//go:build windows
+
+package winservice
+
+import (
+	"log"
+
+	"github.com/goblimey/dailylogger"
+	"golang.org/x/sys/windows/svc"
+)
+
+// handler is a svc.Handler that passes the service control requests to another
+// handler after acting on them itself.
+type handler struct {
+	inner            svc.Handler
+	writer           *dailylogger.Writer
+	reopenOnContinue bool
+}
+
+// Wrap returns a svc.Handler that runs the given handler and, as the service
+// control requests go past, acts on the Writer:
+//
+//   - Stop and Shutdown: flush the log before passing the request on, and close it
+//     when the given handler returns, so the handler can log its final messages.
+//   - Pause: flush the log.
+//   - Continue: if reopenOnContinue is true, reopen the log file, which is useful if
+//     something else moved it while the service was paused.
+func Wrap(inner svc.Handler, writer *dailylogger.Writer, reopenOnContinue bool) svc.Handler {
+	return &handler{inner: inner, writer: writer, reopenOnContinue: reopenOnContinue}
+}
+
+// Execute runs the wrapped handler, intercepting its change requests.
+func (h *handler) Execute(args []string, requests <-chan svc.ChangeRequest,
+	status chan<- svc.Status) (bool, uint32) {
+
+	forwarded := make(chan svc.ChangeRequest)
+	finished := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case request, ok := <-requests:
+				if !ok {
+					close(forwarded)
+					return
+				}
+				h.act(request.Cmd)
+				select {
+				case forwarded <- request:
+				case <-finished:
+					return
+				}
+			case <-finished:
+				return
+			}
+		}
+	}()
+
+	serviceSpecific, exitCode := h.inner.Execute(args, forwarded, status)
+	close(finished)
+
+	if err := h.writer.Close(); err != nil && err != dailylogger.ErrClosed {
+		log.Printf("winservice: error closing the log - %v\n", err)
+	}
+
+	return serviceSpecific, exitCode
+}
+
+// act does whatever is needed to the Writer for the given service command.
+func (h *handler) act(cmd svc.Cmd) {
+	var err error
+
+	switch cmd {
+	case svc.Stop, svc.Shutdown, svc.Pause:
+		err = h.writer.Sync()
+	case svc.Continue:
+		if h.reopenOnContinue {
+			err = h.writer.Reopen()
+		}
+	}
+
+	if err != nil {
+		log.Printf("winservice: error handling service command %d - %v\n", cmd, err)
+	}
+}
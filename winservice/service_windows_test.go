@@ -0,0 +1,67 @@
+//go:build windows
+
+package winservice
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/goblimey/dailylogger"
+	"golang.org/x/sys/windows/svc"
+)
+
+// fakeService is a svc.Handler that writes a line to the log for each request it
+// receives and stops when told to.
+type fakeService struct {
+	writer *dailylogger.Writer
+}
+
+// Execute logs the requests until it receives Stop.
+func (fs *fakeService) Execute(args []string, requests <-chan svc.ChangeRequest,
+	status chan<- svc.Status) (bool, uint32) {
+
+	for request := range requests {
+		fs.writer.Write([]byte("request\n"))
+		if request.Cmd == svc.Stop {
+			fs.writer.Write([]byte("stopping\n"))
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+// TestWrap checks that the log receives everything the service writes and is
+// closed when the service stops.
+func TestWrap(t *testing.T) {
+	directoryName, err := os.MkdirTemp("", "winservice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directoryName)
+
+	const wantContents = "request\nrequest\nstopping\n"
+
+	now := time.Date(2020, time.February, 14, 12, 0, 0, 0, time.UTC)
+	writer := dailylogger.New(now, directoryName, "foo.", ".bar", dailylogger.WithBuffering(1024))
+
+	requests := make(chan svc.ChangeRequest, 2)
+	requests <- svc.ChangeRequest{Cmd: svc.Pause}
+	requests <- svc.ChangeRequest{Cmd: svc.Stop}
+	status := make(chan svc.Status, 10)
+
+	Wrap(&fakeService{writer: writer}, writer, true).Execute(nil, requests, status)
+
+	contents, err := os.ReadFile(directoryName + "/foo.2020-02-14.bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(contents) != wantContents {
+		t.Errorf("want \"%s\" got \"%s\"", wantContents, string(contents))
+	}
+
+	if _, err := writer.Write([]byte("more")); err != dailylogger.ErrClosed {
+		t.Errorf("want the Writer to be closed, got %v", err)
+	}
+}
@@ -1,20 +1,24 @@
 package dailylogger
 
 import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
+	"math/rand/v2"
 	"os"
 	"os/user"
+	"runtime"
 	"strings"
 	"sync"
-
+	"sync/atomic"
 	"time"
 
-	ps "github.com/goblimey/portablesyscall"
-	"github.com/goblimey/switchwriter"
+	"github.com/goblimey/dailylogger/schedule"
 )
 
 // Writer satisfies the io.Writer interface and writes data to a log file.
@@ -24,27 +28,265 @@ import (
 // "data" and trailer "log" would be "data.20201005.log".
 //
 // The Writer rolls the log over at midnight at the start of each day - it
-// closes yesterday's log and creates today's.
+// closes yesterday's log and creates today's.  Midnight is in the timezone of
+// the time given to New, or the one given by WithLocation.  On the days when
+// daylight saving time starts or ends, the log covers 23 or 25 hours.  If the
+// timezone skips midnight altogether on such a day, the log rolls over at the
+// first instant of the day, for example 01:00.
 //
 // On start up, the first call of New creates today's log file if it doesn't
 // already exist.  If the file has already been created, the Writer appends to
 // the existing contents.
 //
+// Data is written in the order that Write is called.  Everything written by a Write
+// call that returns before a rotation starts goes into the old log file, and that
+// file is flushed and closed before anything is written to the new one.  This holds
+// in the buffered and asynchronous modes too (see WithBuffering and WithAsync).
+//
 // The Writer contains a mutex.  It's dangerous to copy an object that contain a
 // mutex, so you should always call its methods via a pointer.  The New function
 // returns a pointer, so that's a good way to create a DailyLogger.
 type Writer struct {
 	logMutex           sync.Mutex
-	loggingDisabled    bool                 // True if logging is disable. (Logging is enabled by default.)
-	startOfToday       time.Time            // The current datestamp for the log.
-	logDir             string               // The log directory.
-	leader             string               // The leading part of the log file name.
-	trailer            string               // The trailing part of the log file name.
-	userName           string               // The user that will own the log file (optional).
-	groupName          string               // the group of the log file (optional).
-	logDirPermissions  os.FileMode          // file permissions on the log directory (0 means leave as is)
-	logFilePermissions os.FileMode          // file permissions to be set on the log file (0 means leave as is).
-	switchwriter       *switchwriter.Writer // The connection to the log file.
+	loggingDisabled    bool              // True if logging is disable. (Logging is enabled by default.)
+	startOfToday       time.Time         // The current datestamp for the log.
+	nextRotation       int64             // The start of the next day in Unix nanoseconds.
+	lazyRotation       bool              // True if Write rotates the log rather than a goroutine (see WithLazyRotation).
+	scheduler          *Scheduler        // The shared Scheduler that rotates the log, if any (see WithScheduler).
+	sequence           int               // The sequence number of today's current log file (0 for the first).
+	logDir             string            // The log directory.
+	leader             string            // The leading part of the log file name.
+	trailer            string            // The trailing part of the log file name.
+	userName           string            // The user that will own the log file (optional).
+	groupName          string            // the group of the log file (optional).
+	logDirPermissions  os.FileMode       // file permissions on the log directory (0 means leave as is)
+	logFilePermissions os.FileMode       // file permissions to be set on the log file (0 means leave as is).
+	sink               *sink             // The connection to the log file.
+	noRotation         bool              // True if the log is never rotated (see WithNoRotation).
+	fixedName          string            // The name of the log file when rotation is disabled.
+	logFile            *os.File          // The current log file (nil if it could not be opened or a file factory is used).
+	logWriter          io.WriteCloser    // The current log file, or the writer from the file factory (nil if not open).
+	pathname           string            // The path name of the current log file.
+	bufferSize         int               // The size of the write buffer (0 means unbuffered).
+	buffer             *bufio.Writer     // The write buffer in front of the log file (buffered mode).
+	closed             bool              // True when the Writer has been closed.
+	done               chan struct{}     // Closed by Close to stop the log rotator.
+	clock              clock             // The source of the time (replaced by unit tests).
+	location           *time.Location    // The timezone that defines the start of each day.
+	rotationJitter     time.Duration     // The upper bound of the random delay before rotation.
+	rotationSlack      time.Duration     // How long after midnight the log rotator wakes (see WithRotationSlack).
+	zoneName           string            // The name of the timezone to load (see WithZone).
+	utcFallback        bool              // True if the Writer uses UTC when the zone can't be loaded.
+	random             func(int64) int64 // Returns a random number in [0, n) (replaced by unit tests).
+	eventHandler       func(Event)       // Receives events from the Writer (optional).
+	umask              int               // The umask to use while creating files (see WithUmask).
+	umaskSet           bool              // True if the umask should be set while creating files.
+	groupInheritance   bool              // True if log files inherit the group of the directory.
+	labeler            FileLabeler       // Applies a security label to new files (optional).
+	errorHandlers      []func(error)     // Receive reports of errors inside the Writer (optional).
+	closers            []func() error    // Release resources used by options when the Writer is closed.
+	staleHandleRetries int               // The number of times to reopen a stale log file (0 means never).
+	stats              Stats             // Counts the writes that were dropped.
+	summaryInterval    time.Duration     // The time between summary lines (0 means none).
+	lastSummary        dropCounts        // The counts at the time of the last summary line.
+	expvarName         string            // The name used to publish the counters (see WithExpvar).
+	instrumentation    Instrumentation   // Receives measurements (optional).
+	lastWriteError     error             // The error from the last write to the log file, if any.
+	lastRotationError  error             // The error from the last rotation, if any.
+	selfTestInterval   time.Duration     // The time between self-tests (0 means none).
+	reopenInterval     time.Duration     // The time between reopens of the log file (0 means none).
+	preallocateSize    int64             // The space to reserve for each new log file (0 means none).
+	writeThrough       bool              // True if each write goes straight to the disk (see WithWriteThrough).
+	fileFactory        FileFactory       // Opens the log files in place of openFile (optional).
+	stream             bool              // True if the log is a stream such as a FIFO (see WithStream).
+	reapplyPermissions bool              // True if permissions are set on every open (see WithPermissionsReapplied).
+
+	// These are used when the filesystem is read-only (see WithReadOnlyFallback).
+	readOnlyBufferSize    int           // The amount of data to keep in memory (0 means don't).
+	readOnlyRetryInterval time.Duration // The time between attempts to reopen the log file.
+	readOnly              bool          // True while the log is kept in memory.
+	readOnlyBuffer        *ringBuffer   // The data kept in memory.
+
+	recent *ringBuffer // The most recent output (see WithRecentOutput).
+
+	// These are used when records are shipped to a central logging service (see
+	// WithShipper).
+	shipper         Shipper // Sends each record (nil means don't ship).
+	shippingStopped bool    // True while the Shipper is failing.
+	shippingGap     Drops   // The writes not shipped since the Shipper started failing.
+
+	// These are used to report the Writer's events (see WithEventChannel,
+	// WithLifecycleEvents and WithMetaLog).
+	eventChannel    chan<- Event // Receives events from the Writer (optional).
+	lifecycleEvents bool         // True if lifecycle events go to the handler and the channel.
+	metaLeader      string       // The leader of the meta log's file names (empty means none).
+	metaTrailer     string       // The trailer of the meta log's file names.
+	metaLog         *Writer      // Records the events (nil if there isn't one).
+
+	dateStyle  DateStyle // The form of the date in the log file names (see WithDateStyle).
+	namer      FileNamer // Produces the log file names (nil means use the default).
+	hostInName string    // The host name to put in the log file names (see WithHostnameInName).
+	pidInName  int       // The process ID to put in the log file names (see WithPIDInName).
+
+	exclusiveFiles bool // True if the log file is locked against other processes (see WithExclusiveFiles).
+
+	// These are used when artifacts are made after rotation (see WithArtifact,
+	// WithArtifactWorkers, WithArtifactPriority and WithArtifactProgress).
+	artifacts        []Artifact     // Make files derived from the old log file.
+	artifactsPending sync.WaitGroup // Counts the goroutines making artifacts.
+	artifactSlots    chan struct{}  // Limits the files worked on at once (nil means no limit).
+	artifactNice     int            // The nice level of the threads making artifacts.
+	artifactIdleIO   bool           // True if the threads making artifacts use idle I/O priority.
+	progressEvery    int64          // The bytes read between progress reports.
+	progressReport   ProgressFunc   // Receives the progress reports (nil means none).
+	compressor       Compressor     // Compresses the log files (see WithCompression).
+	compressLive     bool           // True if the current log file is compressed (see WithCompressedLiveFile).
+	flushBlock       int            // The amount written between flush points of a compressed live file.
+
+	// These are used when each day's files are recorded in a manifest (see
+	// WithManifest).
+	manifest      bool               // True if there are manifests.
+	manifestKey   ed25519.PrivateKey // Signs the manifests (nil means don't sign).
+	manifestMutex sync.Mutex         // Protects the manifests while they are updated.
+
+	deletionMode  DeletionMode    // How expired log files are deleted (see WithSecureDeletion).
+	shredding     uint64          // The space taken by the files that are being destroyed (see removeFile).
+	shredsPending map[string]bool // The private names of the files that are being destroyed.
+
+	lazyCreation    bool // True if each log file is created by the first write to it (see WithLazyCreation).
+	creationPending bool // True if the current log file will be created by the next write.
+
+	emptyFileDays int // How far back to create empty files for days with none (0 means don't, see WithEmptyFiles).
+
+	// These are used when the lines are chained (see WithHashChain).
+	hashChain    bool              // True if each line carries on the hash chain.
+	chainHead    [sha256.Size]byte // The hash of the last line written to the current file.
+	chainNewline bool              // True if the current file ends with a torn line.
+	chainScratch []byte            // Reused to build the chained lines.
+
+	appendOnly       bool // True if the log files are protected so that they can only be appended to (see WithAppendOnly).
+	protectionFailed bool // True once a failure to protect a log file has been reported.
+
+	inUseCheck bool          // True if files that other programs are using are left alone (see WithInUseCheck).
+	inUseGrace time.Duration // How long after a file is modified it's assumed to be in use.
+	inUse      openFileSet   // The files that other processes had open at the start of the pass.
+
+	retentionInclude []string // If not empty, only the files matching one of these are worked on (see WithRetentionFilter).
+	retentionExclude []string // The files matching any of these are left alone.
+
+	sidecar       bool                               // True if a sidecar is written for each day (see WithSidecar).
+	sidecarFields func(day time.Time) map[string]any // Supplies the caller's fields for the sidecar (may be nil).
+
+	// These are used when the Writer writes heartbeats (see WithHeartbeat).
+	heartbeatInterval time.Duration          // The time between heartbeats (0 means none).
+	heartbeatPayload  func(time.Time) string // Makes the heartbeat line (nil means the default).
+
+	// These are used when state is kept in the state file (see WithStateFile).
+	stateFile     string        // The name of the state file in the log directory (empty means none).
+	keepCounters  bool          // True if the counters are kept in the state file.
+	stateInterval time.Duration // The time between saves of the state file (0 means rotation and Close only).
+
+	// These are used when the records are numbered (see WithRecordSequence).
+	recordSequence  bool   // True if the records are numbered.
+	recordNumber    uint64 // The sequence number of the next record.
+	reservedRecords uint64 // The end of the block of numbers recorded in the state file.
+	sequenceScratch []byte // Reused to build each numbered record.
+
+	openFileCacheSize int        // The number of old log files to keep open (see WithOpenFileCache).
+	files             *fileCache // The old log files that are open.
+
+	framing      bool     // True if each record is framed with its length and CRC (see WithRecordFraming).
+	checksum     Checksum // The checksum at the end of each frame (see WithRecordChecksum).
+	frameScratch []byte   // Reused to build each frame.
+
+	// These are used when the journal is enabled (see WithJournal).
+	journalName    string   // The name of the journal in the log directory (empty means none).
+	journal        *os.File // The open journal (nil if there isn't one).
+	journalScratch []byte   // Reused to build each journal record.
+
+	// These are used when a lock file is enabled (see WithLockFile).
+	lockFileName  string // The name of the lock file in the log directory (empty means none).
+	lockFileError error  // The reason that logging is disabled, if it is.
+
+	// These record the first error while the Writer was starting up.
+	starting            bool        // True while newWriter is setting up the Writer.
+	startupError        error       // The first error from creating a directory or file while starting up.
+	startupErrorPending atomic.Bool // True until the startup error has been returned.
+
+	// These are used when hex dumping is enabled (see WithHexDump).
+	hexDumpBytesPerLine int      // The number of bytes on each line of the dump (0 means no dump).
+	hexDumpOnly         bool     // True if the dump replaces the raw data.
+	hexDumpFile         *os.File // The companion file holding the dump.
+
+	// These are used when mirroring is enabled (see WithMirror).
+	mirrorDir         string   // The directory holding the copies of the log files.
+	mirrorFile        *os.File // The current copy of the log file (nil if it could not be opened).
+	mirrorFailing     [2]bool  // True if the primary (0) or mirror (1) side's last write failed.
+	mirrorMissedBytes [2]int64 // The number of bytes that each side failed to write.
+
+	// These are used by the emergency purge (see WithEmergencyPurge).
+	purgeWatermark uint64                       // Old files are deleted when free space falls below this.
+	purgeInterval  time.Duration                // The time between checks of the free space.
+	purgeCallback  func([]LogFile)              // Called with the files that were deleted (optional).
+	freeSpace      func(string) (uint64, error) // Returns the free space for a directory (replaced by unit tests).
+
+	// These are used by the retention rules (see WithMaxAge, WithQuarantine and
+	// WithRetentionDryRun).
+	maxAge          int              // Delete the files for days more than this many days ago (0 means don't).
+	quarantineDir   string           // The subdirectory holding the files removed by retention (empty means none).
+	quarantineDays  int              // The number of days that files stay in the quarantine.
+	restored        map[string]bool  // The dates restored from the quarantine, which retention leaves alone.
+	coldDir         string           // The directory that old log files are moved to (empty means none).
+	coldDays        int              // Move the files for days more than this many days ago.
+	monthlyBundle   bool             // True if each month's files are bundled (see WithMonthlyBundle).
+	retentionDryRun func([]Deletion) // Receives what would be deleted (nil means delete it).
+
+	// These are used when a daily quota is set (see WithDailyQuota).
+	quotaLimit       int64     // The number of bytes that may be written each day (0 means no limit).
+	quotaSampleEvery int       // Keep one write in this many after the quota is exceeded (0 or 1 means none).
+	quotaDay         time.Time // The day that quotaBytes applies to.
+	quotaBytes       int64     // The number of bytes written so far that day.
+	quotaExceeded    bool      // True when the quota for the day has been exceeded.
+	quotaSkipped     int64     // The number of writes since the quota was exceeded.
+
+	// These are used when sampling is enabled (see WithSampling).
+	samplingBudget       int       // The number of writes logged in full each minute.
+	samplingEvery        int       // Keep one write in this many after the budget is used (0 means no sampling).
+	samplingWindow       time.Time // The start of the current minute.
+	samplingCount        int       // The number of writes so far in the current minute.
+	samplingSkipped      int64     // The number of writes skipped in the current minute.
+	samplingSkippedBytes int64     // The number of bytes skipped in the current minute.
+
+	// These control the application of the owner and group (see WithOwnershipRetry).
+	setOwnership           func(filename, userName, groupName string) error // Replaced by unit tests.
+	ownershipRetryInterval time.Duration                                    // The delay before the first retry.
+	ownershipRetryAttempts int                                              // The number of retries (0 means don't retry).
+
+	// These are used when whole lines are enabled (see WithWholeLines and
+	// WithRecordSplitter).
+	splitter         bufio.SplitFunc // Finds the records (nil if records may be split between files).
+	partialLine      []byte          // The start of a record that has not been completed yet.
+	partialLinePath  string          // The log file that the partial record belongs in.
+	heldLog          *logHandles     // The finished log file that the partial record belongs in (nil if none).
+	heldArtifacts    string          // A finished log file waiting for the end of the partial record.
+	heldArtifactsDay time.Time       // The day of that file.
+
+	// These are used in asynchronous mode (see WithAsync).
+	queueMutex  sync.Mutex     // Held while queuing a write and while rotating or flushing.
+	queueLength int            // The length of the write queue (0 means synchronous).
+	queue       chan *[]byte   // Writes waiting to be written to the log.
+	pending     sync.WaitGroup // Counts the writes in the queue that have not been written yet.
+	asyncError  error          // The first error from an asynchronous write, if any.
+
+	// These are used when the queue is sharded (see WithShardedQueue).
+	shardCount    int           // The number of shards (0 means the queue isn't sharded).
+	shardLength   int           // The number of writes that each shard can hold.
+	shards        []*shard      // The shards.
+	shardSequence atomic.Uint64 // The sequence number of the next write.
+	shardWritten  uint64        // The sequence number of the next write to go to the log.
+	shardNotify   chan struct{} // Wakes the goroutine that empties the shards.
+	shardProgress *sync.Cond    // Signalled when writes from the shards have gone to the log.
+	shardsClosed  atomic.Bool   // True when the Writer has been closed.
 }
 
 // This is a compile-time check that Writer implements the io.Writer interface.
@@ -57,6 +299,7 @@ var _ io.Writer = (*Writer)(nil)
 // log file is created with a name refecting the new date.  The optional arguments are log directory
 // permissions(os.FileMode), log file permissions (os.FileMode), user name and group name of the files.  If
 // a permissions value is zero, the permissions are left as they are, they are NOT set to zero.  The
+// permissions may include the special bits os.ModeSetuid, os.ModeSetgid and os.ModeSticky.  The
 // optional arguments are only useful if the calling process is running under a POSIX system (not
 // MS Windows) and is able to change the state of the file, for example, the caller is running as
 // root or as the user that owns the files.  Typical calls are:
@@ -69,6 +312,11 @@ var _ io.Writer = (*Writer)(nil)
 //
 // Note: the os.FileMode type is a uint32 but the object supplied MUST be an os.FileMode type.
 // Any other int type will be interpreted as zero.
+//
+// The optional arguments may also include any number of Options, which can appear anywhere
+// in the list, for example:
+//
+//	New(time, logDirectory, leader, trailer, owner, group, WithNoRotation(""))
 func New(now time.Time, logDir, leader, trailer string, args ...any) *Writer {
 
 	// The logfile is of the form "logDir/leader.yyyy-mm-dd.trailer".  The default
@@ -95,25 +343,80 @@ func New(now time.Time, logDir, leader, trailer string, args ...any) *Writer {
 	// Get the log permissions, the log owner and group.  The owner and group can only be
 	// set under a POSIX system and while running as root.  Under Windows the user and
 	// group are ignored.
-	dirPermissions, filePermissions, userName, groupName := getLogFileDetails(args...)
+	options, args := splitOptions(args)
+	userName, groupName, dirPermissions, filePermissions := getLogFileDetails(args...)
 
 	// Create the writer.
-	dw := newWriter(now, logDir, leader, trailer, dirPermissions, filePermissions, userName, groupName)
+	dw := newWriter(now, logDir, leader, trailer, userName, groupName, dirPermissions, filePermissions,
+		options...)
+
+	// Start a goroutine to roll the log over at the end of each day, unless rotation is
+	// disabled, done by Write or done by a shared Scheduler.
+	if dw.scheduler != nil {
+		dw.scheduler.add(dw)
+	} else if !dw.noRotation && !dw.lazyRotation {
+		go dw.logRotator()
+	}
+
+	if len(dw.expvarName) > 0 {
+		publishExpvars(dw.expvarName, dw)
+	}
+
+	// Start a goroutine to recover from a read-only filesystem, if required.
+	if dw.readOnlyBufferSize > 0 {
+		go dw.readOnlyMonitor()
+	}
+
+	// Start a goroutine to run the self-tests, if required.
+	if dw.selfTestInterval > 0 {
+		go dw.selfTestMonitor()
+	}
+
+	// Start a goroutine to write the heartbeats, if required.
+	if dw.heartbeatInterval > 0 {
+		go dw.heartbeatMonitor()
+	}
+
+	// Start a goroutine to write the summary lines, if required.
+	if dw.summaryInterval > 0 {
+		go dw.summaryMonitor()
+	}
+
+	// Start a goroutine to save the state file periodically, if required.
+	if dw.stateInterval > 0 {
+		go dw.stateMonitor()
+	}
+
+	// Start a goroutine to reopen the log file periodically, if required.
+	if dw.reopenInterval > 0 {
+		go dw.reopenMonitor()
+	}
+
+	// Start a goroutine to watch the free space, if required.
+	if dw.purgeWatermark > 0 {
+		go dw.purgeMonitor()
+	}
 
-	// Start a goroutine to roll the log over at the end of each day.
-	go dw.logRotator()
+	// Start a goroutine to apply the retention rules, if required.
+	if dw.maxAge > 0 || len(dw.quarantineDir) > 0 || len(dw.coldDir) > 0 || dw.monthlyBundle {
+		go dw.retentionMonitor()
+	} else if dw.deletionMode != DeleteNormally {
+		// Destroy any files left behind by secure deletion.  Otherwise the retention
+		// rules do that on each pass.
+		dw.artifactsPending.Add(1)
+		go func() {
+			defer dw.artifactsPending.Done()
+			dw.sweepShreds()
+		}()
+	}
 	return dw
 }
 
-// newWriter creates a daily writer with a supplied switchwriter
+// newWriter creates a daily writer with a sink for the log file
 // and returns a pointer to it. This is called by New as a helper method and by
 // unit tests.
 func newWriter(now time.Time, logDir, leader, trailer, userName, groupName string,
-	dirPermissions, filePermissions os.FileMode) *Writer {
-
-	startOfToday := getLastMidnight(now)
-
-	sw := switchwriter.New()
+	dirPermissions, filePermissions os.FileMode, options ...Option) *Writer {
 
 	dw := Writer{
 		logDir:             logDir,
@@ -123,16 +426,114 @@ func newWriter(now time.Time, logDir, leader, trailer, userName, groupName strin
 		logFilePermissions: filePermissions,
 		userName:           userName,
 		groupName:          groupName,
-		startOfToday:       startOfToday,
-		switchwriter:       sw,
+		sink:               new(sink),
+		done:               make(chan struct{}),
+		clock:              realClock{},
+		rotationSlack:      extraDuration,
+		location:           now.Location(),
+		random:             rand.Int64N,
+		setOwnership:       SetFileUserAndGroup,
+		freeSpace:          diskFreeSpace,
+		restored:           make(map[string]bool),
+		shredsPending:      make(map[string]bool),
+	}
+	dw.stats.WriteLatency = newHistogram(defaultLatencyBounds)
+	dw.stats.RotationLatency = newHistogram(defaultLatencyBounds)
+
+	for _, option := range options {
+		option(&dw)
 	}
 
+	// Keep the first error while starting up for the first Write, Sync or Health.
+	dw.starting = true
+
+	dw.checkZone()
+	dw.checkDeletionMode()
+	dw.checkHashChain()
+	dw.checkAppendOnly()
+	dw.checkRetentionFilter()
+	dw.checkRecordSplitter()
+
+	startOfToday := getLastMidnight(now.In(dw.location))
+	dw.startOfToday = startOfToday
+	dw.stats.Today.Date = startOfToday
+	dw.nextRotation = getNextMidnight(startOfToday).UnixNano()
+
+	dw.files = newFileCache(dw.openFileCacheSize, dw.bufferSize, dw.openFile)
+
 	// Create the log directory if it doesn't already exist.
-	createlogDirectory(logDir, userName, groupName, dirPermissions)
+	if dw.groupInheritance && runtime.GOOS != "windows" {
+		// Set the setgid bit on the directory so that new files inherit its group.
+		if dirPermissions&os.ModePerm == 0 {
+			dirPermissions |= os.ModePerm
+		}
+		dirPermissions |= os.ModeSetgid
+		dw.logDirPermissions = dirPermissions
+	}
+	if dw.needsDirectoryAtStart() {
+		dw.createDirectory(logDir, dirPermissions)
+		dw.applyOwnership(logDir)
+		dw.applyLabel(logDir)
+	}
+
+	if len(dw.mirrorDir) > 0 {
+		dw.createDirectory(dw.mirrorDir, dirPermissions)
+		dw.applyOwnership(dw.mirrorDir)
+		dw.applyLabel(dw.mirrorDir)
+	}
+
+	if len(dw.coldDir) > 0 {
+		dw.createDirectory(dw.coldDir, dirPermissions)
+		dw.applyOwnership(dw.coldDir)
+		dw.applyLabel(dw.coldDir)
+	}
 
-	// Create today's log file and switch the switchwriter to it.
+	if len(dw.metaLeader) > 0 {
+		// Record the events, starting with the opening of the log file.
+		dw.openMetaLog(now)
+	}
 
+	if len(dw.lockFileName) > 0 {
+		// Make sure that no other process is writing these logs.
+		dw.acquireLockFile()
+	}
+
+	if len(dw.stateFile) > 0 {
+		// Carry on from where the last run stopped.
+		dw.startState()
+	}
+
+	// Create today's log file and switch the sink to it.  If the program
+	// has been restarted, carry on writing to the latest of today's files.
+
+	dw.sequence = dw.getAppendSequence(startOfToday)
 	dw.openLog()
+	dw.fillMissingDays()
+
+	if dw.framing && dw.logFile != nil && !dw.stream {
+		// A crash may have left a torn record at the end of the file.
+		dw.repairTornRecord()
+	}
+
+	if len(dw.journalName) > 0 && !dw.loggingDisabled {
+		// Recover anything that a crash lost from memory.
+		dw.openJournal()
+	}
+
+	dw.starting = false
+	dw.startupErrorPending.Store(dw.startupError != nil)
+
+	if dw.shardCount > 0 {
+		// Start the goroutine that empties the shards.
+		dw.queueLength = 0
+		dw.startShards()
+	}
+
+	if dw.queueLength > 0 {
+		// Start the goroutine that writes the queued data.
+		dw.queue = make(chan *[]byte, dw.queueLength)
+		go dw.writeQueue()
+	}
 
 	return &dw
 }
@@ -182,103 +583,674 @@ func getLogFileDetails(args ...any) (string, string, os.FileMode, os.FileMode) {
 // SetFileUserAndGroup sets the owner and group of a file (plain text or directory) to the
 // given user and group.  The application must be running on a POSIX system (eg Linux or UNIX)
 // to do this.  Under Windows the call returns a syscall.EWINDOWS error wrapped in an
-// io.fs.PathError (which is what os.Chown does under Windows).
+// io.fs.PathError (which is what os.Chown does under Windows).  The error is also an
+// ErrNotPosix.  Any other failure is an ErrChown.
+//
+// A process that isn't running as root can't change the owner of a file, but if it
+// owns the file it can change the group to one that it belongs to.  In that case
+// SetFileUserAndGroup sets the group, and succeeds if the file is already owned by
+// the given user.  Otherwise it returns an *OwnershipError saying which of the
+// owner and the group are as requested.
 func SetFileUserAndGroup(filename, userName, groupName string) error {
 
-	if ps.OSName == "windows" {
+	if runtime.GOOS == "windows" {
 		// We are running under Windows, so Chown etc will not work.
-		return &fs.PathError{Op: "SetFileUserAndGroup", Path: filename, Err: ps.EWINDOWS}
+		return &fs.PathError{Op: "SetFileUserAndGroup", Path: filename, Err: errWindows}
 	}
 
 	// We are running on a POSIX system.  Chown etc will work.
 
-	if os.Getuid() != 0 {
-		return errors.New("SetFileUserAndGroup: must be root")
-	}
-
-	// We are root so we can change file ownership.
-
 	uid, ue := getUserIDFromName(userName)
 	if ue != nil {
-		return errors.New(filename + " userName " + userName + " " + ue.Error())
+		return withClass(ErrChown, errors.New(filename+" userName "+userName+" "+ue.Error()))
 	}
 
 	gid, ge := getGroupIDFromName(groupName)
 	if ge != nil {
-		return errors.New(filename + " groupName " + groupName + " " + ge.Error())
+		return withClass(ErrChown, errors.New(filename+" groupName "+groupName+" "+ge.Error()))
+	}
+
+	if os.Getuid() != 0 {
+		// Do what an ordinary user is allowed to do.
+		return setOwnershipWithoutRoot(filename, uid, gid)
 	}
 
+	// We are root so we can change file ownership.
+
 	che := os.Chown(filename, uid, gid)
 
-	return che
+	return withClass(ErrChown, che)
 }
 
 // Write writes the buffer to the daily log file, creating the file at the
-// start of each day.
+// start of each day.  In asynchronous mode the data is queued and written later
+// by a separate goroutine.  If anything went wrong while New was setting up the
+// Writer, for example the log directory couldn't be created, the first call of
+// Write, Sync or Health returns the error, wrapped, in place of any other error.
+// Write still writes the data if it can.
 func (dw *Writer) Write(buffer []byte) (int, error) {
+	n, err := dw.write(buffer)
+	if dw.startupErrorPending.Load() {
+		// The startup error is probably the cause of any error from this write.
+		if se := dw.takeStartupError(); se != nil {
+			err = se
+		}
+	}
+	return n, err
+}
+
+// write is a helper function for Write that does the work.
+func (dw *Writer) write(buffer []byte) (int, error) {
+	if dw.shards != nil {
+		return dw.writeSharded(buffer)
+	}
+
+	if dw.queueLength > 0 {
+		return dw.writeAsync(buffer)
+	}
+
 	// Avoid a race with rotateLogs.
 	dw.logMutex.Lock()
 	defer dw.logMutex.Unlock()
 
+	if dw.closed {
+		return 0, ErrClosed
+	}
+
+	if dw.journal != nil {
+		if err := dw.journalData(buffer); err != nil {
+			dw.reportError(fmt.Errorf("Write: cannot write to the journal - %w", err))
+			return 0, err
+		}
+	}
+
 	// Write to the log.
-	n, err := dw.switchwriter.Write(buffer)
+	n, err := dw.writeToLog(buffer)
+	if err != nil {
+		dw.reportError(fmt.Errorf("Write: %w", err))
+	}
 	return n, err
 }
 
-// logRotator() runs forever, rotating the log files at the end of each day.
+// writeToLog is a helper function that writes the buffer to the current log
+// file.  It doesn't apply the lock, so it should only be called by a function
+// that does.
+func (dw *Writer) writeToLog(buffer []byte) (int, error) {
+	dw.rotateIfDue()
+
+	if dw.samplingEvery > 0 && !dw.samplingAllows(buffer) {
+		// The write is skipped.
+		return len(buffer), nil
+	}
+
+	if dw.quotaLimit > 0 && !dw.quotaAllows(buffer) {
+		// The write is dropped.
+		return len(buffer), nil
+	}
+
+	if dw.recent != nil {
+		dw.recent.write(buffer)
+	}
+
+	if dw.shipper != nil {
+		dw.ship(buffer)
+	}
+
+	// The caller's buffer may be numbered, so the result is adjusted to match it.
+	length := len(buffer)
+	prefix := 0
+	if dw.recordSequence {
+		buffer, prefix = dw.stampRecord(buffer)
+	}
+
+	if dw.readOnly {
+		// The filesystem is read-only, so keep the data in memory.
+		dw.keepInMemory(buffer)
+		return length, nil
+	}
+
+	start := time.Now()
+	n, err := dw.writeToLogOnce(buffer)
+	if err != nil && dw.staleHandleRetries > 0 && isStaleHandleError(err) {
+		n, err = dw.recoverFromStaleHandle(buffer, n, err)
+	}
+	if err != nil && dw.readOnlyBufferSize > 0 && isReadOnlyError(err) {
+		dw.enterReadOnly(err)
+		dw.keepInMemory(buffer[n:])
+		n, err = len(buffer), nil
+	}
+	dw.stats.WriteLatency.observe(time.Since(start))
+
+	dw.stats.BytesToday += int64(n)
+	dw.stats.BytesTotal += int64(n)
+	if n > 0 {
+		dw.countToday(buffer[:n])
+	}
+	dw.lastWriteError = err
+	if dw.instrumentation != nil {
+		dw.instrumentation.Wrote(n, err)
+	}
+	if err != nil {
+		dw.stats.Failed.add(len(buffer) - n)
+		dw.stats.Today.Failed.add(len(buffer) - n)
+	}
+
+	return min(max(n-prefix, 0), length), err
+}
+
+// rotateIfDue is a helper function that rotates the log if rotation is lazy (see
+// WithLazyRotation) and the day has changed since the last write.  It doesn't
+// apply the lock, so it should only be called by a function that does.
+func (dw *Writer) rotateIfDue() {
+	if dw.lazyRotation && !dw.noRotation && dw.now().UnixNano() >= dw.nextRotation {
+		dw.rotateToDay(dw.now())
+	}
+}
+
+// writeToLogOnce is a helper function for writeToLog that makes one attempt to
+// write the buffer.
+func (dw *Writer) writeToLogOnce(buffer []byte) (int, error) {
+	if dw.framing {
+		return dw.writeFrame(buffer)
+	}
+
+	if dw.hashChain {
+		return dw.writeChained(buffer)
+	}
+
+	if dw.splitter != nil {
+		return dw.writeRecords(buffer)
+	}
+
+	return dw.sink.Write(buffer)
+}
+
+// writeNote is a helper function that writes a line of the Writer's own to the
+// log, bypassing sampling and the quota.  In whole lines mode, the line goes
+// before any partial line that is being held back.  It doesn't apply the lock,
+// so it should only be called by a function that does.
+func (dw *Writer) writeNote(line string) error {
+	_, err := dw.writeNoteBytes([]byte(line))
+	return err
+}
+
+// writeNoteBytes is a helper function like writeNote that writes a buffer and
+// returns the number of bytes written.
+func (dw *Writer) writeNoteBytes(buffer []byte) (int, error) {
+	if dw.framing {
+		return dw.writeFrame(buffer)
+	}
+
+	if dw.hashChain {
+		return dw.writeChained(buffer)
+	}
+
+	return dw.sink.Write(buffer)
+}
+
+// Sync flushes any buffered or queued data to the log file and commits it to
+// stable storage.  When Sync returns, everything written by Write calls that
+// returned before Sync was called is in the file.
+func (dw *Writer) Sync() error {
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	err := dw.flush()
+	if dw.asyncError != nil {
+		err = dw.asyncError
+		dw.asyncError = nil
+	}
+	if syncer, ok := dw.logWriter.(interface{ Sync() error }); ok && !dw.stream {
+		if se := syncer.Sync(); se != nil && err == nil {
+			err = se
+		}
+	}
+	if se := dw.files.sync(); se != nil && err == nil {
+		err = se
+	}
+	if se := dw.takeStartupError(); se != nil {
+		err = se
+	}
+
+	return err
+}
+
+// recordStartupError keeps the error if the Writer is starting up and it's the
+// first, so that takeStartupError can return it later.
+func (dw *Writer) recordStartupError(err error) {
+	if dw.starting && dw.startupError == nil {
+		dw.startupError = err
+	}
+}
+
+// takeStartupError returns the first error reported while the Writer was
+// starting up, wrapped, if it hasn't already been returned.  Otherwise it returns
+// nil.
+func (dw *Writer) takeStartupError() error {
+	if !dw.startupErrorPending.CompareAndSwap(true, false) {
+		return nil
+	}
+
+	return fmt.Errorf("dailylogger: error while starting up - %w", dw.startupError)
+}
+
+// Close flushes and closes the log file and stops the log rotator.  Any later
+// call of Write or Sync returns ErrClosed.  If any artifacts are still being
+// made (see WithArtifact) or any files are still being destroyed (see
+// WithSecureDeletion), Close waits for them.
+func (dw *Writer) Close() error {
+	err := dw.close()
+	dw.artifactsPending.Wait()
+	if dw.metaLog != nil {
+		// The artifacts may have recorded events, so this goes last.
+		dw.metaLog.Close()
+	}
+	return err
+}
+
+// close is a helper function for Close that does everything except wait for
+// the artifacts.
+func (dw *Writer) close() error {
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	err := dw.asyncError
+	if pe := dw.writePartialLine(); pe != nil && err == nil {
+		err = pe
+	}
+	if fe := dw.flush(); fe != nil && err == nil {
+		err = fe
+	}
+	if ce := dw.files.close(); ce != nil && err == nil {
+		err = ce
+	}
+	dw.closeLog()
+	dw.closed = true
+	dw.shardsClosed.Store(true)
+	close(dw.done)
+	if dw.queue != nil {
+		close(dw.queue)
+	}
+	for _, closer := range dw.closers {
+		closer()
+	}
+
+	return err
+}
+
+// barrier stops any more writes from being queued, waits for the queue to
+// empty and then takes the lock.  It returns a function that releases the lock
+// and allows queuing to continue.  In sharded mode (see WithShardedQueue) it
+// waits until everything written to the shards before it was called has been
+// written.  In synchronous mode the queue is always empty so barrier just takes
+// the lock.
+func (dw *Writer) barrier() func() {
+	dw.queueMutex.Lock()
+	dw.pending.Wait()
+	sequence := dw.shardSequence.Load()
+	dw.logMutex.Lock()
+	if dw.shards != nil {
+		dw.waitForShards(sequence)
+	}
+
+	return func() {
+		if dw.journal != nil && !dw.closed {
+			// Everything has been written to the log file.
+			dw.markJournal()
+		}
+		dw.logMutex.Unlock()
+		dw.queueMutex.Unlock()
+	}
+}
+
+// flush is a helper function that flushes the write buffer, if there is one.
+// It doesn't apply the lock so it should only be called by a function that does.
+func (dw *Writer) flush() error {
+	if dw.buffer == nil {
+		return nil
+	}
+
+	return dw.buffer.Flush()
+}
+
+// logRotator() runs until the Writer is closed, rotating the log files at the end
+// of each day.
 func (dw *Writer) logRotator() {
 
 	// This should be run in a goroutine.
 	//
-	// As it runs forever it can't be unit tested.
+	// As it runs until the Writer is closed, it can't be unit tested.
 
-	for {
-		now := time.Now()
-		dw.waitAndRotate(now)
+	for dw.waitAndRotateSafely() {
+	}
+}
+
+// waitAndRotateSafely calls waitAndRotate and recovers if it panics, for example
+// because of a bug in an event handler, so that the log rotator carries on
+// running.  The panic is reported as an error and recorded in the Stats.  It
+// returns false if the Writer was closed.
+func (dw *Writer) waitAndRotateSafely() (carryOn bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			dw.logMutex.Lock()
+			dw.recoverRotation(r)
+			dw.logMutex.Unlock()
+			carryOn = true
+		}
+	}()
+
+	return dw.waitAndRotate()
+}
+
+// recoverRotation is a helper function for waitAndRotateSafely and rotateSafely
+// that reports a panic during rotation.  If the panic came after the old log
+// file was closed but before the new one was opened, the log has nowhere to go
+// and every Write would seem to succeed while the data was thrown away, so it
+// opens the file for the current day.  If that panics too, it makes the writes
+// fail instead.  It doesn't apply the lock, so it should only be called by a
+// function that does.
+func (dw *Writer) recoverRotation(r any) {
+	err := fmt.Errorf("dailylogger: the log rotator panicked - %v", r)
+	dw.stats.LastRotatorError = err.Error()
+	dw.reportError(err)
+
+	if dw.closed || dw.loggingDisabled || dw.sink.dest.Load() != nil {
+		// The log is still open, or isn't supposed to be.
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			dw.reportError(fmt.Errorf("dailylogger: reopening the log after a panic panicked - %v", r))
+			if dw.sink.dest.Load() == nil {
+				dw.sink.SwitchTo(notOpenWriter{})
+			}
+		}
+	}()
+
+	if day := getLastMidnight(dw.now()); day.After(dw.startOfToday) {
+		// The panic came before the day was moved on.
+		dw.setStartOfToday(day)
+		dw.sequence = dw.getAppendSequence(day)
 	}
+	dw.openLog()
 }
 
-// waitToRotate sleeps until just after midnight.  It uses the supplied time rather
-// than finding out the time for itself to support unit testing.
-func waitToRotate(now time.Time) {
+// waitToRotate sleeps until the slack after midnight or until the done channel is
+// closed, whichever comes first, but never for longer than maxWaitDuration.  It
+// returns false if the done channel was closed.  It uses the supplied clock and time
+// rather than finding out the time for itself to support unit testing.
+func waitToRotate(c clock, now time.Time, slack time.Duration, done <-chan struct{}) bool {
 
 	// Find the duration between now and a little after the next midnight.
-	waitTime := getDurationToJustAfterMidnight(now)
+	waitTime := getDurationToAfterMidnight(now, slack)
+	if waitTime > maxWaitDuration {
+		waitTime = maxWaitDuration
+	}
 
 	// Sleep until the next day.
-	time.Sleep(waitTime)
+	select {
+	case <-c.After(waitTime):
+		return true
+	case <-done:
+		return false
+	}
 }
 
 // waitAndRotate sleeps until midnight and then switches to the new day's log file.
-func (dw *Writer) waitAndRotate(now time.Time) {
+// It returns false if the Writer was closed while it was waiting.
+func (dw *Writer) waitAndRotate() bool {
 
-	// Sleep until just after midnight.
-	waitToRotate(now)
+	// Start from the day of the current log file rather than the current time, so
+	// that a rotation that failed, for example because of a panic, is done as
+	// soon as the rotator wakes again.
+	dw.logMutex.Lock()
+	today := dw.startOfToday
+	dw.logMutex.Unlock()
+
+	for {
+		// Sleep until just after midnight, or a bit less.  The next midnight is
+		// recalculated on every pass in the Writer's location, so days that are 23
+		// or 25 hours long because of daylight saving changes are handled.
+		if !waitToRotate(dw.clock, dw.now(), dw.rotationSlack, dw.done) {
+			return false
+		}
+
+		// Check the time after waking.  The clock may have been changed while we were
+		// asleep or the timer may have fired early, so we may have woken too early or
+		// too late.  If it has been set back, we may even be on an earlier day.  If
+		// it's still the same day, go back to sleep.
+		now := dw.now()
+		if getLastMidnight(now).After(today) {
+			// The day has changed.  If jitter is enabled, wait a little longer.
+			if !dw.waitForJitter() {
+				return false
+			}
+
+			// Rotate the log file using the new day as the date stamp.
+			dw.rotateLogs(now)
+			return true
+		}
+	}
+}
+
+// TriggerRotation does synchronously what the log rotator does when it wakes up
+// at the given time, so that the tests of an application can simulate midnight
+// without waiting for it.  If the day of the given time, in the Writer's
+// timezone, is later than the day of the current log file, it rotates to that
+// day's log file, with no jitter, and returns true.  Otherwise it does nothing
+// and returns false.  The time doesn't affect the Writer's clock, so the log
+// rotator won't move back to the real day afterwards.
+func (dw *Writer) TriggerRotation(now time.Time) bool {
+	dw.logMutex.Lock()
+	today := dw.startOfToday
+	closed := dw.closed
+	dw.logMutex.Unlock()
+
+	if closed || !getLastMidnight(now.In(dw.location)).After(today) {
+		return false
+	}
 
-	// Wake up and rotate the log file using the new day as the date stamp.
 	dw.rotateLogs(now)
+	return true
+}
+
+// SchedulePolicy returns the policy that decides when the log rotator rotates
+// the log, so that an application can line its own nightly work up with the
+// rotation using schedule.NextBoundary and schedule.BoundaryFor.  If the Writer
+// belongs to a Scheduler, the policy is the Scheduler's.  With lazy rotation
+// (see WithLazyRotation) the boundary is midnight itself, after which the next
+// Write rotates the log, and with WithNoRotation it means nothing.
+func (dw *Writer) SchedulePolicy() schedule.Policy {
+	if dw.scheduler != nil {
+		return schedule.Policy{Location: dw.scheduler.location, Slack: extraDuration}
+	}
+	if dw.lazyRotation {
+		return schedule.Policy{Location: dw.location}
+	}
+	return schedule.Policy{Location: dw.location, Slack: dw.rotationSlack, Jitter: dw.rotationJitter}
 }
 
 // rotateLogs() rotates the daily log files.
 func (dw *Writer) rotateLogs(now time.Time) {
-	// Avoid a race with Write.
-	dw.logMutex.Lock()
-	defer dw.logMutex.Unlock()
-	dw.closeLog()
+	// Avoid a race with Write.  Anything queued before this point is written to
+	// the old log file.
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return
+	}
+
+	dw.rotateToDay(now)
+}
+
+// rotateToDay is a helper function for rotateLogs that does the work.  It
+// doesn't apply the lock, so it should only be called by a function that does.
+func (dw *Writer) rotateToDay(now time.Time) {
+	end := dw.startOperation(OperationRotate)
+	defer func() { end(dw.openError()) }()
+
+	if dw.samplingEvery > 0 {
+		// Finish the day's sampling in the day's log file.
+		dw.endSamplingWindow()
+	}
+
+	finished := dw.pathname
+	dw.finishLog()
+	dw.startArtifacts(finished)
 
 	// Advance the current day.  If the system is running properly, It should by now
 	// be a fraction of a second after midnight at the start of the next day.  If the
 	// system gets very slow for some reason, it could be any amount of time later,
 	// maybe on an even later day.
-	dw.startOfToday = getLastMidnight(now)
+	day := dw.dayOf(now)
+	dw.startSidecar(day)
+	dw.setStartOfToday(day)
+
+	// Pick up the latest of any files already created for the new day.
+	dw.sequence = dw.getAppendSequence(dw.startOfToday)
 
 	// Open the logfile using start of today as the timestamp.
 
 	dw.openLog()
+	dw.fillMissingDays()
+	dw.emitLifecycle(Event{Type: EventRotated, Path: dw.pathname})
+}
+
+// dayOf is a helper function for rotation that returns midnight at the start of
+// the day of the given time in the Writer's timezone.  The date of the log never
+// goes backwards, so if the clock has been set back to an earlier day than the
+// current log file's, for example by NTP, it returns the current log file's day
+// instead and emits an EventClockWentBack.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) dayOf(now time.Time) time.Time {
+	day := getLastMidnight(now.In(dw.location))
+	if !day.Before(dw.startOfToday) {
+		return day
+	}
+
+	dw.emit(Event{
+		Type: EventClockWentBack,
+		Path: dw.pathname,
+		Err: fmt.Errorf("the clock shows %s, which is before the day of the current log file, %s",
+			now.Format(time.RFC3339), dw.startOfToday.Format(time.DateOnly)),
+	})
+
+	return dw.startOfToday
+}
+
+// setStartOfToday is a helper function for rotation that sets the current day and
+// updates the counters.  It doesn't apply the lock, so it should only be called by
+// a function that does.
+func (dw *Writer) setStartOfToday(startOfToday time.Time) {
+	if !startOfToday.Equal(dw.startOfToday) {
+		dw.startDay(startOfToday)
+	}
+
+	dw.startOfToday = startOfToday
+	dw.nextRotation = getNextMidnight(startOfToday).UnixNano()
+	dw.stats.Rotations++
+	dw.stats.LastRotation = dw.now()
+
+	if dw.keepCounters {
+		dw.saveStateOrReport()
+	}
+}
+
+// Rotate closes the current log file and starts a fresh one.  If the day hasn't
+// changed since the current file was opened, the new file has the next sequence
+// number, for example "foo.2020-02-14.bar" is followed by "foo.2020-02-14.1.bar",
+// then "foo.2020-02-14.2.bar" and so on.  If rotation is disabled (see
+// WithNoRotation) Rotate closes and reopens the same file, which is useful if an
+// external tool has moved it.
+func (dw *Writer) Rotate() {
+	dw.rotate(dw.now())
+}
+
+// rotate is a helper function for Rotate.  The time is supplied to aid unit testing.
+func (dw *Writer) rotate(now time.Time) {
+	// Avoid a race with Write.
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return
+	}
+
+	end := dw.startOperation(OperationRotate)
+	defer func() { end(dw.openError()) }()
+
+	finished := dw.pathname
+	dw.finishLog()
+	dw.startArtifacts(finished)
+
+	day := dw.dayOf(now)
+	dw.startSidecar(day)
+	dw.setStartOfToday(day)
+
+	// If there are already files for the day, start a new one after the last of them.
+	// Otherwise start the first one.  If rotation is disabled, just reopen the file.
+	if dw.noRotation {
+		dw.sequence = 0
+	} else if dw.logFileExists(dw.startOfToday, 0) {
+		dw.sequence = dw.getLastSequence(dw.startOfToday) + 1
+	} else {
+		dw.sequence = 0
+	}
+
+	dw.openLog()
+	dw.emitLifecycle(Event{Type: EventRotated, Path: dw.pathname})
+}
+
+// Reopen closes the current log file and opens it again, without changing the
+// date or the sequence number.  If the file has been moved or deleted in the
+// meantime, a new one is created.  Anything buffered or queued is written to the
+// old file first.
+func (dw *Writer) Reopen() error {
+	unlock := dw.barrier()
+	defer unlock()
+
+	if dw.closed {
+		return ErrClosed
+	}
+
+	end := dw.startOperation(OperationReopen)
+	defer func() { end(dw.openError()) }()
+
+	dw.closeLog()
+	dw.openLog()
+
+	if dw.logWriter == nil {
+		return fmt.Errorf("Reopen: cannot open %s", dw.pathname)
+	}
+
+	return nil
+}
+
+// createDirectory is a helper function for newWriter that creates the log
+// directory or the mirror directory, if it doesn't already exist, and reports any
+// error.
+func (dw *Writer) createDirectory(directory string, permissions os.FileMode) {
+	var err error
+	dw.withUmask(func() { err = createlogDirectory(longPath(directory), permissions) })
+	if err != nil {
+		dw.reportError(err)
+		dw.recordStartupError(err)
+	}
 }
 
-// CreateLogDirectory creates the log directory if it does not already exist.
-func createlogDirectory(directory, owner, group string, permissions os.FileMode) {
+// CreateLogDirectory creates the log directory if it does not already exist.  It
+// returns the first error, if any.
+func createlogDirectory(directory string, permissions os.FileMode) error {
 	if uint32(permissions) == 0 {
 		// The given permissons are zero (not set) so use ModePerm
 		permissions = os.ModePerm
@@ -287,63 +1259,220 @@ func createlogDirectory(directory, owner, group string, permissions os.FileMode)
 	// Note - under Windows, Mkdirall creates the directory but ignores the permissions.
 	mError := os.MkdirAll(directory, permissions)
 	if mError != nil {
-		// We don't have a log file so we can only write the error to stdout.
-		log.Printf("%s: cannot create log directory %s - %v",
-			"createlogDirectory", directory, mError.Error())
+		return withClass(ErrDirCreate, fmt.Errorf("%s: cannot create log directory %s - %w",
+			"createlogDirectory", directory, mError))
 	}
 
 	// If the directory already exists, mkdir does nothing.  In particular it doesn't set
 	// thepermissions, so set them again.
 	cError := os.Chmod(directory, permissions)
 	if cError != nil {
-		log.Printf("%s: cannot set permission on log directory %s - %v",
-			"createlogDirectory", directory, mError.Error())
+		return withClass(ErrDirCreate, fmt.Errorf("%s: cannot set permission on log directory %s - %w",
+			"createlogDirectory", directory, cError))
 	}
 
-	if len(owner) > 0 && len(group) > 0 {
-		if os.Getuid() == 0 {
-			// Getuid return -1 under Windows so this is a POSIX system and the calling
-			// program is running as root.  Set the owner and group of the log file.
-			err := SetFileUserAndGroup(directory, owner, group)
-			if err != nil {
-				// We don't have a log file so we can only write the error to stdout.
-				log.Printf("%s: error setting user and group on log directory %s - %v",
-					"createlogDirectory", directory, err.Error())
-			}
-		}
-	}
+	return nil
 }
 
-// closeLog is a helper function that closes the log file (which
-// also flushes any uncommitted writes).  It doesn't apply the
-// lock so it should only be called by a function that does.
+// closeLog is a helper function that flushes any buffered data and
+// closes the log file (which also flushes any uncommitted writes).  It
+// doesn't apply the lock so it should only be called by a function that
+// does.
 func (dw *Writer) closeLog() {
-	dw.switchwriter.SwitchTo(nil)
+	dw.sink.SwitchTo(nil)
+	dw.closeHandles(dw.takeHandles())
+}
+
+// logHandles holds the open files of a log file and the chain of writers that
+// openLogFile builds on them.
+type logHandles struct {
+	pathname    string         // The path name of the log file.
+	dest        io.Writer      // The writer at the top of the chain (nil if none).
+	buffer      *bufio.Writer  // The buffer in front of the log file (nil if none).
+	logWriter   io.WriteCloser // Writes the log file (nil if it's not open).
+	logFile     *os.File       // The log file, if logWriter is one.
+	mirrorFile  *os.File       // The copy of the log file (see WithMirror).
+	hexDumpFile *os.File       // The hex dump of the log file (see WithHexDump).
+}
+
+// takeHandles is a helper function that takes the handles of the current log
+// file away from the Writer and returns them.  It doesn't apply the lock, so it
+// should only be called by a function that does.
+func (dw *Writer) takeHandles() logHandles {
+	handles := logHandles{
+		pathname:    dw.pathname,
+		buffer:      dw.buffer,
+		logWriter:   dw.logWriter,
+		logFile:     dw.logFile,
+		mirrorFile:  dw.mirrorFile,
+		hexDumpFile: dw.hexDumpFile,
+	}
+	if dest := dw.sink.dest.Load(); dest != nil {
+		handles.dest = dest.w
+	}
+
+	dw.buffer = nil
+	dw.logWriter = nil
+	dw.logFile = nil
+	dw.mirrorFile = nil
+	dw.hexDumpFile = nil
+
+	return handles
+}
+
+// closeHandles is a helper function that flushes the buffer of a log file taken
+// by takeHandles and closes its files.  It doesn't apply the lock, so it should
+// only be called by a function that does.
+func (dw *Writer) closeHandles(handles logHandles) {
+	if handles.buffer != nil {
+		if err := handles.buffer.Flush(); err != nil {
+			// The buffered data is lost.
+			dw.reportError(fmt.Errorf("closeLog: error flushing %s - %w", handles.pathname, err))
+		}
+	}
+
+	if handles.logFile != nil && dw.preallocateSize > 0 && !dw.stream {
+		dw.trimLog(handles.logFile, handles.pathname)
+	}
+	if handles.logWriter != nil {
+		if err := handles.logWriter.Close(); err != nil && dw.fileFactory != nil {
+			// The writer from the factory may do its final work on Close.
+			dw.reportError(fmt.Errorf("closeLog: error closing %s - %w", handles.pathname, err))
+		}
+		dw.emitLifecycle(Event{Type: EventFileClosed, Path: handles.pathname})
+	}
+
+	if handles.mirrorFile != nil {
+		handles.mirrorFile.Close()
+	}
+	if handles.hexDumpFile != nil {
+		handles.hexDumpFile.Close()
+	}
 }
 
 // openLog is a helper function that opens today's log.  It doesn't
 // apply the lock, so it should only be done by something that does.
 func (dw *Writer) openLog() {
 
-	// Create the log directory
-	pathname := dw.getLogPathname(dw.startOfToday)
+	if dw.loggingDisabled {
+		// Another process owns the logs (see WithLockFile).
+		return
+	}
+
+	if dw.deferCreation() {
+		// The file is created by the first write (see WithLazyCreation).
+		return
+	}
+
+	dw.openLogFile()
+}
 
-	logFile, err := dw.openFile(pathname)
+// openLogFile is a helper function for openLog that creates or opens the log
+// file.  It doesn't apply the lock, so it should only be called by a function
+// that does.
+func (dw *Writer) openLogFile() {
+	var logWriter io.WriteCloser
+	var err error
+	switch {
+	case dw.fileFactory != nil:
+		dw.pathname = dw.getLogPathname(dw.startOfToday, dw.sequence)
+		dw.createParent(dw.logDir, dw.pathname)
+		logWriter, err = dw.fileFactory(dw.pathname)
+	case dw.compressLive:
+		dw.pathname = dw.getLogPathname(dw.startOfToday, dw.sequence) + dw.compressor.Extension()
+		dw.createParent(dw.logDir, dw.pathname)
+		logWriter, err = dw.openCompressed(dw.pathname)
+	case dw.exclusiveFiles:
+		var logFile *os.File
+		logFile, err = dw.openExclusive()
+		if logFile != nil {
+			logWriter = logFile
+		}
+	default:
+		dw.pathname = dw.getLogPathname(dw.startOfToday, dw.sequence)
+		dw.createParent(dw.logDir, dw.pathname)
+		var logFile *os.File
+		logFile, err = dw.openFile(dw.pathname)
+		if logFile != nil {
+			logWriter = logFile
+		}
+	}
 	if err != nil {
-		log.Printf("openLog: error creating log file %s - %s\n",
-			pathname, err.Error())
+		err = fmt.Errorf("openLog: error creating log file %s - %w", dw.pathname, err)
+		dw.reportError(err)
+		dw.recordStartupError(err)
+		if dw.readOnlyBufferSize > 0 && isReadOnlyError(err) {
+			dw.enterReadOnly(err)
+		}
 		// Continue - file is now nil.
+		logWriter = nil
 	}
 
-	dw.switchwriter.SwitchTo(logFile)
+	if logWriter != nil {
+		dw.emitLifecycle(Event{Type: EventFileOpened, Path: dw.pathname})
+	}
+
+	// The features that work on the file itself are only available if there is one.
+	dw.logWriter = logWriter
+	dw.logFile, _ = logWriter.(*os.File)
+	if dw.logFile != nil && dw.preallocateSize > 0 && !dw.stream {
+		dw.preallocateLog()
+	}
+
+	var dest io.Writer = notOpenWriter{}
+	if logWriter != nil {
+		dest = logWriter
+	}
+	if logWriter != nil && dw.bufferSize > 0 {
+		dw.buffer = bufio.NewWriterSize(logWriter, dw.bufferSize)
+		dest = dw.buffer
+	}
+
+	if dw.hexDumpBytesPerLine > 0 {
+		// Write the hex dump.
+		dest = dw.openHexDump(dest)
+	}
+
+	if len(dw.mirrorDir) > 0 {
+		// Write to the mirror file too.
+		dest = dw.openMirror(dest)
+	}
+
+	dw.sink.SwitchTo(dest)
+
+	if dw.appendOnly && dw.logFile != nil && !dw.stream {
+		dw.protectLog()
+	}
+
+	if dw.hashChain && logWriter != nil && !dw.stream {
+		// Carry on the chain of a file that already has lines in it.
+		dw.loadChainHead()
+	}
+}
+
+// notOpenWriter takes the place of the log file when it couldn't be opened.  Like
+// a nil *os.File, it fails every write.
+type notOpenWriter struct{}
+
+// Write returns os.ErrInvalid.
+func (notOpenWriter) Write(buffer []byte) (int, error) {
+	return 0, os.ErrInvalid
 }
 
 // getLogPathname returns today's log filename, for example "data.2020-01-19.rtcm3".
-// The time is supplied to aid unit testing.
-func (dw *Writer) getLogPathname(now time.Time) string {
+// If the sequence number is not zero it's inserted before the trailer, for example
+// "data.2020-01-19.2.rtcm3".  The time is supplied to aid unit testing.
+func (dw *Writer) getLogPathname(now time.Time, sequence int) string {
+
+	if dw.stream {
+		return dw.fixedName
+	}
 
-	return fmt.Sprintf("%s/%s%04d-%02d-%02d%s",
-		dw.logDir, dw.leader, now.Year(), int(now.Month()), now.Day(), dw.trailer)
+	if dw.noRotation {
+		return dw.logDir + "/" + dw.fixedName
+	}
+
+	return dw.logDir + "/" + dw.fileNamer().Name(now, sequence)
 }
 
 // openFile either creates and opens the file or, if it already exists, opens it
@@ -352,81 +1481,120 @@ func (dw *Writer) openFile(name string) (*os.File, error) {
 
 	fn := "openFile"
 
-	// Open the file for appending, creating it if necessary.
-	file, oe := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	// Open the file for appending, creating it if necessary.  Create it with the
+	// requested permissions so that it's never visible with wider ones.  The umask
+	// may remove some of them, so they are set again below.
+	mode := os.FileMode(0644)
+	if dw.logFilePermissions != 0 {
+		mode = dw.logFilePermissions
+	}
+	flag := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if dw.writeThrough {
+		flag |= writeThroughFlag
+	}
+	var file *os.File
+	var oe error
+	created := true
+	dw.withUmask(func() {
+		if dw.stream {
+			// The stream must already exist (see WithStream).
+			created = false
+			file, oe = openLogFile(name, flag&^os.O_CREATE, mode)
+			return
+		}
+
+		// Find out whether the file is new by trying to create it.
+		file, oe = openLogFile(name, flag|os.O_EXCL, mode)
+		if errors.Is(oe, fs.ErrExist) {
+			created = false
+			file, oe = openLogFile(name, flag, mode)
+		}
+	})
 	if oe != nil {
 		log.Printf("%s: %v\n", fn, oe)
+		return nil, withClass(ErrFileOpen, oe)
 	}
 
-	if dw.logFilePermissions != 0 {
+	// A named pipe (FIFO) or a device can't be positioned and its permissions and
+	// ownership belong to whoever made it.
+	regular := true
+	if info, err := file.Stat(); err == nil {
+		regular = info.Mode().IsRegular()
+	}
+
+	// Set the permissions and the ownership of a new file.  Leave those of an
+	// existing file alone, in case they were changed deliberately, unless asked
+	// to set them every time.
+	setAttributes := (created || dw.reapplyPermissions) && regular
+
+	if dw.logFilePermissions != 0 && setAttributes {
 		// Under Linux, set the file permissions.
-		if ps.OSName != "windows" {
+		if runtime.GOOS != "windows" {
 			err := os.Chmod(name, os.FileMode(dw.logFilePermissions))
 			if err != nil {
 				log.Printf("%s: %v\n", fn, err)
-				return nil, err
+				file.Close()
+				return nil, withClass(ErrFileOpen, err)
 			}
 		}
 	}
 
-	if len(dw.userName) > 0 && len(dw.groupName) > 0 {
-		if os.Getuid() == 0 {
-			// We are running under a POSIX system and logged in as root,
-			// (If we were under Windows, Getuid would return -1.)  Change
-			// the owner and group as specified.
-			SetFileUserAndGroup(name, dw.userName, dw.groupName)
-		}
+	if !dw.groupInheritance && setAttributes {
+		dw.applyOwnership(name)
+	}
+	if regular {
+		dw.applyLabel(name)
+	}
+
+	if !regular {
+		return file, nil
 	}
 
 	// Seek to the end of the file.
 	_, err := file.Seek(0, 2)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("%s: %v\n", fn, err)
+		file.Close()
+		return nil, err
 	}
 	return file, nil
 }
 
-// extraDuration is the extra time to wait after midnight.
+// extraDuration is the default extra time to wait after midnight (see
+// WithRotationSlack).
 const extraDuration = time.Duration(time.Microsecond)
 
+// maxWaitDuration is the longest time that the log rotator sleeps before checking
+// the time again.  Timers run on the monotonic clock, so if the system clock is
+// changed while the rotator is asleep, it would otherwise wake up at the wrong
+// time.
+const maxWaitDuration = time.Hour
+
 // getDurationToMidnight gets the duration between the given time and a tiny fraction
 // of a second after midnight at the beginning of the next day in the same timezone.
 // (Adding a small amount of extra time removes the confusion over which day midnight
 // is in.
 func getDurationToJustAfterMidnight(givenTime time.Time) time.Duration {
-	// Find midnight at the end of the day that the given time is in.
-	// If now is exactly midnight within the discrimination of the system,
-	// the result will be 0 otherwise it will be greater than zero.  It
-	// can never be negative.
-	nextMidnight := getNextMidnight(givenTime)
-
-	// Calculate the duration to wait until a fraction of a second after
-	// the next midnight.
-	durationToWait := nextMidnight.Sub(givenTime)
-
-	durationToWait += extraDuration
+	return getDurationToAfterMidnight(givenTime, extraDuration)
+}
 
-	return durationToWait
+// getDurationToAfterMidnight gets the duration between the given time and the
+// given slack after midnight at the beginning of the next day in the same timezone
+// (see schedule.UntilNextDay).
+func getDurationToAfterMidnight(givenTime time.Time, slack time.Duration) time.Duration {
+	return schedule.UntilNextDay(givenTime, slack)
 }
 
-// getLastMidnight gets midnight at the beginning of the day of the given time.
+// getLastMidnight gets midnight at the beginning of the day of the given time (see
+// schedule.StartOfDay).
 func getLastMidnight(now time.Time) time.Time {
-	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return schedule.StartOfDay(now)
 }
 
-// getNextMidnight gets midnight at the beginning of the day after the given time.
+// getNextMidnight gets midnight at the beginning of the day after the given time
+// (see schedule.NextDay).
 func getNextMidnight(givenTime time.Time) time.Time {
-	// Advance the date by one day.  Timezone issues could potentially make this more
-	// complicated than it looks.  However, to quote the comment on AddDate:
-	// "the same AddDate arguments can produce a different shift in absolute time
-	// depending on the base Time value and its Location. For example, AddDate(0, 0, 1)
-	// applied to 12:00 on March 27 always returns 12:00 on March 28."  So the day
-	// value will always be one bigger than the given time's day value, which is all
-	// that matters for our purposes - getNextMidnight will always return a time after
-	// the given time and it will be the midnight after the one returned by
-	// getLastMidnight.
-	nextDay := givenTime.AddDate(0, 0, 1)
-	return time.Date(nextDay.Year(), nextDay.Month(), nextDay.Day(), 0, 0, 0, 0, givenTime.Location())
+	return schedule.NextDay(givenTime)
 }
 
 // getUserIDFromName gets the user ID, given the user name.  This only works on a POSIX system.
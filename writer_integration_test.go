@@ -3,11 +3,11 @@ package dailylogger
 import (
 	"os"
 	"regexp"
+	"runtime"
 	"testing"
 	"time"
 
 	ts "github.com/goblimey/go-tools/testsupport"
-	"github.com/goblimey/portablesyscall"
 )
 
 // TestDailyLoggerIntegration is an integration test of the daily logger.  If
@@ -98,7 +98,7 @@ func TestDailyLoggerIntegration(t *testing.T) {
 			files[0].Name(), contents, wantMessage)
 	}
 
-	if portablesyscall.OSName != "windows" {
+	if runtime.GOOS != "windows" {
 		// Except when running under Windows, the owner of the file should
 		// be changed.  We must be running as root to do this.
 		if os.Getuid() != 0 {
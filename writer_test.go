@@ -2,11 +2,11 @@ package dailylogger
 
 import (
 	"os"
+	"runtime"
 	"syscall"
 	"testing"
 	"time"
 
-	ps "github.com/goblimey/portablesyscall"
 	"github.com/google/uuid"
 )
 
@@ -57,7 +57,7 @@ func TestLogging(t *testing.T) {
 	defer RemoveWorkingDirectory(testDirectoryName)
 
 	// Under a POSIX system, must be root to run this test.
-	if ps.OSName != "windows" && syscall.Getuid() != 0 {
+	if runtime.GOOS != "windows" && syscall.Getuid() != 0 {
 		// Not root - fail.
 		t.Error("must be root to run this test")
 		return
@@ -166,7 +166,7 @@ func TestLogging(t *testing.T) {
 
 	// The rest of the test only works on a Posix system.  On a Windows system, New creates
 	// the directory and the logfile but it cannot set permissions, user or group.
-	if ps.OSName != "windows" {
+	if runtime.GOOS != "windows" {
 		// On a POSIX system, check the owner, permissions etc of the files.
 
 		wantUserID, uide := getUserIDFromName(userName)
@@ -187,7 +187,7 @@ func TestLogging(t *testing.T) {
 			t.Error(de)
 			return
 		}
-		dStat, dStatErr := ps.Stat(d)
+		dStat, dStatErr := statFile(d)
 		if dStatErr != nil {
 			t.Error(dStatErr)
 		}
@@ -212,7 +212,7 @@ func TestLogging(t *testing.T) {
 		}
 
 		// The log file.
-		fStat, fStatErr := ps.Stat(inputFile)
+		fStat, fStatErr := statFile(inputFile)
 		if fStatErr != nil {
 			t.Error(fStatErr)
 			return
@@ -281,8 +281,10 @@ func TestNewWriterWithExistingFiles(t *testing.T) {
 	f.Close()
 
 	// Test.  Under all systems the New call should open the existing log file.  Under a POSIX
-	// system it should change the owner and permissions to the given settings.
-	New(now, logDirPathName, leader, trailer, owner, group, wantDirPermissions, wantFilePermissions)
+	// system, as the permissions are reapplied, it should change the owner and permissions to
+	// the given settings.
+	New(now, logDirPathName, leader, trailer, owner, group, wantDirPermissions, wantFilePermissions,
+		WithPermissionsReapplied())
 
 	// Check.
 
@@ -329,7 +331,7 @@ func TestNewWriterWithExistingFiles(t *testing.T) {
 		return
 	}
 
-	if ps.OSName != "windows" {
+	if runtime.GOOS != "windows" {
 
 		// Under a POSIX system, the owner and permissions should be reset.  The test must be run
 		// by root to do all that, so fail if the runner is not root.
@@ -358,7 +360,7 @@ func TestNewWriterWithExistingFiles(t *testing.T) {
 			t.Error(de)
 			return
 		}
-		dStat, dStatErr := ps.Stat(d)
+		dStat, dStatErr := statFile(d)
 		if dStatErr != nil {
 			t.Error(dStatErr)
 		}
@@ -390,7 +392,7 @@ func TestNewWriterWithExistingFiles(t *testing.T) {
 		}
 		defer inputFile.Close()
 
-		fStat, fStatErr := ps.Stat(inputFile)
+		fStat, fStatErr := statFile(inputFile)
 		if fStatErr != nil {
 			t.Error(fStatErr)
 			return
@@ -432,7 +434,7 @@ func TestWaitToRotate(t *testing.T) {
 	const minDuration = extraDuration - smallDuration
 
 	// Test.
-	waitToRotate(startTime)
+	waitToRotate(realClock{}, startTime, extraDuration, nil)
 
 	// Check.
 	now := time.Now()
@@ -752,7 +754,7 @@ func TestRolloverWithLongDelay(t *testing.T) {
 		return
 	}
 
-	if ps.OSName != "windows" {
+	if runtime.GOOS != "windows" {
 
 		// Under Linux, check the permissions of the log directory.
 
@@ -781,7 +783,7 @@ func TestRolloverWithLongDelay(t *testing.T) {
 			return
 		}
 
-		stat1, e1 := ps.Stat(inputFile1)
+		stat1, e1 := statFile(inputFile1)
 		if e1 != nil {
 			t.Error(e1)
 		}
@@ -819,7 +821,7 @@ func TestRolloverWithLongDelay(t *testing.T) {
 			return
 		}
 
-		stat2, e2 := ps.Stat(inputFile2)
+		stat2, e2 := statFile(inputFile2)
 		if e2 != nil {
 			t.Error(e2)
 		}
@@ -0,0 +1,10 @@
+//go:build freebsd
+
+package dailylogger
+
+import "golang.org/x/sys/unix"
+
+// writeThroughFlag is the flag that makes openLogFile open a file in write-through
+// mode (see WithWriteThrough).  The system call package has no O_DSYNC for
+// FreeBSD, so O_SYNC is used, which also waits for the rest of the metadata.
+const writeThroughFlag = unix.O_SYNC
@@ -0,0 +1,10 @@
+//go:build !windows && !freebsd
+
+package dailylogger
+
+import "golang.org/x/sys/unix"
+
+// writeThroughFlag is the flag that makes openLogFile open a file in write-through
+// mode (see WithWriteThrough).  O_DSYNC makes each write wait until the data, and
+// the metadata needed to read it back, is on the disk.
+const writeThroughFlag = unix.O_DSYNC
@@ -0,0 +1,71 @@
+package dailylogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadZone loads the timezone with the given IANA name, for example
+// "Europe/London", as time.LoadLocation does, except that the error wraps ErrZone
+// and says how to put it right.  "UTC" and "Local" always load.  Any other name
+// needs a timezone database, which a minimal container, for example one built
+// from scratch, doesn't have, unless the program is built with the
+// dailylogger_tzdata build tag.  That embeds the database in the program (see
+// the time/tzdata package), adding about 450KB to it.
+func LoadZone(name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		err = fmt.Errorf("%q - %w - install the timezone database or build with the dailylogger_tzdata tag", name, err)
+		return nil, withClass(ErrZone, err)
+	}
+
+	return location, nil
+}
+
+// WithZone sets the timezone that defines the start of each day by its IANA
+// name, for example "Europe/London" (see WithLocation).  The Writer loads the
+// zone itself using LoadZone, so that a failure isn't lost: it's reported to the
+// error handlers (see WithErrorHandler) and returned by the first Write, Sync or
+// Health.  The Writer then carries on in the timezone of the time given to New,
+// or in UTC if WithUTCFallback is given.  WithZone takes precedence over
+// WithLocation.
+func WithZone(name string) Option {
+	return func(dw *Writer) {
+		dw.zoneName = name
+	}
+}
+
+// WithUTCFallback makes the Writer fall back to UTC if the timezone given to
+// WithZone can't be loaded, rather than to the timezone of the time given to
+// New.  A program whose times come from time.Now gets the local timezone, which
+// in a container without a timezone database is silently UTC anyway, so the
+// fallback makes the behaviour the same wherever the program runs.  The failure
+// is still reported.
+func WithUTCFallback() Option {
+	return func(dw *Writer) {
+		dw.utcFallback = true
+	}
+}
+
+// checkZone is a helper function for newWriter that loads the timezone given to
+// WithZone, falling back if it can't.
+func (dw *Writer) checkZone() {
+	if len(dw.zoneName) == 0 {
+		return
+	}
+
+	location, err := LoadZone(dw.zoneName)
+	if err == nil {
+		dw.location = location
+		return
+	}
+
+	fallback := "the timezone of the time given to New"
+	if dw.utcFallback {
+		dw.location = time.UTC
+		fallback = "UTC"
+	}
+	err = fmt.Errorf("WithZone: %w - using %s", err, fallback)
+	dw.reportError(err)
+	dw.recordStartupError(err)
+}
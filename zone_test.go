@@ -0,0 +1,86 @@
+package dailylogger
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestLoadZone checks that LoadZone loads a zone and that a failure wraps
+// ErrZone.
+func TestLoadZone(t *testing.T) {
+	location, err := LoadZone("UTC")
+	if err != nil || location != time.UTC {
+		t.Errorf("want UTC, got %v, %v", location, err)
+	}
+
+	if _, err := LoadZone("Nowhere/Atlantis"); !errors.Is(err, ErrZone) {
+		t.Errorf("want ErrZone, got %v", err)
+	}
+}
+
+// TestWithZone checks that WithZone sets the timezone, and that a zone that
+// can't be loaded is reported and the Writer falls back to the time's own
+// timezone or, with WithUTCFallback, to UTC.
+func TestWithZone(t *testing.T) {
+
+	// This test uses the filestore.
+
+	directoryName, err := CreateWorkingDirectory()
+	if err != nil {
+		t.Errorf("createWorkingDirectory failed - %v", err)
+		return
+	}
+	defer RemoveWorkingDirectory(directoryName)
+
+	// 20:00 in New York on the 14th is 01:00 in London on the 15th.
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+	now := time.Date(2020, time.February, 14, 20, 0, 0, 0, newYork)
+
+	var testData = []struct {
+		description string
+		options     []any
+		wantZone    string
+		wantError   bool
+		wantFile    string
+	}{
+		{"loaded", []any{WithZone("Europe/London")}, "Europe/London", false, "a.2020-02-15.log"},
+		{"precedence", []any{WithZone("Europe/London"), WithLocation(newYork)}, "Europe/London", false, "b.2020-02-15.log"},
+		{"fallback", []any{WithZone("Nowhere/Atlantis")}, "America/New_York", true, "c.2020-02-14.log"},
+		{"UTC fallback", []any{WithZone("Nowhere/Atlantis"), WithUTCFallback()}, "UTC", true, "d.2020-02-15.log"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.description, func(t *testing.T) {
+			var reported []error
+			options := append(td.options, withClock(newFakeClock(now)),
+				WithErrorHandler(func(err error) { reported = append(reported, err) }))
+			writer := New(now, ".", td.wantFile[:2], ".log", options...)
+			defer writer.Close()
+
+			if got := writer.SchedulePolicy().Location.String(); got != td.wantZone {
+				t.Errorf("want %s, got %s", td.wantZone, got)
+			}
+
+			err := writer.Sync()
+			if td.wantError {
+				if !errors.Is(err, ErrZone) {
+					t.Errorf("want Sync to return ErrZone, got %v", err)
+				}
+				if len(reported) != 1 || !errors.Is(reported[0], ErrZone) {
+					t.Errorf("want ErrZone reported once, got %v", reported)
+				}
+			} else if err != nil {
+				t.Errorf("want no error, got %v", err)
+			}
+
+			if _, err := os.Stat(td.wantFile); err != nil {
+				t.Errorf("want %s - %v", td.wantFile, err)
+			}
+		})
+	}
+}